@@ -0,0 +1,20 @@
+record Point(int x, int y) {
+    public Point {
+        if (x < 0) {
+            x = 0;
+        }
+    }
+
+    public Point(int both) {
+        this(both, both);
+    }
+
+	public static void main(String[] args){
+        Point origin = new Point(0);
+        Point p = new Point(-3, 4);
+
+        System.out.println(p.y());
+        System.out.println(p.equals(new Point(0, 4)));
+        System.out.println(origin.toString());
+	}
+}
@@ -1,10 +1,12 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"os"
 	"path"
 
+	"github.com/gumelarme/yava/pkg/classfile"
 	"github.com/gumelarme/yava/pkg/lang"
 	"github.com/gumelarme/yava/pkg/text"
 )
@@ -13,19 +15,39 @@ type HasError interface {
 	Errors() []error
 }
 
+type HasWarning interface {
+	Warnings() []string
+}
+
+// stripAssertions remove the assert statements at compile time,
+// unlike java -da they cannot be enabled again when it is run
+var stripAssertions = flag.Bool("strip-asserts", false, "remove assert statements from the compiled code")
+
+// classpath is where the compiled classes used by the program are found,
+// the directories and JAR files are separated like the PATH of the OS
+var classpath string
+
+func init() {
+	usage := "directories and JAR files of the used classes, separated by " + string(os.PathListSeparator)
+	flag.StringVar(&classpath, "classpath", "", usage)
+	flag.StringVar(&classpath, "cp", "", usage)
+}
+
 func main() {
+	flag.Parse()
 	compileFile()
 }
 
 func compileText(content string){
-	compile(text.NewStringScannner(content))
+	compile(text.NewStringScanner(content))
 }
 
 func compileFile() {
-	if len(os.Args) < 2 {
+	if flag.NArg() < 1 {
 		fmt.Println("Please provide a file")
+		return
 	}
-	filename := os.Args[1]
+	filename := flag.Arg(0)
 	compile(text.NewFileScanner(filename))
 }
 
@@ -33,21 +55,38 @@ func compile(scanner text.Scanner) {
 	lexer := text.NewLexer(scanner)
 	parser := text.NewParser(&lexer)
 	ast := parser.Compile()
+	if PrintErrorIfAny(&parser) {
+		return
+	}
 
 	tyanal := lang.NewTypeAnalyzer()
+	if len(classpath) > 0 {
+		cp, err := classfile.NewClasspath(classpath)
+		if err != nil {
+			fmt.Printf("Error while opening the classpath:\n%s\n", err.Error())
+			return
+		}
+		defer cp.Close()
+		tyanal.UseClasspath(cp)
+	}
 	ast.Accept(tyanal)
 	table := tyanal.GetTypeTable()
+	PrintWarningIfAny(tyanal)
 	if PrintErrorIfAny(tyanal) {
 		return
 	}
 
 	nmanal := lang.NewNameAnalyzer(table)
+	nmanal.ImportFrom(tyanal)
 	ast.Accept(nmanal)
 	if PrintErrorIfAny(nmanal) {
 		return
 	}
 
 	generator := lang.NewKrakatauGen(table, nmanal.Tables)
+	if *stripAssertions {
+		generator.StripAssertions()
+	}
 	ast.Accept(generator)
 	dir, name := "./_bin/", "yava.j"
 	lang.WriteToFile(generator.GenerateCode(), path.Join(dir, name))
@@ -70,3 +109,15 @@ func PrintErrorIfAny(h HasError) bool {
 	}
 	return true
 }
+
+func PrintWarningIfAny(h HasWarning) {
+	warnings := h.Warnings()
+	if len(warnings) == 0 {
+		return
+	}
+
+	fmt.Println("Warnings: ")
+	for _, warning := range warnings {
+		fmt.Println(warning)
+	}
+}
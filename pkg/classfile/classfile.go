@@ -0,0 +1,338 @@
+// Package classfile read the signatures of the classes compiled into
+// .class files, either on a directory or inside a JAR archive.
+// Only the parts needed to link against a class is read, the code and
+// the other attributes are skipped.
+package classfile
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"unicode/utf16"
+)
+
+const magic = 0xCAFEBABE
+
+// Access flags of a class, field or method, some of them share
+// the same value since they are used on different kind of member.
+const (
+	AccPublic       uint16 = 0x0001
+	AccPrivate      uint16 = 0x0002
+	AccProtected    uint16 = 0x0004
+	AccStatic       uint16 = 0x0008
+	AccFinal        uint16 = 0x0010
+	AccSuper        uint16 = 0x0020
+	AccSynchronized uint16 = 0x0020
+	AccVolatile     uint16 = 0x0040
+	AccBridge       uint16 = 0x0040
+	AccTransient    uint16 = 0x0080
+	AccVarargs      uint16 = 0x0080
+	AccNative       uint16 = 0x0100
+	AccInterface    uint16 = 0x0200
+	AccAbstract     uint16 = 0x0400
+	AccStrict       uint16 = 0x0800
+	AccSynthetic    uint16 = 0x1000
+	AccAnnotation   uint16 = 0x2000
+	AccEnum         uint16 = 0x4000
+	AccModule       uint16 = 0x8000
+)
+
+// Tags of the constant pool entries
+const (
+	TagUtf8               byte = 1
+	TagInteger            byte = 3
+	TagFloat              byte = 4
+	TagLong               byte = 5
+	TagDouble             byte = 6
+	TagClass              byte = 7
+	TagString             byte = 8
+	TagFieldref           byte = 9
+	TagMethodref          byte = 10
+	TagInterfaceMethodref byte = 11
+	TagNameAndType        byte = 12
+	TagMethodHandle       byte = 15
+	TagMethodType         byte = 16
+	TagDynamic            byte = 17
+	TagInvokeDynamic      byte = 18
+	TagModule             byte = 19
+	TagPackage            byte = 20
+)
+
+var ErrNotClassFile = errors.New("not a class file")
+
+// Constant is an entry of the constant pool. Refs are the indexes of the
+// entries it refer, e.g. the class and the name-and-type of a Methodref,
+// a MethodHandle keep its reference kind as the first one.
+// Value is set on Utf8, Integer, Float, Long and Double.
+type Constant struct {
+	Tag   byte
+	Refs  [2]uint16
+	Value interface{}
+}
+
+// Member is a field or a method of a class
+type Member struct {
+	AccessFlags uint16
+	Name        string
+	Descriptor  string
+}
+
+func (m Member) Is(flag uint16) bool {
+	return m.AccessFlags&flag != 0
+}
+
+// ClassFile is the signature of a compiled class, the class names
+// are written in their internal form, e.g. java/lang/String
+type ClassFile struct {
+	MinorVersion uint16
+	MajorVersion uint16
+	// ConstantPool is indexed as it is in the file, so the first entry
+	// and the one after a Long or a Double is unused
+	ConstantPool []Constant
+	AccessFlags  uint16
+	ThisClass    string
+	// SuperClass is empty only on java/lang/Object
+	SuperClass string
+	Interfaces []string
+	Fields     []Member
+	Methods    []Member
+}
+
+func (c *ClassFile) Is(flag uint16) bool {
+	return c.AccessFlags&flag != 0
+}
+
+// Utf8 get the string of the Utf8 constant at index
+func (c *ClassFile) Utf8(index uint16) (string, error) {
+	constant, err := c.constant(index, TagUtf8)
+	if err != nil {
+		return "", err
+	}
+	return constant.Value.(string), nil
+}
+
+// ClassName get the name of the Class constant at index
+func (c *ClassFile) ClassName(index uint16) (string, error) {
+	constant, err := c.constant(index, TagClass)
+	if err != nil {
+		return "", err
+	}
+	return c.Utf8(constant.Refs[0])
+}
+
+func (c *ClassFile) constant(index uint16, tag byte) (Constant, error) {
+	if index == 0 || int(index) >= len(c.ConstantPool) {
+		return Constant{}, fmt.Errorf("constant pool index %d is out of range", index)
+	}
+
+	constant := c.ConstantPool[index]
+	if constant.Tag != tag {
+		return Constant{}, fmt.Errorf("constant pool entry %d has tag %d, expecting %d", index, constant.Tag, tag)
+	}
+	return constant, nil
+}
+
+// reader read the big-endian items of a class file,
+// the first error stop every read after it.
+type reader struct {
+	r   *bufio.Reader
+	err error
+}
+
+func (r *reader) read(data interface{}) {
+	if r.err == nil {
+		r.err = binary.Read(r.r, binary.BigEndian, data)
+	}
+}
+
+func (r *reader) u1() (val uint8) {
+	r.read(&val)
+	return
+}
+
+func (r *reader) u2() (val uint16) {
+	r.read(&val)
+	return
+}
+
+func (r *reader) u4() (val uint32) {
+	r.read(&val)
+	return
+}
+
+func (r *reader) bytes(n int) []byte {
+	buf := make([]byte, n)
+	if r.err == nil {
+		_, r.err = io.ReadFull(r.r, buf)
+	}
+	return buf
+}
+
+// Parse read a class file from src
+func Parse(src io.Reader) (*ClassFile, error) {
+	r := &reader{r: bufio.NewReader(src)}
+	if r.u4() != magic {
+		if r.err != nil {
+			return nil, r.err
+		}
+		return nil, ErrNotClassFile
+	}
+
+	class := &ClassFile{}
+	class.MinorVersion, class.MajorVersion = r.u2(), r.u2()
+	class.ConstantPool = r.constantPool()
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	var this, super uint16
+	class.AccessFlags, this, super = r.u2(), r.u2(), r.u2()
+	interfaces := make([]uint16, r.u2())
+	for i := range interfaces {
+		interfaces[i] = r.u2()
+	}
+
+	fields := r.members()
+	methods := r.members()
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	var err error
+	if class.ThisClass, err = class.ClassName(this); err != nil {
+		return nil, err
+	}
+
+	if super != 0 {
+		if class.SuperClass, err = class.ClassName(super); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, index := range interfaces {
+		name, err := class.ClassName(index)
+		if err != nil {
+			return nil, err
+		}
+		class.Interfaces = append(class.Interfaces, name)
+	}
+
+	if class.Fields, err = class.resolveMembers(fields); err != nil {
+		return nil, err
+	}
+
+	if class.Methods, err = class.resolveMembers(methods); err != nil {
+		return nil, err
+	}
+	return class, nil
+}
+
+func (r *reader) constantPool() []Constant {
+	pool := make([]Constant, r.u2())
+	for i := 1; i < len(pool) && r.err == nil; i++ {
+		constant := Constant{Tag: r.u1()}
+		switch constant.Tag {
+		case TagUtf8:
+			buf := r.bytes(int(r.u2()))
+			if r.err == nil {
+				constant.Value, r.err = decodeUtf8(buf)
+			}
+		case TagInteger:
+			constant.Value = int32(r.u4())
+		case TagFloat:
+			constant.Value = math.Float32frombits(r.u4())
+		case TagLong, TagDouble:
+			high, low := uint64(r.u4()), uint64(r.u4())
+			if constant.Tag == TagLong {
+				constant.Value = int64(high<<32 | low)
+			} else {
+				constant.Value = math.Float64frombits(high<<32 | low)
+			}
+		case TagClass, TagString, TagMethodType, TagModule, TagPackage:
+			constant.Refs[0] = r.u2()
+		case TagFieldref, TagMethodref, TagInterfaceMethodref,
+			TagNameAndType, TagDynamic, TagInvokeDynamic:
+			constant.Refs = [2]uint16{r.u2(), r.u2()}
+		case TagMethodHandle:
+			constant.Refs = [2]uint16{uint16(r.u1()), r.u2()}
+		default:
+			if r.err == nil {
+				r.err = fmt.Errorf("unknown constant pool tag %d at %d", constant.Tag, i)
+			}
+		}
+
+		pool[i] = constant
+		// the entry after a long or double is unusable
+		if constant.Tag == TagLong || constant.Tag == TagDouble {
+			i++
+		}
+	}
+	return pool
+}
+
+// rawMember is a member whose name and descriptor is not resolved yet
+type rawMember struct {
+	flags, name, descriptor uint16
+}
+
+func (r *reader) members() []rawMember {
+	members := make([]rawMember, r.u2())
+	for i := range members {
+		members[i] = rawMember{r.u2(), r.u2(), r.u2()}
+		r.skipAttributes()
+	}
+	return members
+}
+
+func (r *reader) skipAttributes() {
+	for count := r.u2(); count > 0 && r.err == nil; count-- {
+		r.u2()
+		if _, err := r.r.Discard(int(r.u4())); err != nil && r.err == nil {
+			r.err = err
+		}
+	}
+}
+
+func (c *ClassFile) resolveMembers(raws []rawMember) ([]Member, error) {
+	members := make([]Member, len(raws))
+	for i, raw := range raws {
+		name, err := c.Utf8(raw.name)
+		if err != nil {
+			return nil, err
+		}
+
+		descriptor, err := c.Utf8(raw.descriptor)
+		if err != nil {
+			return nil, err
+		}
+		members[i] = Member{raw.flags, name, descriptor}
+	}
+	return members, nil
+}
+
+// decodeUtf8 decode the modified UTF-8 of the class file, the null
+// character takes two bytes and a supplementary character is written
+// as its surrogate pair
+func decodeUtf8(buf []byte) (string, error) {
+	units := make([]uint16, 0, len(buf))
+	for i := 0; i < len(buf); {
+		b := buf[i]
+		switch {
+		case b&0x80 == 0:
+			units = append(units, uint16(b))
+			i++
+		case b&0xE0 == 0xC0 && i+1 < len(buf):
+			units = append(units, uint16(b&0x1F)<<6|uint16(buf[i+1]&0x3F))
+			i += 2
+		case b&0xF0 == 0xE0 && i+2 < len(buf):
+			units = append(units, uint16(b&0x0F)<<12|uint16(buf[i+1]&0x3F)<<6|uint16(buf[i+2]&0x3F))
+			i += 3
+		default:
+			return "", fmt.Errorf("malformed utf8 constant at byte %d", i)
+		}
+	}
+	return string(utf16.Decode(units)), nil
+}
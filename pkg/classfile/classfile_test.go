@@ -0,0 +1,189 @@
+package classfile
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func parseFile(t *testing.T, path string) *ClassFile {
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	class, err := Parse(file)
+	if err != nil {
+		t.Fatalf("Parsing %s: %v", path, err)
+	}
+	return class
+}
+
+func TestParse(t *testing.T) {
+	class := parseFile(t, "testdata/classes/com/acme/Greeter.class")
+	if class.MajorVersion != 52 || class.ThisClass != "com/acme/Greeter" || class.SuperClass != "java/lang/Object" {
+		t.Errorf("Expecting com/acme/Greeter extends java/lang/Object version 52, but got %s extends %s version %d",
+			class.ThisClass, class.SuperClass, class.MajorVersion)
+	}
+
+	if !class.Is(AccPublic) || class.Is(AccInterface) || class.Is(AccFinal) {
+		t.Errorf("Expecting a public class but got flags %#x", class.AccessFlags)
+	}
+
+	fields := []Member{
+		{AccPublic | AccStatic | AccFinal, "VERSION", "I"},
+		{AccPublic, "name", "Ljava/lang/String;"},
+		{AccPrivate, "secret", "J"},
+		{AccPublic, "grid", "[[I"},
+	}
+
+	if !reflect.DeepEqual(class.Fields, fields) {
+		t.Errorf("Expecting fields %v but got %v", fields, class.Fields)
+	}
+
+	methods := []Member{
+		{AccPublic, "<init>", "()V"},
+		{AccPublic, "<init>", "(Ljava/lang/String;)V"},
+		{AccPublic, "greet", "(Ljava/lang/String;)Ljava/lang/String;"},
+		{AccPublic | AccStatic, "twice", "(I)I"},
+		{AccPublic | AccStatic | AccVarargs, "sum", "([I)I"},
+		{AccPublic, "self", "()Lcom/acme/Greeter;"},
+		{AccPublic, "shape", "()Lcom/acme/Shape;"},
+		{AccPublic, "builder", "()Ljava/lang/StringBuilder;"},
+		{AccPublic, "missing", "(Lcom/missing/Gone;)V"},
+		{AccStatic, "<clinit>", "()V"},
+		{AccStatic | AccSynthetic, "access$000", "(Lcom/acme/Greeter;)J"},
+	}
+
+	if !reflect.DeepEqual(class.Methods, methods) {
+		t.Errorf("Expecting methods %v but got %v", methods, class.Methods)
+	}
+}
+
+func TestParse_constantPool(t *testing.T) {
+	class := parseFile(t, "testdata/classes/com/acme/Greeter.class")
+	values := map[interface{}]bool{}
+	for i, constant := range class.ConstantPool {
+		if constant.Tag == TagLong || constant.Tag == TagDouble {
+			if next := class.ConstantPool[i+1]; next.Tag != 0 {
+				t.Errorf("Expecting the entry after %v to be unused but got %v", constant.Value, next)
+			}
+		}
+
+		if constant.Tag == TagString {
+			value, _ := class.Utf8(constant.Refs[0])
+			values[value] = true
+		} else if constant.Value != nil {
+			values[constant.Value] = true
+		}
+	}
+
+	for _, value := range []interface{}{int32(3), int64(1 << 40), 1.5, "héllo\x00 𝄞", "Code"} {
+		if !values[value] {
+			t.Errorf("Expecting %#v in the constant pool", value)
+		}
+	}
+}
+
+func TestParse_interface(t *testing.T) {
+	class := parseFile(t, "testdata/classes/com/acme/shapes/Square.class")
+	if !reflect.DeepEqual(class.Interfaces, []string{"com/acme/Shape"}) || !class.Is(AccFinal) {
+		t.Errorf("Expecting a final class implementing com/acme/Shape but got %v %#x", class.Interfaces, class.AccessFlags)
+	}
+
+	shape := parseFile(t, "testdata/classes/com/acme/Shape.class")
+	if !shape.Is(AccInterface) || !shape.Methods[0].Is(AccAbstract) || shape.Methods[1].Is(AccAbstract) {
+		t.Errorf("Expecting an interface with abstract area() but got %#x %v", shape.AccessFlags, shape.Methods)
+	}
+}
+
+func TestParse_error(t *testing.T) {
+	valid, err := ioutil.ReadFile("testdata/classes/com/acme/Greeter.class")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := [][]byte{
+		[]byte("hello world"),
+		{0xCA, 0xFE},
+		valid[:len(valid)/2],
+		append(append([]byte{}, valid[:10]...), 99),
+	}
+
+	for _, d := range data {
+		if class, err := Parse(bytes.NewReader(d)); err == nil {
+			t.Errorf("Expecting an error on %q but got %v", d, class)
+		}
+	}
+}
+
+func TestParseFieldDescriptor(t *testing.T) {
+	data := []struct {
+		descriptor string
+		typ        Type
+		str        string
+	}{
+		{"I", Type{"int", 0}, "int"},
+		{"Z", Type{"boolean", 0}, "boolean"},
+		{"[J", Type{"long", 1}, "long[]"},
+		{"Ljava/lang/String;", Type{"java/lang/String", 0}, "java.lang.String"},
+		{"[[Lcom/acme/Greeter;", Type{"com/acme/Greeter", 2}, "com.acme.Greeter[][]"},
+	}
+
+	for _, d := range data {
+		typ, err := ParseFieldDescriptor(d.descriptor)
+		if err != nil || typ != d.typ || typ.String() != d.str {
+			t.Errorf("Expecting %s to be %v but got %v, %v", d.descriptor, d.typ, typ, err)
+		}
+	}
+
+	for _, descriptor := range []string{"", "[", "X", "Ljava/lang/String", "L;", "II"} {
+		if typ, err := ParseFieldDescriptor(descriptor); err == nil {
+			t.Errorf("Expecting an error on %q but got %v", descriptor, typ)
+		}
+	}
+}
+
+func TestParseMethodDescriptor(t *testing.T) {
+	data := []struct {
+		descriptor string
+		method     MethodType
+	}{
+		{"()V", MethodType{nil, Type{"void", 0}}},
+		{"(I[CLjava/lang/String;)Z", MethodType{
+			[]Type{{"int", 0}, {"char", 1}, {"java/lang/String", 0}},
+			Type{"boolean", 0},
+		}},
+		{"([I)[Lcom/acme/Shape;", MethodType{[]Type{{"int", 1}}, Type{"com/acme/Shape", 1}}},
+	}
+
+	for _, d := range data {
+		method, err := ParseMethodDescriptor(d.descriptor)
+		if err != nil || !reflect.DeepEqual(method, d.method) {
+			t.Errorf("Expecting %s to be %v but got %v, %v", d.descriptor, d.method, method, err)
+		}
+	}
+
+	for _, descriptor := range []string{"", "I", "(I", "(I)", "(X)V", "()VV"} {
+		if method, err := ParseMethodDescriptor(descriptor); err == nil {
+			t.Errorf("Expecting an error on %q but got %v", descriptor, method)
+		}
+	}
+}
+
+func TestType_SimpleName(t *testing.T) {
+	data := map[string]string{
+		"int":                    "int",
+		"java/lang/String":       "String",
+		"com/acme/shapes/Square": "Square",
+	}
+
+	for name, simple := range data {
+		if got := (Type{name, 0}).SimpleName(); got != simple {
+			t.Errorf("Expecting %s but got %s", simple, got)
+		}
+	}
+}
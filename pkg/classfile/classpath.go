@@ -0,0 +1,187 @@
+package classfile
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var ErrClassNotFound = errors.New("class not found")
+
+// Classpath find the classes in a list of directories and JAR or zip
+// archives, a class is only parsed when it is first looked up.
+type Classpath struct {
+	entries []entry
+	classes map[string]*ClassFile
+	// simpleNames map the simple name of every class into its internal
+	// name, it is built on the first lookup by simple name
+	simpleNames map[string]string
+}
+
+// entry is a directory or an archive of the classpath,
+// the files are named by their slash separated path.
+type entry interface {
+	open(name string) (io.ReadCloser, error)
+	files() ([]string, error)
+	close() error
+}
+
+// NewClasspath open every entry of path, which is separated by
+// the list separator of the OS, e.g. lib:build/classes:helper.jar
+func NewClasspath(path string) (*Classpath, error) {
+	cp := &Classpath{classes: make(map[string]*ClassFile)}
+	for _, name := range filepath.SplitList(path) {
+		if len(name) == 0 {
+			continue
+		}
+
+		info, err := os.Stat(name)
+		if err != nil {
+			cp.Close()
+			return nil, err
+		}
+
+		if info.IsDir() {
+			cp.entries = append(cp.entries, directory(name))
+			continue
+		}
+
+		archive, err := zip.OpenReader(name)
+		if err != nil {
+			cp.Close()
+			return nil, fmt.Errorf("%s: %v", name, err)
+		}
+		cp.entries = append(cp.entries, zipArchive{archive})
+	}
+	return cp, nil
+}
+
+// Close close the archives of the classpath
+func (cp *Classpath) Close() error {
+	var err error
+	for _, entry := range cp.entries {
+		if closeErr := entry.close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}
+	return err
+}
+
+// Find get the class of an internal name, e.g. com/acme/Helper, the first
+// entry which has it is used. ErrClassNotFound is returned if none has it.
+func (cp *Classpath) Find(name string) (*ClassFile, error) {
+	if class, exist := cp.classes[name]; exist {
+		return class, nil
+	}
+
+	for _, entry := range cp.entries {
+		file, err := entry.open(name + ".class")
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		class, err := Parse(file)
+		file.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", name, err)
+		}
+
+		cp.classes[name] = class
+		return class, nil
+	}
+	return nil, ErrClassNotFound
+}
+
+// FindSimple get the class by its name without the package, e.g. Helper.
+// If it exist in several packages the first one on the classpath is used.
+func (cp *Classpath) FindSimple(name string) (*ClassFile, error) {
+	if cp.simpleNames == nil {
+		if err := cp.indexSimpleNames(); err != nil {
+			return nil, err
+		}
+	}
+
+	internal, exist := cp.simpleNames[name]
+	if !exist {
+		return nil, ErrClassNotFound
+	}
+	return cp.Find(internal)
+}
+
+func (cp *Classpath) indexSimpleNames() error {
+	cp.simpleNames = make(map[string]string)
+	for _, entry := range cp.entries {
+		files, err := entry.files()
+		if err != nil {
+			return err
+		}
+
+		for _, file := range files {
+			if !strings.HasSuffix(file, ".class") {
+				continue
+			}
+
+			internal := strings.TrimSuffix(file, ".class")
+			simple := internal[strings.LastIndex(internal, "/")+1:]
+			if _, exist := cp.simpleNames[simple]; !exist {
+				cp.simpleNames[simple] = internal
+			}
+		}
+	}
+	return nil
+}
+
+type directory string
+
+func (d directory) open(name string) (io.ReadCloser, error) {
+	return os.Open(filepath.Join(string(d), filepath.FromSlash(name)))
+}
+
+func (d directory) files() ([]string, error) {
+	var files []string
+	err := filepath.Walk(string(d), func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+
+		rel, err := filepath.Rel(string(d), path)
+		files = append(files, filepath.ToSlash(rel))
+		return err
+	})
+	return files, err
+}
+
+func (d directory) close() error {
+	return nil
+}
+
+type zipArchive struct {
+	*zip.ReadCloser
+}
+
+func (z zipArchive) open(name string) (io.ReadCloser, error) {
+	for _, file := range z.File {
+		if file.Name == name {
+			return file.Open()
+		}
+	}
+	return nil, os.ErrNotExist
+}
+
+func (z zipArchive) files() ([]string, error) {
+	files := make([]string, len(z.File))
+	for i, file := range z.File {
+		files[i] = file.Name
+	}
+	return files, nil
+}
+
+func (z zipArchive) close() error {
+	return z.ReadCloser.Close()
+}
@@ -0,0 +1,85 @@
+package classfile
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func withClasspath(t *testing.T, path string, test func(cp *Classpath)) {
+	cp, err := NewClasspath(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cp.Close()
+	test(cp)
+}
+
+func TestClasspath_Find(t *testing.T) {
+	paths := []string{"testdata/classes", "testdata/helpers.jar"}
+	for _, path := range paths {
+		withClasspath(t, path, func(cp *Classpath) {
+			for _, name := range []string{"com/acme/Greeter", "com/acme/Shape", "com/acme/shapes/Square"} {
+				class, err := cp.Find(name)
+				if err != nil || class.ThisClass != name {
+					t.Errorf("Expecting %s in %s but got %v, %v", name, path, class, err)
+					continue
+				}
+
+				if again, _ := cp.Find(name); again != class {
+					t.Errorf("Expecting %s to be parsed once", name)
+				}
+			}
+
+			if class, err := cp.Find("com/acme/Missing"); err != ErrClassNotFound {
+				t.Errorf("Expecting ErrClassNotFound in %s but got %v, %v", path, class, err)
+			}
+		})
+	}
+}
+
+func TestClasspath_FindSimple(t *testing.T) {
+	path := strings.Join([]string{"", "testdata/helpers.jar", "testdata/classes"}, string(os.PathListSeparator))
+	withClasspath(t, path, func(cp *Classpath) {
+		data := map[string]string{
+			"Greeter": "com/acme/Greeter",
+			"Square":  "com/acme/shapes/Square",
+		}
+
+		for simple, name := range data {
+			if class, err := cp.FindSimple(simple); err != nil || class.ThisClass != name {
+				t.Errorf("Expecting %s to be %s but got %v, %v", simple, name, class, err)
+			}
+		}
+
+		for _, simple := range []string{"Missing", "MANIFEST", "Shape.class"} {
+			if class, err := cp.FindSimple(simple); err != ErrClassNotFound {
+				t.Errorf("Expecting %s to be not found but got %v, %v", simple, class, err)
+			}
+		}
+
+		if class, err := cp.FindSimple("not_a_class"); err != ErrClassNotFound {
+			t.Errorf("Expecting only the classes of the package tree, but got %v, %v", class, err)
+		}
+	})
+}
+
+func TestClasspath_error(t *testing.T) {
+	withClasspath(t, "testdata", func(cp *Classpath) {
+		if class, err := cp.Find("not_a_class"); err == nil {
+			t.Errorf("Expecting a parse error but got %v", class)
+		}
+	})
+
+	paths := []string{
+		"testdata/missing.jar",
+		filepath.Join("testdata", "not_a_class.class"),
+	}
+
+	for _, path := range paths {
+		if cp, err := NewClasspath(path); err == nil {
+			t.Errorf("Expecting an error on %s but got %v", path, cp)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package classfile
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Type is a type written in a descriptor, Name is either a primitive
+// type, e.g. int, void, or the internal name of a class.
+type Type struct {
+	Name       string
+	Dimensions int
+}
+
+// SimpleName get the name of the type without its package
+func (t Type) SimpleName() string {
+	return t.Name[strings.LastIndex(t.Name, "/")+1:]
+}
+
+func (t Type) IsPrimitive() bool {
+	return primitives[t.Name]
+}
+
+func (t Type) String() string {
+	return strings.ReplaceAll(t.Name, "/", ".") + strings.Repeat("[]", t.Dimensions)
+}
+
+// MethodType is the parameters and the return type of a method descriptor
+type MethodType struct {
+	Params []Type
+	Return Type
+}
+
+var baseTypes = map[byte]string{
+	'B': "byte",
+	'C': "char",
+	'D': "double",
+	'F': "float",
+	'I': "int",
+	'J': "long",
+	'S': "short",
+	'Z': "boolean",
+}
+
+var primitives = map[string]bool{
+	"byte":    true,
+	"char":    true,
+	"double":  true,
+	"float":   true,
+	"int":     true,
+	"long":    true,
+	"short":   true,
+	"boolean": true,
+	"void":    true,
+}
+
+// ParseFieldDescriptor read a field descriptor, e.g. [Ljava/lang/String;
+func ParseFieldDescriptor(descriptor string) (Type, error) {
+	t, rest, err := parseType(descriptor)
+	if err == nil && len(rest) > 0 {
+		err = fmt.Errorf("unexpected %q after field descriptor %q", rest, descriptor)
+	}
+	return t, err
+}
+
+// ParseMethodDescriptor read a method descriptor, e.g. (I[C)V
+func ParseMethodDescriptor(descriptor string) (MethodType, error) {
+	if !strings.HasPrefix(descriptor, "(") {
+		return MethodType{}, fmt.Errorf("method descriptor %q should start with (", descriptor)
+	}
+
+	var method MethodType
+	rest := descriptor[1:]
+	for len(rest) > 0 && rest[0] != ')' {
+		param, next, err := parseType(rest)
+		if err != nil {
+			return MethodType{}, err
+		}
+		method.Params, rest = append(method.Params, param), next
+	}
+
+	if len(rest) == 0 {
+		return MethodType{}, fmt.Errorf("method descriptor %q is not closed", descriptor)
+	}
+
+	if rest = rest[1:]; rest == "V" {
+		method.Return = Type{"void", 0}
+		return method, nil
+	}
+
+	ret, err := ParseFieldDescriptor(rest)
+	method.Return = ret
+	return method, err
+}
+
+// parseType read a field type at the start of descriptor,
+// the rest of descriptor is returned with it
+func parseType(descriptor string) (Type, string, error) {
+	dimensions := len(descriptor) - len(strings.TrimLeft(descriptor, "["))
+	rest := descriptor[dimensions:]
+	if len(rest) == 0 {
+		return Type{}, "", fmt.Errorf("missing type in descriptor %q", descriptor)
+	}
+
+	if name, ok := baseTypes[rest[0]]; ok {
+		return Type{name, dimensions}, rest[1:], nil
+	}
+
+	end := strings.Index(rest, ";")
+	if rest[0] != 'L' || end < 2 {
+		return Type{}, "", fmt.Errorf("invalid type %q in descriptor", rest)
+	}
+	return Type{rest[1:end], dimensions}, rest[end+1:], nil
+}
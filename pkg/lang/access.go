@@ -0,0 +1,185 @@
+package lang
+
+import (
+	"path"
+	"strings"
+
+	"github.com/gumelarme/yava/pkg/text"
+)
+
+var (
+	msgPrivateAccess   = "%s has private access in %s."
+	msgProtectedAccess = "%s has protected access in %s."
+	msgPackageAccess   = "%s is not public in %s, it cannot be accessed from outside its package."
+)
+
+// packageOf get the package of a class in its internal form, every class
+// declared in the program is in the unnamed package, which is ".".
+func packageOf(t *TypeSymbol) string {
+	return path.Dir(t.base().internalName())
+}
+
+// topLevel get the class that enclose t which is not nested in any other
+func (t *TypeSymbol) topLevel() *TypeSymbol {
+	top := t.base()
+	for top.outer != nil {
+		top = top.outer
+	}
+	return top
+}
+
+// propertyOwner get the class which declare the property name,
+// looked up from t through its super classes.
+func (t *TypeSymbol) propertyOwner(name string) *TypeSymbol {
+	for typeof := t; typeof != nil; typeof = typeof.base().extends {
+		if _, exist := typeof.base().Properties[name]; exist {
+			return typeof.base()
+		}
+	}
+	return nil
+}
+
+// methodOwner get the class which declare method, looked up from t
+// through its super classes. Nil if it is declared by an interface.
+func (t *TypeSymbol) methodOwner(method *MethodSymbol) *TypeSymbol {
+	declared := method.declared()
+	for typeof := t; typeof != nil; typeof = typeof.base().super() {
+		for _, m := range typeof.base().Methods {
+			if m == declared {
+				return typeof.base()
+			}
+		}
+	}
+	return nil
+}
+
+// qualifierOf get the type of the current field, which a member is
+// accessed through, nil if the member is static or accessed through super.
+func (n *NameAnalyzer) qualifierOf(isStatic bool) *TypeSymbol {
+	if isStatic || isTypeReference(n.curField) || isSuper(n.curField) {
+		return nil
+	}
+	return n.curField.Type().dataType
+}
+
+// enclosingClasses get the classes whose body enclose the current
+// scope, the innermost first. A lambda is also counted as a class.
+func (n *NameAnalyzer) enclosingClasses() []*TypeSymbol {
+	var classes []*TypeSymbol
+	for table := &n.scope; table != nil; table = table.parent {
+		if table.owner != nil {
+			classes = append(classes, table.owner)
+		}
+	}
+	return classes
+}
+
+// checkAccess report an error if the member name of owner, which has
+// access, cannot be used from the current class. The qualifier is
+// the type the member is accessed through, nil for a static member.
+// A private member is accessible inside its top level class, and the
+// other members of the unnamed package are accessible in the program.
+// A protected member of another package is only accessible in its
+// subclass, through the subclass itself, since the JVM check the same.
+func (n *NameAnalyzer) checkAccess(name string, access text.AccessModifier, owner, qualifier *TypeSymbol) bool {
+	if owner == nil || access&text.Public != 0 {
+		return true
+	}
+
+	classes := n.enclosingClasses()
+	if access&text.Private != 0 {
+		if len(classes) == 0 || classes[len(classes)-1] != owner.topLevel() {
+			n.AddErrorf(msgPrivateAccess, name, owner.name)
+			return false
+		}
+		return true
+	}
+
+	if packageOf(owner) == "." {
+		return true
+	}
+
+	if access&text.Protected == 0 {
+		n.AddErrorf(msgPackageAccess, name, owner.name)
+		return false
+	}
+
+	// the code is generated into the innermost class
+	var current *TypeSymbol
+	if len(classes) > 0 {
+		current = classes[0]
+	}
+
+	if current == nil || !current.isDescendantOf(owner) ||
+		(qualifier != nil && !qualifier.base().isSubtypeOf(current)) {
+		n.AddErrorf(msgProtectedAccess, name, owner.name)
+		return false
+	}
+	return true
+}
+
+// useNestMember keep the private member of owner which is used from another
+// class of the same top level class. The class file version has no nest
+// mates, so the member could not be used by its nested classes and lambdas,
+// it is written like a member without an access modifier instead.
+func (n *NameAnalyzer) useNestMember(key string, access text.AccessModifier, owner *TypeSymbol) {
+	classes := n.enclosingClasses()
+	if access&text.Private == 0 || owner == nil || len(classes) == 0 || classes[0] == owner.base() {
+		return
+	}
+
+	owner = owner.base()
+	if owner.nestMembers == nil {
+		owner.nestMembers = make(map[string]bool)
+	}
+	owner.nestMembers[key] = true
+}
+
+// enclosingOwner get the class which declare a member used without a
+// qualifier, found by ownerOf from the innermost enclosing class.
+func (n *NameAnalyzer) enclosingOwner(ownerOf func(*TypeSymbol) *TypeSymbol) *TypeSymbol {
+	for _, class := range n.enclosingClasses() {
+		if owner := ownerOf(class); owner != nil {
+			return owner
+		}
+	}
+	return nil
+}
+
+// methodKey get the signature which method is kept by in the class owner
+func methodKey(owner *TypeSymbol, method *MethodSymbol) string {
+	declared := method.declared()
+	for key, m := range owner.base().Methods {
+		if m == declared {
+			return key
+		}
+	}
+	return method.String()
+}
+
+// memberAccess get the access of a member of t written into the class
+// file followed by a space, empty if it has no access modifier. The key
+// of a property is its name, and a method its declared signature.
+func memberAccess(t *TypeSymbol, key string, access text.AccessModifier) string {
+	if t != nil && t.nestMembers[key] {
+		access &^= text.Private
+	}
+
+	var words []string
+	if access&(text.Public|text.Protected|text.Private) != 0 {
+		words = append(words, (access &^ (text.Static | text.Final)).String())
+	}
+
+	if access&text.Static != 0 {
+		words = append(words, "static")
+	}
+
+	if access&text.Final != 0 {
+		words = append(words, "final")
+	}
+
+	if len(words) == 0 {
+		return ""
+	}
+	return strings.Join(words, " ") + " "
+}
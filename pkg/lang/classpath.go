@@ -0,0 +1,318 @@
+package lang
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/gumelarme/yava/pkg/classfile"
+	"github.com/gumelarme/yava/pkg/text"
+)
+
+// UseClasspath let the analyzer import the types which are not declared
+// from the class files of cp, a type is imported when it is first referred.
+func (t *TypeAnalyzer) UseClasspath(cp *classfile.Classpath) {
+	t.classpath = cp
+}
+
+// VisitImport declare the imported class by its simple name, the classes
+// of a package imported on demand are declared when they are referred.
+func (t *TypeAnalyzer) VisitImport(imp *text.Import) {
+	name := strings.ReplaceAll(imp.Name, ".", "/")
+	if imp.IsOnDemand {
+		t.packages = append(t.packages, name)
+		return
+	}
+
+	typeof := t.importClass(name)
+	if typeof == nil {
+		t.AddErrorf(msgImportNotFound, imp.Name)
+		return
+	}
+	t.table[path.Base(name)] = typeof
+}
+
+// importNamed import the type of named and its type arguments,
+// only the first name of a qualified name is a top level type.
+func (t *TypeAnalyzer) importNamed(named text.NamedType) {
+	t.importType(strings.Split(named.Name, ".")[0])
+	for _, arg := range named.TypeArgs {
+		t.importNamed(arg)
+	}
+}
+
+// importType declare the class with the simple name if there is no type
+// of that name yet. Like java, only the classes imported by their name,
+// those of the packages imported on demand and of java.lang are visible.
+func (t *TypeAnalyzer) importType(name string) {
+	if t.typeExist(name) {
+		return
+	}
+
+	for _, pkg := range t.packages {
+		if typeof := t.importClass(pkg + "/" + name); typeof != nil {
+			t.table[name] = typeof
+			return
+		}
+	}
+
+	if typeof := t.importClass("java/lang/" + name); typeof != nil {
+		t.table[name] = typeof
+	}
+}
+
+// notImported get the error of a type which is not visible, it tell
+// the class to import if there is one of that name in the classpath.
+func (t *TypeAnalyzer) notImported(named text.NamedType, msg string) string {
+	if t.classpath == nil {
+		return msg
+	}
+
+	name := strings.Split(named.Name, ".")[0]
+	if !t.typeExist(name) {
+		if class, err := t.classpath.FindSimple(name); err == nil {
+			return fmt.Sprintf(msgClassNotImported, name, strings.ReplaceAll(class.ThisClass, "/", "."))
+		}
+	}
+
+	for _, arg := range named.TypeArgs {
+		if argMsg := t.notImported(arg, ""); len(argMsg) > 0 {
+			return argMsg
+		}
+	}
+	return msg
+}
+
+// The types referred inside a method body are imported as they are visited,
+// so they are declared before the name analyzer need them.
+
+func (t *TypeAnalyzer) VisitVariableDeclaration(v *text.VariableDeclaration) {
+	t.importNamed(v.Type)
+}
+
+// ImportFrom let the name analyzer import the classes from the classpath of
+// t, which is only done once a simple name is known not to be a variable.
+func (n *NameAnalyzer) ImportFrom(t *TypeAnalyzer) {
+	n.importer = t
+}
+
+// lookupType get the type of a simple name in an expression, it is imported
+// if it is not declared yet, the errors of the import are reported here.
+func (n *NameAnalyzer) lookupType(name string) *TypeSymbol {
+	if typeof := n.typeTable.Lookup(name); typeof != nil || n.importer == nil {
+		return typeof
+	}
+
+	count := len(n.importer.ErrorCollector)
+	n.importer.importType(name)
+	for _, err := range n.importer.ErrorCollector[count:] {
+		n.AddError(err)
+	}
+	return n.typeTable.Lookup(name)
+}
+
+// VisitFieldAccess import nothing, the name can be a variable or a type,
+// e.g. Helper.run(), it is imported by the name analyzer once it is known.
+func (t *TypeAnalyzer) VisitFieldAccess(field *text.FieldAccess) {}
+
+func (t *TypeAnalyzer) VisitArrayCreation(arr *text.ArrayCreation) {
+	t.importType(arr.Type)
+}
+
+func (t *TypeAnalyzer) VisitObjectCreation(obj *text.ObjectCreation) {
+	t.importNamed(obj.Type())
+}
+
+func (t *TypeAnalyzer) VisitCast(cast *text.Cast) {
+	t.importNamed(cast.Type)
+}
+
+func (t *TypeAnalyzer) VisitMethodReference(ref *text.MethodReference) {}
+
+// importClass get the type of an internal name, e.g. com/acme/Helper, which
+// is either a library class or declared from its class file if it is not
+// imported yet. Nil is returned if the class is not in the classpath.
+func (t *TypeAnalyzer) importClass(name string) *TypeSymbol {
+	switch name {
+	case "java/lang/Object":
+		return javaLangObject
+	case "java/lang/String":
+		return PrimitiveString
+	}
+
+	if typeof, exist := t.imports[name]; exist {
+		return typeof
+	}
+
+	for _, typeof := range library {
+		if typeof.name == name {
+			return typeof
+		}
+	}
+
+	if t.classpath == nil {
+		return nil
+	}
+
+	class, err := t.classpath.Find(name)
+	if err != nil {
+		if err != classfile.ErrClassNotFound {
+			t.AddErrorf(msgCannotImportClass, name, err)
+		}
+		t.imports[name] = nil
+		return nil
+	}
+	return t.declareClassFile(class)
+}
+
+// declareClassFile create the type of a compiled class. The members whose
+// type cannot be imported or written in yava, e.g. int[][], are left out,
+// and so are the generic signatures, the class is used as a raw type.
+func (t *TypeAnalyzer) declareClassFile(class *classfile.ClassFile) *TypeSymbol {
+	category := Class
+	if class.Is(classfile.AccInterface) {
+		category = Interface
+	}
+
+	typeof := NewType(class.ThisClass, category)
+	typeof.isFinal = class.Is(classfile.AccFinal)
+	// registered before its members, which may refer to the class itself
+	t.imports[class.ThisClass] = typeof
+
+	if len(class.SuperClass) > 0 && class.SuperClass != "java/lang/Object" {
+		typeof.extends = t.importClass(class.SuperClass)
+	}
+
+	// a type only has one interface, the first one which can be imported,
+	// the others are warned since their methods cannot be called
+	ignored := make([]string, 0)
+	for _, name := range class.Interfaces {
+		if typeof.implements == nil {
+			if typeof.implements = t.importClass(name); typeof.implements != nil {
+				continue
+			}
+		}
+		ignored = append(ignored, strings.ReplaceAll(name, "/", "."))
+	}
+
+	if typeof.implements != nil && len(ignored) > 0 {
+		t.AddWarningf(msgInterfacesIgnored, strings.ReplaceAll(class.ThisClass, "/", "."),
+			strings.ReplaceAll(typeof.implements.name, "/", "."), strings.Join(ignored, ", "))
+	}
+
+	for _, field := range class.Fields {
+		if field.Is(classfile.AccSynthetic) {
+			continue
+		}
+
+		typ, err := classfile.ParseFieldDescriptor(field.Descriptor)
+		if err != nil {
+			continue
+		}
+
+		if dt, ok := t.importDescriptorType(typ); ok {
+			typeof.Properties[field.Name] = &PropertySymbol{
+				importAccess(field.AccessFlags),
+				FieldSymbol{dt, field.Name},
+				nil,
+			}
+		}
+	}
+
+	for _, method := range class.Methods {
+		t.declareClassFileMethod(typeof, method)
+	}
+	return typeof
+}
+
+func (t *TypeAnalyzer) declareClassFileMethod(typeof *TypeSymbol, member classfile.Member) {
+	if member.Name == "<clinit>" || member.Is(classfile.AccSynthetic|classfile.AccBridge) {
+		return
+	}
+
+	// default methods are not supported, so the instance methods
+	// of an interface are the ones to be implemented
+	if typeof.TypeCategory == Interface && !member.Is(classfile.AccStatic|classfile.AccAbstract) {
+		return
+	}
+
+	descriptor, err := classfile.ParseMethodDescriptor(member.Descriptor)
+	if err != nil {
+		return
+	}
+
+	args := make([]DataType, len(descriptor.Params))
+	for i, param := range descriptor.Params {
+		dt, ok := t.importDescriptorType(param)
+		if !ok {
+			return
+		}
+		args[i] = dt
+	}
+
+	returnType, ok := DataType{typeof, false}, true
+	if member.Name != "<init>" {
+		returnType, ok = t.importDescriptorType(descriptor.Return)
+	}
+
+	if !ok {
+		return
+	}
+
+	access := importAccess(member.AccessFlags)
+	method := &MethodSymbol{
+		DataType:  returnType,
+		accessMod: access,
+		name:      member.Name,
+		args:      args,
+		isStatic:  access&text.Static != 0,
+		isVarargs: member.Is(classfile.AccVarargs),
+	}
+
+	if member.Name == "<init>" {
+		method.name = typeof.name[strings.LastIndex(typeof.name, "/")+1:]
+		typeof.constructors = append(typeof.constructors, method)
+		return
+	}
+	// keyed by the internal names, e.g. fit(com/acme/Shape), so the
+	// parameters of the same simple name in other packages do not clash
+	typeof.Methods[method.String()] = method
+}
+
+// importDescriptorType get the data type of a type in a descriptor,
+// false if it is not imported or it has more than one dimension.
+func (t *TypeAnalyzer) importDescriptorType(typ classfile.Type) (DataType, bool) {
+	var typeof *TypeSymbol
+	switch {
+	case typ.Dimensions > 1:
+		return DataType{}, false
+	case typ.Name == "void":
+		typeof = NewType("void", Primitive)
+	case typ.IsPrimitive():
+		typeof = t.table[typ.Name]
+	default:
+		typeof = t.importClass(typ.Name)
+	}
+	return DataType{typeof, typ.Dimensions == 1}, typeof != nil
+}
+
+var classFileAccess = []struct {
+	flag   uint16
+	access text.AccessModifier
+}{
+	{classfile.AccPublic, text.Public},
+	{classfile.AccProtected, text.Protected},
+	{classfile.AccPrivate, text.Private},
+	{classfile.AccStatic, text.Static},
+	{classfile.AccFinal, text.Final},
+}
+
+func importAccess(flags uint16) text.AccessModifier {
+	var access text.AccessModifier
+	for _, a := range classFileAccess {
+		if flags&a.flag != 0 {
+			access |= a.access
+		}
+	}
+	return access
+}
@@ -0,0 +1,446 @@
+package lang
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/gumelarme/yava/pkg/text"
+)
+
+// constantVariable is a static final property of a primitive or String type,
+// its value is folded from the initializer when it is first used.
+type constantVariable struct {
+	owner       *TypeSymbol
+	initializer text.Expression
+	value       text.Expression
+	isFolding   bool
+}
+
+// isConstantType check if a property of dt can be a constant variable
+func isConstantType(dt DataType) bool {
+	return IsPrimitive(dt) || (!dt.isArray && dt.dataType == PrimitiveString)
+}
+
+// valueOf get the value of the constant as a literal of dt,
+// nil if the initializer is not a constant expression.
+func (c *constantVariable) valueOf(table TypeTable, dt DataType) text.Expression {
+	// a constant that refer to itself is not a constant expression
+	if c.value != nil || c.isFolding {
+		return c.value
+	}
+
+	c.isFolding = true
+	defer func() { c.isFolding = false }()
+	value := table.fold(c.initializer, c.owner.typesInScope(), c.owner.lookupConstant)
+	if value != nil && isConstantAssignable(table, value, dt) {
+		c.value = convertConstant(value, dt)
+	}
+	return c.value
+}
+
+// lookupConstant find a property by its simple name in t,
+// then in the classes that enclose it.
+func (t *TypeSymbol) lookupConstant(name string) *PropertySymbol {
+	for current := t; current != nil; current = current.outer {
+		if prop := current.LookupProperty(name); prop != nil {
+			return prop
+		}
+	}
+	return nil
+}
+
+// lookupConstant find a property by its simple name in the
+// current scope, a local variable of the same name shadow it.
+func (n *NameAnalyzer) lookupConstant(name string) *PropertySymbol {
+	member, _ := n.scope.Lookup(name, true)
+	prop, _ := member.(*PropertySymbol)
+	return prop
+}
+
+// literalType get the type of a folded constant
+func literalType(table TypeTable, value text.Expression) DataType {
+	typename, _ := value.NodeContent()
+	return DataType{table.Lookup(typename), false}
+}
+
+// isConstantAssignable check if value can be the value of a variable of dt
+func isConstantAssignable(table TypeTable, value text.Expression, dt DataType) bool {
+	return isAssignable(value, literalType(table, value), dt)
+}
+
+// fold evaluate ex if it is a constant expression, nil otherwise. A simple
+// name is found by lookup, while a qualified one start from types.
+func (t TypeTable) fold(ex text.Expression, types []*TypeSymbol, lookup func(string) *PropertySymbol) text.Expression {
+	switch val := ex.(type) {
+	case text.Num, text.Long, text.Float, text.Double, text.Char, text.Boolean, text.String:
+		return ex
+	case *text.UnaryOp:
+		if operand := t.fold(val.Exp, types, lookup); operand != nil {
+			return foldUnary(val.Operator.Type, operand)
+		}
+	case *text.BinOp:
+		left := t.fold(val.Left, types, lookup)
+		right := t.fold(val.Right, types, lookup)
+		if left != nil && right != nil {
+			return foldBinary(val.GetOperator().Type, left, right)
+		}
+	case *text.Cast:
+		if operand := t.fold(val.Exp, types, lookup); operand != nil {
+			return castConstant(operand, val.Type.Name)
+		}
+	case *text.FieldAccess:
+		if prop := t.constantProperty(val, types, lookup); prop != nil {
+			return prop.constant.valueOf(t, prop.DataType)
+		}
+	}
+	return nil
+}
+
+// constantProperty get the constant variable that field refer to,
+// either by its simple name or qualified by its type, e.g. Limits.MAX
+func (t TypeTable) constantProperty(field *text.FieldAccess, types []*TypeSymbol, lookup func(string) *PropertySymbol) *PropertySymbol {
+	names := []string{field.Name}
+	for child := field.Child; child != nil; child = child.GetChild() {
+		next, ok := child.(*text.FieldAccess)
+		if !ok {
+			return nil
+		}
+		names = append(names, next.Name)
+	}
+
+	var prop *PropertySymbol
+	if last := len(names) - 1; last == 0 {
+		prop = lookup(field.Name)
+	} else if typeof := t.lookupQualified(strings.Join(names[:last], "."), types); typeof != nil {
+		prop = typeof.LookupProperty(names[last])
+	}
+
+	if prop == nil || prop.constant == nil {
+		return nil
+	}
+	return prop
+}
+
+// numericKind get the type a numeric constant is promoted into,
+// empty if it is not numeric.
+func numericKind(value text.Expression) string {
+	switch value.(type) {
+	case text.Num, text.Char:
+		return "int"
+	case text.Long:
+		return "long"
+	case text.Float:
+		return "float"
+	case text.Double:
+		return "double"
+	}
+	return ""
+}
+
+func integralValue(value text.Expression) int64 {
+	switch val := value.(type) {
+	case text.Num:
+		return int64(val)
+	case text.Char:
+		return int64(val)
+	case text.Long:
+		return int64(val)
+	case text.Float:
+		return int64(val)
+	case text.Double:
+		return int64(val)
+	}
+	return 0
+}
+
+func floatingValue(value text.Expression) float64 {
+	switch val := value.(type) {
+	case text.Float:
+		return float64(val)
+	case text.Double:
+		return float64(val)
+	}
+	return float64(integralValue(value))
+}
+
+// promotedKind get the type both numeric operand are converted into
+func promotedKind(left, right string) string {
+	for _, kind := range []string{"double", "float", "long"} {
+		if left == kind || right == kind {
+			return kind
+		}
+	}
+	return "int"
+}
+
+func foldUnary(operator text.TokenType, operand text.Expression) text.Expression {
+	kind := numericKind(operand)
+	if len(kind) == 0 {
+		return nil
+	}
+
+	if operator == text.Addition {
+		return castConstant(operand, kind)
+	}
+
+	switch kind {
+	case "int":
+		return text.Num(-int32(integralValue(operand)))
+	case "long":
+		return text.Long(-integralValue(operand))
+	case "float":
+		return text.Float(-float32(floatingValue(operand)))
+	default:
+		return text.Double(-floatingValue(operand))
+	}
+}
+
+func foldBinary(operator text.TokenType, left, right text.Expression) text.Expression {
+	_, leftIsString := left.(text.String)
+	_, rightIsString := right.(text.String)
+	if operator == text.Addition && (leftIsString || rightIsString) {
+		leftStr, leftOk := constantString(left)
+		rightStr, rightOk := constantString(right)
+		if !leftOk || !rightOk {
+			return nil
+		}
+		return text.String(leftStr + rightStr)
+	}
+
+	leftBool, leftIsBool := left.(text.Boolean)
+	rightBool, rightIsBool := right.(text.Boolean)
+	if leftIsBool && rightIsBool {
+		return foldBoolean(operator, leftBool, rightBool)
+	}
+
+	leftKind, rightKind := numericKind(left), numericKind(right)
+	if len(leftKind) == 0 || len(rightKind) == 0 {
+		return nil
+	}
+
+	switch operator {
+	case text.LeftShift, text.RightShift, text.UnsignedRightShift:
+		return foldShift(operator, castConstant(left, leftKind), integralValue(right))
+	}
+
+	switch kind := promotedKind(leftKind, rightKind); kind {
+	case "int", "long":
+		return foldIntegral(operator, kind, integralValue(left), integralValue(right))
+	default:
+		return foldFloating(operator, kind, floatingValue(left), floatingValue(right))
+	}
+}
+
+func foldBoolean(operator text.TokenType, left, right text.Boolean) text.Expression {
+	switch operator {
+	case text.And, text.BitwiseAnd:
+		return left && right
+	case text.Or, text.BitwiseOr:
+		return left || right
+	case text.BitwiseXor, text.NotEqual:
+		return text.Boolean(left != right)
+	case text.Equal:
+		return text.Boolean(left == right)
+	}
+	return nil
+}
+
+// foldIntegral evaluate an int or long operation, int overflow
+// is wrapped around and a division by zero is not a constant.
+func foldIntegral(operator text.TokenType, kind string, left, right int64) text.Expression {
+	if kind == "int" {
+		left, right = int64(int32(left)), int64(int32(right))
+	}
+
+	var result int64
+	switch operator {
+	case text.Addition:
+		result = left + right
+	case text.Subtraction:
+		result = left - right
+	case text.Multiplication:
+		result = left * right
+	case text.Division, text.Modulus:
+		if right == 0 {
+			return nil
+		}
+
+		if operator == text.Division {
+			result = left / right
+		} else {
+			result = left % right
+		}
+	case text.BitwiseAnd:
+		result = left & right
+	case text.BitwiseOr:
+		result = left | right
+	case text.BitwiseXor:
+		result = left ^ right
+	default:
+		return compareConstant(operator, left < right, left == right)
+	}
+
+	if kind == "int" {
+		return text.Num(int32(result))
+	}
+	return text.Long(result)
+}
+
+func foldFloating(operator text.TokenType, kind string, left, right float64) text.Expression {
+	var result float64
+	switch operator {
+	case text.Addition:
+		result = left + right
+	case text.Subtraction:
+		result = left - right
+	case text.Multiplication:
+		result = left * right
+	case text.Division:
+		result = left / right
+	case text.Modulus:
+		result = math.Mod(left, right)
+	case text.BitwiseAnd, text.BitwiseOr, text.BitwiseXor:
+		return nil
+	default:
+		if math.IsNaN(left) || math.IsNaN(right) {
+			return text.Boolean(operator == text.NotEqual)
+		}
+		return compareConstant(operator, left < right, left == right)
+	}
+
+	if kind == "float" {
+		return text.Float(float32(result))
+	}
+	return text.Double(result)
+}
+
+// compareConstant evaluate a comparison of two ordered operands
+func compareConstant(operator text.TokenType, isLess, isEqual bool) text.Expression {
+	switch operator {
+	case text.GreaterThan:
+		return text.Boolean(!isLess && !isEqual)
+	case text.GreaterThanEqual:
+		return text.Boolean(!isLess)
+	case text.LessThan:
+		return text.Boolean(isLess)
+	case text.LessThanEqual:
+		return text.Boolean(isLess || isEqual)
+	case text.Equal:
+		return text.Boolean(isEqual)
+	case text.NotEqual:
+		return text.Boolean(!isEqual)
+	}
+	return nil
+}
+
+// foldShift shift an int or long, only the lowest 5 or 6 bits
+// of the distance are used like the shift instruction does.
+func foldShift(operator text.TokenType, left text.Expression, distance int64) text.Expression {
+	switch val := left.(type) {
+	case text.Num:
+		n, shift := int32(val), uint(distance&31)
+		switch operator {
+		case text.LeftShift:
+			return text.Num(n << shift)
+		case text.RightShift:
+			return text.Num(n >> shift)
+		default:
+			return text.Num(int32(uint32(n) >> shift))
+		}
+	case text.Long:
+		n, shift := int64(val), uint(distance&63)
+		switch operator {
+		case text.LeftShift:
+			return text.Long(n << shift)
+		case text.RightShift:
+			return text.Long(n >> shift)
+		default:
+			return text.Long(int64(uint64(n) >> shift))
+		}
+	}
+	return nil
+}
+
+// castConstant convert a constant into a primitive type, byte and short
+// are kept as int since there is no literal for them.
+func castConstant(value text.Expression, typename string) text.Expression {
+	if typename == "boolean" {
+		if _, ok := value.(text.Boolean); ok {
+			return value
+		}
+		return nil
+	}
+
+	kind := numericKind(value)
+	if len(kind) == 0 {
+		return nil
+	}
+
+	isFloating := kind == "float" || kind == "double"
+	switch typename {
+	case "int", "char", "byte", "short":
+		var n int32
+		if isFloating {
+			n = int32(saturate(floatingValue(value), math.MinInt32, math.MaxInt32))
+		} else {
+			n = int32(integralValue(value))
+		}
+
+		switch typename {
+		case "char":
+			return text.Char(uint16(n))
+		case "byte":
+			return text.Num(int8(n))
+		case "short":
+			return text.Num(int16(n))
+		}
+		return text.Num(n)
+	case "long":
+		if isFloating {
+			return text.Long(saturate(floatingValue(value), math.MinInt64, math.MaxInt64))
+		}
+		return text.Long(integralValue(value))
+	case "float":
+		return text.Float(float32(floatingValue(value)))
+	case "double":
+		return text.Double(floatingValue(value))
+	}
+	return nil
+}
+
+// saturate convert a floating point into an integer the way Java does,
+// NaN become zero and anything out of range become the nearest bound.
+func saturate(f float64, min, max int64) int64 {
+	switch {
+	case math.IsNaN(f):
+		return 0
+	case f <= float64(min):
+		return min
+	case f >= float64(max):
+		return max
+	}
+	return int64(f)
+}
+
+// convertConstant get the literal of value as it is stored in dt
+func convertConstant(value text.Expression, dt DataType) text.Expression {
+	if dt.dataType == PrimitiveString {
+		return value
+	}
+	return castConstant(value, dt.Name())
+}
+
+// constantString get the string of a constant as it is concatenated,
+// floating point is not folded since its format differ from Java.
+func constantString(value text.Expression) (string, bool) {
+	switch val := value.(type) {
+	case text.String:
+		return string(val), true
+	case text.Char:
+		return string(rune(val)), true
+	case text.Num, text.Long, text.Boolean:
+		return fmt.Sprint(val), true
+	}
+	return "", false
+}
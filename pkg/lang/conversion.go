@@ -0,0 +1,214 @@
+package lang
+
+import "github.com/gumelarme/yava/pkg/text"
+
+// conversionContext is where a value is converted into another type,
+// which decide the conversions allowed, see chapter 5 of the JLS.
+type conversionContext int
+
+const (
+	// assignmentContext convert the value assigned, initialized or returned
+	assignmentContext conversionContext = iota
+	// invocationContext convert the arguments passed into a method
+	invocationContext
+	// castingContext convert the operand of a cast
+	castingContext
+)
+
+// isConvertible check if a value of from can be converted into to in context.
+// There is no boxing, so an assignment allow the same conversions as an
+// invocation, except the narrowing of a constant, see isAssignable.
+func isConvertible(from, to DataType, context conversionContext) bool {
+	if from.dataType == nil || to.dataType == nil {
+		return false
+	}
+
+	// the error is already reported
+	if isErrorType(from) || isErrorType(to) {
+		return true
+	}
+
+	if isIdentity(from, to) || isWideningPrimitive(from, to) || isWideningReference(from, to) {
+		return true
+	}
+
+	if context == castingContext {
+		return (IsNumeric(from) && IsNumeric(to)) || isNarrowingReference(from, to)
+	}
+	return false
+}
+
+// isAssignable check if exp of type from can be assigned into to,
+// an int constant can also be narrowed if it fit into to.
+func isAssignable(exp text.Expression, from, to DataType) bool {
+	return isConvertible(from, to, assignmentContext) || isConstantNarrowing(exp, to)
+}
+
+func isIdentity(from, to DataType) bool {
+	return from.Equals(to)
+}
+
+// isReference check if dt is a class, interface, type variable or array type
+func isReference(dt DataType) bool {
+	if dt.isArray {
+		return true
+	}
+
+	switch dt.Name() {
+	case "null", "void":
+		return false
+	default:
+		return IsNullOk(dt)
+	}
+}
+
+func isNullType(dt DataType) bool {
+	return !dt.isArray && dt.Name() == "null"
+}
+
+// errorType is the type of an expression whose error is already reported,
+// it is accepted anywhere so the error does not cause any other error.
+var errorType = DataType{NewType("<error>", Primitive), false}
+
+func isErrorType(dt DataType) bool {
+	return dt.dataType == errorType.dataType
+}
+
+// hasErrorType check if any of types is errorType
+func hasErrorType(types ...DataType) bool {
+	for _, dt := range types {
+		if isErrorType(dt) {
+			return true
+		}
+	}
+	return false
+}
+
+// isWideningPrimitive check if from can be converted to
+// another numeric type without an explicit cast
+func isWideningPrimitive(from, to DataType) bool {
+	if !IsNumeric(from) || !IsNumeric(to) || from.Name() == to.Name() {
+		return false
+	}
+
+	if to.Name() == "char" {
+		return false
+	}
+
+	if from.Name() == "char" {
+		return numericRank[to.Name()] >= numericRank["int"]
+	}
+
+	return numericRank[from.Name()] < numericRank[to.Name()]
+}
+
+// isWideningReference check if from is a subtype of to. null is a subtype
+// of every reference, and an array is covariant in its reference component.
+func isWideningReference(from, to DataType) bool {
+	if !isReference(to) {
+		return false
+	}
+
+	if isNullType(from) {
+		return true
+	}
+
+	if !isReference(from) {
+		return false
+	}
+
+	// every reference is an object, including arrays
+	if to == (DataType{javaLangObject, false}) {
+		return true
+	}
+
+	if from.isArray != to.isArray || !isReference(component(from)) || !isReference(component(to)) {
+		return false
+	}
+	return from.dataType.isSubtypeOf(to.dataType)
+}
+
+// isNarrowingReference check if a reference of from may refer to an object
+// of to, which is checked at run time. A class can be cast into an interface
+// unless it is final, since its subclass may implement the interface.
+func isNarrowingReference(from, to DataType) bool {
+	if !isReference(from) || !isReference(to) {
+		return false
+	}
+
+	from, to = upperBound(from), upperBound(to)
+	if from == (DataType{javaLangObject, false}) || isWideningReference(from, to) {
+		return true
+	}
+
+	if from.isArray || to.isArray {
+		return from.isArray == to.isArray &&
+			isReference(component(from)) && isReference(component(to)) &&
+			isConvertible(component(from), component(to), castingContext)
+	}
+
+	source, target := from.dataType, to.dataType
+	switch {
+	case target.isSubtypeOf(source) || source.isSubtypeOf(target):
+		return true
+	case source.TypeCategory == Interface && target.TypeCategory == Interface:
+		return true
+	case source.TypeCategory == Interface:
+		return !target.base().isFinal
+	case target.TypeCategory == Interface:
+		return !source.base().isFinal
+	default:
+		return false
+	}
+}
+
+// isComparableReference check if the references left and right can be
+// compared with == or !=, one of them should be castable into the other.
+func isComparableReference(left, right DataType) bool {
+	if !(isReference(left) || isNullType(left)) || !(isReference(right) || isNullType(right)) {
+		return false
+	}
+	return isConvertible(left, right, castingContext) || isConvertible(right, left, castingContext)
+}
+
+// component get the type of the elements of an array
+func component(dt DataType) DataType {
+	return DataType{dt.dataType, false}
+}
+
+// upperBound get the bound of a type variable, Object if it has none
+func upperBound(dt DataType) DataType {
+	if dt.dataType.TypeCategory != TypeVariable {
+		return dt
+	}
+
+	if bound := dt.dataType.bound(); bound != nil {
+		return upperBound(DataType{bound, dt.isArray})
+	}
+	return DataType{javaLangObject, dt.isArray}
+}
+
+// isSubtypeOf check if t extends val, or implements it directly or through
+// its super classes. A type variable is a subtype of its bound.
+func (t *TypeSymbol) isSubtypeOf(val *TypeSymbol) bool {
+	return t == val || t.isDescendantOf(val) || t.isImplementing(val)
+}
+
+// unaryNumericPromotion promote byte, short and char into int
+func unaryNumericPromotion(dt DataType) DataType {
+	if isIntegral(dt) && numericRank[dt.Name()] < numericRank["int"] {
+		return DataType{PrimitiveInt, false}
+	}
+	return dt
+}
+
+// binaryNumericPromotion get the type both numeric operand
+// should be converted into before doing an operation
+func binaryNumericPromotion(left, right DataType) DataType {
+	for _, sym := range []*TypeSymbol{PrimitiveDouble, PrimitiveFloat, PrimitiveLong} {
+		if left.dataType == sym || right.dataType == sym {
+			return DataType{sym, false}
+		}
+	}
+	return DataType{PrimitiveInt, false}
+}
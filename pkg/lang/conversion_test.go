@@ -0,0 +1,60 @@
+package lang
+
+import "testing"
+
+func Test_isConvertible(t *testing.T) {
+	speak := NewType("ISpeak", Interface)
+	walk := NewType("IWalk", Interface)
+	animal := NewType("Animal", Class)
+	animal.implements = speak
+	cat := NewType("Cat", Class)
+	cat.extends = animal
+	rock := NewType("Rock", Class)
+	rock.isFinal = true
+
+	reference := func(sym *TypeSymbol) DataType { return DataType{sym, false} }
+	array := func(sym *TypeSymbol) DataType { return DataType{sym, true} }
+	object := reference(javaLangObject)
+	null := reference(PrimitiveNull)
+
+	data := []struct {
+		from, to DataType
+		context  conversionContext
+		expect   bool
+	}{
+		{mockChar, mockInt, assignmentContext, true},
+		{mockInt, mockChar, assignmentContext, false},
+		{mockInt, mockChar, castingContext, true},
+		{mockDouble, mockByte, castingContext, true},
+		{mockBoolean, mockInt, castingContext, false},
+		{mockBoolean, mockBoolean, castingContext, true},
+		{reference(cat), reference(animal), assignmentContext, true},
+		{reference(cat), reference(speak), assignmentContext, true},
+		{reference(cat), object, invocationContext, true},
+		{reference(animal), reference(cat), assignmentContext, false},
+		{reference(animal), reference(cat), castingContext, true},
+		{reference(speak), reference(cat), castingContext, true},
+		{reference(speak), reference(walk), castingContext, true},
+		{reference(rock), reference(speak), castingContext, false},
+		{reference(rock), reference(cat), castingContext, false},
+		{null, mockString, assignmentContext, true},
+		{null, array(PrimitiveInt), invocationContext, true},
+		{null, mockInt, assignmentContext, false},
+		{null, mockInt, castingContext, false},
+		{array(cat), array(animal), assignmentContext, true},
+		{array(animal), array(cat), assignmentContext, false},
+		{array(animal), array(cat), castingContext, true},
+		{array(PrimitiveInt), array(PrimitiveLong), assignmentContext, false},
+		{array(PrimitiveInt), array(PrimitiveLong), castingContext, false},
+		{array(PrimitiveInt), object, assignmentContext, true},
+		{object, array(cat), castingContext, true},
+		{array(cat), reference(cat), castingContext, false},
+		{mockInt, object, invocationContext, false},
+	}
+
+	for _, d := range data {
+		if res := isConvertible(d.from, d.to, d.context); res != d.expect {
+			t.Errorf("Converting %s to %s in context %d expected to be %v", d.from, d.to, d.context, d.expect)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package lang
+
+import (
+	"github.com/gumelarme/yava/pkg/text"
+)
+
+// typeReference is a name that refer to a type instead of a value,
+// only the static member can be accessed from it, e.g. Color.RED
+type typeReference struct {
+	FieldSymbol
+}
+
+func newTypeReference(typeof *TypeSymbol) *typeReference {
+	return &typeReference{FieldSymbol{DataType{typeof, false}, typeof.name}}
+}
+
+func isTypeReference(member TypeMember) bool {
+	_, ok := member.(*typeReference)
+	return ok
+}
+
+// memberTypeOf get the nested type named by field when it is qualified
+// by the type reference, e.g. Inner of Outer.Inner.MAX, nil otherwise.
+func memberTypeOf(ref TypeMember, field *text.FieldAccess) *TypeSymbol {
+	if !isTypeReference(ref) || field.Child == nil {
+		return nil
+	}
+	return lookupTypeVariable(ref.Type().dataType.base().members, field.Name)
+}
+
+// isEnumConstant check if prop is one of the constant of typeof
+func isEnumConstant(typeof *TypeSymbol, prop *PropertySymbol) bool {
+	return prop != nil && typeof.TypeCategory == Enum &&
+		prop.dataType == typeof && typeof.ordinalOf(prop.name) != -1
+}
+
+// caseKey get the int value of a case label, enum constant
+// is represented by its ordinal and string by its hash code.
+func caseKey(label text.Expression, switchType DataType) (int, bool) {
+	isEnum := switchType.dataType.TypeCategory == Enum
+	switch val := label.(type) {
+	case text.Num:
+		return int(val), !isEnum
+	case text.Char:
+		return int(val), !isEnum
+	case text.String:
+		return int(stringHash(string(val))), !isEnum
+	case *text.FieldAccess:
+		if !isEnum || val.Child != nil {
+			return 0, false
+		}
+
+		ordinal := switchType.dataType.ordinalOf(val.Name)
+		return ordinal, ordinal != -1
+	}
+	return 0, false
+}
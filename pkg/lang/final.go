@@ -0,0 +1,320 @@
+package lang
+
+import (
+	"github.com/gumelarme/yava/pkg/text"
+)
+
+var (
+	msgCannotAssignFinal   = "Cannot assign a value to final variable %s."
+	msgFinalMaybeAssigned  = "Variable %s might already have been assigned."
+	msgFinalAssignedInLoop = "Variable %s might be assigned in loop."
+	msgFinalNotInitialized = "Variable %s might not have been initialized."
+)
+
+// finalFlow is the assignment state of the blank final variables at a
+// point of a method. A blank final can only be assigned when it is
+// definitely unassigned, and must be definitely assigned at the end
+// of every constructor if it is a property.
+type finalFlow struct {
+	// unassigned keep the number of loops enclosing the declaration,
+	// a blank final cannot be assigned inside a loop it is declared out of
+	unassigned map[TypeMember]int
+	assigned   map[TypeMember]bool
+	// returned is set after a return statement, the rest of the
+	// branch is never run so it is left out when the branches join
+	returned bool
+}
+
+func newFinalFlow() finalFlow {
+	return finalFlow{make(map[TypeMember]int), make(map[TypeMember]bool), false}
+}
+
+func (f finalFlow) copy() finalFlow {
+	flow := newFinalFlow()
+	for member, loops := range f.unassigned {
+		flow.unassigned[member] = loops
+	}
+
+	for member := range f.assigned {
+		flow.assigned[member] = true
+	}
+	flow.returned = f.returned
+	return flow
+}
+
+// merge get the state after two branches join, a variable is still
+// definitely (un)assigned only if it is so in both of them.
+func (f finalFlow) merge(other finalFlow) finalFlow {
+	if f.returned {
+		return other.copy()
+	} else if other.returned {
+		return f.copy()
+	}
+
+	flow := newFinalFlow()
+	for member, loops := range f.unassigned {
+		if _, ok := other.unassigned[member]; ok {
+			flow.unassigned[member] = loops
+		}
+	}
+
+	for member := range f.assigned {
+		if other.assigned[member] {
+			flow.assigned[member] = true
+		}
+	}
+	return flow
+}
+
+// branchFlow keep the state before an if statement, along
+// with the state at the end of its body once it is visited.
+type branchFlow struct {
+	stmt   *text.IfStatement
+	before finalFlow
+	body   finalFlow
+}
+
+// finalState track the blank finals of the method being analyzed
+type finalState struct {
+	flow     finalFlow
+	branches []branchFlow
+	// loops keep the assigned variables before each enclosing loop,
+	// since the body of a loop may not be executed at all
+	loops []map[TypeMember]bool
+	// constructing is the class whose constructor or initializer is being
+	// analyzed, blankFinals are its properties that must be assigned by it
+	constructing *TypeSymbol
+	blankFinals  []*PropertySymbol
+	// initialized is the state after the instance initializers, which
+	// every constructor start from, staticInitialized is the same for
+	// the static initializers, isStatic tell which one is analyzed
+	initialized       finalFlow
+	staticInitialized finalFlow
+	isStatic          bool
+}
+
+func newFinalState() finalState {
+	return finalState{
+		flow:              newFinalFlow(),
+		initialized:       newFinalFlow(),
+		staticInitialized: newFinalFlow(),
+	}
+}
+
+// enterClass start tracking the blank final properties of a class
+func (f *finalState) enterClass(blankFinals []*PropertySymbol) {
+	f.blankFinals = blankFinals
+	f.initialized, f.staticInitialized = newFinalFlow(), newFinalFlow()
+	for _, prop := range blankFinals {
+		if prop.AccessModifier&text.Static != 0 {
+			f.staticInitialized.unassigned[prop] = 0
+		} else {
+			f.initialized.unassigned[prop] = 0
+		}
+	}
+}
+
+// enterMethod start tracking a method, constructing is the class of a
+// constructor, nil for any other method. A constructor start after
+// the instance initializers, which are run before its body.
+func (f *finalState) enterMethod(constructing *TypeSymbol) {
+	f.flow, f.branches, f.loops = newFinalFlow(), nil, nil
+	f.constructing, f.isStatic = constructing, false
+	if constructing != nil {
+		f.flow = f.initialized.copy()
+	}
+}
+
+// enterInitializer continue from the state after the previous
+// initializer blocks, since they are run one after another
+func (f *finalState) enterInitializer(class *TypeSymbol, isStatic bool) {
+	f.flow, f.branches, f.loops = f.initialized, nil, nil
+	if isStatic {
+		f.flow = f.staticInitialized
+	}
+	f.constructing, f.isStatic = class, isStatic
+}
+
+func (f *finalState) exitInitializer() {
+	if f.isStatic {
+		f.staticInitialized = f.flow
+	} else {
+		f.initialized = f.flow
+	}
+	f.constructing, f.isStatic = nil, false
+}
+
+// delegate assign every blank final, since they are
+// assigned by the constructor called with this(...)
+func (f *finalState) delegate() {
+	for _, prop := range f.blankFinals {
+		if prop.AccessModifier&text.Static == 0 {
+			delete(f.flow.unassigned, prop)
+			f.flow.assigned[prop] = true
+		}
+	}
+}
+
+// declareBlank make member assignable once in the rest of the method
+func (f *finalState) declareBlank(member TypeMember) {
+	f.flow.unassigned[member] = len(f.loops)
+}
+
+func (f *finalState) isBlankFinal(prop *PropertySymbol) bool {
+	for _, blank := range f.blankFinals {
+		if blank == prop {
+			return true
+		}
+	}
+	return false
+}
+
+// blankFinalsOf get the final properties of class which has no value,
+// in the order they are declared
+func blankFinalsOf(class *text.Class, classType *TypeSymbol) []*PropertySymbol {
+	var blanks []*PropertySymbol
+	for _, prop := range class.Properties {
+		isBlank := prop.AccessModifier&text.Final != 0 && prop.Value == nil
+		if symbol := classType.Properties[prop.Name]; isBlank && symbol != nil {
+			blanks = append(blanks, symbol)
+		}
+	}
+	return blanks
+}
+
+// assignmentTarget get the last name of the left side of an
+// assignment, nil if an array element is assigned instead
+func assignmentTarget(assign *text.AssignmentStatement) *text.FieldAccess {
+	var last text.NamedValue = assign.Left
+	for last.GetChild() != nil {
+		last = last.GetChild()
+	}
+
+	field, _ := last.(*text.FieldAccess)
+	return field
+}
+
+// checkFinalAssignment check an assignment into member, a property can
+// only be assigned through this inside the constructor of its class.
+func (n *NameAnalyzer) checkFinalAssignment(member TypeMember, isThis bool) {
+	isCompound := n.assignment.Operator.Type != text.Assignment
+	n.assignment = nil
+
+	switch sym := member.(type) {
+	case *FieldSymbol:
+		isBlank, isFinal := n.finals[sym]
+		if !isFinal {
+			return
+		}
+
+		if !isBlank {
+			n.AddErrorf(msgCannotAssignFinal, sym.name)
+			return
+		}
+	case *PropertySymbol:
+		if sym.AccessModifier&text.Final == 0 {
+			return
+		}
+
+		isStatic := sym.AccessModifier&text.Static != 0
+		if !isThis || !n.finality.isBlankFinal(sym) || n.finality.constructing == nil || isStatic != n.finality.isStatic {
+			n.AddErrorf(msgCannotAssignFinal, sym.name)
+			return
+		}
+	default:
+		return
+	}
+
+	if isCompound {
+		n.AddErrorf(msgFinalNotInitialized, member.Name())
+		return
+	}
+
+	loops, isUnassigned := n.finality.flow.unassigned[member]
+	if !isUnassigned {
+		n.AddErrorf(msgFinalMaybeAssigned, member.Name())
+		return
+	}
+
+	if loops < len(n.finality.loops) {
+		n.AddErrorf(msgFinalAssignedInLoop, member.Name())
+		return
+	}
+
+	delete(n.finality.flow.unassigned, member)
+	n.finality.flow.assigned[member] = true
+}
+
+// enterBranch keep the state before an if statement
+func (n *NameAnalyzer) enterBranch(stmt *text.IfStatement) {
+	n.finality.branches = append(n.finality.branches, branchFlow{
+		stmt,
+		n.finality.flow.copy(),
+		finalFlow{},
+	})
+}
+
+// enterElse keep the state at the end of the if body,
+// the else body start from the state before the if.
+func (n *NameAnalyzer) enterElse() {
+	branch := &n.finality.branches[len(n.finality.branches)-1]
+	branch.body = n.finality.flow
+	n.finality.flow = branch.before.copy()
+}
+
+// exitBranch join the body and the else of stmt, those of an
+// else-if chain are joined in turn since they end together
+func (n *NameAnalyzer) exitBranch(stmt *text.IfStatement) {
+	for {
+		last := len(n.finality.branches) - 1
+		branch := n.finality.branches[last]
+		n.finality.branches = n.finality.branches[:last]
+		n.finality.flow = branch.body.merge(n.finality.flow)
+
+		if last == 0 {
+			return
+		}
+
+		outer := n.finality.branches[last-1].stmt
+		if elseIf, ok := outer.Else.(*text.IfStatement); !ok || elseIf != stmt {
+			return
+		}
+		stmt = outer
+	}
+}
+
+func (n *NameAnalyzer) enterLoop() {
+	n.finality.loops = append(n.finality.loops, n.finality.flow.copy().assigned)
+}
+
+// exitLoop restore the assigned variables before the loop,
+// assignment in the body cannot be relied on after the loop.
+func (n *NameAnalyzer) exitLoop() {
+	last := len(n.finality.loops) - 1
+	n.finality.flow.assigned = n.finality.loops[last]
+	n.finality.flow.returned = false
+	n.finality.loops = n.finality.loops[:last]
+}
+
+// checkFinalRead report a blank final local which is read before it
+// is definitely assigned, e.g. `final int y; int z = y;`
+func (n *NameAnalyzer) checkFinalRead(member TypeMember) {
+	local, ok := member.(*FieldSymbol)
+	if !ok || !n.finals[local] {
+		return
+	}
+
+	if flow := n.finality.flow; !flow.assigned[local] && !flow.returned {
+		n.AddErrorf(msgFinalNotInitialized, local.name)
+	}
+}
+
+// checkBlankFinals report every blank final property of the current class
+// that is not definitely assigned in flow, static ones if isStatic is set
+func (n *NameAnalyzer) checkBlankFinals(flow finalFlow, isStatic bool) {
+	for _, prop := range n.finality.blankFinals {
+		if (prop.AccessModifier&text.Static != 0) == isStatic && !flow.assigned[prop] {
+			n.AddErrorf(msgFinalNotInitialized, prop.name)
+		}
+	}
+}
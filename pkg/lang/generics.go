@@ -0,0 +1,378 @@
+package lang
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gumelarme/yava/pkg/text"
+)
+
+// resolve get the data type of named, the type variables and nested types
+// in vars shadow any type of the same name. The message of the first
+// problem found is returned along with it, empty if there is none.
+func (t TypeTable) resolve(named text.NamedType, vars []*TypeSymbol) (DataType, string) {
+	typeof := t.lookupQualified(named.Name, vars)
+	if typeof == nil {
+		return DataType{}, fmt.Sprintf(msgTypeNotExist, named.Name)
+	}
+
+	// generic type without type arguments is used as a raw type
+	raw := DataType{typeof, named.IsArray}
+	if len(named.TypeArgs) == 0 {
+		return raw, ""
+	}
+
+	if len(named.TypeArgs) != len(typeof.typeParams) {
+		return raw, fmt.Sprintf(msgWrongTypeArgumentCount,
+			typeof.name,
+			len(typeof.typeParams),
+			len(named.TypeArgs),
+		)
+	}
+
+	args := make([]DataType, len(named.TypeArgs))
+	for i, arg := range named.TypeArgs {
+		dt, msg := t.resolve(arg, vars)
+		if len(msg) > 0 {
+			return raw, msg
+		}
+
+		if IsPrimitive(dt) {
+			return raw, fmt.Sprintf(msgPrimitiveTypeArgument, dt)
+		}
+		args[i] = dt
+	}
+
+	for i, param := range typeof.typeParams {
+		bound := param.bound()
+		if bound == nil {
+			continue
+		}
+
+		expect := substituteType(DataType{bound, false}, typeof.typeParams, args)
+		if !isConvertible(args[i], expect, assignmentContext) {
+			return raw, fmt.Sprintf(msgTypeArgumentNotWithinBound, args[i], param.name, expect)
+		}
+	}
+
+	return DataType{typeof.parameterize(args), named.IsArray}, ""
+}
+
+// lookupTypeVariable find a type in vars by its simple name
+func lookupTypeVariable(vars []*TypeSymbol, name string) *TypeSymbol {
+	for _, v := range vars {
+		if v.simpleName() == name {
+			return v
+		}
+	}
+	return nil
+}
+
+// bound get the upper bound of a type variable, nil if it has none
+func (t *TypeSymbol) bound() *TypeSymbol {
+	if t.TypeCategory != TypeVariable {
+		return nil
+	}
+
+	if t.extends != nil {
+		return t.extends
+	}
+	return t.implements
+}
+
+// base get the generic type of a parameterized type, e.g. Box of Box<String>
+func (t *TypeSymbol) base() *TypeSymbol {
+	if t.generic != nil {
+		return t.generic
+	}
+	return t
+}
+
+// arguments get the type arguments of t, a generic type
+// is considered to be parameterized by its own type variables.
+func (t *TypeSymbol) arguments() []DataType {
+	if t.generic != nil {
+		return t.typeArgs
+	}
+
+	args := make([]DataType, len(t.typeParams))
+	for i, param := range t.typeParams {
+		args[i] = DataType{param, false}
+	}
+	return args
+}
+
+// parameterize get the instance of the generic type t with args as its
+// type arguments, the same arguments always give the same instance
+// so the result can be compared like any other type.
+func (t *TypeSymbol) parameterize(args []DataType) *TypeSymbol {
+	keys, names := make([]string, len(args)), make([]string, len(args))
+	isOwnParams := len(args) == len(t.typeParams)
+	for i, arg := range args {
+		keys[i] = fmt.Sprintf("%p:%t", arg.dataType, arg.isArray)
+		names[i] = arg.String()
+		isOwnParams = isOwnParams && !arg.isArray && arg.dataType == t.typeParams[i]
+	}
+
+	if isOwnParams {
+		return t
+	}
+
+	key := strings.Join(keys, ",")
+	if instance, exist := t.instances[key]; exist {
+		return instance
+	}
+
+	name := fmt.Sprintf("%s<%s>", t.name, strings.Join(names, ", "))
+	instance := NewType(name, t.TypeCategory)
+	instance.generic = t
+	instance.typeArgs = args
+	t.instances[key] = instance
+
+	if t.extends != nil {
+		instance.extends = instance.substitute(DataType{t.extends, false}).dataType
+	}
+
+	if t.implements != nil {
+		instance.implements = instance.substitute(DataType{t.implements, false}).dataType
+	}
+	return instance
+}
+
+// substitute replace the type variables of the generic type
+// of t in dt with the type arguments of t
+func (t *TypeSymbol) substitute(dt DataType) DataType {
+	if t.generic == nil {
+		return dt
+	}
+	return substituteType(dt, t.generic.typeParams, t.typeArgs)
+}
+
+func (t *TypeSymbol) substituteMethod(method *MethodSymbol) *MethodSymbol {
+	if t.generic == nil {
+		return method
+	}
+	return substituteMethod(method, t.generic.typeParams, t.typeArgs)
+}
+
+// substituteType replace every params in dt with its argument
+func substituteType(dt DataType, params []*TypeSymbol, args []DataType) DataType {
+	if dt.dataType == nil {
+		return dt
+	}
+
+	for i, param := range params {
+		if dt.dataType == param {
+			return DataType{args[i].dataType, args[i].isArray || dt.isArray}
+		}
+	}
+
+	typeArgs := dt.dataType.arguments()
+	if len(typeArgs) == 0 {
+		return dt
+	}
+
+	substituted := make([]DataType, len(typeArgs))
+	for i, arg := range typeArgs {
+		substituted[i] = substituteType(arg, params, args)
+	}
+	return DataType{dt.dataType.base().parameterize(substituted), dt.isArray}
+}
+
+// substituteMethod create a copy of method with every params replaced,
+// the copy keep the declared method to be used for its descriptor.
+func substituteMethod(method *MethodSymbol, params []*TypeSymbol, args []DataType) *MethodSymbol {
+	methodArgs := make([]DataType, len(method.args))
+	for i, arg := range method.args {
+		methodArgs[i] = substituteType(arg, params, args)
+	}
+
+	return &MethodSymbol{
+		substituteType(method.DataType, params, args),
+		method.accessMod,
+		method.name,
+		methodArgs,
+		method.isStatic,
+		method.typeParams,
+		method.declared(),
+		method.isVarargs,
+		method.isExpanded,
+	}
+}
+
+// erasure get the type that is used at runtime, the type arguments
+// are removed and type variable is replaced by its bound.
+func (t *TypeSymbol) erasure() *TypeSymbol {
+	if t.generic != nil {
+		return t.generic
+	}
+
+	if t.TypeCategory != TypeVariable {
+		return t
+	}
+
+	if bound := t.bound(); bound != nil {
+		return bound.erasure()
+	}
+	return javaLangObject
+}
+
+// declaredProperty get the property as it is declared,
+// before any of its type variable is substituted
+func (t *TypeSymbol) declaredProperty(name string) *PropertySymbol {
+	for parent := t.base(); parent != nil; {
+		if prop := parent.Properties[name]; prop != nil {
+			return prop
+		}
+
+		if parent.extends == nil {
+			break
+		}
+		parent = parent.extends.base()
+	}
+	return nil
+}
+
+// declared get the method as it is declared,
+// before any of its type variable is substituted
+func (m *MethodSymbol) declared() *MethodSymbol {
+	if m.origin != nil {
+		return m.origin
+	}
+	return m
+}
+
+// applicableIn get the method if it accept args in phase, the type variables
+// of a generic method is inferred from args. nil if not applicable.
+func (m *MethodSymbol) applicableIn(args []DataType, phase invocationPhase) *MethodSymbol {
+	if phase == varargsInvocation {
+		if !m.isVarargs || len(args) < len(m.args)-1 {
+			return nil
+		}
+		m = m.expand(len(args))
+	}
+
+	if len(m.typeParams) > 0 {
+		if m = m.infer(args); m == nil {
+			return nil
+		}
+	}
+
+	if !m.acceptIn(args, phase) {
+		return nil
+	}
+	return m
+}
+
+// infer bind every type variable of the generic method into the type
+// of the argument passed to it, those that cannot be inferred
+// are replaced by their erasure.
+func (m *MethodSymbol) infer(args []DataType) *MethodSymbol {
+	if len(args) != len(m.args) {
+		return nil
+	}
+
+	bindings := make(map[*TypeSymbol]DataType)
+	for i, param := range m.args {
+		if !unify(param, args[i], m.typeParams, bindings) {
+			return nil
+		}
+	}
+
+	inferred := make([]DataType, len(m.typeParams))
+	for i, param := range m.typeParams {
+		dt, bound := bindings[param]
+		if !bound {
+			dt = DataType{param.erasure(), false}
+		}
+		inferred[i] = dt
+	}
+
+	for i, param := range m.typeParams {
+		if bound := param.bound(); bound != nil {
+			expect := substituteType(DataType{bound, false}, m.typeParams, inferred)
+			if !isConvertible(inferred[i], expect, assignmentContext) {
+				return nil
+			}
+		}
+	}
+
+	method := substituteMethod(m, m.typeParams, inferred)
+	method.typeParams = nil
+	return method
+}
+
+// unify bind the type variables in formal with the matching part
+// of actual, false if they cannot be matched.
+func unify(formal, actual DataType, vars []*TypeSymbol, bindings map[*TypeSymbol]DataType) bool {
+	if v := lookupTypeVariable(vars, formal.dataType.name); v != nil && v == formal.dataType {
+		if formal.isArray {
+			if !actual.isArray {
+				return false
+			}
+			actual.isArray = false
+		}
+
+		// null can be passed into any type variable
+		if actual.dataType == PrimitiveNull {
+			return true
+		}
+
+		if IsPrimitive(actual) {
+			return false
+		}
+
+		// keep the most general one, e.g. Shape for Circle and Shape
+		if prev, exist := bindings[v]; exist {
+			if isConvertible(actual, prev, assignmentContext) {
+				return true
+			}
+
+			if !isConvertible(prev, actual, assignmentContext) {
+				return false
+			}
+		}
+
+		bindings[v] = actual
+		return true
+	}
+
+	generic := formal.dataType.base()
+	if formal.dataType.generic == nil || actual.dataType.base() != generic {
+		return true
+	}
+
+	actualArgs := actual.dataType.arguments()
+	for i, arg := range formal.dataType.arguments() {
+		if !unify(arg, actualArgs[i], vars, bindings) {
+			return false
+		}
+	}
+	return true
+}
+
+// descriptor get the field descriptor of the erasure of d
+func (d DataType) descriptor() string {
+	return fieldDescriptor(d.dataType.erasure().name, d.isArray)
+}
+
+// internalName get the name of the erasure of t as it is referred in a class file
+func (t *TypeSymbol) internalName() string {
+	descriptor := DataType{t, false}.descriptor()
+	return descriptor[1 : len(descriptor)-1]
+}
+
+// checkcastCode get the cast from the erasure of the declared type into
+// the erasure of the actual one, empty if both have the same erasure.
+func checkcastCode(declared, actual DataType) string {
+	descriptor := actual.descriptor()
+	if declared.descriptor() == descriptor {
+		return ""
+	}
+
+	if !actual.isArray {
+		// a class is referred by its name instead of its descriptor
+		descriptor = descriptor[1 : len(descriptor)-1]
+	}
+	return "checkcast " + descriptor
+}
@@ -0,0 +1,80 @@
+package lang
+
+import "github.com/gumelarme/yava/pkg/text"
+
+var (
+	msgFieldHides        = "Field %s hides the field of %s."
+	msgSuperInStatic     = "Cannot use super in a static context."
+	msgSuperCallInLambda = "Method %s cannot be called through super inside a lambda."
+)
+
+// inheritedProperty get the property name which t inherit from the nearest
+// super class that declare it, substituted by its type arguments. A private
+// property is not inherited, and neither is a package property of another
+// package, they still hide the properties of the classes above them.
+func (t *TypeSymbol) inheritedProperty(name string) *PropertySymbol {
+	for parent := t.extends; parent != nil; parent = parent.extends {
+		prop, exist := parent.base().Properties[name]
+		if !exist {
+			continue
+		}
+
+		if prop.AccessModifier&text.Private != 0 ||
+			prop.AccessModifier&(text.Public|text.Protected) == 0 && packageOf(parent) != packageOf(t) {
+			return nil
+		}
+		return parent.LookupProperty(name)
+	}
+	return nil
+}
+
+// inheritedProperties get every property inherited by t,
+// except the ones hidden by a property declared in t.
+func (t *TypeSymbol) inheritedProperties() []*PropertySymbol {
+	var props []*PropertySymbol
+	seen := make(map[string]bool)
+	for parent := t.extends; parent != nil; parent = parent.extends {
+		for name := range parent.base().Properties {
+			if _, declared := t.Properties[name]; declared || seen[name] {
+				continue
+			}
+
+			seen[name] = true
+			if prop := t.inheritedProperty(name); prop != nil {
+				props = append(props, prop)
+			}
+		}
+	}
+	return props
+}
+
+// checkFieldHiding warn every property of the current class that hide a
+// property of its super classes, which is then only accessible through
+// super or a variable of the super class.
+func (t *TypeAnalyzer) checkFieldHiding(props []*text.PropertyDeclaration) {
+	for _, prop := range props {
+		if t.current.inheritedProperty(prop.Name) != nil {
+			t.AddWarningf(msgFieldHides, prop.Name, t.current.extends.propertyOwner(prop.Name).name)
+		}
+	}
+}
+
+// superOf get the type of super in the current scope, which is the super
+// class of this. An error is reported if there is no this, the super class
+// of the current class is used so the rest of the expression is checked.
+func (n *NameAnalyzer) superOf() *TypeSymbol {
+	if this, _ := n.scope.Lookup("this", true); this != nil {
+		return this.Type().dataType.super()
+	}
+
+	n.AddError(msgSuperInStatic)
+	if classes := n.enclosingClasses(); len(classes) > 0 {
+		return classes[0].super()
+	}
+	return nil
+}
+
+// isSuper check if member is the super class of this, e.g. super.name
+func isSuper(member TypeMember) bool {
+	return member != nil && member.Name() == "super"
+}
@@ -2,6 +2,9 @@ package lang
 
 import (
 	"fmt"
+	"math"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/gumelarme/yava/pkg/text"
@@ -12,6 +15,10 @@ const (
 	MinorVersion = 0
 )
 
+// enumConstructorPrefix is the descriptor of the name and ordinal,
+// that is passed into every enum constructor before its own parameters
+const enumConstructorPrefix = "Ljava/lang/String;I"
+
 func invokeDefaultConstructor(name string) string {
 	return fmt.Sprintf("invokespecial Method %s <init> ()V", name)
 }
@@ -20,6 +27,20 @@ func isMathOperator(token text.TokenType) bool {
 	switch token {
 	case text.Addition, text.Subtraction, text.Multiplication, text.Division, text.Modulus:
 		return true
+	case text.BitwiseAnd, text.BitwiseOr, text.BitwiseXor:
+		return true
+	case text.LeftShift, text.RightShift, text.UnsignedRightShift:
+		return true
+	default:
+		return false
+	}
+}
+
+func isShiftOperator(token text.TokenType) bool {
+	switch token {
+	case text.LeftShift, text.RightShift, text.UnsignedRightShift,
+		text.LeftShiftAssignment, text.RightShiftAssignment, text.UnsignedRightShiftAssignment:
+		return true
 	default:
 		return false
 	}
@@ -30,6 +51,12 @@ func codeConstant(exp text.Expression) (code string) {
 	switch name {
 	case "int":
 		code = codeInt(exp.(text.Num))
+	case "long":
+		code = codeLong(exp.(text.Long))
+	case "float":
+		code = codeFloat(exp.(text.Float))
+	case "double":
+		code = codeDouble(exp.(text.Double))
 	case "boolean":
 		code = codeBoolean(exp.(text.Boolean))
 	case "char":
@@ -43,19 +70,65 @@ func codeConstant(exp text.Expression) (code string) {
 	return
 }
 
+// codeInt choose the shortest instruction to load an int constant
 func codeInt(i text.Num) string {
 	var format string
 	switch {
-	case i <= 5:
+	case i == -1:
+		return "iconst_m1"
+	case i >= 0 && i <= 5:
 		format = "iconst_%d"
-	case i <= 128:
+	case i >= math.MinInt8 && i <= math.MaxInt8:
 		format = "bipush %d"
+	case i >= math.MinInt16 && i <= math.MaxInt16:
+		format = "sipush %d"
 	default:
 		format = "ldc %d"
 	}
 	return fmt.Sprintf(format, i)
 }
 
+func codeLong(l text.Long) string {
+	if l == 0 || l == 1 {
+		return fmt.Sprintf("lconst_%d", l)
+	}
+	return fmt.Sprintf("ldc2_w %dL", l)
+}
+
+// floatingPointString format f so it always have a decimal point
+// or an exponent, as required by krakatau floating point literal.
+// The infinities and NaN are written with their sign, e.g. +Infinity.
+func floatingPointString(f float64, bitSize int) string {
+	switch {
+	case math.IsInf(f, 1):
+		return "+Infinity"
+	case math.IsInf(f, -1):
+		return "-Infinity"
+	case math.IsNaN(f):
+		return "+NaN"
+	}
+
+	str := strconv.FormatFloat(f, 'g', -1, bitSize)
+	if !strings.ContainsAny(str, ".e") {
+		str += ".0"
+	}
+	return str
+}
+
+func codeFloat(f text.Float) string {
+	if (f == 0 && !math.Signbit(float64(f))) || f == 1 || f == 2 {
+		return fmt.Sprintf("fconst_%d", int(f))
+	}
+	return fmt.Sprintf("ldc %sf", floatingPointString(float64(f), 32))
+}
+
+func codeDouble(d text.Double) string {
+	if (d == 0 && !math.Signbit(float64(d))) || d == 1 {
+		return fmt.Sprintf("dconst_%d", int(d))
+	}
+	return fmt.Sprintf("ldc2_w %s", floatingPointString(float64(d), 64))
+}
+
 func codeChar(c text.Char) string {
 	intVal := text.Num(c)
 	return codeInt(intVal)
@@ -74,6 +147,30 @@ func codeString(s text.String) string {
 	return fmt.Sprintf("ldc %#v", s)
 }
 
+// codeFieldConstant get the initial value of a constant field
+func codeFieldConstant(exp text.Expression) string {
+	switch val := exp.(type) {
+	case text.Num:
+		return fmt.Sprint(int(val))
+	case text.Char:
+		return fmt.Sprint(int(val))
+	case text.Boolean:
+		if val {
+			return "1"
+		}
+		return "0"
+	case text.Long:
+		return fmt.Sprintf("%dL", val)
+	case text.Float:
+		return floatingPointString(float64(val), 32) + "f"
+	case text.Double:
+		return floatingPointString(float64(val), 64)
+	case text.String:
+		return fmt.Sprintf("%#v", val)
+	}
+	return ""
+}
+
 func indent(code string, level int) string {
 	var text []string
 	for i := 1; i < level; i++ {
@@ -96,10 +193,7 @@ const (
 func loadOrStore(local Local, action LS) string {
 	strArray := make([]string, 3)
 
-	strArray[0] = "a"
-	if IsPrimitive(local.Member.Type()) {
-		strArray[0] = "i"
-	}
+	strArray[0] = typePrefix(local.Member.Type())
 
 	strArray[1] = "store"
 	if action == Load {
@@ -114,6 +208,72 @@ func loadOrStore(local Local, action LS) string {
 	return strings.Join(strArray, "")
 }
 
+// typePrefix get the opcode prefix for a value of type dt,
+// byte, short, char and boolean are treated as int
+func typePrefix(dt DataType) string {
+	if !IsPrimitive(dt) {
+		return "a"
+	}
+
+	switch dt.Name() {
+	case "long":
+		return "l"
+	case "float":
+		return "f"
+	case "double":
+		return "d"
+	default:
+		return "i"
+	}
+}
+
+// typedOpcode change the int opcode into the one for dt, e.g. iadd into ladd
+func typedOpcode(code string, dt DataType) string {
+	return typePrefix(dt) + code[1:]
+}
+
+// conversionCode get the opcodes to convert a numeric value
+// from a type into another, nil if no conversion needed
+func conversionCode(from, to DataType) (codes []string) {
+	if !IsNumeric(from) || !IsNumeric(to) || from.Name() == to.Name() {
+		return nil
+	}
+
+	fromPrefix, toPrefix := typePrefix(from), typePrefix(to)
+	if fromPrefix != toPrefix {
+		codes = append(codes, fmt.Sprintf("%s2%s", fromPrefix, toPrefix))
+	}
+
+	if isWideningPrimitive(from, to) {
+		return codes
+	}
+
+	switch to.Name() {
+	case "byte":
+		codes = append(codes, "i2b")
+	case "short":
+		codes = append(codes, "i2s")
+	case "char":
+		codes = append(codes, "i2c")
+	}
+	return codes
+}
+
+// swapCode swap the two top most value of the stack
+// according to their slot size
+func swapCode(top, below int) []string {
+	switch {
+	case top == 1 && below == 1:
+		return []string{"swap"}
+	case top == 1:
+		return []string{"dup_x2", "pop"}
+	case below == 1:
+		return []string{"dup2_x1", "pop2"}
+	default:
+		return []string{"dup2_x2", "pop2"}
+	}
+}
+
 func fieldDescriptor(name string, isArray bool) (result string) {
 	switch name {
 	case "void":
@@ -124,9 +284,23 @@ func fieldDescriptor(name string, isArray bool) (result string) {
 		result = "Z"
 	case "char":
 		result = "C"
+	case "byte":
+		result = "B"
+	case "short":
+		result = "S"
+	case "long":
+		result = "J"
+	case "float":
+		result = "F"
+	case "double":
+		result = "D"
 	case "String":
 		result = "Ljava/lang/String;"
 	default:
+		// type arguments does not exist at runtime
+		if i := strings.Index(name, "<"); i != -1 {
+			name = name[:i]
+		}
 		result = fmt.Sprintf("L%s;", name)
 	}
 
@@ -150,6 +324,25 @@ func (i *IntStack) Push(val int) {
 	*i = append(*i, val)
 }
 
+// assertion is an assert statement being generated, its code start
+// at start of the code buffer and it jumps into end if it holds
+type assertion struct {
+	start int
+	end   int
+}
+
+// switchLabels hold the jump target of a switch statement, the
+// value of a switch expression is yielded by jumping into its end
+type switchLabels struct {
+	cases        []int
+	next         int
+	defaultLabel int
+	end          int
+	isArrow      bool
+	isExpression bool
+	valueType    DataType
+}
+
 type KrakatauGen struct {
 	stackMax         int
 	stackSize        int
@@ -164,12 +357,36 @@ type KrakatauGen struct {
 	scopeIndex       int
 	typeStack        TypeStack
 	isAssignment     bool
-	isObjectCreation bool
+	pendingCreations []*text.ObjectCreation
 	hasField         bool
 	loopOuter        IntStack
 	loopHead         IntStack
 	isInterface      bool
 	currentClass     *text.Class
+	assignOperator   text.TokenType
+	returnType       DataType
+	currentEnum      *text.Enum
+	isTypeReference  bool
+	switches         []*switchLabels
+	typeVars         []*TypeSymbol
+	currentType      *TypeSymbol
+	enclosing        []KrakatauGen
+	nestedCodes      []string
+	innerClasses     []string
+	// initializers keep the scope index before each initializer block,
+	// so its code can be generated again where it is run
+	initializers map[*text.Initializer]int
+	localOffset  int
+	isRerun      bool
+	switchValues []*text.SwitchExpression
+	assertions   []assertion
+	// hasAssertions is set once the current class has an assert statement,
+	// which need $assertionsDisabled to be initialized
+	hasAssertions   bool
+	stripAssertions bool
+	// superCalls are the method calls through super being generated,
+	// which invoke the method of the super class even if it is overridden
+	superCalls []*text.MethodCall
 }
 
 func NewKrakatauGen(typeTable TypeTable, symbolTables []*SymbolTable) *KrakatauGen {
@@ -187,12 +404,30 @@ func NewKrakatauGen(typeTable TypeTable, symbolTables []*SymbolTable) *KrakatauG
 		-1,
 		TypeStack{},
 		false,
-		false,
+		nil,
 		false,
 		make([]int, 0),
 		make([]int, 0),
 		false,
 		nil,
+		text.Assignment,
+		DataType{},
+		nil,
+		false,
+		make([]*switchLabels, 0),
+		nil,
+		nil,
+		make([]KrakatauGen, 0),
+		make([]string, 0),
+		make([]string, 0),
+		make(map[*text.Initializer]int),
+		0,
+		false,
+		make([]*text.SwitchExpression, 0),
+		make([]assertion, 0),
+		false,
+		false,
+		nil,
 	}
 }
 
@@ -201,6 +436,12 @@ func NewEmptyKrakatauGen() *KrakatauGen {
 	return NewKrakatauGen(typeTable, nil)
 }
 
+// StripAssertions drop the assert statements from the generated code,
+// they are still analyzed but never run, even with java -ea
+func (c *KrakatauGen) StripAssertions() {
+	c.stripAssertions = true
+}
+
 func (c *KrakatauGen) Codes() []string {
 	codes := make([]string, len(c.codes)+len(c.codeBuffer))
 	i := 0
@@ -231,7 +472,25 @@ func (c *KrakatauGen) AppendCode(text string) {
 func (c *KrakatauGen) Lookup(name string) Local {
 	// FIXME: Should this be always deep
 	member, addr := c.symbolTable[c.scopeIndex].Lookup(name, true)
-	return Local{member, addr}
+	return Local{member, c.localAddress(member, addr)}
+}
+
+// lookupCrossing find name from the current scope, see SymbolTable.lookupCrossing
+func (c *KrakatauGen) lookupCrossing(name string) (TypeMember, int, []*TypeSymbol) {
+	member, address, crossed := c.symbolTable[c.scopeIndex].lookupCrossing(name)
+	if len(crossed) == 0 {
+		address = c.localAddress(member, address)
+	}
+	return member, address, crossed
+}
+
+// localAddress move a local variable of an initializer block
+// after the locals of the method it is run in, see runInitializer
+func (c *KrakatauGen) localAddress(member TypeMember, address int) int {
+	if local, ok := member.(*FieldSymbol); ok && local.name != "this" {
+		return address + c.localOffset
+	}
+	return address
 }
 
 func (c *KrakatauGen) incScopeIndex() {
@@ -257,6 +516,174 @@ func (c *KrakatauGen) incStackSize(count int) {
 	}
 }
 
+// convertTop convert the value on top of the stack
+// from a numeric type into another
+func (c *KrakatauGen) convertTop(from, to DataType) {
+	codes := conversionCode(from, to)
+	if len(codes) == 0 {
+		return
+	}
+
+	for _, code := range codes {
+		c.AppendCode(code)
+	}
+
+	if diff := to.slotSize() - from.slotSize(); diff > 0 {
+		c.incStackSize(diff)
+	} else {
+		c.decStackSize(-diff)
+	}
+}
+
+// swapTop swap the two top most value of the stack
+func (c *KrakatauGen) swapTop(top, below int) {
+	for _, code := range swapCode(top, below) {
+		c.AppendCode(code)
+	}
+	// dup_x instruction temporarily copy the top value
+	c.incStackSize(top)
+	c.decStackSize(top)
+}
+
+// convertOperands convert both operand of a binary operation,
+// the left operand is swapped to the top to be converted
+func (c *KrakatauGen) convertOperands(left, right, leftTo, rightTo DataType) {
+	c.convertTop(right, rightTo)
+	if len(conversionCode(left, leftTo)) == 0 {
+		return
+	}
+
+	c.swapTop(rightTo.slotSize(), left.slotSize())
+	c.convertTop(left, leftTo)
+	c.swapTop(leftTo.slotSize(), rightTo.slotSize())
+}
+
+// convertArguments convert method arguments into the parameter type,
+// every argument after the first one that need conversion
+// is stored temporarily in a local so it can be converted in order.
+func (c *KrakatauGen) convertArguments(args, params []DataType) {
+	first := -1
+	for i := range args {
+		if len(conversionCode(args[i], params[i])) > 0 {
+			first = i
+			break
+		}
+	}
+
+	if first == -1 {
+		return
+	}
+
+	if first == len(args)-1 {
+		c.convertTop(args[first], params[first])
+		return
+	}
+
+	temps := make([]Local, len(args))
+	address := c.localCount
+	for i := first; i < len(args); i++ {
+		temps[i] = Local{&FieldSymbol{args[i], ""}, address}
+		address += args[i].slotSize()
+	}
+
+	for i := len(args) - 1; i >= first; i-- {
+		c.AppendCode(loadOrStore(temps[i], Store))
+	}
+
+	for i := first; i < len(args); i++ {
+		c.AppendCode(loadOrStore(temps[i], Load))
+		c.convertTop(args[i], params[i])
+	}
+
+	c.localCount = address
+}
+
+// packVarargs put the arguments passed into the variable arity parameter
+// into an array, like convertArguments they are stored temporarily in
+// locals, so the array can be created before them.
+func (c *KrakatauGen) packVarargs(method *MethodSymbol) {
+	if !method.isExpanded {
+		return
+	}
+
+	fixed := len(method.declared().args) - 1
+	trailing := method.args[fixed:]
+	temps := make([]Local, len(trailing))
+	address := c.localCount
+	for i, arg := range trailing {
+		temps[i] = Local{&FieldSymbol{arg, ""}, address}
+		address += arg.slotSize()
+	}
+
+	for i := len(temps) - 1; i >= 0; i-- {
+		c.AppendCode(loadOrStore(temps[i], Store))
+		c.decStackSize(trailing[i].slotSize())
+	}
+
+	component := method.varargsComponent()
+	c.AppendCode(codeInt(text.Num(len(trailing))))
+	c.AppendCode(newArrayCode(component))
+	c.incStackSize(1)
+	for i, temp := range temps {
+		c.AppendCode("dup")
+		c.AppendCode(codeInt(text.Num(i)))
+		c.AppendCode(loadOrStore(temp, Load))
+		c.incStackSize(2 + trailing[i].slotSize())
+		c.AppendCode(arrayStoreCode(component))
+		c.decStackSize(2 + trailing[i].slotSize())
+	}
+
+	if address > c.localCount {
+		c.localCount = address
+	}
+}
+
+// newArrayCode get the opcode to create an array of dt, whose length is on the stack
+func newArrayCode(dt DataType) string {
+	if IsPrimitive(dt) {
+		return "newarray " + dt.Name()
+	}
+
+	// a class is referred by its name instead of its descriptor
+	descriptor := dt.descriptor()
+	return "anewarray " + descriptor[1:len(descriptor)-1]
+}
+
+// arrayStoreCode get the opcode to store a value of dt into an array
+func arrayStoreCode(dt DataType) string {
+	switch dt.Name() {
+	case "boolean", "byte":
+		return "bastore"
+	case "char":
+		return "castore"
+	case "short":
+		return "sastore"
+	}
+	return typePrefix(dt) + "astore"
+}
+
+// arrayLoadCode get the opcode to load a value of dt from an array
+func arrayLoadCode(dt DataType) string {
+	switch dt.Name() {
+	case "boolean", "byte":
+		return "baload"
+	case "char":
+		return "caload"
+	case "short":
+		return "saload"
+	}
+	return typePrefix(dt) + "aload"
+}
+
+// parameterSlots count local slot used by the parameters and `this`
+func parameterSlots(params []text.Parameter) int {
+	count := 1
+	for _, p := range params {
+		count += slotSize(p.Type.Name, p.Type.IsArray)
+	}
+	return count
+}
+
 func (c *KrakatauGen) getLabel() (result int) {
 	result = c.labelCount
 	c.labelCount += 1
@@ -271,20 +698,45 @@ func (c *KrakatauGen) isCodeEndsWithReturn() bool {
 	}
 
 	switch c.codeBuffer[length] {
-	case "ireturn", "return", "areturn":
+	case "ireturn", "lreturn", "freturn", "dreturn", "return", "areturn":
 		return true
 	default:
 		return false
 	}
 }
 
+// resolveType get the data type of named,
+// with the type variables of the current class and method.
+func (c *KrakatauGen) resolveType(named text.NamedType) DataType {
+	dt, _ := c.typeTable.resolve(named, c.typeVars)
+	return dt
+}
+
+// descriptorOf get the field descriptor of the erasure of named
+func (c *KrakatauGen) descriptorOf(named text.NamedType) string {
+	dt := c.resolveType(named)
+	if dt.dataType == nil {
+		return fieldDescriptor(named.Name, named.IsArray)
+	}
+	return dt.descriptor()
+}
+
+// setTypeVars change the type variables in scope into those of typeof
+func (c *KrakatauGen) setTypeVars(typeof *TypeSymbol) {
+	c.currentType = typeof
+	c.typeVars = nil
+	if typeof != nil {
+		c.typeVars = typeof.typesInScope()
+	}
+}
+
 func (c *KrakatauGen) getDefaultInitialization(t text.NamedType) (DataType, string) {
-	typeof := c.typeTable.Lookup(t.Name)
-	dt := DataType{typeof, t.IsArray}
-	if IsPrimitive(dt) {
-		return dt, "iconst_0"
-	} else {
+	dt := c.resolveType(t)
+	switch prefix := typePrefix(dt); prefix {
+	case "a":
 		return dt, "aconst_null"
+	default:
+		return dt, prefix + "const_0"
 	}
 }
 
@@ -304,14 +756,40 @@ func (c *KrakatauGen) VisitProgram(program text.Program) {
 	c.Append(fmt.Sprintf(".version %d %d", MajorVersion, MinorVersion))
 }
 
+// VisitImport does nothing, the classes are always referred by their internal name
+func (c *KrakatauGen) VisitImport(*text.Import) {}
+
 func (c *KrakatauGen) VisitClass(class *text.Class) {
+	if class.Kind != text.TopLevelClass {
+		c.enterNestedClass()
+	}
+
 	c.currentClass = class
+	c.currentEnum = nil
+	c.hasAssertions = false
+	classType := c.typeTable.Lookup(class.Name)
+	c.setTypeVars(classType)
 	c.incScopeIndex()
 	declareClass := fmt.Sprintf(".class %s", class.Name)
+	if class.Access&text.Final != 0 {
+		declareClass = fmt.Sprintf(".class final %s", class.Name)
+	}
+
+	super, implement := class.Extend, class.Implement
+	if classType != nil && classType.extends != nil {
+		super = classType.extends.erasure().name
+	}
+
+	if classType != nil && classType.implements != nil {
+		implement = classType.implements.erasure().name
+		if class.Kind == text.AnonymousClass {
+			// the created type is an interface
+			super = ""
+		}
+	}
 
-	super := "java/lang/Object"
-	if len(class.Extend) != 0 {
-		super = class.Extend
+	if len(super) == 0 {
+		super = "java/lang/Object"
 	}
 
 	declareSuper := fmt.Sprintf(".super %s", super)
@@ -319,8 +797,26 @@ func (c *KrakatauGen) VisitClass(class *text.Class) {
 	c.Append(declareClass)
 	c.Append(declareSuper)
 
-	if len(class.Implement) > 0 {
-		c.Append(fmt.Sprintf(".implements %s", class.Implement))
+	if len(implement) > 0 {
+		c.Append(fmt.Sprintf(".implements %s", implement))
+	}
+
+	if classType != nil {
+		c.declareNestedFields(classType)
+	}
+}
+
+// declareNestedFields declare the fields that keep the outer instance
+// and the captured variables of a nested class
+func (c *KrakatauGen) declareNestedFields(classType *TypeSymbol) {
+	if classType.isInner {
+		c.Append(fmt.Sprintf(".field final synthetic this$0 %s",
+			fieldDescriptor(classType.outer.name, false),
+		))
+	}
+
+	for _, v := range classType.captures {
+		c.Append(fmt.Sprintf(".field final synthetic val$%s %s", v.name, v.descriptor()))
 	}
 }
 
@@ -329,115 +825,947 @@ func (c *KrakatauGen) VisitAfterClass(class *text.Class) {
 	if len(class.Constructor) == 0 {
 		c.makeDefaultConstructor(*class)
 	}
-	c.Append(".end class")
-	c.incScopeIndex()
-}
+	c.makeStaticInitializer(class)
 
-func (c *KrakatauGen) VisitInterface(i *text.Interface) {
-	c.isInterface = true
-	c.Append(fmt.Sprintf(".class interface abstract %s", i.Name))
-	c.Append(".super java/lang/Object")
-	c.incScopeIndex()
-}
-func (c *KrakatauGen) VisitAfterInterface(*text.Interface) {
+	if classType := c.typeTable.Lookup(class.Name); classType != nil {
+		if class.IsRecord() {
+			c.makeRecordMethods(class, classType)
+		}
+		c.makeBridgeMethods(class, classType)
+	}
+	c.makeInnerClasses(class)
 	c.Append(".end class")
-	c.isInterface = false
 	c.incScopeIndex()
+
+	if class.Kind != text.TopLevelClass {
+		c.exitNestedClass(class)
+		return
+	}
+
+	// nested classes are put after its top level class
+	for _, code := range c.nestedCodes {
+		c.Append(code)
+	}
+	c.nestedCodes = make([]string, 0)
+	c.innerClasses = make([]string, 0)
+}
+
+// enterNestedClass keep the state of the enclosing class, since
+// a nested class is generated separately in the middle of it
+func (c *KrakatauGen) enterNestedClass() {
+	c.enclosing = append(c.enclosing, *c)
+	c.resetStackSize()
+	c.localCount = 0
+	c.codes, c.codeBuffer = make([]string, 0), make([]string, 0)
+	c.typeStack = TypeStack{}
+	c.isAssignment, c.pendingCreations, c.hasField = false, nil, false
+	c.isInterface, c.isTypeReference = false, false
+	c.superCalls = nil
+	c.loopOuter, c.loopHead = make([]int, 0), make([]int, 0)
+	c.switches = make([]*switchLabels, 0)
+	c.switchValues = make([]*text.SwitchExpression, 0)
+	c.assertions, c.hasAssertions = make([]assertion, 0), false
+	c.nestedCodes, c.innerClasses = make([]string, 0), make([]string, 0)
+	c.localOffset = 0
+}
+
+// exitNestedClass continue the enclosing class, only the scope
+// index is carried since the symbol tables are shared.
+func (c *KrakatauGen) exitNestedClass(class *text.Class) {
+	last := len(c.enclosing) - 1
+	outer := c.enclosing[last]
+	outer.enclosing = c.enclosing[:last]
+	outer.scopeIndex = c.scopeIndex
+	outer.isScopeCreated = c.isScopeCreated
+	// the class is already generated the first time the initializer is visited
+	if !c.isRerun {
+		outer.nestedCodes = append(append(outer.nestedCodes, c.codes...), c.nestedCodes...)
+		outer.innerClasses = append(outer.innerClasses, innerClassEntry(class))
+	}
+
+	// a local class can be used in the rest of the block
+	if class.Kind == text.LocalClass {
+		local := c.typeTable.Lookup(class.Name)
+		outer.typeVars = append([]*TypeSymbol{local}, outer.typeVars...)
+	}
+	*c = outer
 }
 
-func (c *KrakatauGen) VisitPropertyDeclaration(prop *text.PropertyDeclaration) {
-	c.Append(fmt.Sprintf(".field %s %s %s",
-		prop.AccessModifier,
-		prop.Name,
-		fieldDescriptor(prop.Type.Name, prop.Type.IsArray),
-	))
+// innerClassEntry describe a nested class for the InnerClasses attribute,
+// the outer class of a local and anonymous class is not recorded.
+func innerClassEntry(class *text.Class) string {
+	switch class.Kind {
+	case text.LocalClass:
+		return fmt.Sprintf("%s [0] %s", class.Name, class.SimpleName())
+	case text.AnonymousClass:
+		return fmt.Sprintf("%s [0] [0]", class.Name)
+	}
+
+	outer := strings.TrimSuffix(class.Name, "$"+class.SimpleName())
+	entry := fmt.Sprintf("%s %s %s", class.Name, outer, class.SimpleName())
+	if class.Access != 0 {
+		entry += " " + class.Access.String()
+	}
+
+	if class.Kind == text.NestedClass {
+		entry += " static"
+	}
+	return entry
 }
 
-func (c *KrakatauGen) makeConstructor(class text.Class, constructor text.ConstructorDeclaration) {
-	signature := make([]string, len(constructor.ParameterList))
-	for i, p := range constructor.ParameterList {
-		signature[i] = fieldDescriptor(p.Type.Name, p.Type.IsArray)
+// makeInnerClasses list the class itself if it is nested,
+// along with every class nested directly inside it
+func (c *KrakatauGen) makeInnerClasses(class *text.Class) {
+	entries := c.innerClasses
+	if class.Kind != text.TopLevelClass {
+		entries = append([]string{innerClassEntry(class)}, entries...)
 	}
 
-	c.localCount = len(constructor.ParameterList) + 1
-	header := fmt.Sprintf(".method <init> : (%s)V", strings.Join(signature, ""))
-	c.Append(header)
-	c.initializeConstructor(class.Extend)
+	if len(entries) == 0 {
+		return
+	}
 
-	for _, p := range class.Properties {
-		c.putProperties(class.Name, *p)
+	c.Append(".innerclasses")
+	for _, entry := range entries {
+		c.Append(entry)
 	}
+	c.Append(".end innerclasses")
+}
+
+// makeBridgeMethods create a synthetic method for every method that
+// override a method with a different erasure, e.g. compareTo(Object) that
+// delegate into compareTo(Foo), or a method with a covariant return type.
+func (c *KrakatauGen) makeBridgeMethods(class *text.Class, classType *TypeSymbol) {
+	for _, decl := range class.Methods {
+		key := decl.Signature()
+		method := classType.Methods[key]
+		if method == nil {
+			continue
+		}
 
+		overridden, _ := classType.overriddenMethod(key)
+		implemented, _ := classType.implementedMethod(key)
+		bridged := map[string]bool{methodDescriptor(method): true}
+		for _, parent := range []*MethodSymbol{overridden, implemented} {
+			if parent == nil || parent.isStatic {
+				continue
+			}
+
+			bridge := parent.declared()
+			if descriptor := methodDescriptor(bridge); !bridged[descriptor] {
+				bridged[descriptor] = true
+				c.makeBridgeMethod(classType, method, bridge)
+			}
+		}
+	}
 }
 
-func (c *KrakatauGen) initializeConstructor(extend string) {
-	c.AppendCode("aload_0")
-	object := "java/lang/Object"
-	if len(extend) > 0 {
-		object = extend
+func methodDescriptor(method *MethodSymbol) string {
+	params := make([]string, len(method.args))
+	for i, arg := range method.args {
+		params[i] = arg.descriptor()
 	}
-	c.AppendCode(invokeDefaultConstructor(object))
-	c.incStackSize(1)
-	c.decStackSize(1)
+	return fmt.Sprintf("(%s)%s", strings.Join(params, ""), method.descriptor())
 }
 
-func (c *KrakatauGen) makeDefaultConstructor(class text.Class) {
+func (c *KrakatauGen) makeBridgeMethod(classType *TypeSymbol, method, bridge *MethodSymbol) {
+	c.Append(fmt.Sprintf(".method public bridge synthetic %s : %s", method.name, methodDescriptor(bridge)))
+	c.AppendCode("aload_0")
+	c.incStackSize(1)
+
 	c.localCount = 1
-	// FIXME: Do something about the parameter
-	c.Append(".method <init> : ()V")
-	c.initializeConstructor(class.Extend)
-	for _, p := range class.Properties {
-		c.putProperties(class.Name, *p)
+	for i, arg := range method.args {
+		local := Local{&FieldSymbol{arg, ""}, c.localCount}
+		c.AppendCode(loadOrStore(local, Load))
+		if code := checkcastCode(bridge.args[i], arg); len(code) > 0 {
+			c.AppendCode(code)
+		}
+		c.localCount += arg.slotSize()
+		c.incStackSize(arg.slotSize())
+	}
+
+	c.AppendCode(fmt.Sprintf("invokevirtual Method %s %s %s",
+		classType.name,
+		method.name,
+		methodDescriptor(method),
+	))
+	c.decStackSize(c.localCount)
+	if method.dataType.name == "void" {
+		c.AppendCode("return")
+	} else {
+		c.AppendCode(typePrefix(method.DataType) + "return")
 	}
-	c.AppendCode("return")
 
-	//count stack
 	c.Append(c.getStackAndLocalCount())
 	c.combineCodes()
 	c.Append(".end code")
 	c.Append(".end method")
 }
 
-func (c *KrakatauGen) putProperties(className string, p text.PropertyDeclaration) {
-	c.AppendCode("aload_0")
-	c.incStackSize(1)
-
-	if p.Value == nil {
-		dt, code := c.getDefaultInitialization(p.Type)
-		c.AppendCode(code)
-		c.typeStack.Push(dt)
-		c.incStackSize(1)
-	} else {
-		p.Value.Accept(c)
+func (c *KrakatauGen) VisitEnum(enum *text.Enum) {
+	c.currentClass = &enum.Class
+	c.currentEnum = enum
+	c.hasAssertions = false
+	c.setTypeVars(c.typeTable.Lookup(enum.Name))
+	c.incScopeIndex()
+	c.Append(fmt.Sprintf(".class final super enum %s", enum.Name))
+	c.Append(".super java/lang/Enum")
+	if len(enum.Implement) > 0 {
+		c.Append(fmt.Sprintf(".implements %s", enum.Implement))
 	}
 
-	c.AppendCode(fmt.Sprintf("putfield Field %s %s %s",
-		className,
-		p.Name,
-		fieldDescriptor(p.Type.Name, p.Type.IsArray),
-	))
-	// remove aload_0 and the value
-	c.decStackSize(2)
+	descriptor := fieldDescriptor(enum.Name, false)
+	for _, constant := range enum.Constants {
+		c.Append(fmt.Sprintf(".field public static final enum %s %s", constant.Name, descriptor))
+	}
+	c.Append(fmt.Sprintf(".field private static final synthetic $VALUES %s", "["+descriptor))
 }
 
-func (c *KrakatauGen) VisitMethodSignature(signature *text.MethodSignature) {
-	c.incScopeIndex()
-	c.isScopeCreated = true
-	c.localCount = len(signature.ParameterList) + 1
-	params := make([]string, len(signature.ParameterList))
-	for i, p := range signature.ParameterList {
-		params[i] = fieldDescriptor(p.Type.Name, p.Type.IsArray)
+func (c *KrakatauGen) VisitAfterEnum(enum *text.Enum) {
+	if len(enum.Constructor) == 0 {
+		c.makeDefaultConstructor(enum.Class)
 	}
+	c.makeEnumValues(enum)
+	c.makeEnumInitializer(enum)
+	c.Append(".end class")
+	c.incScopeIndex()
+	c.currentEnum = nil
+}
+
+// makeEnumValues create the implicit values method,
+// which return a copy of the $VALUES array.
+func (c *KrakatauGen) makeEnumValues(enum *text.Enum) {
+	arrayDescriptor := fieldDescriptor(enum.Name, true)
+	c.localCount = 0
+	c.Append(fmt.Sprintf(".method public static values : ()%s", arrayDescriptor))
+	c.AppendCode(fmt.Sprintf("getstatic Field %s $VALUES %s", enum.Name, arrayDescriptor))
+	c.incStackSize(1)
+	c.AppendCode(fmt.Sprintf("invokevirtual Method %s clone ()Ljava/lang/Object;", arrayDescriptor))
+	c.AppendCode(fmt.Sprintf("checkcast %s", arrayDescriptor))
+	c.AppendCode("areturn")
+	c.decStackSize(1)
+	c.Append(c.getStackAndLocalCount())
+	c.combineCodes()
+	c.Append(".end code")
+	c.Append(".end method")
+}
+
+// makeEnumInitializer create the static initializer, which instantiate
+// every constant in order, then put all of them into $VALUES.
+func (c *KrakatauGen) makeEnumInitializer(enum *text.Enum) {
+	descriptor := fieldDescriptor(enum.Name, false)
+	c.localCount = 0
+	c.declareAssertions()
+	c.Append(".method static <clinit> : ()V")
+	c.initializeAssertions(enum.Name)
+	for i, constant := range enum.Constants {
+		c.AppendCode(fmt.Sprintf("new %s", enum.Name))
+		c.AppendCode("dup")
+		c.AppendCode(codeString(text.String(constant.Name)))
+		c.AppendCode(codeInt(text.Num(i)))
+		c.incStackSize(4)
+
+		for _, arg := range constant.Args {
+			arg.Accept(c)
+		}
+
+		args := c.getArgDataTypes(len(constant.Args))
+		params := args
+		if constructor := c.typeTable.Lookup(enum.Name).LookupConstructor(args); constructor != nil {
+			c.convertArguments(args, constructor.args)
+			c.packVarargs(constructor)
+			params = constructor.declared().args
+		}
+		c.AppendCode(fmt.Sprintf("invokespecial Method %s <init> (%s%s)V",
+			enum.Name,
+			enumConstructorPrefix,
+			c.createSignatureFromDataTypes(params),
+		))
+
+		paramSlots := 0
+		for _, p := range params {
+			paramSlots += p.slotSize()
+		}
+		c.decStackSize(3 + paramSlots)
+
+		c.AppendCode(fmt.Sprintf("putstatic Field %s %s %s", enum.Name, constant.Name, descriptor))
+		c.decStackSize(1)
+	}
+
+	c.AppendCode(codeInt(text.Num(len(enum.Constants))))
+	c.AppendCode(fmt.Sprintf("anewarray %s", enum.Name))
+	c.incStackSize(1)
+	for i, constant := range enum.Constants {
+		c.AppendCode("dup")
+		c.AppendCode(codeInt(text.Num(i)))
+		c.AppendCode(fmt.Sprintf("getstatic Field %s %s %s", enum.Name, constant.Name, descriptor))
+		c.incStackSize(3)
+		c.AppendCode("aastore")
+		c.decStackSize(3)
+	}
+
+	c.AppendCode(fmt.Sprintf("putstatic Field %s $VALUES %s", enum.Name, "["+descriptor))
+	c.decStackSize(1)
+	c.initializeStatic(&enum.Class)
+	c.AppendCode("return")
+	c.Append(c.getStackAndLocalCount())
+	c.combineCodes()
+	c.Append(".end code")
+	c.Append(".end method")
+}
+
+// makeRecordMethods create the implicit methods of a record,
+// which compare, hash and show the fields of its components in order
+func (c *KrakatauGen) makeRecordMethods(class *text.Class, classType *TypeSymbol) {
+	fields := make([]*PropertySymbol, 0, len(class.Components))
+	for _, component := range class.Components {
+		if prop := classType.Properties[component.Name]; prop != nil {
+			fields = append(fields, prop)
+		}
+	}
+
+	if class.DeclaredMethod("equals", "Object") == nil {
+		c.makeRecordEquals(class.Name, fields)
+	}
+
+	if class.DeclaredMethod("hashCode") == nil {
+		c.makeRecordHashCode(class.Name, fields)
+	}
+
+	if class.DeclaredMethod("toString") == nil {
+		c.makeRecordToString(class.Name, class.SimpleName(), fields)
+	}
+}
+
+// getRecordField push the field of the record at address
+func (c *KrakatauGen) getRecordField(className string, address int, field *PropertySymbol) {
+	c.AppendCode(fmt.Sprintf("aload_%d", address))
+	c.AppendCode(fmt.Sprintf("getfield Field %s %s %s", className, field.name, field.descriptor()))
+	c.incStackSize(field.slotSize())
+}
+
+// makeRecordEquals create equals, the other object is equal if it is
+// the same record whose fields are equal, references are compared by
+// Objects.equals and floating points by their compare method.
+func (c *KrakatauGen) makeRecordEquals(className string, fields []*PropertySymbol) {
+	c.resetStackSize()
+	c.localCount = 3
+	notSame, sameType, notEqual := c.getLabel(), c.getLabel(), c.getLabel()
+	c.Append(".method public equals : (Ljava/lang/Object;)Z")
+	c.AppendCode("aload_0")
+	c.AppendCode("aload_1")
+	c.incStackSize(2)
+	c.AppendCode(fmt.Sprintf("if_acmpne L%d", notSame))
+	c.decStackSize(2)
+	c.AppendCode("iconst_1")
+	c.AppendCode("ireturn")
+
+	c.AppendCode(labelCode("aload_1", notSame))
+	c.incStackSize(1)
+	c.AppendCode(fmt.Sprintf("instanceof %s", className))
+	c.AppendCode(fmt.Sprintf("ifne L%d", sameType))
+	c.decStackSize(1)
+	c.AppendCode("iconst_0")
+	c.AppendCode("ireturn")
+
+	c.AppendCode(labelCode("aload_1", sameType))
+	c.incStackSize(1)
+	c.AppendCode(fmt.Sprintf("checkcast %s", className))
+	c.AppendCode("astore_2")
+	c.decStackSize(1)
+	for _, field := range fields {
+		c.getRecordField(className, 0, field)
+		c.getRecordField(className, 2, field)
+		compare := "if_icmpne"
+		switch {
+		case !IsPrimitive(field.DataType):
+			c.AppendCode("invokestatic Method java/util/Objects equals (Ljava/lang/Object;Ljava/lang/Object;)Z")
+			compare = "ifeq"
+		case field.DataType.Name() == "long":
+			c.AppendCode("lcmp")
+			compare = "ifne"
+		case field.DataType.Name() == "float":
+			c.AppendCode("invokestatic Method java/lang/Float compare (FF)I")
+			compare = "ifne"
+		case field.DataType.Name() == "double":
+			c.AppendCode("invokestatic Method java/lang/Double compare (DD)I")
+			compare = "ifne"
+		}
+		c.AppendCode(fmt.Sprintf("%s L%d", compare, notEqual))
+		c.decStackSize(2 * field.slotSize())
+	}
+
+	c.AppendCode("iconst_1")
+	c.AppendCode("ireturn")
+	c.AppendCode(labelCode("iconst_0", notEqual))
+	c.AppendCode("ireturn")
+	c.Append(c.getStackAndLocalCount())
+	c.combineCodes()
+	c.Append(".end code")
+	c.Append(".end method")
+}
+
+// makeRecordHashCode create hashCode, which combine
+// the hash code of every field as 31 * result + hash
+func (c *KrakatauGen) makeRecordHashCode(className string, fields []*PropertySymbol) {
+	c.resetStackSize()
+	c.localCount = 1
+	c.Append(".method public hashCode : ()I")
+	c.AppendCode("iconst_0")
+	c.incStackSize(1)
+	for _, field := range fields {
+		c.AppendCode("bipush 31")
+		c.incStackSize(1)
+		c.AppendCode("imul")
+		c.decStackSize(1)
+		c.getRecordField(className, 0, field)
+		switch name := field.DataType.Name(); {
+		case !IsPrimitive(field.DataType):
+			c.AppendCode("invokestatic Method java/util/Objects hashCode (Ljava/lang/Object;)I")
+		case name == "boolean":
+			c.AppendCode("invokestatic Method java/lang/Boolean hashCode (Z)I")
+		case name == "long":
+			c.AppendCode("invokestatic Method java/lang/Long hashCode (J)I")
+		case name == "float":
+			c.AppendCode("invokestatic Method java/lang/Float hashCode (F)I")
+		case name == "double":
+			c.AppendCode("invokestatic Method java/lang/Double hashCode (D)I")
+		}
+		c.decStackSize(field.slotSize() - 1)
+		c.AppendCode("iadd")
+		c.decStackSize(1)
+	}
+
+	c.AppendCode("ireturn")
+	c.decStackSize(1)
+	c.Append(c.getStackAndLocalCount())
+	c.combineCodes()
+	c.Append(".end code")
+	c.Append(".end method")
+}
+
+// makeRecordToString create toString, which show the
+// record by its name and its fields, e.g. Point[x=1, y=2]
+func (c *KrakatauGen) makeRecordToString(className, simpleName string, fields []*PropertySymbol) {
+	const builder = "java/lang/StringBuilder"
+	appendCode := func(descriptor string) {
+		c.AppendCode(fmt.Sprintf("invokevirtual Method %s append (%s)L%s;", builder, descriptor, builder))
+	}
+
+	c.resetStackSize()
+	c.localCount = 1
+	c.Append(".method public toString : ()Ljava/lang/String;")
+	c.AppendCode(fmt.Sprintf("new %s", builder))
+	c.AppendCode("dup")
+	c.AppendCode(codeString(text.String(simpleName + "[")))
+	c.incStackSize(3)
+	c.AppendCode(fmt.Sprintf("invokespecial Method %s <init> (Ljava/lang/String;)V", builder))
+	c.decStackSize(2)
+	for i, field := range fields {
+		separator := field.name + "="
+		if i > 0 {
+			separator = ", " + separator
+		}
+		c.AppendCode(codeString(text.String(separator)))
+		c.incStackSize(1)
+		appendCode("Ljava/lang/String;")
+		c.decStackSize(1)
+
+		c.getRecordField(className, 0, field)
+		appendCode(appendDescriptor(field.DataType))
+		c.decStackSize(field.slotSize())
+	}
+
+	c.AppendCode(codeString(text.String("]")))
+	c.incStackSize(1)
+	appendCode("Ljava/lang/String;")
+	c.decStackSize(1)
+	c.AppendCode(fmt.Sprintf("invokevirtual Method %s toString ()Ljava/lang/String;", builder))
+	c.AppendCode("areturn")
+	c.decStackSize(1)
+	c.Append(c.getStackAndLocalCount())
+	c.combineCodes()
+	c.Append(".end code")
+	c.Append(".end method")
+}
+
+// appendDescriptor get the parameter of the StringBuilder.append
+// overload that accept dt, see primitiveOrObject
+func appendDescriptor(dt DataType) string {
+	if !dt.isArray && dt.dataType == PrimitiveString {
+		return "Ljava/lang/String;"
+	}
+	return primitiveOrObject(dt)
+}
+
+// primitiveOrObject get the parameter of a method overloaded for every
+// primitive and Object that accept dt, byte and short are passed as int
+func primitiveOrObject(dt DataType) string {
+	switch {
+	case !IsPrimitive(dt):
+		return "Ljava/lang/Object;"
+	case dt.Name() == "byte" || dt.Name() == "short":
+		return "I"
+	}
+	return dt.descriptor()
+}
+
+func (c *KrakatauGen) VisitInterface(i *text.Interface) {
+	c.isInterface = true
+	c.setTypeVars(c.typeTable.Lookup(i.Name))
+	c.Append(fmt.Sprintf(".class interface abstract %s", i.Name))
+	c.Append(".super java/lang/Object")
+	c.incScopeIndex()
+}
+func (c *KrakatauGen) VisitAfterInterface(*text.Interface) {
+	c.Append(".end class")
+	c.isInterface = false
+	c.incScopeIndex()
+}
+
+func (c *KrakatauGen) VisitPropertyDeclaration(prop *text.PropertyDeclaration) {
+	field := fmt.Sprintf(".field %s%s %s",
+		memberAccess(c.currentType, prop.Name, prop.AccessModifier),
+		prop.Name,
+		c.descriptorOf(prop.Type),
+	)
+
+	// the value of a constant is kept in the class file
+	if prop.AccessModifier&text.Static != 0 {
+		symbol := c.typeTable.Lookup(c.currentClass.Name).Properties[prop.Name]
+		if symbol.constant != nil {
+			field += " = " + codeFieldConstant(symbol.constant.value)
+		}
+	}
+	c.Append(field)
+}
+
+func (c *KrakatauGen) makeConstructor(class text.Class, constructor text.ConstructorDeclaration) {
+	signature := make([]string, len(constructor.ParameterList))
+	for i, p := range constructor.ParameterList {
+		signature[i] = c.descriptorOf(p.Type)
+	}
+
+	c.localCount = parameterSlots(constructor.ParameterList)
+	prefix := ""
+	if c.currentEnum != nil {
+		prefix = enumConstructorPrefix
+		c.localCount += 2
+	} else if outer := outerDescriptor(c.currentType); len(outer) > 0 {
+		prefix = outer
+		c.localCount += 1
+	}
+
+	captureAddress := c.localCount
+	captures := capturesDescriptor(c.currentType)
+	c.localCount += capturesSlots(c.currentType)
+
+	header := fmt.Sprintf(".method <init> : (%s%s%s)V", prefix, strings.Join(signature, ""), captures)
+	c.Append(header)
+	// the instance is initialized by the constructor called with this(...)
+	if constructor.Delegate() != nil {
+		return
+	}
+
+	c.initializeNested(captureAddress)
+	c.initializeConstructor(class.Extend, nil)
+	c.initializeInstance(&class)
+}
+
+// initializeInstance put the property values and run the instance
+// initializers in the order they are declared, after the super constructor
+func (c *KrakatauGen) initializeInstance(class *text.Class) {
+	for _, decl := range class.Initializers {
+		switch decl := decl.(type) {
+		case *text.PropertyDeclaration:
+			c.putProperties(class.Name, *decl)
+		case *text.Initializer:
+			if !decl.IsStatic {
+				c.runInitializer(decl)
+			}
+		}
+	}
+}
+
+// initializeStatic put the static property values and run the static
+// initializers in the order they are declared, the value of a constant
+// is kept in the class file instead.
+func (c *KrakatauGen) initializeStatic(class *text.Class) {
+	classType := c.typeTable.Lookup(class.Name)
+	for _, decl := range class.Initializers {
+		switch decl := decl.(type) {
+		case *text.PropertyDeclaration:
+			prop := classType.Properties[decl.Name]
+			if decl.AccessModifier&text.Static != 0 && decl.Value != nil && prop.constant == nil {
+				c.putStaticProperty(class.Name, prop, decl.Value)
+			}
+		case *text.Initializer:
+			if decl.IsStatic {
+				c.runInitializer(decl)
+			}
+		}
+	}
+}
+
+// hasStaticInitializer check if class has any static code to run
+func hasStaticInitializer(class *text.Class, classType *TypeSymbol) bool {
+	for _, decl := range class.Initializers {
+		switch decl := decl.(type) {
+		case *text.PropertyDeclaration:
+			prop := classType.Properties[decl.Name]
+			if decl.AccessModifier&text.Static != 0 && decl.Value != nil && prop.constant == nil {
+				return true
+			}
+		case *text.Initializer:
+			if decl.IsStatic {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// makeStaticInitializer create the static initializer of the class,
+// if it has any static property value or static initializer.
+func (c *KrakatauGen) makeStaticInitializer(class *text.Class) {
+	classType := c.typeTable.Lookup(class.Name)
+	if classType == nil || !hasStaticInitializer(class, classType) && !c.hasAssertions {
+		return
+	}
+
+	c.localCount = 0
+	c.declareAssertions()
+	c.Append(".method static <clinit> : ()V")
+	c.initializeAssertions(class.Name)
+	c.initializeStatic(class)
+	c.AppendCode("return")
+	c.Append(c.getStackAndLocalCount())
+	c.combineCodes()
+	c.Append(".end code")
+	c.Append(".end method")
+}
+
+// declareAssertions declare the flag read by every assert statement
+// of the class, it is only declared if the class has any of them
+func (c *KrakatauGen) declareAssertions() {
+	if c.hasAssertions {
+		c.Append(".field static final synthetic $assertionsDisabled Z")
+	}
+}
+
+// initializeAssertions put whether the assertions are disabled, which
+// is decided by the JVM for the top level class, e.g. by java -ea
+func (c *KrakatauGen) initializeAssertions(className string) {
+	if !c.hasAssertions {
+		return
+	}
+
+	enabled, done := c.getLabel(), c.getLabel()
+	c.AppendCode(fmt.Sprintf("ldc Class %s", strings.SplitN(className, "$", 2)[0]))
+	c.incStackSize(1)
+	c.AppendCode("invokevirtual Method java/lang/Class desiredAssertionStatus ()Z")
+	c.AppendCode(fmt.Sprintf("ifne L%d", enabled))
+	c.AppendCode("iconst_1")
+	c.AppendCode(gotoLabel(done))
+	c.AppendCode(labelCode("iconst_0", enabled))
+	c.AppendCode(labelCode(fmt.Sprintf("putstatic Field %s $assertionsDisabled Z", className), done))
+	c.decStackSize(1)
+}
+
+// putStaticProperty evaluate the value of a static property and put it
+func (c *KrakatauGen) putStaticProperty(className string, prop *PropertySymbol, value text.Expression) {
+	value.Accept(c)
+	valueType, _ := c.typeStack.Pop()
+	c.convertTop(valueType, prop.DataType)
+	c.AppendCode(fmt.Sprintf("putstatic Field %s %s %s", className, prop.name, prop.descriptor()))
+	c.decStackSize(prop.slotSize())
+}
+
+// VisitInitializer follow the scopes of an initializer block, its code
+// is generated where it is run instead, see runInitializer. The classes
+// declared inside it are generated only once, in this visit.
+func (c *KrakatauGen) VisitInitializer(init *text.Initializer) {
+	c.incScopeIndex()
+	c.initializers[init] = c.scopeIndex
+	c.isScopeCreated = true
+	c.localCount = 0
+}
+
+func (c *KrakatauGen) VisitAfterInitializer(*text.Initializer) {
+	c.codeBuffer = make([]string, 0)
+	c.typeStack = TypeStack{}
+}
+
+// runInitializer generate the code of an initializer block in the method
+// being generated, its local variables are put after those of the method.
+func (c *KrakatauGen) runInitializer(init *text.Initializer) {
+	scopeIndex, isScopeCreated := c.scopeIndex, c.isScopeCreated
+	localOffset, isRerun := c.localOffset, c.isRerun
+	defer func() {
+		c.scopeIndex, c.isScopeCreated = scopeIndex, isScopeCreated
+		c.localOffset, c.isRerun = localOffset, isRerun
+	}()
+
+	c.scopeIndex, c.isScopeCreated = c.initializers[init], true
+
+	// this is already in the first slot of a constructor
+	c.localOffset = c.localCount
+	if !init.IsStatic {
+		c.localOffset -= 1
+	}
+	c.isRerun = true
+	c.isAssignment, c.hasField, c.isTypeReference = false, false, false
+	init.Body.Accept(c)
+}
+
+// outerDescriptor get the descriptor of the outer instance, which is
+// passed first into the constructor of an inner class, empty if none.
+func outerDescriptor(t *TypeSymbol) string {
+	if t == nil || !t.isInner {
+		return ""
+	}
+	return fieldDescriptor(t.outer.name, false)
+}
+
+// capturesDescriptor get the descriptor of the captured variables,
+// which are passed last into the constructor of a local class.
+func capturesDescriptor(t *TypeSymbol) string {
+	if t == nil {
+		return ""
+	}
+
+	descriptors := make([]string, len(t.captures))
+	for i, v := range t.captures {
+		descriptors[i] = v.descriptor()
+	}
+	return strings.Join(descriptors, "")
+}
+
+func capturesSlots(t *TypeSymbol) (count int) {
+	if t == nil {
+		return
+	}
+
+	for _, v := range t.captures {
+		count += v.slotSize()
+	}
+	return
+}
+
+// initializeNested store the outer instance and the captured variables
+// passed into the constructor, before the super constructor is called.
+func (c *KrakatauGen) initializeNested(captureAddress int) {
+	t := c.currentType
+	if t == nil {
+		return
+	}
+
+	if t.isInner {
+		c.AppendCode("aload_0")
+		c.AppendCode("aload_1")
+		c.AppendCode(fmt.Sprintf("putfield Field %s this$0 %s", t.name, outerDescriptor(t)))
+		c.incStackSize(2)
+		c.decStackSize(2)
+	}
+
+	address := captureAddress
+	for _, v := range t.captures {
+		c.AppendCode("aload_0")
+		c.AppendCode(loadOrStore(Local{v, address}, Load))
+		c.AppendCode(fmt.Sprintf("putfield Field %s val$%s %s", t.name, v.name, v.descriptor()))
+		c.incStackSize(1 + v.slotSize())
+		c.decStackSize(1 + v.slotSize())
+		address += v.slotSize()
+	}
+}
+
+// getOuterFields replace the instance on top of the stack with its outer
+// instance, once for every type in path, see outerPath
+func (c *KrakatauGen) getOuterFields(path []*TypeSymbol) {
+	for _, t := range path {
+		c.AppendCode(fmt.Sprintf("getfield Field %s this$0 %s", t.name, outerDescriptor(t)))
+	}
+}
+
+// loadOuterInstance push the instance of target that enclose the current instance
+func (c *KrakatauGen) loadOuterInstance(target *TypeSymbol) {
+	c.AppendCode("aload_0")
+	c.incStackSize(1)
+	path, _ := outerPath(c.currentType, target)
+	c.getOuterFields(path)
+}
+
+// loadSuperOuter push the outer instance required by the super class,
+// it is reached from the outer instance since this is not initialized yet
+func (c *KrakatauGen) loadSuperOuter(t *TypeSymbol) {
+	c.incStackSize(1)
+	path, ok := t.superOuterPath()
+	if !ok {
+		c.AppendCode("aconst_null")
+		return
+	}
+
+	c.AppendCode("aload_1")
+	c.getOuterFields(path)
+}
+
+// initializeConstructor call the super constructor,
+// args are the parameters that is passed into it.
+func (c *KrakatauGen) initializeConstructor(extend string, args []DataType) {
+	if c.currentEnum != nil {
+		// pass the name and ordinal into java.lang.Enum
+		c.AppendCode("aload_0")
+		c.AppendCode("aload_1")
+		c.AppendCode("iload_2")
+		c.AppendCode(fmt.Sprintf("invokespecial Method java/lang/Enum <init> (%s)V", enumConstructorPrefix))
+		c.incStackSize(3)
+		c.decStackSize(3)
+		return
+	}
+
+	c.AppendCode("aload_0")
+	c.incStackSize(1)
+	object := "java/lang/Object"
+	if len(extend) > 0 {
+		object = extend
+	}
+
+	outer, loaded := "", 1
+	if t := c.currentType; t != nil {
+		object = "java/lang/Object"
+		if t.extends != nil {
+			object = t.extends.erasure().name
+		}
+
+		if t.hasInnerSuper() {
+			c.loadSuperOuter(t)
+			outer = outerDescriptor(t.extends.base())
+			loaded += 1
+		}
+	}
+
+	if len(outer) == 0 && len(args) == 0 {
+		c.AppendCode(invokeDefaultConstructor(object))
+		c.decStackSize(1)
+		return
+	}
+
+	address := 1
+	if len(outerDescriptor(c.currentType)) > 0 {
+		address += 1
+	}
+
+	for _, arg := range args {
+		c.AppendCode(loadOrStore(Local{&FieldSymbol{arg, ""}, address}, Load))
+		c.incStackSize(arg.slotSize())
+		address += arg.slotSize()
+		loaded += arg.slotSize()
+	}
+
+	c.AppendCode(fmt.Sprintf("invokespecial Method %s <init> (%s%s)V",
+		object,
+		outer,
+		c.createSignatureFromDataTypes(args),
+	))
+	c.decStackSize(loaded)
+}
+
+func (c *KrakatauGen) makeDefaultConstructor(class text.Class) {
+	c.localCount = 1
+	header := ".method <init> : ()V"
+	var args []DataType
+	captureAddress := 0
+	if c.currentEnum != nil {
+		c.localCount += 2
+		header = fmt.Sprintf(".method private <init> : (%s)V", enumConstructorPrefix)
+	} else {
+		// an anonymous class pass its arguments into the super constructor
+		if t := c.currentType; t != nil && class.Kind == text.AnonymousClass && len(t.constructors) > 0 {
+			args = t.constructors[0].declared().args
+		}
+
+		outer := outerDescriptor(c.currentType)
+		if len(outer) > 0 {
+			c.localCount += 1
+		}
+
+		for _, arg := range args {
+			c.localCount += arg.slotSize()
+		}
+
+		captureAddress = c.localCount
+		c.localCount += capturesSlots(c.currentType)
+		header = fmt.Sprintf(".method <init> : (%s%s%s)V",
+			outer,
+			c.createSignatureFromDataTypes(args),
+			capturesDescriptor(c.currentType),
+		)
+	}
+
+	// FIXME: Do something about the parameter
+	c.Append(header)
+	c.initializeNested(captureAddress)
+	c.initializeConstructor(class.Extend, args)
+	c.initializeInstance(&class)
+	c.AppendCode("return")
+
+	//count stack
+	c.Append(c.getStackAndLocalCount())
+	c.combineCodes()
+	c.Append(".end code")
+	c.Append(".end method")
+}
+
+func (c *KrakatauGen) putProperties(className string, p text.PropertyDeclaration) {
+	// a static property is put by <clinit>, and a blank final is assigned by the constructor
+	if p.AccessModifier&text.Static != 0 || (p.AccessModifier&text.Final != 0 && p.Value == nil) {
+		return
+	}
+
+	c.AppendCode("aload_0")
+	c.incStackSize(1)
 
-	returnType := fieldDescriptor(signature.ReturnType.Name, signature.ReturnType.IsArray)
+	propType := c.resolveType(p.Type)
+	if p.Value == nil {
+		dt, code := c.getDefaultInitialization(p.Type)
+		c.AppendCode(code)
+		c.incStackSize(dt.slotSize())
+	} else {
+		p.Value.Accept(c)
+		valueType, _ := c.typeStack.Pop()
+		c.convertTop(valueType, propType)
+	}
+
+	c.AppendCode(fmt.Sprintf("putfield Field %s %s %s",
+		className,
+		p.Name,
+		c.descriptorOf(p.Type),
+	))
+	// remove aload_0 and the value
+	c.decStackSize(1 + propType.slotSize())
+}
+
+func (c *KrakatauGen) VisitMethodSignature(signature *text.MethodSignature) {
+	c.incScopeIndex()
+	c.isScopeCreated = true
+	c.localCount = parameterSlots(signature.ParameterList)
+	c.setMethodTypeVars(signature)
+
+	c.returnType = c.resolveType(signature.ReturnType)
+	params := make([]string, len(signature.ParameterList))
+	for i, p := range signature.ParameterList {
+		params[i] = c.descriptorOf(p.Type)
+	}
+
+	returnType := c.descriptorOf(signature.ReturnType)
 
 	var abstractModifier string
 	if c.isInterface {
-		abstractModifier = " abstract"
+		abstractModifier = "abstract "
 	}
 
-	code := fmt.Sprintf(".method %s%s %s : (%s)%s",
-		signature.AccessModifier,
+	code := fmt.Sprintf(".method %s%s%s : (%s)%s",
+		memberAccess(c.currentType, signature.Signature(), signature.AccessModifier),
 		abstractModifier,
 		signature.Name,
 		strings.Join(params, ""),
@@ -453,6 +1781,19 @@ func (c *KrakatauGen) VisitMethodSignature(signature *text.MethodSignature) {
 	}
 }
 
+// setMethodTypeVars put the type variables of a generic method
+// in front of those of its type
+func (c *KrakatauGen) setMethodTypeVars(signature *text.MethodSignature) {
+	c.setTypeVars(c.currentType)
+	if c.currentType == nil {
+		return
+	}
+
+	if method := c.currentType.Methods[signature.Signature()]; method != nil && len(method.typeParams) > 0 {
+		c.typeVars = append(append([]*TypeSymbol{}, method.typeParams...), c.typeVars...)
+	}
+}
+
 func (c *KrakatauGen) VisitMethodDeclaration(*text.MethodDeclaration) {}
 func (c *KrakatauGen) VisitConstructor(con *text.ConstructorDeclaration) {
 	c.incScopeIndex()
@@ -468,6 +1809,67 @@ func (c *KrakatauGen) VisitAfterConstructor(*text.ConstructorDeclaration) {
 	c.Append(".end method")
 }
 
+// VisitConstructorCall push this and the arguments that are passed
+// implicitly into the constructor called with this(...)
+func (c *KrakatauGen) VisitConstructorCall(*text.ConstructorCallStatement) {
+	c.AppendCode("aload_0")
+	c.incStackSize(1)
+	if c.currentEnum != nil {
+		c.AppendCode("aload_1")
+		c.AppendCode("iload_2")
+		c.incStackSize(2)
+	} else if len(outerDescriptor(c.currentType)) > 0 {
+		c.AppendCode("aload_1")
+		c.incStackSize(1)
+	}
+}
+
+func (c *KrakatauGen) VisitAfterConstructorCall(call *text.ConstructorCallStatement) {
+	args := c.getArgDataTypes(len(call.Args))
+	params := args
+	if constructor := c.currentType.LookupConstructor(args); constructor != nil {
+		c.convertArguments(args, constructor.args)
+		c.packVarargs(constructor)
+		params = constructor.declared().args
+	}
+
+	// the captured variables are passed on from the last parameters
+	address := c.localCount - capturesSlots(c.currentType)
+	for _, v := range c.currentType.captures {
+		c.AppendCode(loadOrStore(Local{v, address}, Load))
+		c.incStackSize(v.slotSize())
+		address += v.slotSize()
+	}
+
+	prefix := outerDescriptor(c.currentType)
+	if c.currentEnum != nil {
+		prefix = enumConstructorPrefix
+	}
+
+	c.AppendCode(fmt.Sprintf("invokespecial Method %s <init> (%s%s%s)V",
+		c.currentType.name,
+		prefix,
+		c.createSignatureFromDataTypes(params),
+		capturesDescriptor(c.currentType),
+	))
+	c.decStackSize(c.stackSize)
+}
+
+func (c *KrakatauGen) VisitMethodCallStatement(*text.MethodCallStatement) {}
+
+// VisitAfterMethodCallStatement discard the value returned by the method
+func (c *KrakatauGen) VisitAfterMethodCallStatement(*text.MethodCallStatement) {
+	value, _ := c.typeStack.Pop()
+	if value.dataType != nil && value.Name() != "void" {
+		if value.slotSize() == 2 {
+			c.AppendCode("pop2")
+		} else {
+			c.AppendCode("pop")
+		}
+	}
+	c.decStackSize(c.stackSize)
+}
+
 func (c *KrakatauGen) VisitMainMethodDeclaration(*text.MainMethodDeclaration) {
 	c.localCount = 1
 	c.Append(".method public static main : ([Ljava/lang/String;)V")
@@ -489,16 +1891,22 @@ func (c *KrakatauGen) VisitVariableDeclaration(v *text.VariableDeclaration) {}
 func (c *KrakatauGen) VisitAfterVariableDeclaration(varDecl *text.VariableDeclaration) {
 	// assign default value
 	if varDecl.Value == nil {
-		c.incStackSize(1)
 		dt, code := c.getDefaultInitialization(varDecl.Type)
+		c.incStackSize(dt.slotSize())
 		c.AppendCode(code)
 		c.typeStack.Push(dt)
 	}
 
-	c.localCount += 1
 	local := c.Lookup(varDecl.Name)
+	varType := local.Member.Type()
+	valueType, _ := c.typeStack.Pop()
+	if !isConstantNarrowing(varDecl.Value, varType) {
+		c.convertTop(valueType, varType)
+	}
+
+	c.localCount += varType.slotSize()
 	c.AppendCode(loadOrStore(local, Store))
-	c.decStackSize(1)
+	c.decStackSize(varType.slotSize())
 }
 
 func (c *KrakatauGen) VisitStatementList(text.StatementList) {
@@ -512,9 +1920,181 @@ func (c *KrakatauGen) VisitAfterStatementList() {
 	c.incScopeIndex()
 }
 
-func (c *KrakatauGen) VisitSwitchStatement(*text.SwitchStatement)      {}
-func (c *KrakatauGen) VisitSwitchCase(*text.CaseStatement)             {}
-func (c *KrakatauGen) VisitAfterSwitchStatement(*text.SwitchStatement) {}
+// VisitSwitchStatement jump into the matching case with lookupswitch,
+// an enum value is switched by its ordinal.
+func (c *KrakatauGen) VisitSwitchStatement(s *text.SwitchStatement) {
+	switchType, _ := c.typeStack.Pop()
+	if switchType.dataType.TypeCategory == Enum {
+		c.AppendCode(fmt.Sprintf("invokevirtual Method %s ordinal ()I", switchType.Name()))
+	}
+
+	labels := &switchLabels{end: c.getLabel(), isArrow: s.IsArrow}
+	if last := len(c.switchValues) - 1; last >= 0 && &c.switchValues[last].SwitchStatement == s {
+		labels.isExpression = true
+		labels.valueType, _ = c.typeTable.resolve(c.switchValues[last].Type, c.typeVars)
+	}
+
+	labels.defaultLabel = labels.end
+	// a switch expression without default throw on an unknown value
+	if s.DefaultCase != nil || labels.isExpression {
+		labels.defaultLabel = c.getLabel()
+	}
+
+	for range s.CaseList {
+		labels.cases = append(labels.cases, c.getLabel())
+	}
+
+	if isStringSwitch(switchType) {
+		c.switchOnString(s, labels, switchType)
+	} else {
+		var keys []int
+		target := make(map[int]int)
+		for i, cs := range s.CaseList {
+			for _, value := range cs.Values {
+				key, _ := caseKey(value, switchType)
+				keys = append(keys, key)
+				target[key] = labels.cases[i]
+			}
+		}
+		c.lookupSwitch(keys, target, labels.defaultLabel)
+	}
+
+	c.switches = append(c.switches, labels)
+	// break inside the switch jump to its end
+	c.loopOuter.Push(labels.end)
+}
+
+// lookupSwitch jump into the target of the int on top of the stack
+func (c *KrakatauGen) lookupSwitch(keys []int, target map[int]int, defaultLabel int) {
+	// the jvm require the key to be sorted
+	sort.Ints(keys)
+	c.AppendCode("lookupswitch")
+	for _, key := range keys {
+		c.AppendCode(fmt.Sprintf("\t%d : L%d", key, target[key]))
+	}
+	c.AppendCode(fmt.Sprintf("\tdefault : L%d", defaultLabel))
+	c.decStackSize(1)
+}
+
+// switchOnString look up the case of a string by its hash code, then
+// compare it with every label of the same hash code using equals.
+func (c *KrakatauGen) switchOnString(s *text.SwitchStatement, labels *switchLabels, switchType DataType) {
+	temp := Local{&FieldSymbol{switchType, ""}, c.localCount}
+	c.localCount += 1
+	c.AppendCode(loadOrStore(temp, Store))
+	c.AppendCode(loadOrStore(temp, Load))
+	c.AppendCode("invokevirtual Method java/lang/String hashCode ()I")
+
+	var keys []int
+	target := make(map[int]int)
+	candidates := make(map[int][]text.Expression)
+	cases := make(map[text.Expression]int)
+	for i, cs := range s.CaseList {
+		for _, value := range cs.Values {
+			key, _ := caseKey(value, switchType)
+			if _, ok := target[key]; !ok {
+				keys = append(keys, key)
+				target[key] = c.getLabel()
+			}
+			candidates[key] = append(candidates[key], value)
+			cases[value] = labels.cases[i]
+		}
+	}
+	c.lookupSwitch(keys, target, labels.defaultLabel)
+
+	for _, key := range keys {
+		c.AppendCode(labelCode("", target[key]))
+		for _, value := range candidates[key] {
+			c.AppendCode(loadOrStore(temp, Load))
+			c.AppendCode(codeConstant(value))
+			c.incStackSize(2)
+			c.AppendCode("invokevirtual Method java/lang/String equals (Ljava/lang/Object;)Z")
+			c.decStackSize(1)
+			c.AppendCode(fmt.Sprintf("ifne L%d", cases[value]))
+			c.decStackSize(1)
+		}
+		c.AppendCode(gotoLabel(labels.defaultLabel))
+	}
+}
+
+func (c *KrakatauGen) currentSwitch() *switchLabels {
+	return c.switches[len(c.switches)-1]
+}
+
+// VisitSwitchCase start the code of a case, an arrow case
+// jump out of the switch instead of falling through
+func (c *KrakatauGen) VisitSwitchCase(*text.CaseStatement) {
+	labels := c.currentSwitch()
+	if labels.next > 0 {
+		c.leaveArrowCase()
+	}
+
+	c.AppendCode(labelCode("", labels.cases[labels.next]))
+	labels.next += 1
+}
+
+func (c *KrakatauGen) VisitSwitchDefault(s *text.SwitchStatement) {
+	labels := c.currentSwitch()
+	if s.DefaultCase != nil {
+		if labels.next > 0 {
+			c.leaveArrowCase()
+		}
+		c.AppendCode(labelCode("", labels.defaultLabel))
+	} else if labels.isExpression {
+		c.AppendCode(labelCode("", labels.defaultLabel))
+		c.AppendCode("new java/lang/IncompatibleClassChangeError")
+		c.AppendCode("dup")
+		c.incStackSize(2)
+		c.AppendCode("invokespecial Method java/lang/IncompatibleClassChangeError <init> ()V")
+		c.AppendCode("athrow")
+		c.decStackSize(2)
+	}
+}
+
+// leaveArrowCase jump to the end of an arrow switch statement, while
+// every arrow case of a switch expression already end with a yield
+func (c *KrakatauGen) leaveArrowCase() {
+	if labels := c.currentSwitch(); labels.isArrow && !labels.isExpression {
+		c.AppendCode(gotoLabel(labels.end))
+	}
+}
+
+func (c *KrakatauGen) VisitAfterSwitchStatement(*text.SwitchStatement) {
+	labels := c.currentSwitch()
+	c.switches = c.switches[:len(c.switches)-1]
+	c.loopOuter.Pop()
+	c.AppendCode(labelCode("", labels.end))
+}
+
+func (c *KrakatauGen) VisitSwitchExpression(s *text.SwitchExpression) {
+	c.switchValues = append(c.switchValues, s)
+}
+
+// VisitAfterSwitchExpression leave the yielded value on the stack,
+// which is already converted into the type of the switch.
+func (c *KrakatauGen) VisitAfterSwitchExpression(s *text.SwitchExpression) {
+	c.switchValues = c.switchValues[:len(c.switchValues)-1]
+	valueType, _ := c.typeTable.resolve(s.Type, c.typeVars)
+	c.incStackSize(valueType.slotSize())
+	c.typeStack.Push(valueType)
+}
+
+// yield jump out of the innermost switch expression with the value on
+// top of the stack, every yield leave the value of the same type.
+func (c *KrakatauGen) yield() {
+	value, _ := c.typeStack.Pop()
+	for i := len(c.switches) - 1; i >= 0; i-- {
+		labels := c.switches[i]
+		if !labels.isExpression {
+			continue
+		}
+
+		c.convertTop(value, labels.valueType)
+		c.AppendCode(gotoLabel(labels.end))
+		c.decStackSize(labels.valueType.slotSize())
+		return
+	}
+}
 
 func gotoLabel(number int) string {
 	return fmt.Sprintf("goto L%d", number)
@@ -598,6 +2178,58 @@ func (c *KrakatauGen) VisitAfterForStatement(forStmt *text.ForStatement) {
 	}
 }
 
+func (c *KrakatauGen) VisitForEachStatement(*text.ForEachStatement) {}
+
+// VisitAfterForEachIterable keep the array in a local, then load
+// the element at the index into the variable while it is in bound.
+func (c *KrakatauGen) VisitAfterForEachIterable(f *text.ForEachStatement) {
+	iterable, _ := c.typeStack.Pop()
+	c.incScopeIndex()
+	c.isScopeCreated = true
+
+	array, index, element := c.Lookup(forEachArray), c.Lookup(forEachIndex), c.Lookup(f.Variable.Name)
+	varType := element.Member.Type()
+	c.localCount += 2 + varType.slotSize()
+	c.AppendCode(loadOrStore(array, Store))
+	c.AppendCode("iconst_0")
+	c.AppendCode(loadOrStore(index, Store))
+	c.decStackSize(1)
+
+	head, update, outer := c.getLabel(), c.getLabel(), c.getLabel()
+	c.loopHead.Push(head)
+	c.loopHead.Push(update)
+	c.loopOuter.Push(outer)
+
+	c.AppendCode(labelCode("", head))
+	c.AppendCode(loadOrStore(index, Load))
+	c.AppendCode(loadOrStore(array, Load))
+	c.AppendCode("arraylength")
+	c.incStackSize(2)
+	c.AppendCode(fmt.Sprintf("if_icmpge L%d", outer))
+	c.decStackSize(2)
+
+	component := DataType{iterable.dataType.erasure(), false}
+	c.AppendCode(loadOrStore(array, Load))
+	c.AppendCode(loadOrStore(index, Load))
+	c.incStackSize(2)
+	c.AppendCode(arrayLoadCode(component))
+	c.decStackSize(2)
+	c.incStackSize(component.slotSize())
+	c.convertTop(component, varType)
+	c.AppendCode(loadOrStore(element, Store))
+	c.decStackSize(varType.slotSize())
+}
+
+// VisitAfterForEachStatement increment the index, continue jump here
+func (c *KrakatauGen) VisitAfterForEachStatement(*text.ForEachStatement) {
+	update, head, outer := c.loopHead.Pop(), c.loopHead.Pop(), c.loopOuter.Pop()
+	index := c.Lookup(forEachIndex)
+	c.AppendCode(labelCode("", update))
+	c.AppendCode(fmt.Sprintf("iinc %d 1", index.address))
+	c.AppendCode(gotoLabel(head))
+	c.AppendCode(labelCode("", outer))
+}
+
 func (c *KrakatauGen) VisitWhileStatement(*text.WhileStatement) {
 	head := c.getLabel()
 	c.AppendCode(labelCode("", head))
@@ -614,6 +2246,53 @@ func (c *KrakatauGen) VisitAfterWhileStatementCondition(*text.WhileStatement) {
 	c.AppendCode(labelCode("", whileBody))
 }
 
+// VisitAssertStatement skip the assertion if it is disabled,
+// which is decided once by the static initializer of the class
+func (c *KrakatauGen) VisitAssertStatement(*text.AssertStatement) {
+	end := c.getLabel()
+	c.assertions = append(c.assertions, assertion{len(c.codeBuffer), end})
+	c.AppendCode(fmt.Sprintf("getstatic Field %s $assertionsDisabled Z", c.currentClass.Name))
+	c.incStackSize(1)
+	c.AppendCode(fmt.Sprintf("ifne L%d", end))
+	c.decStackSize(1)
+}
+
+func (c *KrakatauGen) VisitAfterAssertCondition(*text.AssertStatement) {
+	c.typeStack.Pop()
+	c.AppendCode(fmt.Sprintf("ifne L%d", c.assertions[len(c.assertions)-1].end))
+	c.decStackSize(1)
+	c.AppendCode("new java/lang/AssertionError")
+	c.AppendCode("dup")
+	c.incStackSize(2)
+}
+
+// VisitAfterAssertStatement throw an AssertionError, constructed by the
+// message if any. A stripped assertion is dropped once it is generated,
+// so the labels and the scopes inside it are still followed.
+func (c *KrakatauGen) VisitAfterAssertStatement(stmt *text.AssertStatement) {
+	last := len(c.assertions) - 1
+	current := c.assertions[last]
+	c.assertions = c.assertions[:last]
+
+	var param string
+	if stmt.Message != nil {
+		message, _ := c.typeStack.Pop()
+		param = primitiveOrObject(message)
+		c.decStackSize(message.slotSize())
+	}
+
+	c.AppendCode(fmt.Sprintf("invokespecial Method java/lang/AssertionError <init> (%s)V", param))
+	c.AppendCode("athrow")
+	c.decStackSize(2)
+	c.AppendCode(labelCode("", current.end))
+
+	if c.stripAssertions {
+		c.codeBuffer = c.codeBuffer[:current.start]
+	} else {
+		c.hasAssertions = true
+	}
+}
+
 func (c *KrakatauGen) VisitAfterWhileStatement(*text.WhileStatement) {
 	// popping
 	head := c.loopHead.Pop()
@@ -623,25 +2302,38 @@ func (c *KrakatauGen) VisitAfterWhileStatement(*text.WhileStatement) {
 	c.AppendCode(labelCode("", outer))
 }
 
-func (c *KrakatauGen) VisitAssignmentStatement(*text.AssignmentStatement) {
+func (c *KrakatauGen) VisitAssignmentStatement(a *text.AssignmentStatement) {
 	c.isAssignment = true
+	c.assignOperator = a.Operator.Type
+}
+
+// isCompoundAssignment check if the current assignment
+// need to read the old value before storing, e.g. `a += 1`
+func (c *KrakatauGen) isCompoundAssignment() bool {
+	_, ok := compoundOpString[c.assignOperator]
+	return ok
 }
+
 func (c *KrakatauGen) VisitAfterAssignmentStatement(a *text.AssignmentStatement) {
 	defer func() { c.isAssignment = false }()
+	//pop the right one
+	rightType, _ := c.typeStack.Pop()
+
 	if a.Left.ChildNode() == nil {
 		field := a.Left.(*text.FieldAccess)
 		local := c.Lookup(field.Name)
+		c.assignValue(a, local.Member.Type(), rightType)
+		if prop, ok := local.Member.(*PropertySymbol); ok {
+			c.putProperty(c.propertyOwner(prop), prop)
+			return
+		}
 		c.AppendCode(loadOrStore(local, Store))
 		return
 	}
-	//pop the right one
-	c.typeStack.Pop()
 	// the parentField
-	// the paretnField type name
 	var parentField text.NamedValue
 	parentField = a.Left
 	leftType, _ := c.typeStack.Pop()
-	parentFieldTypeName := leftType.dataType.name
 
 	for {
 		child := parentField.GetChild()
@@ -652,17 +2344,75 @@ func (c *KrakatauGen) VisitAfterAssignmentStatement(a *text.AssignmentStatement)
 	}
 
 	lastField := parentField.GetChild().(*text.FieldAccess)
-	typeOfField := c.typeTable.Lookup(parentFieldTypeName)
-	prop := typeOfField.LookupProperty(lastField.Name)
+	prop := leftType.dataType.LookupProperty(lastField.Name)
+	c.assignValue(a, prop.DataType, rightType)
+	if prop.AccessModifier&text.Static != 0 {
+		c.putProperty(leftType.dataType.erasure(), leftType.dataType.declaredProperty(lastField.Name))
+		return
+	}
+
 	c.AppendCode(fmt.Sprintf("putfield Field %s %s %s",
-		parentFieldTypeName,
+		leftType.dataType.erasure().name,
 		lastField.Name,
-		fieldDescriptor(prop.dataType.name, prop.isArray),
+		leftType.dataType.declaredProperty(lastField.Name).descriptor(),
 	))
 }
 
+// assignValue apply the compound operator if any, then convert
+// the value on top of the stack into the assignment target type
+func (c *KrakatauGen) assignValue(a *text.AssignmentStatement, target, right DataType) {
+	operator := a.Operator.Type
+	code, ok := compoundOpString[operator]
+	if !ok {
+		// constant that fit the target type does not need conversion
+		if !isConstantNarrowing(a.Right, target) {
+			c.convertTop(right, target)
+		}
+		return
+	}
+
+	if !target.isArray && target.dataType == PrimitiveString {
+		c.appendString(right)
+		return
+	}
+
+	opType, rightTo := binaryNumericPromotion(target, right), DataType{}
+	switch {
+	case target.dataType == PrimitiveBoolean:
+		opType, rightTo = target, right
+	case isShiftOperator(operator):
+		opType, rightTo = unaryNumericPromotion(target), DataType{PrimitiveInt, false}
+	default:
+		rightTo = opType
+	}
+
+	c.convertOperands(target, right, opType, rightTo)
+	c.AppendCode(typedOpcode(code, opType))
+	c.decStackSize(rightTo.slotSize())
+	// compound assignment have an implicit cast into the target type
+	c.convertTop(opType, target)
+}
+
+// appendString concatenate the String below the value of type right on top
+// of the stack, both are converted by String.valueOf so null become "null".
+func (c *KrakatauGen) appendString(right DataType) {
+	const valueOf = "invokestatic Method java/lang/String valueOf (%s)Ljava/lang/String;"
+	c.AppendCode(fmt.Sprintf(valueOf, primitiveOrObject(right)))
+	c.decStackSize(right.slotSize() - 1)
+	c.AppendCode("swap")
+	c.AppendCode(fmt.Sprintf(valueOf, "Ljava/lang/Object;"))
+	c.AppendCode("swap")
+	c.AppendCode("invokevirtual Method java/lang/String concat (Ljava/lang/String;)Ljava/lang/String;")
+	c.decStackSize(1)
+}
+
 func (c *KrakatauGen) VisitJumpStatement(*text.JumpStatement) {}
 func (c *KrakatauGen) VisitAfterJumpStatement(jump *text.JumpStatement) {
+	if jump.Type == text.YieldJump {
+		c.yield()
+		return
+	}
+
 	// assume its inside a loop
 	if jump.Type != text.ReturnJump {
 		labelSource := &c.loopHead
@@ -683,11 +2433,13 @@ func (c *KrakatauGen) VisitAfterJumpStatement(jump *text.JumpStatement) {
 	}
 
 	data, _ := c.typeStack.Pop()
-	if IsPrimitive(data) {
-		c.AppendCode("ireturn")
-	} else {
-		c.AppendCode("areturn")
+	retType := c.returnType
+	if retType.dataType == nil {
+		retType = data
 	}
+
+	c.convertTop(data, retType)
+	c.AppendCode(typePrefix(retType) + "return")
 }
 
 func (c *KrakatauGen) VisitFieldAccess(field *text.FieldAccess) {
@@ -697,33 +2449,237 @@ func (c *KrakatauGen) VisitFieldAccess(field *text.FieldAccess) {
 
 	if c.isAssignment && field.Child == nil {
 		c.isAssignment = false
+		c.loadAssignmentTarget(field)
 		return
 	}
 
 	if !c.hasField {
-		local := c.Lookup(field.Name)
+		member, address, crossed := c.lookupCrossing(field.Name)
+		local := Local{member, address}
+		if local.Member == nil {
+			// the name refer to a type, e.g. Color in Color.RED
+			c.isTypeReference = true
+			c.typeStack.Push(DataType{c.typeTable.Lookup(field.Name), false})
+			return
+		}
+
+		if prop, ok := local.Member.(*PropertySymbol); ok && isEnumConstant(prop.dataType, prop) {
+			c.getEnumConstant(prop)
+			return
+		}
+
+		if prop, ok := local.Member.(*PropertySymbol); ok && prop.constant != nil {
+			c.VisitConstant(prop.constant.value)
+			return
+		}
+
+		if prop, ok := local.Member.(*PropertySymbol); ok {
+			c.getProperty(c.propertyOwner(prop), prop)
+			return
+		}
+
+		if isCaptured(local.Member, crossed) {
+			c.typeStack.Push(local.Member.Type())
+			c.loadVariable(field.Name)
+			return
+		}
+
 		c.typeStack.Push(local.Member.Type())
 		c.AppendCode(loadOrStore(local, Load))
-		c.incStackSize(1)
+		c.incStackSize(local.Member.Type().slotSize())
 		return
 	}
 
 	dt, _ := c.typeStack.Pop()
+	if c.isTypeReference {
+		if member := memberTypeOf(newTypeReference(dt.dataType), field); member != nil {
+			c.typeStack.Push(DataType{member, false})
+			return
+		}
+	}
+
 	prop := dt.dataType.LookupProperty(field.Name)
+	if prop.constant != nil {
+		if !c.isTypeReference {
+			// the value does not depend on the instance
+			c.AppendCode("pop")
+			c.decStackSize(1)
+		}
+
+		c.isTypeReference = false
+		c.VisitConstant(prop.constant.value)
+		return
+	}
+
+	if prop.AccessModifier&text.Static != 0 {
+		c.discardInstance()
+		c.getProperty(dt.dataType.erasure(), dt.dataType.declaredProperty(field.Name))
+		return
+	}
+
+	if c.isTypeReference {
+		c.isTypeReference = false
+		c.getEnumConstant(prop)
+		return
+	}
 
+	declared := dt.dataType.declaredProperty(field.Name)
 	c.AppendCode(fmt.Sprintf("getfield Field %s %s %s",
-		dt.dataType.name,
+		dt.dataType.erasure().name,
+		prop.name,
+		declared.descriptor(),
+	))
+	if code := checkcastCode(declared.DataType, prop.DataType); len(code) > 0 {
+		c.AppendCode(code)
+	}
+
+	// the object reference is replaced by the value
+	c.incStackSize(prop.slotSize() - 1)
+	c.typeStack.Push(prop.DataType)
+}
+
+func (c *KrakatauGen) getEnumConstant(prop *PropertySymbol) {
+	c.AppendCode(fmt.Sprintf("getstatic Field %s %s %s",
+		prop.dataType.name,
 		prop.name,
-		fieldDescriptor(prop.dataType.name, prop.isArray),
+		fieldDescriptor(prop.dataType.name, false),
 	))
+	c.incStackSize(1)
+	c.typeStack.Push(prop.DataType)
+}
+
+// propertyOwner get the class that has a property found by its simple name,
+// which is the current class or one of the classes that enclose it. The
+// property is either declared by the class or inherited from its super class.
+func (c *KrakatauGen) propertyOwner(prop *PropertySymbol) *TypeSymbol {
+	for t := c.currentType; t != nil; t = t.outer {
+		declared, exist := t.Properties[prop.name]
+		if declared == prop || !exist && t.inheritedProperty(prop.name) != nil {
+			return t
+		}
+	}
+	return c.currentType
+}
+
+// discardInstance pop the instance a static property is accessed from,
+// since only its type is needed, nothing is pushed for a type reference.
+func (c *KrakatauGen) discardInstance() {
+	if !c.isTypeReference {
+		c.AppendCode("pop")
+		c.decStackSize(1)
+	}
+	c.isTypeReference = false
+}
+
+// getProperty push the value of prop declared in owner, an instance
+// property of an enclosing class is read from its outer instance
+func (c *KrakatauGen) getProperty(owner *TypeSymbol, prop *PropertySymbol) {
+	opcode := "getstatic"
+	if prop.AccessModifier&text.Static == 0 {
+		c.loadOuterInstance(owner)
+		c.decStackSize(1)
+		opcode = "getfield"
+	}
+
+	c.AppendCode(fmt.Sprintf("%s Field %s %s %s", opcode, owner.name, prop.name, prop.descriptor()))
+	c.incStackSize(prop.slotSize())
 	c.typeStack.Push(prop.DataType)
 }
 
+// putProperty store the value on top of the stack into prop declared in
+// owner, the instance of an instance property is already pushed before it
+func (c *KrakatauGen) putProperty(owner *TypeSymbol, prop *PropertySymbol) {
+	opcode, slots := "putstatic", prop.slotSize()
+	if prop.AccessModifier&text.Static == 0 {
+		opcode, slots = "putfield", slots+1
+	}
+
+	c.AppendCode(fmt.Sprintf("%s Field %s %s %s", opcode, owner.name, prop.name, prop.descriptor()))
+	c.decStackSize(slots)
+}
+
+// loadAssignmentTarget push what is needed to store into field before
+// the value is pushed, followed by its current value if it is compound
+func (c *KrakatauGen) loadAssignmentTarget(field *text.FieldAccess) {
+	var prop *PropertySymbol
+	owner := c.currentType
+	if !c.hasField {
+		prop, _ = c.Lookup(field.Name).Member.(*PropertySymbol)
+		if prop != nil {
+			owner = c.propertyOwner(prop)
+		}
+	} else if dt := c.typeStack[len(c.typeStack)-1]; dt.dataType.LookupProperty(field.Name).AccessModifier&text.Static != 0 {
+		// the static property is stored without any instance
+		prop, owner = dt.dataType.declaredProperty(field.Name), dt.dataType.erasure()
+		c.discardInstance()
+	}
+
+	if prop == nil {
+		if c.isCompoundAssignment() {
+			c.loadCompoundTarget(field)
+		}
+		return
+	}
+
+	isStatic := prop.AccessModifier&text.Static != 0
+	if !isStatic && !c.hasField {
+		c.loadOuterInstance(owner)
+	}
+
+	if !c.isCompoundAssignment() {
+		return
+	}
+
+	opcode := "getstatic"
+	if !isStatic {
+		// getfield replace the duplicated instance
+		c.AppendCode("dup")
+		opcode = "getfield"
+	}
+	c.AppendCode(fmt.Sprintf("%s Field %s %s %s", opcode, owner.name, prop.name, prop.descriptor()))
+	c.incStackSize(prop.slotSize())
+}
+
+// loadCompoundTarget load the current value of the assignment
+// target, so the compound operator can be applied to it.
+func (c *KrakatauGen) loadCompoundTarget(field *text.FieldAccess) {
+	if !c.hasField {
+		local := c.Lookup(field.Name)
+		c.AppendCode(loadOrStore(local, Load))
+		c.incStackSize(local.Member.Type().slotSize())
+		return
+	}
+
+	// keep the object reference for the putfield
+	dt := c.typeStack[len(c.typeStack)-1]
+	prop := dt.dataType.LookupProperty(field.Name)
+	c.AppendCode("dup")
+	c.AppendCode(fmt.Sprintf("getfield Field %s %s %s",
+		dt.dataType.erasure().name,
+		prop.name,
+		dt.dataType.declaredProperty(field.Name).descriptor(),
+	))
+	c.incStackSize(prop.slotSize())
+}
+
 func (c *KrakatauGen) VisitArrayAccess(*text.ArrayAccess)       {}
 func (c *KrakatauGen) VisitAfterArrayAccess(*text.ArrayAccess)  {}
 func (c *KrakatauGen) VisitArrayAccessDelegate(text.NamedValue) {}
 func (c *KrakatauGen) VisitMethodCall(method *text.MethodCall) {
+	if c.isConstructorCall(method) {
+		return
+	}
+
 	c.hasField = method.Child != nil
+	// static method does not need the reference of its type
+	c.isTypeReference = false
+}
+
+// isConstructorCall check if method is the constructor call of
+// the innermost object creation being generated
+func (c *KrakatauGen) isConstructorCall(method *text.MethodCall) bool {
+	last := len(c.pendingCreations) - 1
+	return last >= 0 && &c.pendingCreations[last].MethodCall == method
 }
 
 func (c *KrakatauGen) getArgDataTypes(argLength int) []DataType {
@@ -739,60 +2695,148 @@ func (c *KrakatauGen) getArgDataTypes(argLength int) []DataType {
 func (c *KrakatauGen) createSignatureFromDataTypes(dt []DataType) string {
 	strArgs := make([]string, len(dt))
 	for i, a := range dt {
-		strArgs[i] = fieldDescriptor(a.dataType.name, a.isArray)
+		strArgs[i] = a.descriptor()
 	}
 
 	return strings.Join(strArgs, "")
 }
 
 func (c *KrakatauGen) VisitAfterMethodCall(method *text.MethodCall) {
-	if c.isObjectCreation {
-		c.isObjectCreation = false
+	if c.isConstructorCall(method) {
 		return
 	}
 
 	args := c.getArgDataTypes(len(method.Args))
 	objectRef, _ := c.typeStack.Pop()
-	objectReferenceType := objectRef.dataType.name
+	objectType := objectRef.dataType.erasure()
 	methodSymbol := objectRef.dataType.LookupMethodByArgs(method.Name, args)
+	c.convertArguments(args, methodSymbol.args)
+	c.packVarargs(methodSymbol)
 
-	javaMethodSignature := c.createSignatureFromDataTypes(methodSymbol.args)
-	returnType := fieldDescriptor(methodSymbol.Type().dataType.name, methodSymbol.isArray)
+	// the descriptor is always of the declared method, not the substituted one
+	declared := methodSymbol.declared()
+	javaMethodSignature := c.createSignatureFromDataTypes(declared.args)
+	returnType := declared.descriptor()
 
+	owner := objectType.internalName()
 	opcode, referenceType := "invokevirtual", "Method"
-	if objectRef.dataType.TypeCategory == Interface {
+	if objectType.TypeCategory == Interface && isObjectMethod(methodSymbol) {
+		// an interface does not declare the methods of java.lang.Object
+		owner = javaLangObject.name
+	} else if objectType.TypeCategory == Interface {
 		opcode, referenceType = "invokeinterface", "InterfaceMethod"
 	}
 
+	if methodSymbol.isStatic {
+		// a static method of an interface is still an InterfaceMethod
+		opcode = "invokestatic"
+		c.incStackSize(methodSymbol.slotSize())
+	}
+
+	if last := len(c.superCalls) - 1; last >= 0 && c.superCalls[last] == method {
+		c.superCalls = c.superCalls[:last]
+		opcode = "invokespecial"
+	}
+
 	c.AppendCode(fmt.Sprintf("%s %s %s %s (%s)%s",
 		opcode,
 		referenceType,
-		objectReferenceType,
+		owner,
 		method.Name,
 		javaMethodSignature,
 		returnType,
 	))
+	if code := checkcastCode(declared.DataType, methodSymbol.DataType); len(code) > 0 {
+		c.AppendCode(code)
+	}
 	c.typeStack.Push(methodSymbol.Type())
 }
 
 func (c *KrakatauGen) VisitArrayCreation(*text.ArrayCreation)      {}
 func (c *KrakatauGen) VisitAfterArrayCreation(*text.ArrayCreation) {}
 func (c *KrakatauGen) VisitObjectCreation(obj *text.ObjectCreation) {
-	c.isObjectCreation = true
-	c.AppendCode(fmt.Sprintf("new %s", obj.Name))
+	c.pendingCreations = append(c.pendingCreations, obj)
+	name := obj.Name
+	created := c.createdType(obj)
+	if created != nil {
+		name = created.erasure().name
+	}
+
+	c.AppendCode(fmt.Sprintf("new %s", name))
 	c.AppendCode("dup")
 	c.incStackSize(2)
+
+	// the outer instance is passed first into the constructor
+	if created != nil && created.base().isInner {
+		c.loadOuterInstance(created.base().outer)
+	}
+}
+
+// createdType get the type of the object created by obj, which
+// is the anonymous class if it has a body, nil if unknown.
+func (c *KrakatauGen) createdType(obj *text.ObjectCreation) *TypeSymbol {
+	if obj.Body != nil {
+		return c.typeTable.Lookup(obj.Body.Name)
+	}
+	return c.resolveType(obj.Type()).dataType
 }
 
 func (c *KrakatauGen) VisitAfterObjectCreation(obj *text.ObjectCreation) {
-	//FIXME: check for constructor availability
+	c.pendingCreations = c.pendingCreations[:len(c.pendingCreations)-1]
 	args := c.getArgDataTypes(len(obj.Args))
-	signature := c.createSignatureFromDataTypes(args)
-	c.AppendCode(fmt.Sprintf("invokespecial Method %s <init> (%s)V",
-		obj.Name,
+	params := args
+	name, outer, captures := obj.Name, "", ""
+	created := c.createdType(obj)
+	if created != nil {
+		name = created.erasure().name
+		outer = outerDescriptor(created.base())
+		if constructor := created.LookupConstructor(args); constructor != nil {
+			c.convertArguments(args, constructor.args)
+			c.packVarargs(constructor)
+			params = constructor.declared().args
+		}
+
+		// the captured variables are passed last into the constructor
+		for _, v := range created.base().captures {
+			c.loadVariable(v.name)
+		}
+		captures = capturesDescriptor(created.base())
+	}
+
+	signature := c.createSignatureFromDataTypes(params)
+	c.AppendCode(fmt.Sprintf("invokespecial Method %s <init> (%s%s%s)V",
+		name,
+		outer,
 		signature,
+		captures,
 	))
 	c.decStackSize(1)
+
+	if created != nil {
+		c.typeStack.Push(DataType{created, false})
+	}
+}
+
+// loadVariable push the value of a local variable, which is
+// read from its field if it is captured from an enclosing method.
+func (c *KrakatauGen) loadVariable(name string) {
+	member, address, crossed := c.lookupCrossing(name)
+	if member == nil {
+		return
+	}
+
+	c.incStackSize(member.Type().slotSize())
+	if !isCaptured(member, crossed) {
+		c.AppendCode(loadOrStore(Local{member, address}, Load))
+		return
+	}
+
+	c.AppendCode("aload_0")
+	c.AppendCode(fmt.Sprintf("getfield Field %s val$%s %s",
+		c.currentType.name,
+		name,
+		member.Type().descriptor(),
+	))
 }
 func (c *KrakatauGen) VisitBinOp(*text.BinOp) {}
 
@@ -810,21 +2854,103 @@ var opString = map[text.TokenType]string{
 	text.NotEqual:         "if_icmpne",
 	// text.And:              "if_icmpgt",
 	// text.Or:               "if_icmpgt",
+	text.BitwiseAnd:         "iand",
+	text.BitwiseOr:          "ior",
+	text.BitwiseXor:         "ixor",
+	text.LeftShift:          "ishl",
+	text.RightShift:         "ishr",
+	text.UnsignedRightShift: "iushr",
+}
+
+var compoundOpString = map[text.TokenType]string{
+	text.AdditionAssignment:           "iadd",
+	text.SubtractionAssignment:        "isub",
+	text.MultiplicationAssignment:     "imul",
+	text.DivisionAssignment:           "idiv",
+	text.ModulusAssignment:            "irem",
+	text.BitwiseAndAssignment:         "iand",
+	text.BitwiseOrAssignment:          "ior",
+	text.BitwiseXorAssignment:         "ixor",
+	text.LeftShiftAssignment:          "ishl",
+	text.RightShiftAssignment:         "ishr",
+	text.UnsignedRightShiftAssignment: "iushr",
+}
+
+// compareCode get the opcode to compare two non-int numeric operand,
+// NaN should make any comparison false, hence the g or l variant.
+func compareCode(operator text.TokenType, dt DataType) string {
+	prefix := typePrefix(dt)
+	if prefix == "l" {
+		return "lcmp"
+	}
+
+	if operator == text.LessThan || operator == text.LessThanEqual {
+		return prefix + "cmpg"
+	}
+	return prefix + "cmpl"
+}
+
+// referenceCompareCode get the opcode to compare two references with
+// == or !=. A comparison with null does not need the null pushed,
+// it is removed so the other operand is checked with ifnull.
+func (c *KrakatauGen) referenceCompareCode(bin *text.BinOp) string {
+	isEqual := bin.GetOperator().Type == text.Equal
+	last := len(c.codeBuffer) - 1
+	if _, ok := bin.Right.(text.Null); ok && last >= 0 && c.codeBuffer[last] == "aconst_null" {
+		// the stack size is kept, the result take the place of the null
+		c.codeBuffer = c.codeBuffer[:last]
+		if isEqual {
+			return "ifnull"
+		}
+		return "ifnonnull"
+	}
+
+	if isEqual {
+		return "if_acmpeq"
+	}
+	return "if_acmpne"
 }
 
 func (c *KrakatauGen) VisitAfterBinOp(bin *text.BinOp) {
 	// use (remove) two operand, and place the result in the stack
-	defer c.decStackSize(1)
-	c.typeStack.Pop()
-	c.typeStack.Pop()
-	strOperator := opString[bin.GetOperator().Type]
-	if isMathOperator(bin.GetOperator().Type) {
-		c.AppendCode(strOperator)
-		c.typeStack.Push(DataType{PrimitiveInt, false})
+	right, _ := c.typeStack.Pop()
+	left, _ := c.typeStack.Pop()
+	operator := bin.GetOperator().Type
+	strOperator := opString[operator]
+	if isMathOperator(operator) {
+		result, rightTo := binaryNumericPromotion(left, right), DataType{}
+		switch {
+		// & | ^ on booleans are non short-circuit logical operator
+		case left.dataType == PrimitiveBoolean:
+			result, rightTo = left, right
+		case isShiftOperator(operator):
+			result, rightTo = unaryNumericPromotion(left), DataType{PrimitiveInt, false}
+		default:
+			rightTo = result
+		}
+
+		c.convertOperands(left, right, result, rightTo)
+		c.AppendCode(typedOpcode(strOperator, result))
+		c.decStackSize(rightTo.slotSize())
+		c.typeStack.Push(result)
 		return
 	}
 
 	// from here its boolean operation
+	defer c.decStackSize(1)
+	if IsNumeric(left) && IsNumeric(right) {
+		operandType := binaryNumericPromotion(left, right)
+		c.convertOperands(left, right, operandType, operandType)
+		if operandType.dataType != PrimitiveInt {
+			// compare into an int, then compare the int with zero
+			c.AppendCode(compareCode(operator, operandType))
+			c.decStackSize(2*operandType.slotSize() - 2)
+			strOperator = "if" + strings.TrimPrefix(strOperator, "if_icmp")
+		}
+	} else if isComparableReference(left, right) {
+		strOperator = c.referenceCompareCode(bin)
+	}
+
 	trueLabel, falseLabel := c.getLabel(), c.getLabel()
 	c.AppendCode(fmt.Sprintf("%s L%d", strOperator, trueLabel))
 	c.AppendCode(codeBoolean(false))
@@ -835,8 +2961,13 @@ func (c *KrakatauGen) VisitAfterBinOp(bin *text.BinOp) {
 }
 
 func (c *KrakatauGen) VisitConstant(e text.Expression) {
-	defer c.incStackSize(1)
 	typename, _ := e.NodeContent()
+	defer c.incStackSize(slotSize(typename, false))
+	if super, ok := e.(*text.Super); ok {
+		c.loadSuper(super)
+		return
+	}
+
 	if typename != "this" {
 		symbol := c.typeTable.Lookup(typename)
 		c.typeStack.Push(DataType{symbol, false})
@@ -848,28 +2979,290 @@ func (c *KrakatauGen) VisitConstant(e text.Expression) {
 	local := c.Lookup("this")
 	c.hasField = true
 	c.AppendCode("aload_0")
+	if this, ok := e.(*text.This); ok && len(this.Qualifier) > 0 {
+		// the instance of an enclosing class is reached through this$0
+		target := c.typeTable.lookupQualified(this.Qualifier, c.typeVars)
+		path, _ := outerPath(c.currentType, target)
+		c.getOuterFields(path)
+		c.typeStack.Push(DataType{target, false})
+		return
+	}
+
+	// this inside a lambda is the instance that enclose it
+	path, _ := outerPath(c.currentType, local.Member.Type().dataType)
+	c.getOuterFields(path)
 	c.typeStack.Push(local.Member.Type())
 }
 
-func (c *KrakatauGen) VisitSystemOut() {
-	c.AppendCode("getstatic Field java/lang/System out Ljava/io/PrintStream;")
-	c.incStackSize(1)
+// loadSuper push this as an instance of its super class, a method
+// called through it is invoked without looking up its override
+func (c *KrakatauGen) loadSuper(super *text.Super) {
+	local := c.Lookup("this")
+	c.hasField = true
+	c.AppendCode("aload_0")
+	path, _ := outerPath(c.currentType, local.Member.Type().dataType)
+	c.getOuterFields(path)
+	c.typeStack.Push(DataType{local.Member.Type().dataType.super(), false})
+	if method, ok := super.Child.(*text.MethodCall); ok {
+		c.superCalls = append(c.superCalls, method)
+	}
 }
 
-func sysOutDescriptor(dt DataType) string {
-	if IsPrimitive(dt) {
-		return fieldDescriptor(dt.dataType.name, dt.isArray)
-	} else if dt.dataType == PrimitiveString && dt.isArray == false {
-		return "Ljava/lang/String;"
+func (c *KrakatauGen) VisitUnaryOp(*text.UnaryOp) {}
+func (c *KrakatauGen) VisitAfterUnaryOp(unary *text.UnaryOp) {
+	operand, _ := c.typeStack.Pop()
+	result := unaryNumericPromotion(operand)
+	c.convertTop(operand, result)
+	if unary.Operator.Type == text.Subtraction {
+		c.AppendCode(typedOpcode("ineg", result))
+	}
+	c.typeStack.Push(result)
+}
+
+func (c *KrakatauGen) VisitCast(*text.Cast) {}
+func (c *KrakatauGen) VisitAfterCast(cast *text.Cast) {
+	from, _ := c.typeStack.Pop()
+	to := c.resolveType(cast.Type)
+	// a narrowing reference is checked at run time
+	if isReference(to) && !isWideningReference(from, to) {
+		if code := checkcastCode(from, to); len(code) > 0 {
+			c.AppendCode(code)
+		}
 	} else {
-		return "Ljava/lang/Object;"
+		c.convertTop(from, to)
 	}
+	c.typeStack.Push(to)
 }
 
-func (c *KrakatauGen) VisitAfterSystemOut() {
-	dt, _ := c.typeStack.Pop()
-	argtype := sysOutDescriptor(dt)
-	invoke := fmt.Sprintf("invokevirtual Method java/io/PrintStream println (%s)V", argtype)
-	c.AppendCode(invoke)
-	c.decStackSize(2)
+// VisitLambda create the instance of the lambda class, then generate
+// the class with the body as the implementation of its method.
+func (c *KrakatauGen) VisitLambda(l *text.Lambda) {
+	lambda := c.typeTable.Lookup(l.Name)
+	c.createLambda(lambda)
+	c.beginLambda(lambda)
+	// the scope of the class, then the method
+	c.incScopeIndex()
+	c.incScopeIndex()
+
+	function := lambda.function
+	declared := function.declared()
+	c.returnType = function.DataType
+	c.localCount = 1
+	for i, arg := range function.args {
+		local := Local{&FieldSymbol{arg, ""}, c.localCount}
+		c.localCount += arg.slotSize()
+
+		// the parameter is passed as the erasure of the declared one
+		if code := checkcastCode(declared.args[i], arg); len(code) > 0 {
+			c.AppendCode(loadOrStore(local, Load))
+			c.AppendCode(code)
+			c.AppendCode(loadOrStore(local, Store))
+			c.incStackSize(1)
+			c.decStackSize(1)
+		}
+	}
+
+	// a block body is the method body
+	c.isScopeCreated = l.IsBlock()
+}
+
+func (c *KrakatauGen) VisitAfterLambda(l *text.Lambda) {
+	lambda := c.typeTable.Lookup(l.Name)
+	if l.IsBlock() {
+		if !c.isCodeEndsWithReturn() {
+			c.AppendCode("return")
+		}
+	} else {
+		var value DataType
+		if len(c.typeStack) > 0 {
+			value, _ = c.typeStack.Pop()
+		}
+		c.returnValue(value, lambda.function.DataType)
+		c.incScopeIndex()
+	}
+
+	c.incScopeIndex()
+	c.endLambda(lambda)
+}
+
+// VisitMethodReference create the instance of the class of a method
+// reference, whose method pass its parameters into the referred method.
+func (c *KrakatauGen) VisitMethodReference(m *text.MethodReference) {
+	lambda := c.typeTable.Lookup(m.Name)
+	c.createLambda(lambda)
+	c.beginLambda(lambda)
+
+	ref, function := lambda.reference, lambda.function
+	declared := function.declared()
+	address := parameterAddress(function)
+	c.localCount = 1
+	for _, arg := range function.args {
+		c.localCount += arg.slotSize()
+	}
+
+	owner := ref.owner.erasure().internalName()
+	first := 0
+	switch ref.kind {
+	case boundReference:
+		if ref.receiver == nil {
+			c.loadOuterInstance(ref.owner)
+		} else {
+			c.AppendCode("aload_0")
+			c.AppendCode(fmt.Sprintf("getfield Field %s val$%s %s",
+				lambda.name,
+				ref.receiver.name,
+				ref.receiver.descriptor(),
+			))
+			c.incStackSize(1)
+		}
+	case unboundReference:
+		// the first parameter is the receiver
+		c.AppendCode("aload_1")
+		if code := checkcastCode(declared.args[0], DataType{ref.owner, false}); len(code) > 0 {
+			c.AppendCode(code)
+		}
+		c.incStackSize(1)
+		first = 1
+	case constructorReference:
+		c.AppendCode(fmt.Sprintf("new %s", owner))
+		c.AppendCode("dup")
+		c.incStackSize(2)
+		if base := ref.owner.base(); base.isInner {
+			c.loadOuterInstance(base.outer)
+		}
+	}
+
+	for i := first; i < len(function.args); i++ {
+		arg := function.args[i]
+		c.AppendCode(loadOrStore(Local{&FieldSymbol{arg, ""}, address[i]}, Load))
+		if code := checkcastCode(declared.args[i], arg); len(code) > 0 {
+			c.AppendCode(code)
+		}
+		c.incStackSize(arg.slotSize())
+	}
+
+	method := ref.method
+	c.convertArguments(function.args[first:], method.args)
+	c.packVarargs(method)
+	result := c.invokeReference(ref)
+	c.returnValue(result, function.DataType)
+	c.endLambda(lambda)
+}
+
+// invokeReference call the method of a method reference, whose
+// receiver and arguments are already loaded. The result is returned.
+func (c *KrakatauGen) invokeReference(ref *methodReference) DataType {
+	method, owner := ref.method, ref.owner.erasure()
+	declared := method.declared()
+	signature := c.createSignatureFromDataTypes(declared.args)
+	if ref.kind == constructorReference {
+		c.AppendCode(fmt.Sprintf("invokespecial Method %s <init> (%s%s)V",
+			owner.internalName(),
+			outerDescriptor(ref.owner.base()),
+			signature,
+		))
+		return DataType{ref.owner, false}
+	}
+
+	opcode, referenceType := "invokevirtual", "Method"
+	if owner.TypeCategory == Interface {
+		opcode, referenceType = "invokeinterface", "InterfaceMethod"
+	} else if method.isStatic {
+		opcode = "invokestatic"
+	}
+
+	c.AppendCode(fmt.Sprintf("%s %s %s %s (%s)%s",
+		opcode,
+		referenceType,
+		owner.internalName(),
+		method.name,
+		signature,
+		declared.descriptor(),
+	))
+	if code := checkcastCode(declared.DataType, method.DataType); len(code) > 0 {
+		c.AppendCode(code)
+	}
+	c.incStackSize(method.slotSize())
+	return method.DataType
+}
+
+// returnValue return the value on top of the stack as returnType,
+// the value is discarded if the method is void.
+func (c *KrakatauGen) returnValue(value, returnType DataType) {
+	if returnType.Name() != "void" {
+		c.convertTop(value, returnType)
+		c.AppendCode(typePrefix(returnType) + "return")
+		return
+	}
+
+	if value.dataType != nil && value.Name() != "void" {
+		if value.slotSize() == 2 {
+			c.AppendCode("pop2")
+		} else {
+			c.AppendCode("pop")
+		}
+	}
+	c.AppendCode("return")
+}
+
+// createLambda push a new instance of the lambda class, which is
+// given the outer instance and the captured variables.
+func (c *KrakatauGen) createLambda(lambda *TypeSymbol) {
+	c.AppendCode(fmt.Sprintf("new %s", lambda.name))
+	c.AppendCode("dup")
+	c.incStackSize(2)
+
+	loaded := 1
+	if lambda.isInner {
+		c.loadOuterInstance(lambda.outer)
+		loaded += 1
+	}
+
+	for _, v := range lambda.captures {
+		c.loadVariable(v.name)
+		loaded += v.slotSize()
+	}
+
+	c.AppendCode(fmt.Sprintf("invokespecial Method %s <init> (%s%s)V",
+		lambda.name,
+		outerDescriptor(lambda),
+		capturesDescriptor(lambda),
+	))
+	c.decStackSize(loaded)
+	c.typeStack.Push(DataType{lambda.implements, false})
+}
+
+// beginLambda start the lambda class, up to the header
+// of the method that implement the functional interface
+func (c *KrakatauGen) beginLambda(lambda *TypeSymbol) {
+	c.enterNestedClass()
+	c.currentType, c.currentEnum = lambda, nil
+
+	c.Append(fmt.Sprintf(".class %s", lambda.name))
+	c.Append(".super java/lang/Object")
+	c.Append(fmt.Sprintf(".implements %s", lambda.implements.erasure().name))
+	c.declareNestedFields(lambda)
+
+	declared := lambda.function.declared()
+	c.Append(fmt.Sprintf(".method public %s : (%s)%s",
+		declared.name,
+		c.createSignatureFromDataTypes(declared.args),
+		declared.descriptor(),
+	))
+}
+
+// endLambda end the method of the lambda class, then the class itself
+// with the constructor that keep the outer instance and the captures.
+func (c *KrakatauGen) endLambda(lambda *TypeSymbol) {
+	c.Append(c.getStackAndLocalCount())
+	c.combineCodes()
+	c.Append(".end code")
+	c.Append(".end method")
+
+	class := text.NewEmptyClass(lambda.name, "", "")
+	class.Kind = text.AnonymousClass
+	c.makeDefaultConstructor(*class)
+	c.makeInnerClasses(class)
+	c.Append(".end class")
+	c.exitNestedClass(class)
 }
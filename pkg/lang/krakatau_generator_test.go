@@ -2,6 +2,8 @@ package lang
 
 import (
 	"fmt"
+	"math"
+	"strings"
 	"testing"
 
 	"github.com/gumelarme/yava/pkg/text"
@@ -26,14 +28,30 @@ func Test_codeInt(t *testing.T) {
 		num    int
 		expect string
 	}{
+		{-2147483648, "ldc -2147483648"},
+		{-1000000, "ldc -1000000"},
+		{-32769, "ldc -32769"},
+		{-32768, "sipush -32768"},
+		{-129, "sipush -129"},
+		{-128, "bipush -128"},
+		{-7, "bipush -7"},
+		{-2, "bipush -2"},
+		{-1, "iconst_m1"},
 		{0, "iconst_0"},
+		{1, "iconst_1"},
+		{2, "iconst_2"},
 		{3, "iconst_3"},
+		{4, "iconst_4"},
 		{5, "iconst_5"},
 		{6, "bipush 6"},
 		{120, "bipush 120"},
-		{255, "ldc 255"},
-		{256, "ldc 256"},
+		{127, "bipush 127"},
+		{128, "sipush 128"},
+		{255, "sipush 255"},
+		{32767, "sipush 32767"},
+		{32768, "ldc 32768"},
 		{1000_000, "ldc 1000000"},
+		{2147483647, "ldc 2147483647"},
 	}
 
 	for _, d := range data {
@@ -44,6 +62,83 @@ func Test_codeInt(t *testing.T) {
 	}
 }
 
+func Test_codeLong(t *testing.T) {
+	data := []struct {
+		num    int64
+		expect string
+	}{
+		{-9223372036854775808, "ldc2_w -9223372036854775808L"},
+		{-1, "ldc2_w -1L"},
+		{0, "lconst_0"},
+		{1, "lconst_1"},
+		{2, "ldc2_w 2L"},
+		{2147483648, "ldc2_w 2147483648L"},
+		{9223372036854775807, "ldc2_w 9223372036854775807L"},
+	}
+
+	for _, d := range data {
+		result := codeLong(text.Long(d.num))
+		if d.expect != result {
+			t.Errorf("%d should coverted to %#v but got %#v", d.num, d.expect, result)
+		}
+	}
+}
+
+func Test_codeFloat(t *testing.T) {
+	data := []struct {
+		num    float32
+		expect string
+	}{
+		{float32(math.Copysign(0, -1)), "ldc -0.0f"},
+		{-1, "ldc -1.0f"},
+		{0, "fconst_0"},
+		{0.5, "ldc 0.5f"},
+		{1, "fconst_1"},
+		{2, "fconst_2"},
+		{3, "ldc 3.0f"},
+		{1e10, "ldc 1e+10f"},
+		{math.MaxFloat32, "ldc 3.4028235e+38f"},
+		{math.SmallestNonzeroFloat32, "ldc 1e-45f"},
+		{float32(math.Inf(1)), "ldc +Infinityf"},
+		{float32(math.Inf(-1)), "ldc -Infinityf"},
+		{float32(math.NaN()), "ldc +NaNf"},
+	}
+
+	for _, d := range data {
+		result := codeFloat(text.Float(d.num))
+		if d.expect != result {
+			t.Errorf("%v should coverted to %#v but got %#v", d.num, d.expect, result)
+		}
+	}
+}
+
+func Test_codeDouble(t *testing.T) {
+	data := []struct {
+		num    float64
+		expect string
+	}{
+		{math.Copysign(0, -1), "ldc2_w -0.0"},
+		{-1, "ldc2_w -1.0"},
+		{0, "dconst_0"},
+		{0.5, "ldc2_w 0.5"},
+		{1, "dconst_1"},
+		{2, "ldc2_w 2.0"},
+		{1e100, "ldc2_w 1e+100"},
+		{math.MaxFloat64, "ldc2_w 1.7976931348623157e+308"},
+		{math.SmallestNonzeroFloat64, "ldc2_w 5e-324"},
+		{math.Inf(1), "ldc2_w +Infinity"},
+		{math.Inf(-1), "ldc2_w -Infinity"},
+		{math.NaN(), "ldc2_w +NaN"},
+	}
+
+	for _, d := range data {
+		result := codeDouble(text.Double(d.num))
+		if d.expect != result {
+			t.Errorf("%v should coverted to %#v but got %#v", d.num, d.expect, result)
+		}
+	}
+}
+
 func Test_codeChar(t *testing.T) {
 	data := []struct {
 		char   rune
@@ -52,7 +147,14 @@ func Test_codeChar(t *testing.T) {
 		{'a', "bipush 97"},
 		{'A', "bipush 65"},
 		{'\u0053', "bipush 83"},
-		{'你', "ldc 20320"},
+		{'你', "sipush 20320"},
+		{'\u0000', "iconst_0"},
+		{'\u0005', "iconst_5"},
+		{'\u007f', "bipush 127"},
+		{'\u0080', "sipush 128"},
+		{'\u7fff', "sipush 32767"},
+		{'\u8000', "ldc 32768"},
+		{'\uffff', "ldc 65535"},
 	}
 
 	for _, d := range data {
@@ -90,6 +192,16 @@ func Test_codeConstant(t *testing.T) {
 		{text.Char('a'), "bipush 97"},
 		{text.String("Nice"), `ldc "Nice"`},
 		{text.Null{}, "aconst_null"},
+		{text.Long(0), "lconst_0"},
+		{text.Long(1), "lconst_1"},
+		{text.Long(200), "ldc2_w 200L"},
+		{text.Float(0), "fconst_0"},
+		{text.Float(2), "fconst_2"},
+		{text.Float(1.5), "ldc 1.5f"},
+		{text.Float(100), "ldc 100.0f"},
+		{text.Double(1), "dconst_1"},
+		{text.Double(2), "ldc2_w 2.0"},
+		{text.Double(1e-10), "ldc2_w 1e-10"},
 	}
 
 	for _, d := range data {
@@ -101,6 +213,32 @@ func Test_codeConstant(t *testing.T) {
 	}
 }
 
+func Test_codeFieldConstant(t *testing.T) {
+	data := []struct {
+		exp    text.Expression
+		expect string
+	}{
+		{text.Num(10), "10"},
+		{text.Char('a'), "97"},
+		{text.Boolean(true), "1"},
+		{text.Boolean(false), "0"},
+		{text.Long(200), "200L"},
+		{text.Float(1.5), "1.5f"},
+		{text.Double(2), "2.0"},
+		{text.Float(math.Inf(-1)), "-Infinityf"},
+		{text.Double(math.NaN()), "+NaN"},
+		{text.String("Nice"), `"Nice"`},
+	}
+
+	for _, d := range data {
+		result := codeFieldConstant(d.exp)
+		if d.expect != result {
+			content := text.PrettyPrint(d.exp)
+			t.Errorf("%s should return %#v but got %#v", content, d.expect, result)
+		}
+	}
+}
+
 func Test_fieldDescriptor(t *testing.T) {
 	data := []struct {
 		name    string
@@ -114,6 +252,11 @@ func Test_fieldDescriptor(t *testing.T) {
 		{"Hello", false, "LHello;"},
 		{"AnyOtherElse", true, "[LAnyOtherElse;"},
 		{"void", true, "V"},
+		{"byte", false, "B"},
+		{"short", false, "S"},
+		{"long", false, "J"},
+		{"float", true, "[F"},
+		{"double", false, "D"},
 	}
 
 	for _, d := range data {
@@ -124,6 +267,31 @@ func Test_fieldDescriptor(t *testing.T) {
 	}
 }
 
+func Test_checkcastCode(t *testing.T) {
+	box := NewType("Box", Class)
+	variable := NewType("T", TypeVariable)
+	box.typeParams = []*TypeSymbol{variable}
+	boxOfString := box.parameterize([]DataType{mockString})
+
+	data := []struct {
+		declared DataType
+		actual   DataType
+		expect   string
+	}{
+		{DataType{variable, false}, mockString, "checkcast java/lang/String"},
+		{DataType{variable, false}, DataType{boxOfString, false}, "checkcast Box"},
+		{DataType{variable, false}, DataType{PrimitiveString, true}, "checkcast [Ljava/lang/String;"},
+		{DataType{box, false}, DataType{boxOfString, false}, ""},
+		{mockString, mockString, ""},
+	}
+
+	for _, d := range data {
+		if result := checkcastCode(d.declared, d.actual); result != d.expect {
+			t.Errorf("Cast from %s into %s expecting %#v but got %#v", d.declared, d.actual, d.expect, result)
+		}
+	}
+}
+
 func assertHasNCode(t *testing.T, gen *KrakatauGen, count int) bool {
 	if codeCount := len(gen.Codes()); codeCount != count {
 		t.Errorf("Should at least has %d line of codes, but got %d.", count, codeCount)
@@ -200,6 +368,13 @@ func TestKrakatauGen_Class(t *testing.T) {
 				".implements ICallable",
 			},
 		},
+		{
+			&text.Class{Name: "Person", Access: text.Final},
+			[]string{
+				".class final Person",
+				objectSuper,
+			},
+		},
 	}
 
 	for _, d := range data {
@@ -297,6 +472,17 @@ func TestKrakatauGen_PropertyDeclaration(t *testing.T) {
 				".field private name Ljava/lang/String;",
 			},
 		},
+		{
+			text.PropertyDeclaration{
+				VariableDeclaration: text.VariableDeclaration{
+					Type: text.NamedType{Name: "int", IsArray: false},
+					Name: "limit",
+				},
+			},
+			[]string{
+				".field limit I",
+			},
+		},
 	}
 
 	for _, d := range data {
@@ -307,6 +493,55 @@ func TestKrakatauGen_PropertyDeclaration(t *testing.T) {
 	}
 }
 
+func TestKrakatauGen_ConstantPropertyDeclaration(t *testing.T) {
+	var mul, div, sub text.Token
+	mul.Type = text.Multiplication
+	div.Type = text.Division
+	sub.Type = text.Subtraction
+
+	op := func(token text.Token, left, right text.Expression) *text.BinOp {
+		value := text.NewBinOp(token, left, right)
+		return &value
+	}
+
+	data := []struct {
+		typename string
+		value    text.Expression
+		expect   string
+	}{
+		{"int", op(mul, text.Num(2), text.Num(5)), ".field public static final MAX I = 10"},
+		// the non-finite values are folded as they are in java
+		{"double", op(div, text.Double(1), text.Num(0)), ".field public static final MAX D = +Infinity"},
+		{"double", op(div, op(sub, text.Num(0), text.Double(1)), text.Num(0)), ".field public static final MAX D = -Infinity"},
+		{"float", op(div, text.Float(0), text.Num(0)), ".field public static final MAX F = +NaNf"},
+	}
+
+	for _, d := range data {
+		prop := &text.PropertyDeclaration{
+			AccessModifier: text.Public | text.Static | text.Final,
+			VariableDeclaration: text.VariableDeclaration{
+				Type:  text.NamedType{Name: d.typename, IsArray: false},
+				Name:  "MAX",
+				Value: d.value,
+			},
+		}
+
+		class := text.NewEmptyClass("Limit", "", "")
+		class.Properties = append(class.Properties, prop)
+
+		mockKrakatau(func(gen *KrakatauGen) {
+			gen.typeTable = getMockTypeTable(class)
+			gen.currentClass = class
+
+			symbol := gen.typeTable.Lookup("Limit").Properties["MAX"]
+			symbol.constant.valueOf(gen.typeTable, symbol.DataType)
+
+			gen.VisitPropertyDeclaration(prop)
+			assertHasSameCodes(t, gen, d.expect)
+		})
+	}
+}
+
 func TestKrakataugGen_makeDefaultConstructor(t *testing.T) {
 	var mockClass text.Class
 	mockClass.Name = "Mock"
@@ -336,10 +571,14 @@ func TestKrakataugGen_makeDefaultConstructor(t *testing.T) {
 			},
 		},
 	}
+	for _, prop := range mockClass.Properties {
+		mockClass.Initializers = append(mockClass.Initializers, prop)
+	}
 
 	mockExtend := mockClass
 	mockExtend.Extend = "Person"
 	mockExtend.Properties = []*text.PropertyDeclaration{}
+	mockExtend.Initializers = nil
 
 	data := []struct {
 		class  *text.Class
@@ -359,7 +598,7 @@ func TestKrakataugGen_makeDefaultConstructor(t *testing.T) {
 				"aconst_null",
 				"putfield Field Mock stringProp Ljava/lang/String;",
 				"aload_0",
-				"ldc 4000",
+				"sipush 4000",
 				"putfield Field Mock age I",
 				"return",
 				".end code",
@@ -405,7 +644,7 @@ func TestKrakatauGen_AfterBinOp(t *testing.T) {
 		{
 			text.NewBinOp(add, text.Num(12000), text.Num(3)),
 			[]string{
-				"ldc 12000",
+				"sipush 12000",
 				"iconst_3",
 				"iadd",
 			},
@@ -474,8 +713,8 @@ func TestKrakatauGen_AfterBinOp_boolean(t *testing.T) {
 			text.NewBinOp(eq, text.Num(1), &hundredsOp),
 			[]string{
 				"iconst_1",
-				"ldc 300",
-				"ldc 200",
+				"sipush 300",
+				"sipush 200",
 				"if_icmpne L0",
 				"iconst_0",
 				"goto L1",
@@ -497,6 +736,189 @@ func TestKrakatauGen_AfterBinOp_boolean(t *testing.T) {
 	}
 }
 
+func TestKrakatauGen_AfterBinOp_bitwise(t *testing.T) {
+	data := []struct {
+		tokenType text.TokenType
+		opcode    string
+	}{
+		{text.BitwiseAnd, "iand"},
+		{text.BitwiseOr, "ior"},
+		{text.BitwiseXor, "ixor"},
+		{text.LeftShift, "ishl"},
+		{text.RightShift, "ishr"},
+		{text.UnsignedRightShift, "iushr"},
+	}
+
+	for _, d := range data {
+		var op text.Token
+		op.Type = d.tokenType
+		bin := text.NewBinOp(op, text.Num(12), text.Num(3))
+
+		gen := NewEmptyKrakatauGen()
+		bin.Accept(gen)
+		assertHasSameCodes(t, gen, "bipush 12", "iconst_3", d.opcode)
+
+		if result, _ := gen.typeStack.Pop(); result != mockInt {
+			t.Errorf("%s should result in int but got %s", d.tokenType, result)
+		}
+	}
+
+	// non short-circuit logic keep the boolean type
+	var and text.Token
+	and.Type = text.BitwiseAnd
+	bin := text.NewBinOp(and, text.Boolean(true), text.Boolean(false))
+	gen := NewEmptyKrakatauGen()
+	bin.Accept(gen)
+	assertHasSameCodes(t, gen, "iconst_1", "iconst_0", "iand")
+	if result, _ := gen.typeStack.Pop(); result != mockBoolean {
+		t.Errorf("boolean & boolean should result in boolean but got %s", result)
+	}
+}
+
+func TestKrakatauGen_AfterBinOp_promotion(t *testing.T) {
+	var add, sub, shl, lt, gt text.Token
+	add.Type = text.Addition
+	sub.Type = text.Subtraction
+	shl.Type = text.LeftShift
+	lt.Type = text.LessThan
+	gt.Type = text.GreaterThan
+
+	data := []struct {
+		bin      text.BinOp
+		expect   []string
+		result   DataType
+		stackMax int
+	}{
+		{
+			text.NewBinOp(add, text.Long(2), text.Num(3)),
+			[]string{"ldc2_w 2L", "iconst_3", "i2l", "ladd"},
+			mockLong,
+			4,
+		},
+		{
+			text.NewBinOp(sub, text.Num(3), text.Double(2)),
+			[]string{"iconst_3", "ldc2_w 2.0", "dup2_x1", "pop2", "i2d", "dup2_x2", "pop2", "dsub"},
+			mockDouble,
+			6,
+		},
+		{
+			text.NewBinOp(sub, text.Float(3), text.Num(3)),
+			[]string{"ldc 3.0f", "iconst_3", "i2f", "fsub"},
+			mockFloat,
+			2,
+		},
+		{
+			text.NewBinOp(shl, text.Long(2), text.Long(3)),
+			[]string{"ldc2_w 2L", "ldc2_w 3L", "l2i", "lshl"},
+			mockLong,
+			4,
+		},
+		{
+			text.NewBinOp(lt, text.Double(2), text.Double(3)),
+			[]string{"ldc2_w 2.0", "ldc2_w 3.0", "dcmpg", "iflt L0", "iconst_0", "goto L1", "L0:\ticonst_1", "L1:\t"},
+			mockBoolean,
+			4,
+		},
+		{
+			text.NewBinOp(gt, text.Long(2), text.Num(3)),
+			[]string{"ldc2_w 2L", "iconst_3", "i2l", "lcmp", "ifgt L0", "iconst_0", "goto L1", "L0:\ticonst_1", "L1:\t"},
+			mockBoolean,
+			4,
+		},
+	}
+
+	for _, d := range data {
+		gen := NewEmptyKrakatauGen()
+		d.bin.Accept(gen)
+		assertHasSameCodes(t, gen, d.expect...)
+
+		if result, _ := gen.typeStack.Pop(); result != d.result {
+			t.Errorf("%s should result in %s but got %s", text.PrettyPrint(&d.bin), d.result, result)
+		}
+
+		if gen.stackMax != d.stackMax {
+			t.Errorf("Stack size of %s should be %d, but got %d", text.PrettyPrint(&d.bin), d.stackMax, gen.stackMax)
+		}
+	}
+}
+
+func Test_conversionCode(t *testing.T) {
+	data := []struct {
+		from, to DataType
+		expect   []string
+	}{
+		{mockInt, mockInt, nil},
+		{mockByte, mockInt, nil},
+		{mockChar, mockInt, nil},
+		{mockInt, mockLong, []string{"i2l"}},
+		{mockLong, mockDouble, []string{"l2d"}},
+		{mockDouble, mockFloat, []string{"d2f"}},
+		{mockInt, mockByte, []string{"i2b"}},
+		{mockInt, mockChar, []string{"i2c"}},
+		{mockByte, mockChar, []string{"i2c"}},
+		{mockDouble, mockByte, []string{"d2i", "i2b"}},
+		{mockLong, mockInt, []string{"l2i"}},
+		{mockString, mockInt, nil},
+	}
+
+	for _, d := range data {
+		result := conversionCode(d.from, d.to)
+		if strings.Join(result, " ") != strings.Join(d.expect, " ") {
+			t.Errorf("Converting %s to %s should be %v but got %v", d.from, d.to, d.expect, result)
+		}
+	}
+}
+
+func TestKrakatauGen_UnaryOp(t *testing.T) {
+	var minus, plus text.Token
+	minus.Type = text.Subtraction
+	plus.Type = text.Addition
+
+	data := []struct {
+		unary  text.UnaryOp
+		expect []string
+		result DataType
+	}{
+		{text.UnaryOp{minus, text.Num(3)}, []string{"iconst_3", "ineg"}, mockInt},
+		{text.UnaryOp{minus, text.Long(3)}, []string{"ldc2_w 3L", "lneg"}, mockLong},
+		{text.UnaryOp{minus, text.Double(1.5)}, []string{"ldc2_w 1.5", "dneg"}, mockDouble},
+		{text.UnaryOp{minus, text.Char('a')}, []string{"bipush 97", "ineg"}, mockInt},
+		{text.UnaryOp{plus, text.Float(1.5)}, []string{"ldc 1.5f"}, mockFloat},
+	}
+
+	for _, d := range data {
+		gen := NewEmptyKrakatauGen()
+		d.unary.Accept(gen)
+		assertHasSameCodes(t, gen, d.expect...)
+
+		if result, _ := gen.typeStack.Pop(); result != d.result {
+			t.Errorf("%s should result in %s but got %s", text.PrettyPrint(&d.unary), d.result, result)
+		}
+	}
+}
+
+func TestKrakatauGen_Cast(t *testing.T) {
+	data := []struct {
+		cast   text.Cast
+		expect []string
+	}{
+		{text.Cast{newNamedType("int", false), text.Double(1.5)}, []string{"ldc2_w 1.5", "d2i"}},
+		{text.Cast{newNamedType("long", false), text.Num(3)}, []string{"iconst_3", "i2l"}},
+		{text.Cast{newNamedType("char", false), text.Num(3)}, []string{"iconst_3", "i2c"}},
+		{text.Cast{newNamedType("int", false), text.Num(3)}, []string{"iconst_3"}},
+	}
+
+	for _, d := range data {
+		gen := NewEmptyKrakatauGen()
+		d.cast.Accept(gen)
+		assertHasSameCodes(t, gen, d.expect...)
+
+		if result, _ := gen.typeStack.Pop(); result.Name() != d.cast.Type.Name {
+			t.Errorf("Cast should result in %s but got %s", d.cast.Type.Name, result)
+		}
+	}
+}
+
 func TestKrakatauGen_MethodSignature(t *testing.T) {
 	getAge := methodGetAge.MethodSignature
 	getName := methodGetNameWithParam.MethodSignature
@@ -601,13 +1023,13 @@ func TestKrakatauGen_stackSize(t *testing.T) {
 	}
 }
 
-func mockSysout(args ...text.Expression) *text.FieldAccess {
+func mockPrintCall(stream, method string, args ...text.Expression) *text.FieldAccess {
 	return &text.FieldAccess{
 		Name: "System",
 		Child: &text.FieldAccess{
-			Name: "out",
+			Name: stream,
 			Child: &text.MethodCall{
-				Name:  "println",
+				Name:  method,
 				Args:  args,
 				Child: nil,
 			},
@@ -615,39 +1037,32 @@ func mockSysout(args ...text.Expression) *text.FieldAccess {
 	}
 }
 
+func mockSysout(args ...text.Expression) *text.FieldAccess {
+	return mockPrintCall("out", "println", args...)
+}
+
 func TestKrakatauGen_SystemOut(t *testing.T) {
 	var gt text.Token
 	gt.Type = text.GreaterThan
 	condition := text.NewBinOp(gt, text.Num(1), text.Num(2))
+	out := "getstatic Field java/lang/System out Ljava/io/PrintStream;"
 
 	data := []struct {
-		lastDT DataType
-		arg    text.Expression
+		print  *text.FieldAccess
 		expect []string
 	}{
 		{
-			mockInt,
-			text.Num(1),
-			[]string{
-				"getstatic Field java/lang/System out Ljava/io/PrintStream;",
-				"iconst_1",
-				"invokevirtual Method java/io/PrintStream println (I)V",
-			},
+			mockSysout(text.Num(1)),
+			[]string{out, "iconst_1", "invokevirtual Method java/io/PrintStream println (I)V"},
 		},
 		{
-			mockString,
-			text.String("Hello"),
-			[]string{
-				"getstatic Field java/lang/System out Ljava/io/PrintStream;",
-				`ldc "Hello"`,
-				"invokevirtual Method java/io/PrintStream println (Ljava/lang/String;)V",
-			},
+			mockSysout(text.String("Hello")),
+			[]string{out, `ldc "Hello"`, "invokevirtual Method java/io/PrintStream println (Ljava/lang/String;)V"},
 		},
 		{
-			mockBoolean,
-			&condition,
+			mockSysout(&condition),
 			[]string{
-				"getstatic Field java/lang/System out Ljava/io/PrintStream;",
+				out,
 				"iconst_1",
 				"iconst_2",
 				"if_icmpgt L0",
@@ -658,67 +1073,150 @@ func TestKrakatauGen_SystemOut(t *testing.T) {
 				"invokevirtual Method java/io/PrintStream println (Z)V",
 			},
 		},
-	}
-	for _, d := range data {
-		gen := NewEmptyKrakatauGen()
-		gen.typeStack.Push(d.lastDT)
-		sys := mockSysout(d.arg)
-		sys.Accept(gen)
-		assertHasSameCodes(t, gen, d.expect...)
-	}
-}
-
-func TestKrakatauGen_JumpStatement(t *testing.T) {
-	data := []struct {
-		jump   text.JumpStatement
-		expect []string
-	}{
 		{
-			text.JumpStatement{Type: text.ReturnJump, Exp: nil},
-			[]string{"return"},
+			mockSysout(),
+			[]string{out, "invokevirtual Method java/io/PrintStream println ()V"},
 		},
 		{
-			text.JumpStatement{Type: text.ReturnJump, Exp: text.String("Nice")},
-			[]string{`ldc "Nice"`, "areturn"},
+			mockSysout(&text.FieldAccess{Name: "b"}),
+			[]string{out, "iload_1", "invokevirtual Method java/io/PrintStream println (I)V"},
 		},
 		{
-			text.JumpStatement{Type: text.ReturnJump, Exp: text.Num(1)},
-			[]string{"iconst_1", "ireturn"},
+			mockSysout(&text.FieldAccess{Name: "chars"}),
+			[]string{out, "aload_2", "invokevirtual Method java/io/PrintStream println ([C)V"},
 		},
 		{
-			text.JumpStatement{Type: text.ReturnJump, Exp: text.Char('\u0001')},
-			[]string{"iconst_1", "ireturn"},
+			mockSysout(&text.FieldAccess{Name: "names"}),
+			[]string{out, "aload_3", "invokevirtual Method java/io/PrintStream println (Ljava/lang/Object;)V"},
 		},
 		{
-			text.JumpStatement{Type: text.ReturnJump, Exp: text.Boolean(true)},
-			[]string{"iconst_1", "ireturn"},
+			mockPrintCall("out", "print", text.Double(1.5)),
+			[]string{out, "ldc2_w 1.5", "invokevirtual Method java/io/PrintStream print (D)V"},
+		},
+		{
+			mockPrintCall("err", "println", text.Char('c')),
+			[]string{
+				"getstatic Field java/lang/System err Ljava/io/PrintStream;",
+				"bipush 99",
+				"invokevirtual Method java/io/PrintStream println (C)V",
+			},
+		},
+		{
+			mockPrintCall("out", "printf", text.String("%s"), &text.FieldAccess{Name: "names"}),
+			[]string{
+				out,
+				`ldc "%s"`,
+				"aload_3",
+				"invokevirtual Method java/io/PrintStream printf (Ljava/lang/String;[Ljava/lang/Object;)Ljava/io/PrintStream;",
+			},
 		},
 	}
 
 	for _, d := range data {
-		gen := NewEmptyKrakatauGen()
-		d.jump.Accept(gen)
-		assertHasSameCodes(t, gen, d.expect...)
+		mockKrakatau(func(gen *KrakatauGen) {
+			table := NewSymbolTable("mock", 0, nil)
+			table.Insert(&FieldSymbol{mockByte, "b"}, 1)
+			table.Insert(&FieldSymbol{DataType{PrimitiveChar, true}, "chars"}, 2)
+			table.Insert(&FieldSymbol{DataType{PrimitiveString, true}, "names"}, 3)
+			gen.scopeIndex = 0
+			gen.symbolTable = []*SymbolTable{&table}
+
+			d.print.Accept(gen)
+			assertHasSameCodes(t, gen, d.expect...)
+		})
 	}
 }
 
-func TestKrakatauGen_IfStatement(t *testing.T) {
-	getStatic := "getstatic Field java/lang/System out Ljava/io/PrintStream;"
-	invokeSysout := "invokevirtual Method java/io/PrintStream println (I)V"
+func TestKrakatauGen_MethodCallStatement(t *testing.T) {
 	data := []struct {
-		ifstmt text.IfStatement
+		method text.NamedValue
 		expect []string
 	}{
 		{
-			text.IfStatement{
-				Condition: text.Boolean(true),
-				Body:      &text.MethodCallStatement{Method: mockSysout(text.Num(12))},
-				Else:      nil,
-			},
+			mockSysout(text.Num(1)),
 			[]string{
+				"getstatic Field java/lang/System out Ljava/io/PrintStream;",
 				"iconst_1",
-				"ifne L0", // if true
-				"goto L1",
+				"invokevirtual Method java/io/PrintStream println (I)V",
+			},
+		},
+		{
+			&text.FieldAccess{Name: "sb", Child: &text.MethodCall{Name: "reverse"}},
+			[]string{"aload_1", "invokevirtual Method java/lang/StringBuilder reverse ()Ljava/lang/StringBuilder;", "pop"},
+		},
+		{
+			&text.FieldAccess{Name: "System", Child: &text.MethodCall{Name: "nanoTime"}},
+			[]string{"invokestatic Method java/lang/System nanoTime ()J", "pop2"},
+		},
+	}
+
+	for _, d := range data {
+		mockKrakatau(func(gen *KrakatauGen) {
+			table := NewSymbolTable("mock", 0, nil)
+			table.Insert(&FieldSymbol{DataType{library["StringBuilder"], false}, "sb"}, 1)
+			gen.scopeIndex = 0
+			gen.symbolTable = []*SymbolTable{&table}
+
+			(&text.MethodCallStatement{Method: d.method}).Accept(gen)
+			assertHasSameCodes(t, gen, d.expect...)
+			if len(gen.typeStack) != 0 || gen.stackSize != 0 {
+				t.Errorf("Expecting an empty stack after the statement, but got %v", gen.typeStack)
+			}
+		})
+	}
+}
+
+func TestKrakatauGen_JumpStatement(t *testing.T) {
+	data := []struct {
+		jump   text.JumpStatement
+		expect []string
+	}{
+		{
+			text.JumpStatement{Type: text.ReturnJump, Exp: nil},
+			[]string{"return"},
+		},
+		{
+			text.JumpStatement{Type: text.ReturnJump, Exp: text.String("Nice")},
+			[]string{`ldc "Nice"`, "areturn"},
+		},
+		{
+			text.JumpStatement{Type: text.ReturnJump, Exp: text.Num(1)},
+			[]string{"iconst_1", "ireturn"},
+		},
+		{
+			text.JumpStatement{Type: text.ReturnJump, Exp: text.Char('\u0001')},
+			[]string{"iconst_1", "ireturn"},
+		},
+		{
+			text.JumpStatement{Type: text.ReturnJump, Exp: text.Boolean(true)},
+			[]string{"iconst_1", "ireturn"},
+		},
+	}
+
+	for _, d := range data {
+		gen := NewEmptyKrakatauGen()
+		d.jump.Accept(gen)
+		assertHasSameCodes(t, gen, d.expect...)
+	}
+}
+
+func TestKrakatauGen_IfStatement(t *testing.T) {
+	getStatic := "getstatic Field java/lang/System out Ljava/io/PrintStream;"
+	invokeSysout := "invokevirtual Method java/io/PrintStream println (I)V"
+	data := []struct {
+		ifstmt text.IfStatement
+		expect []string
+	}{
+		{
+			text.IfStatement{
+				Condition: text.Boolean(true),
+				Body:      &text.MethodCallStatement{Method: mockSysout(text.Num(12))},
+				Else:      nil,
+			},
+			[]string{
+				"iconst_1",
+				"ifne L0", // if true
+				"goto L1",
 				"L0:\t",
 				getStatic,
 				"bipush 12",
@@ -775,9 +1273,14 @@ func TestKrakatauGen_IfStatement(t *testing.T) {
 	}
 
 	for _, d := range data {
-		gen := NewEmptyKrakatauGen()
-		d.ifstmt.Accept(gen)
-		assertHasSameCodes(t, gen, d.expect...)
+		mockKrakatau(func(gen *KrakatauGen) {
+			table := NewSymbolTable("mock", 0, nil)
+			gen.scopeIndex = 0
+			gen.symbolTable = []*SymbolTable{&table}
+
+			d.ifstmt.Accept(gen)
+			assertHasSameCodes(t, gen, d.expect...)
+		})
 	}
 }
 
@@ -806,6 +1309,7 @@ func TestKrakatauGen_VariableDeclaration(t *testing.T) {
 				newNamedType("int", false),
 				"count",
 				text.Num(1),
+				false,
 			},
 			[]string{
 				"iconst_1",
@@ -818,6 +1322,7 @@ func TestKrakatauGen_VariableDeclaration(t *testing.T) {
 				newNamedType("String", false),
 				"name",
 				text.String("Hello"),
+				false,
 			},
 			[]string{
 				`ldc "Hello"`,
@@ -830,6 +1335,7 @@ func TestKrakatauGen_VariableDeclaration(t *testing.T) {
 				newNamedType("String", false),
 				"name",
 				nil,
+				false,
 			},
 			[]string{
 				`aconst_null`,
@@ -842,12 +1348,53 @@ func TestKrakatauGen_VariableDeclaration(t *testing.T) {
 				newNamedType("boolean", false),
 				"name",
 				nil,
+				false,
 			},
 			[]string{
 				`iconst_0`,
 				"istore_1",
 			},
 		},
+		{
+			Local{&FieldSymbol{mockDouble, "ratio"}, 2},
+			text.VariableDeclaration{
+				newNamedType("double", false),
+				"ratio",
+				nil,
+				false,
+			},
+			[]string{
+				`dconst_0`,
+				"dstore_2",
+			},
+		},
+		{
+			Local{&FieldSymbol{mockLong, "total"}, 1},
+			text.VariableDeclaration{
+				newNamedType("long", false),
+				"total",
+				text.Num(3),
+				false,
+			},
+			[]string{
+				"iconst_3",
+				"i2l",
+				"lstore_1",
+			},
+		},
+		{
+			Local{&FieldSymbol{mockByte, "small"}, 1},
+			text.VariableDeclaration{
+				newNamedType("byte", false),
+				"small",
+				text.Num(3),
+				false,
+			},
+			[]string{
+				"iconst_3",
+				"istore_1",
+			},
+		},
 	}
 
 	for _, d := range data {
@@ -910,8 +1457,11 @@ func TestKrakatauGen_FieldAccess(t *testing.T) {
 }
 
 func TestKrakatauGen_AssignmentStatement(t *testing.T) {
-	var eq text.Token
+	var eq, shl, or, add text.Token
 	eq.Type = text.Assignment
+	shl.Type = text.LeftShiftAssignment
+	or.Type = text.BitwiseOrAssignment
+	add.Type = text.AdditionAssignment
 
 	data := []struct {
 		symbol     Local
@@ -947,6 +1497,57 @@ func TestKrakatauGen_AssignmentStatement(t *testing.T) {
 				"putfield Field Human age I",
 			},
 		},
+		{
+			Local{&FieldSymbol{mockInt, "count"}, 1},
+			text.AssignmentStatement{Operator: shl,
+				Left:  &text.FieldAccess{Name: "count", Child: nil},
+				Right: text.Num(2),
+			},
+			[]string{
+				"iload_1",
+				"iconst_2",
+				"ishl",
+				"istore_1",
+			},
+		},
+		{
+			Local{&FieldSymbol{mockHuman, "human"}, 3},
+			text.AssignmentStatement{Operator: or,
+				Left: &text.FieldAccess{
+					Name: "human",
+					Child: &text.FieldAccess{
+						Name:  "age",
+						Child: nil,
+					},
+				},
+				Right: text.Num(4),
+			},
+			[]string{
+				"aload_3",
+				"dup",
+				"getfield Field Human age I",
+				"iconst_4",
+				"ior",
+				"putfield Field Human age I",
+			},
+		},
+		{
+			Local{&FieldSymbol{mockString, "s"}, 2},
+			text.AssignmentStatement{Operator: add,
+				Left:  &text.FieldAccess{Name: "s", Child: nil},
+				Right: text.Long(7),
+			},
+			[]string{
+				"aload_2",
+				"ldc2_w 7L",
+				"invokestatic Method java/lang/String valueOf (J)Ljava/lang/String;",
+				"swap",
+				"invokestatic Method java/lang/String valueOf (Ljava/lang/Object;)Ljava/lang/String;",
+				"swap",
+				"invokevirtual Method java/lang/String concat (Ljava/lang/String;)Ljava/lang/String;",
+				"astore_2",
+			},
+		},
 	}
 
 	for _, d := range data {
@@ -979,6 +1580,8 @@ func TestKrakatau_ObjecCreation(t *testing.T) {
 					Args:  []text.Expression{},
 					Child: nil,
 				},
+				nil,
+				nil,
 			},
 			[]string{
 				"new Human",
@@ -1056,6 +1659,203 @@ func TestKrakatauGen_MethodCall(t *testing.T) {
 	}
 }
 
+func TestKrakatauGen_MethodCall_object(t *testing.T) {
+	callable := DataType{NewType("ICall", Interface), false}
+	call := func(name string, args ...text.Expression) text.NamedValue {
+		return &text.FieldAccess{Name: "obj", Child: &text.MethodCall{Name: name, Args: args}}
+	}
+
+	data := []struct {
+		local  DataType
+		method text.NamedValue
+		expect []string
+	}{
+		{
+			mockHuman,
+			call("toString"),
+			[]string{"aload_1", "invokevirtual Method Human toString ()Ljava/lang/String;"},
+		},
+		{
+			callable,
+			call("hashCode"),
+			[]string{"aload_1", "invokevirtual Method java/lang/Object hashCode ()I"},
+		},
+		{
+			mockString,
+			call("equals", text.String("a")),
+			[]string{"aload_1", `ldc "a"`, "invokevirtual Method java/lang/String equals (Ljava/lang/Object;)Z"},
+		},
+	}
+
+	for _, d := range data {
+		mockKrakatau(func(gen *KrakatauGen) {
+			table := NewSymbolTable("mock", 0, nil)
+			table.Insert(&FieldSymbol{d.local, "obj"}, 1)
+			gen.scopeIndex = 0
+			gen.symbolTable = []*SymbolTable{&table}
+
+			d.method.Accept(gen)
+			assertHasSameCodes(t, gen, d.expect...)
+		})
+	}
+}
+
+func TestKrakatauGen_Classpath(t *testing.T) {
+	engine := analyzeWithClasspath(t, "import com.acme.*; class Box { public Greeter g; public Shape s; }")
+	greeter := DataType{engine.table["Greeter"], false}
+	call := func(name, method string, args ...text.Expression) text.NamedValue {
+		return &text.FieldAccess{Name: name, Child: &text.MethodCall{Name: method, Args: args}}
+	}
+
+	data := []struct {
+		value  text.NamedValue
+		expect []string
+	}{
+		{
+			call("Greeter", "twice", text.Num(2)),
+			[]string{"iconst_2", "invokestatic Method com/acme/Greeter twice (I)I"},
+		},
+		{
+			&text.FieldAccess{Name: "Greeter", Child: &text.FieldAccess{Name: "VERSION"}},
+			[]string{"getstatic Field com/acme/Greeter VERSION I"},
+		},
+		{
+			call("g", "greet", text.String("a")),
+			[]string{"aload_1", `ldc "a"`, "invokevirtual Method com/acme/Greeter greet (Ljava/lang/String;)Ljava/lang/String;"},
+		},
+		{
+			call("Shape", "unit"),
+			[]string{"invokestatic InterfaceMethod com/acme/Shape unit ()Lcom/acme/Shape;"},
+		},
+	}
+
+	for _, d := range data {
+		gen := NewKrakatauGen(engine.table, nil)
+		table := NewSymbolTable("mock", 0, nil)
+		table.Insert(&FieldSymbol{greeter, "g"}, 1)
+		gen.scopeIndex = 0
+		gen.symbolTable = []*SymbolTable{&table}
+
+		d.value.Accept(gen)
+		assertHasSameCodes(t, gen, d.expect...)
+	}
+}
+
+func TestKrakatauGen_StandardInput(t *testing.T) {
+	lexer := text.NewLexer(text.NewStringScanner(`import java.util.Scanner;
+	import java.io.*;
+	class Box { public BufferedReader r; public InputStreamReader i; }`))
+	parser := text.NewParser(&lexer)
+	engine := NewTypeAnalyzer()
+	parser.Compile().Accept(engine)
+
+	systemIn := &text.FieldAccess{Name: "System", Child: &text.FieldAccess{Name: "in"}}
+	create := func(name string, args ...text.Expression) *text.ObjectCreation {
+		return &text.ObjectCreation{MethodCall: text.MethodCall{Name: name, Args: args}}
+	}
+
+	data := []struct {
+		value  text.Expression
+		expect []string
+	}{
+		{
+			create("Scanner", systemIn),
+			[]string{
+				"new java/util/Scanner",
+				"dup",
+				"getstatic Field java/lang/System in Ljava/io/InputStream;",
+				"invokespecial Method java/util/Scanner <init> (Ljava/io/InputStream;)V",
+			},
+		},
+		{
+			create("BufferedReader", create("InputStreamReader", systemIn)),
+			[]string{
+				"new java/io/BufferedReader",
+				"dup",
+				"new java/io/InputStreamReader",
+				"dup",
+				"getstatic Field java/lang/System in Ljava/io/InputStream;",
+				"invokespecial Method java/io/InputStreamReader <init> (Ljava/io/InputStream;)V",
+				"invokespecial Method java/io/BufferedReader <init> (Ljava/io/Reader;)V",
+			},
+		},
+		{
+			&text.FieldAccess{Name: "sc", Child: &text.MethodCall{Name: "nextInt"}},
+			[]string{"aload_1", "invokevirtual Method java/util/Scanner nextInt ()I"},
+		},
+		{
+			&text.FieldAccess{Name: "reader", Child: &text.MethodCall{Name: "readLine"}},
+			[]string{"aload_2", "invokevirtual Method java/io/BufferedReader readLine ()Ljava/lang/String;"},
+		},
+		{
+			mockSysout(&text.FieldAccess{Name: "sc", Child: &text.MethodCall{Name: "nextLine"}}),
+			[]string{
+				"getstatic Field java/lang/System out Ljava/io/PrintStream;",
+				"aload_1",
+				"invokevirtual Method java/util/Scanner nextLine ()Ljava/lang/String;",
+				"invokevirtual Method java/io/PrintStream println (Ljava/lang/String;)V",
+			},
+		},
+	}
+
+	for _, d := range data {
+		gen := NewKrakatauGen(engine.table, nil)
+		table := NewSymbolTable("mock", 0, nil)
+		table.Insert(&FieldSymbol{DataType{engine.table["Scanner"], false}, "sc"}, 1)
+		table.Insert(&FieldSymbol{DataType{engine.table["BufferedReader"], false}, "reader"}, 2)
+		gen.scopeIndex = 0
+		gen.symbolTable = []*SymbolTable{&table}
+
+		d.value.Accept(gen)
+		assertHasSameCodes(t, gen, d.expect...)
+	}
+}
+
+func TestKrakatauGen_Library(t *testing.T) {
+	data := []struct {
+		value  text.NamedValue
+		expect []string
+	}{
+		{
+			&text.FieldAccess{Name: "Math", Child: &text.MethodCall{
+				Name: "max",
+				Args: []text.Expression{text.Num(1), text.Num(2)},
+			}},
+			[]string{"iconst_1", "iconst_2", "invokestatic Method java/lang/Math max (II)I"},
+		},
+		{
+			&text.FieldAccess{Name: "Integer", Child: &text.FieldAccess{Name: "MAX_VALUE"}},
+			[]string{"getstatic Field java/lang/Integer MAX_VALUE I"},
+		},
+		{
+			&text.FieldAccess{Name: "s", Child: &text.MethodCall{
+				Name: "charAt",
+				Args: []text.Expression{text.Num(0)},
+			}},
+			[]string{"aload_1", "iconst_0", "invokevirtual Method java/lang/String charAt (I)C"},
+		},
+		{
+			&text.FieldAccess{Name: "String", Child: &text.MethodCall{
+				Name: "valueOf",
+				Args: []text.Expression{text.Char('c')},
+			}},
+			[]string{"bipush 99", "invokestatic Method java/lang/String valueOf (C)Ljava/lang/String;"},
+		},
+	}
+
+	for _, d := range data {
+		mockKrakatau(func(gen *KrakatauGen) {
+			table := NewSymbolTable("mock", 0, nil)
+			table.Insert(&FieldSymbol{mockString, "s"}, 1)
+			gen.scopeIndex = 0
+			gen.symbolTable = []*SymbolTable{&table}
+
+			d.value.Accept(gen)
+			assertHasSameCodes(t, gen, d.expect...)
+		})
+	}
+}
+
 func TestKrakatauGen_This(t *testing.T) {
 	data := []struct {
 		namedValue text.NamedValue
@@ -1091,6 +1891,7 @@ func TestKrakatauGen_This(t *testing.T) {
 			mockInt,
 			"age",
 		},
+		nil,
 	}
 
 	human.Methods[methodGetAge.Signature()] = NewMethodSymbol(methodGetAge.MethodSignature, *mockInt.dataType)
@@ -1106,6 +1907,7 @@ func TestKrakatauGen_This(t *testing.T) {
 			table.Insert(&PropertySymbol{
 				text.Public,
 				FieldSymbol{mockHuman, "this"},
+				nil,
 			}, 0)
 
 			gen.scopeIndex = 0
@@ -1353,3 +2155,1265 @@ func TestKrakatauGen_ForStatement(t *testing.T) {
 		})
 	}
 }
+
+func mockEnum(constants ...*text.EnumConstant) *text.Enum {
+	enum := text.NewEmptyEnum("Color", "")
+	for _, c := range constants {
+		enum.AddConstant(c)
+	}
+	return enum
+}
+
+func TestKrakatauGen_Enum(t *testing.T) {
+	enum := mockEnum(&text.EnumConstant{Name: "RED"}, &text.EnumConstant{Name: "GREEN"})
+	enum.Implement = "Named"
+
+	gen := NewEmptyKrakatauGen()
+	gen.VisitEnum(enum)
+	assertHasSameCodes(t, gen,
+		".class final super enum Color",
+		".super java/lang/Enum",
+		".implements Named",
+		".field public static final enum RED LColor;",
+		".field public static final enum GREEN LColor;",
+		".field private static final synthetic $VALUES [LColor;",
+	)
+}
+
+func TestKrakatauGen_AfterEnum(t *testing.T) {
+	enum := mockEnum(&text.EnumConstant{Name: "RED"}, &text.EnumConstant{Name: "GREEN"})
+
+	gen := NewEmptyKrakatauGen()
+	gen.typeTable = getMockTypeTable(enum)
+	gen.currentEnum = enum
+	gen.VisitAfterEnum(enum)
+	assertHasSameCodes(t, gen,
+		".method private <init> : (Ljava/lang/String;I)V",
+		".code stack 3 locals 3",
+		"aload_0",
+		"aload_1",
+		"iload_2",
+		"invokespecial Method java/lang/Enum <init> (Ljava/lang/String;I)V",
+		"return",
+		".end code",
+		".end method",
+		".method public static values : ()[LColor;",
+		".code stack 3 locals 0",
+		"getstatic Field Color $VALUES [LColor;",
+		"invokevirtual Method [LColor; clone ()Ljava/lang/Object;",
+		"checkcast [LColor;",
+		"areturn",
+		".end code",
+		".end method",
+		".method static <clinit> : ()V",
+		".code stack 4 locals 0",
+		"new Color",
+		"dup",
+		`ldc "RED"`,
+		"iconst_0",
+		"invokespecial Method Color <init> (Ljava/lang/String;I)V",
+		"putstatic Field Color RED LColor;",
+		"new Color",
+		"dup",
+		`ldc "GREEN"`,
+		"iconst_1",
+		"invokespecial Method Color <init> (Ljava/lang/String;I)V",
+		"putstatic Field Color GREEN LColor;",
+		"iconst_2",
+		"anewarray Color",
+		"dup",
+		"iconst_0",
+		"getstatic Field Color RED LColor;",
+		"aastore",
+		"dup",
+		"iconst_1",
+		"getstatic Field Color GREEN LColor;",
+		"aastore",
+		"putstatic Field Color $VALUES [LColor;",
+		"return",
+		".end code",
+		".end method",
+		".end class",
+	)
+}
+
+func TestKrakatauGen_makeEnumInitializer_args(t *testing.T) {
+	enum := mockEnum(&text.EnumConstant{Name: "RED", Args: []text.Expression{text.Num(1), text.Num(2)}})
+	enum.AddDeclaration(text.NewConstructor(
+		text.Private,
+		"Color",
+		[]text.Parameter{
+			{Type: newNamedType("long", false), Name: "code"},
+			{Type: newNamedType("int", false), Name: "weight"},
+		},
+		text.StatementList{},
+	))
+
+	gen := NewEmptyKrakatauGen()
+	gen.typeTable = getMockTypeTable(enum)
+	gen.makeEnumInitializer(enum)
+	assertHasSameCodes(t, gen,
+		".method static <clinit> : ()V",
+		".code stack 7 locals 2",
+		"new Color",
+		"dup",
+		`ldc "RED"`,
+		"iconst_0",
+		"iconst_1",
+		"iconst_2",
+		"istore_1",
+		"istore_0",
+		"iload_0",
+		"i2l",
+		"iload_1",
+		"invokespecial Method Color <init> (Ljava/lang/String;IJI)V",
+		"putstatic Field Color RED LColor;",
+		"iconst_1",
+		"anewarray Color",
+		"dup",
+		"iconst_0",
+		"getstatic Field Color RED LColor;",
+		"aastore",
+		"putstatic Field Color $VALUES [LColor;",
+		"return",
+		".end code",
+		".end method",
+	)
+}
+
+func TestKrakatauGen_EnumConstructor(t *testing.T) {
+	enum := mockEnum(&text.EnumConstant{Name: "RED", Args: []text.Expression{text.Num(1)}})
+	con := text.NewConstructor(
+		text.Private,
+		"Color",
+		[]text.Parameter{{Type: newNamedType("int", false), Name: "code"}},
+		text.StatementList{},
+	)
+
+	gen := NewEmptyKrakatauGen()
+	gen.currentEnum = enum
+	gen.makeConstructor(enum.Class, *con)
+	assertHasSameCodes(t, gen,
+		".method <init> : (Ljava/lang/String;II)V",
+		"aload_0",
+		"aload_1",
+		"iload_2",
+		"invokespecial Method java/lang/Enum <init> (Ljava/lang/String;I)V",
+	)
+
+	if gen.localCount != 4 {
+		t.Errorf("Enum constructor should use 4 locals, but got %d", gen.localCount)
+	}
+}
+
+func TestKrakatauGen_EnumStaticAccess(t *testing.T) {
+	data := []struct {
+		value  text.NamedValue
+		expect []string
+	}{
+		{
+			&text.FieldAccess{Name: "Color", Child: &text.FieldAccess{Name: "RED"}},
+			[]string{"getstatic Field Color RED LColor;"},
+		},
+		{
+			&text.FieldAccess{Name: "Color", Child: &text.MethodCall{Name: "values", Args: []text.Expression{}}},
+			[]string{"invokestatic Method Color values ()[LColor;"},
+		},
+		{
+			&text.FieldAccess{Name: "Color", Child: &text.FieldAccess{
+				Name:  "RED",
+				Child: &text.MethodCall{Name: "ordinal", Args: []text.Expression{}},
+			}},
+			[]string{
+				"getstatic Field Color RED LColor;",
+				"invokevirtual Method Color ordinal ()I",
+			},
+		},
+	}
+
+	enum := mockEnum(&text.EnumConstant{Name: "RED"})
+	for _, d := range data {
+		mockKrakatau(func(gen *KrakatauGen) {
+			gen.typeTable = getMockTypeTable(enum)
+			table := NewSymbolTable("mock", 0, nil)
+			gen.scopeIndex = 0
+			gen.symbolTable = []*SymbolTable{&table}
+
+			d.value.Accept(gen)
+			assertHasSameCodes(t, gen, d.expect...)
+		})
+	}
+}
+
+func TestKrakatauGen_SwitchStatement(t *testing.T) {
+	breakStmt := text.StatementList{&text.JumpStatement{Type: text.BreakJump}}
+	enum := mockEnum(&text.EnumConstant{Name: "RED"}, &text.EnumConstant{Name: "GREEN"})
+	typeTable := getMockTypeTable(enum)
+	color := DataType{typeTable["Color"], false}
+
+	data := []struct {
+		local  Local
+		stmt   text.SwitchStatement
+		expect []string
+	}{
+		{
+			Local{&FieldSymbol{mockInt, "i"}, 1},
+			text.SwitchStatement{
+				ValueToCompare: &text.FieldAccess{Name: "i"},
+				CaseList: []*text.CaseStatement{
+					{Values: []text.Expression{text.Num(2)}, StatementList: breakStmt},
+					{Values: []text.Expression{text.Num(-1)}, StatementList: breakStmt},
+				},
+				DefaultCase: []text.Statement{&text.JumpStatement{Type: text.BreakJump}},
+			},
+			[]string{
+				"iload_1",
+				"lookupswitch",
+				"\t-1 : L3",
+				"\t2 : L2",
+				"\tdefault : L1",
+				"L2:\t",
+				"goto L0",
+				"L3:\t",
+				"goto L0",
+				"L1:\t",
+				"goto L0",
+				"L0:\t",
+			},
+		},
+		{
+			Local{&FieldSymbol{color, "c"}, 2},
+			text.SwitchStatement{
+				ValueToCompare: &text.FieldAccess{Name: "c"},
+				CaseList: []*text.CaseStatement{
+					{Values: []text.Expression{&text.FieldAccess{Name: "GREEN"}}, StatementList: text.StatementList{}},
+				},
+			},
+			[]string{
+				"aload_2",
+				"invokevirtual Method Color ordinal ()I",
+				"lookupswitch",
+				"\t1 : L1",
+				"\tdefault : L0",
+				"L1:\t",
+				"L0:\t",
+			},
+		},
+		{
+			Local{&FieldSymbol{mockString, "s"}, 1},
+			text.SwitchStatement{
+				ValueToCompare: &text.FieldAccess{Name: "s"},
+				CaseList: []*text.CaseStatement{
+					{Values: []text.Expression{text.String("Aa"), text.String("BB")}, StatementList: breakStmt},
+					{Values: []text.Expression{text.String("b")}, StatementList: breakStmt},
+				},
+			},
+			[]string{
+				"aload_1",
+				"astore_2",
+				"aload_2",
+				"invokevirtual Method java/lang/String hashCode ()I",
+				"lookupswitch",
+				"\t98 : L4",
+				"\t2112 : L3",
+				"\tdefault : L0",
+				"L4:\t",
+				"aload_2",
+				`ldc "b"`,
+				"invokevirtual Method java/lang/String equals (Ljava/lang/Object;)Z",
+				"ifne L2",
+				"goto L0",
+				"L3:\t",
+				"aload_2",
+				`ldc "Aa"`,
+				"invokevirtual Method java/lang/String equals (Ljava/lang/Object;)Z",
+				"ifne L1",
+				"aload_2",
+				`ldc "BB"`,
+				"invokevirtual Method java/lang/String equals (Ljava/lang/Object;)Z",
+				"ifne L1",
+				"goto L0",
+				"L1:\t",
+				"goto L0",
+				"L2:\t",
+				"goto L0",
+				"L0:\t",
+			},
+		},
+		{
+			Local{&FieldSymbol{mockInt, "i"}, 1},
+			text.SwitchStatement{
+				ValueToCompare: &text.FieldAccess{Name: "i"},
+				CaseList: []*text.CaseStatement{
+					{Values: []text.Expression{text.Num(1), text.Num(2)}, StatementList: text.StatementList{}},
+				},
+				DefaultCase: []text.Statement{text.StatementList{}},
+				IsArrow:     true,
+			},
+			[]string{
+				"iload_1",
+				"lookupswitch",
+				"\t1 : L2",
+				"\t2 : L2",
+				"\tdefault : L1",
+				"L2:\t",
+				"goto L0",
+				"L1:\t",
+				"L0:\t",
+			},
+		},
+	}
+
+	for _, d := range data {
+		mockKrakatau(func(gen *KrakatauGen) {
+			gen.typeTable = typeTable
+			table := NewSymbolTable("mock", 0, nil)
+			table.Insert(d.local.Member, d.local.address)
+			gen.localCount = d.local.address + 1
+			gen.scopeIndex = 0
+			gen.symbolTable = []*SymbolTable{&table}
+
+			d.stmt.Accept(gen)
+			assertHasSameCodes(t, gen, d.expect...)
+			if len(gen.switches) != 0 || len(gen.loopOuter) != 0 {
+				t.Errorf("Switch labels should be removed after the switch statement")
+			}
+		})
+	}
+}
+
+func TestKrakatauGen_BridgeMethod(t *testing.T) {
+	content := `
+interface Comparable<T> {
+	public int compareTo(T other);
+}
+class Item implements Comparable<Item> {
+	public int compareTo(Item other) {
+		return 0;
+	}
+}`
+	lexer := text.NewLexer(text.NewStringScanner(content))
+	parser := text.NewParser(&lexer)
+	program := parser.Compile()
+	item := program[1].(*text.Class)
+
+	mockKrakatau(func(gen *KrakatauGen) {
+		gen.typeTable = getMockTypeTable(program...)
+		gen.makeBridgeMethods(item, gen.typeTable["Item"])
+		assertHasSameCodes(t, gen,
+			".method public bridge synthetic compareTo : (Ljava/lang/Object;)I",
+			".code stack 2 locals 2",
+			"aload_0",
+			"aload_1",
+			"checkcast Item",
+			"invokevirtual Method Item compareTo (LItem;)I",
+			"ireturn",
+			".end code",
+			".end method",
+		)
+	})
+}
+
+func TestKrakatauGen_CovariantBridgeMethod(t *testing.T) {
+	content := `
+class Base {
+	public Object make() { return null; }
+}
+class Sub extends Base {
+	public String make() { return ""; }
+}
+class Deeper extends Sub {
+	public String make() { return ""; }
+}`
+	lexer := text.NewLexer(text.NewStringScanner(content))
+	parser := text.NewParser(&lexer)
+	program := parser.Compile()
+
+	mockKrakatau(func(gen *KrakatauGen) {
+		gen.typeTable = getMockTypeTable(program...)
+		gen.makeBridgeMethods(program[1].(*text.Class), gen.typeTable["Sub"])
+		assertHasSameCodes(t, gen,
+			".method public bridge synthetic make : ()Ljava/lang/Object;",
+			".code stack 1 locals 1",
+			"aload_0",
+			"invokevirtual Method Sub make ()Ljava/lang/String;",
+			"areturn",
+			".end code",
+			".end method",
+		)
+	})
+
+	// the bridge of Sub already dispatch into the method of Deeper
+	mockKrakatau(func(gen *KrakatauGen) {
+		gen.typeTable = getMockTypeTable(program...)
+		gen.makeBridgeMethods(program[2].(*text.Class), gen.typeTable["Deeper"])
+		assertHasSameCodes(t, gen)
+	})
+}
+
+func Test_innerClassEntry(t *testing.T) {
+	nested := func(name string, kind text.ClassKind, access text.AccessModifier) *text.Class {
+		class := text.NewEmptyClass(name, "", "")
+		class.Kind, class.Access = kind, access
+		return class
+	}
+
+	data := []struct {
+		class  *text.Class
+		expect string
+	}{
+		{nested("Outer$Inner", text.InnerClass, 0), "Outer$Inner Outer Inner"},
+		{nested("Outer$Inner", text.InnerClass, text.Private), "Outer$Inner Outer Inner private"},
+		{nested("Outer$Nested", text.NestedClass, text.Public), "Outer$Nested Outer Nested public static"},
+		{nested("Outer$1Local", text.LocalClass, 0), "Outer$1Local [0] Local"},
+		{nested("Outer$1", text.AnonymousClass, 0), "Outer$1 [0] [0]"},
+	}
+
+	for _, d := range data {
+		if result := innerClassEntry(d.class); result != d.expect {
+			t.Errorf("Entry of %s expecting %#v but got %#v", d.class.Name, d.expect, result)
+		}
+	}
+}
+
+func TestKrakatauGen_initializeNested(t *testing.T) {
+	outer := NewType("Outer", Class)
+	local := NewType("Outer$1Counter", Class)
+	local.outer, local.isInner = outer, true
+	local.capture(&FieldSymbol{mockInt, "step"})
+	local.capture(&FieldSymbol{mockString, "name"})
+
+	mockKrakatau(func(gen *KrakatauGen) {
+		gen.currentType = local
+		gen.initializeNested(2)
+		assertHasSameCodes(t, gen,
+			"aload_0",
+			"aload_1",
+			"putfield Field Outer$1Counter this$0 LOuter;",
+			"aload_0",
+			"iload_2",
+			"putfield Field Outer$1Counter val$step I",
+			"aload_0",
+			"aload_3",
+			"putfield Field Outer$1Counter val$name Ljava/lang/String;",
+		)
+	})
+}
+
+func TestKrakatauGen_createLambda(t *testing.T) {
+	outer := NewType("Main", Class)
+	op := NewType("Op", Interface)
+	lambda := NewType("Main$$Lambda$1", Class)
+	lambda.implements = op
+	lambda.outer, lambda.isInner = outer, true
+
+	mockKrakatau(func(gen *KrakatauGen) {
+		gen.currentType = outer
+		gen.createLambda(lambda)
+		assertHasSameCodes(t, gen,
+			"new Main$$Lambda$1",
+			"dup",
+			"aload_0",
+			"invokespecial Method Main$$Lambda$1 <init> (LMain;)V",
+		)
+
+		if top := gen.typeStack[len(gen.typeStack)-1]; top.dataType != op {
+			t.Errorf("Expecting the lambda to be typed as Op but got %s", top)
+		}
+	})
+}
+
+// generateText compile content through every pass into the krakatau
+// assembly, every line is trimmed
+func generateText(t *testing.T, content string) []string {
+	lexer := text.NewLexer(text.NewStringScanner(content))
+	parser := text.NewParser(&lexer)
+	program := parser.Compile()
+
+	typeAnal := NewTypeAnalyzer()
+	program.Accept(typeAnal)
+	nameAnal := NewNameAnalyzer(typeAnal.GetTypeTable())
+	program.Accept(nameAnal)
+	if errors := append(typeAnal.Errors(), nameAnal.Errors()...); len(errors) != 0 {
+		t.Fatalf("Should not have any error, but got: %v", errors)
+	}
+
+	gen := NewKrakatauGen(typeAnal.GetTypeTable(), nameAnal.Tables)
+	program.Accept(gen)
+	lines := strings.Split(gen.GenerateCode(), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(line)
+	}
+	return lines
+}
+
+// assertHasCodesInOrder check if expect is found in codes in the same order
+func assertHasCodesInOrder(t *testing.T, codes []string, expect ...string) {
+	i := 0
+	for _, code := range codes {
+		if i < len(expect) && code == expect[i] {
+			i++
+		}
+	}
+
+	if i < len(expect) {
+		t.Errorf("Expecting %q after %q in:\n%s", expect[i], expect[:i], strings.Join(codes, "\n"))
+	}
+}
+
+func TestKrakatauGen_ReferenceCast(t *testing.T) {
+	codes := generateText(t, `
+class Shape {}
+class Square extends Shape {}
+class Main {
+	public void run(Object o, Shape shape, Shape[] shapes) {
+		String s = (String) o;
+		Square square = (Square) shape;
+		Square[] squares = (Square[]) shapes;
+		Object back = (Object) square;
+		int n = (int) 2.5;
+	}
+}`)
+
+	assertHasCodesInOrder(t, codes,
+		"aload_1",
+		"checkcast java/lang/String",
+		"astore 4",
+		"aload_2",
+		"checkcast Square",
+		"astore 5",
+		"aload_3",
+		"checkcast [LSquare;",
+		"astore 6",
+		// the widening of a reference is not checked
+		"aload 5",
+		"astore 7",
+		"ldc2_w 2.5",
+		"d2i",
+		"istore 8",
+	)
+}
+
+func TestKrakatauGen_ReferenceEquality(t *testing.T) {
+	codes := generateText(t, `
+class Shape {}
+class Main {
+	public void run(Object o, Shape shape) {
+		boolean a = o == shape;
+		boolean b = shape != null;
+		boolean c = null == o;
+	}
+}`)
+
+	assertHasCodesInOrder(t, codes,
+		"aload_1",
+		"aload_2",
+		"if_acmpeq L0",
+		"istore_3",
+		// comparing with null does not load the null
+		"aload_2",
+		"ifnonnull L2",
+		"istore 4",
+		"aconst_null",
+		"aload_1",
+		"if_acmpeq L4",
+		"istore 5",
+	)
+}
+
+func TestKrakatauGen_ForEach(t *testing.T) {
+	codes := generateText(t, `
+class Main {
+	public int sum(int[] xs, char[] cs) {
+		int total = 0;
+		for (var x : xs) {
+			if (x == 0) continue;
+			total += x;
+		}
+		for (long c : cs) total += 1;
+		return total;
+	}
+}`)
+
+	assertHasCodesInOrder(t, codes,
+		"iconst_0",
+		"istore_3",
+		// the array and the index are kept before the variable
+		"aload_1",
+		"astore 4",
+		"iconst_0",
+		"istore 5",
+		"L0:",
+		"iload 5",
+		"aload 4",
+		"arraylength",
+		"if_icmpge L2",
+		"aload 4",
+		"iload 5",
+		"iaload",
+		"istore 6",
+		"goto L1",
+		"L1:",
+		"iinc 5 1",
+		"goto L0",
+		"L2:",
+		"aload_2",
+		"astore 7",
+		"caload",
+		"i2l",
+		"lstore 9",
+		"iinc 8 1",
+	)
+}
+
+func TestKrakatauGen_invokeReference(t *testing.T) {
+	builder := library["StringBuilder"]
+
+	data := []struct {
+		ref    *methodReference
+		expect string
+	}{
+		{
+			&methodReference{unboundReference, PrimitiveString, PrimitiveString.Methods["toUpperCase()"], nil},
+			"invokevirtual Method java/lang/String toUpperCase ()Ljava/lang/String;",
+		},
+		{
+			&methodReference{staticReference, PrimitiveString, PrimitiveString.Methods["valueOf(int)"], nil},
+			"invokestatic Method java/lang/String valueOf (I)Ljava/lang/String;",
+		},
+		{
+			&methodReference{constructorReference, builder, builder.constructors[0], nil},
+			"invokespecial Method java/lang/StringBuilder <init> ()V",
+		},
+	}
+
+	for _, d := range data {
+		if d.ref.method == nil {
+			t.Fatalf("Expecting the method of %s to exist", d.expect)
+		}
+
+		mockKrakatau(func(gen *KrakatauGen) {
+			gen.invokeReference(d.ref)
+			assertHasSameCodes(t, gen, d.expect)
+		})
+	}
+}
+
+func TestKrakatauGen_returnValue(t *testing.T) {
+	void := DataType{NewType("void", Primitive), false}
+	data := []struct {
+		value      DataType
+		returnType DataType
+		expect     []string
+	}{
+		{mockInt, mockInt, []string{"ireturn"}},
+		{mockInt, mockLong, []string{"i2l", "lreturn"}},
+		{mockString, void, []string{"pop", "return"}},
+		{mockDouble, void, []string{"pop2", "return"}},
+		{void, void, []string{"return"}},
+	}
+
+	for _, d := range data {
+		mockKrakatau(func(gen *KrakatauGen) {
+			gen.returnValue(d.value, d.returnType)
+			assertHasSameCodes(t, gen, d.expect...)
+		})
+	}
+}
+
+func mockCounterClass() *text.Class {
+	class := text.NewEmptyClass("Counter", "", "")
+	class.AddDeclaration(&text.PropertyDeclaration{
+		AccessModifier: text.Public | text.Static,
+		VariableDeclaration: text.VariableDeclaration{
+			Type:  text.NamedType{Name: "int", IsArray: false},
+			Name:  "count",
+			Value: text.Num(10),
+		},
+	})
+	class.AddDeclaration(&text.Initializer{IsStatic: true, Body: text.StatementList{
+		&text.AssignmentStatement{
+			Operator: text.Token{Type: text.AdditionAssignment},
+			Left:     &text.FieldAccess{Name: "count"},
+			Right:    text.Num(1),
+		},
+	}})
+	class.AddDeclaration(&text.PropertyDeclaration{
+		AccessModifier: text.Public,
+		VariableDeclaration: text.VariableDeclaration{
+			Type: text.NamedType{Name: "int", IsArray: false},
+			Name: "id",
+		},
+	})
+	return class
+}
+
+func TestKrakatauGen_PropertyAccess(t *testing.T) {
+	var eq, add text.Token
+	eq.Type = text.Assignment
+	add.Type = text.AdditionAssignment
+
+	data := []struct {
+		node   text.INode
+		expect []string
+	}{
+		{
+			&text.FieldAccess{Name: "count"},
+			[]string{"getstatic Field Counter count I"},
+		},
+		{
+			&text.FieldAccess{Name: "id"},
+			[]string{"aload_0", "getfield Field Counter id I"},
+		},
+		{
+			&text.FieldAccess{Name: "Counter", Child: &text.FieldAccess{Name: "count"}},
+			[]string{"getstatic Field Counter count I"},
+		},
+		{
+			&text.AssignmentStatement{Operator: eq, Left: &text.FieldAccess{Name: "id"}, Right: text.Num(1)},
+			[]string{"aload_0", "iconst_1", "putfield Field Counter id I"},
+		},
+		{
+			&text.AssignmentStatement{Operator: add, Left: &text.FieldAccess{Name: "count"}, Right: text.Num(2)},
+			[]string{
+				"getstatic Field Counter count I",
+				"iconst_2",
+				"iadd",
+				"putstatic Field Counter count I",
+			},
+		},
+		{
+			&text.AssignmentStatement{Operator: add, Left: &text.FieldAccess{Name: "id"}, Right: text.Num(2)},
+			[]string{
+				"aload_0",
+				"dup",
+				"getfield Field Counter id I",
+				"iconst_2",
+				"iadd",
+				"putfield Field Counter id I",
+			},
+		},
+	}
+
+	class := mockCounterClass()
+	for _, d := range data {
+		mockKrakatau(func(gen *KrakatauGen) {
+			gen.typeTable = getMockTypeTable(class)
+			gen.currentType = gen.typeTable.Lookup("Counter")
+			table := NewSymbolTable("mock", 0, nil)
+			table.Insert(&FieldSymbol{DataType{gen.currentType, false}, "this"}, 0)
+			for _, prop := range gen.currentType.Properties {
+				table.Insert(prop, 0)
+			}
+			gen.scopeIndex = 0
+			gen.symbolTable = []*SymbolTable{&table}
+
+			d.node.Accept(gen)
+			assertHasSameCodes(t, gen, d.expect...)
+		})
+	}
+}
+
+func TestKrakatauGen_SuperAccess(t *testing.T) {
+	content := `
+class A {
+	public int x;
+	public String name;
+	public String describe() { return "A"; }
+}
+class B extends A {
+	public long x;
+}
+class C extends B {
+	public boolean x;
+	public String describe() { return "C"; }
+}`
+	lexer := text.NewLexer(text.NewStringScanner(content))
+	parser := text.NewParser(&lexer)
+	program := parser.Compile()
+
+	data := []struct {
+		node   text.INode
+		expect []string
+	}{
+		{
+			&text.FieldAccess{Name: "x"},
+			[]string{"aload_0", "getfield Field C x Z"},
+		},
+		{
+			// an inherited field is resolved by the JVM from the current class
+			&text.FieldAccess{Name: "name"},
+			[]string{"aload_0", "getfield Field C name Ljava/lang/String;"},
+		},
+		{
+			&text.Super{Child: &text.FieldAccess{Name: "x"}},
+			[]string{"aload_0", "getfield Field B x J"},
+		},
+		{
+			&text.Super{Child: &text.FieldAccess{Name: "name"}},
+			[]string{"aload_0", "getfield Field B name Ljava/lang/String;"},
+		},
+		{
+			&text.Super{Child: &text.MethodCall{Name: "describe"}},
+			[]string{"aload_0", "invokespecial Method B describe ()Ljava/lang/String;"},
+		},
+	}
+
+	for _, d := range data {
+		mockKrakatau(func(gen *KrakatauGen) {
+			gen.typeTable = getMockTypeTable(program...)
+			gen.currentType = gen.typeTable.Lookup("C")
+			table := NewSymbolTable("mock", 0, nil)
+			table.Insert(&FieldSymbol{DataType{gen.currentType, false}, "this"}, 0)
+			for _, prop := range gen.currentType.Properties {
+				table.Insert(prop, 0)
+			}
+			for _, prop := range gen.currentType.inheritedProperties() {
+				table.Insert(prop, 0)
+			}
+			gen.scopeIndex = 0
+			gen.symbolTable = []*SymbolTable{&table}
+
+			d.node.Accept(gen)
+			assertHasSameCodes(t, gen, d.expect...)
+		})
+	}
+}
+
+func TestKrakatauGen_makeStaticInitializer(t *testing.T) {
+	class := mockCounterClass()
+	mockKrakatau(func(gen *KrakatauGen) {
+		gen.typeTable = getMockTypeTable(class)
+		gen.currentType = gen.typeTable.Lookup("Counter")
+		classTable := NewSymbolTable("class-Counter", 0, nil)
+		for _, prop := range gen.currentType.Properties {
+			classTable.Insert(prop, 0)
+		}
+		initTable := NewSymbolTable("static-initializer", 1, &classTable)
+		gen.symbolTable = []*SymbolTable{&classTable, &initTable}
+		gen.initializers[class.InitializerBlocks()[0]] = 1
+
+		gen.makeStaticInitializer(class)
+		assertHasSameCodes(t, gen,
+			".method static <clinit> : ()V",
+			".code stack 2 locals 0",
+			"bipush 10",
+			"putstatic Field Counter count I",
+			"getstatic Field Counter count I",
+			"iconst_1",
+			"iadd",
+			"putstatic Field Counter count I",
+			"return",
+			".end code",
+			".end method",
+		)
+	})
+}
+
+func TestKrakatauGen_ConstructorCall(t *testing.T) {
+	class := text.NewEmptyClass("Point", "", "")
+	class.AddDeclaration(text.NewConstructor(text.Public, "Point",
+		[]text.Parameter{{Type: text.NamedType{Name: "int", IsArray: false}, Name: "x"}},
+		text.StatementList{},
+	))
+
+	mockKrakatau(func(gen *KrakatauGen) {
+		gen.typeTable = getMockTypeTable(class)
+		gen.currentType = gen.typeTable.Lookup("Point")
+		table := NewSymbolTable("mock", 0, nil)
+		gen.symbolTable = []*SymbolTable{&table}
+
+		call := &text.ConstructorCallStatement{Args: []text.Expression{text.Char('a')}}
+		call.Accept(gen)
+		assertHasSameCodes(t, gen,
+			"aload_0",
+			"bipush 97",
+			"invokespecial Method Point <init> (I)V",
+		)
+	})
+}
+
+func TestKrakatauGen_packVarargs(t *testing.T) {
+	intArray, stringArray := DataType{mockInt.dataType, true}, DataType{mockString.dataType, true}
+	data := []struct {
+		args   []DataType
+		count  int
+		expect []string
+	}{
+		{
+			[]DataType{intArray},
+			0,
+			[]string{"iconst_0", "newarray int"},
+		},
+		{
+			[]DataType{mockString, intArray},
+			3,
+			[]string{
+				"istore 4",
+				"istore_3",
+				"iconst_2",
+				"newarray int",
+				"dup",
+				"iconst_0",
+				"iload_3",
+				"iastore",
+				"dup",
+				"iconst_1",
+				"iload 4",
+				"iastore",
+			},
+		},
+		{
+			[]DataType{stringArray},
+			1,
+			[]string{
+				"astore_3",
+				"iconst_1",
+				"anewarray java/lang/String",
+				"dup",
+				"iconst_0",
+				"aload_3",
+				"aastore",
+			},
+		},
+	}
+
+	for _, d := range data {
+		method := &MethodSymbol{name: "mock", args: d.args, isVarargs: true}
+		mockKrakatau(func(gen *KrakatauGen) {
+			gen.localCount = 3
+			gen.packVarargs(method.expand(d.count))
+			assertHasSameCodes(t, gen, d.expect...)
+		})
+	}
+
+	mockKrakatau(func(gen *KrakatauGen) {
+		gen.packVarargs(&MethodSymbol{name: "mock", args: []DataType{intArray}, isVarargs: true})
+		assertHasSameCodes(t, gen)
+	})
+}
+
+func TestKrakatauGen_SwitchExpression(t *testing.T) {
+	yield := func(value text.Expression) text.StatementList {
+		return text.StatementList{&text.JumpStatement{Type: text.YieldJump, Exp: value}}
+	}
+
+	enum := mockEnum(&text.EnumConstant{Name: "RED"}, &text.EnumConstant{Name: "GREEN"})
+	typeTable := getMockTypeTable(enum)
+	color := DataType{typeTable["Color"], false}
+
+	data := []struct {
+		local  Local
+		exp    text.SwitchExpression
+		expect []string
+	}{
+		{
+			Local{&FieldSymbol{mockInt, "i"}, 1},
+			text.SwitchExpression{
+				SwitchStatement: text.SwitchStatement{
+					ValueToCompare: &text.FieldAccess{Name: "i"},
+					CaseList: []*text.CaseStatement{
+						{Values: []text.Expression{text.Num(1)}, StatementList: yield(text.Num(1))},
+					},
+					DefaultCase: []text.Statement{&text.JumpStatement{Type: text.YieldJump, Exp: text.Long(2)}},
+				},
+				Type: text.NamedType{Name: "long"},
+			},
+			[]string{
+				"iload_1",
+				"lookupswitch",
+				"\t1 : L2",
+				"\tdefault : L1",
+				"L2:\t",
+				"iconst_1",
+				"i2l",
+				"goto L0",
+				"L1:\t",
+				"ldc2_w 2L",
+				"goto L0",
+				"L0:\t",
+			},
+		},
+		{
+			Local{&FieldSymbol{color, "c"}, 1},
+			text.SwitchExpression{
+				SwitchStatement: text.SwitchStatement{
+					ValueToCompare: &text.FieldAccess{Name: "c"},
+					CaseList: []*text.CaseStatement{
+						{Values: []text.Expression{&text.FieldAccess{Name: "RED"}}, StatementList: yield(text.Num(1))},
+						{Values: []text.Expression{&text.FieldAccess{Name: "GREEN"}}, StatementList: yield(text.Num(2))},
+					},
+					IsArrow: true,
+				},
+				Type: text.NamedType{Name: "int"},
+			},
+			[]string{
+				"aload_1",
+				"invokevirtual Method Color ordinal ()I",
+				"lookupswitch",
+				"\t0 : L2",
+				"\t1 : L3",
+				"\tdefault : L1",
+				"L2:\t",
+				"iconst_1",
+				"goto L0",
+				"L3:\t",
+				"iconst_2",
+				"goto L0",
+				"L1:\t",
+				"new java/lang/IncompatibleClassChangeError",
+				"dup",
+				"invokespecial Method java/lang/IncompatibleClassChangeError <init> ()V",
+				"athrow",
+				"L0:\t",
+			},
+		},
+	}
+
+	for _, d := range data {
+		mockKrakatau(func(gen *KrakatauGen) {
+			gen.typeTable = typeTable
+			table := NewSymbolTable("mock", 0, nil)
+			table.Insert(d.local.Member, d.local.address)
+			gen.scopeIndex = 0
+			gen.symbolTable = []*SymbolTable{&table}
+
+			d.exp.Accept(gen)
+			assertHasSameCodes(t, gen, d.expect...)
+			valueType, _ := gen.typeTable.resolve(d.exp.Type, nil)
+			if top, _ := gen.typeStack.Pop(); top != valueType {
+				t.Errorf("Expecting the switch to be typed as %s but got %s", valueType, top)
+			}
+		})
+	}
+}
+
+func TestKrakatauGen_makeRecordMethods(t *testing.T) {
+	class := text.NewEmptyClass("Item", "", "")
+	class.Components = []text.Parameter{
+		{Type: newNamedType("long", false), Name: "id"},
+		{Type: newNamedType("String", false), Name: "name"},
+	}
+	class.AddDeclaration(text.NewMethodDeclaration(text.Public, newNamedType("String", false), "toString", nil, nil))
+
+	classType := NewType("Item", Class)
+	classType.Properties["id"] = &PropertySymbol{text.Private | text.Final, FieldSymbol{mockLong, "id"}, nil}
+	classType.Properties["name"] = &PropertySymbol{text.Private | text.Final, FieldSymbol{mockString, "name"}, nil}
+
+	mockKrakatau(func(gen *KrakatauGen) {
+		gen.makeRecordMethods(class, classType)
+		assertHasSameCodes(t, gen,
+			".method public equals : (Ljava/lang/Object;)Z",
+			".code stack 4 locals 3",
+			"aload_0",
+			"aload_1",
+			"if_acmpne L0",
+			"iconst_1",
+			"ireturn",
+			"L0:\taload_1",
+			"instanceof Item",
+			"ifne L1",
+			"iconst_0",
+			"ireturn",
+			"L1:\taload_1",
+			"checkcast Item",
+			"astore_2",
+			"aload_0",
+			"getfield Field Item id J",
+			"aload_2",
+			"getfield Field Item id J",
+			"lcmp",
+			"ifne L2",
+			"aload_0",
+			"getfield Field Item name Ljava/lang/String;",
+			"aload_2",
+			"getfield Field Item name Ljava/lang/String;",
+			"invokestatic Method java/util/Objects equals (Ljava/lang/Object;Ljava/lang/Object;)Z",
+			"ifeq L2",
+			"iconst_1",
+			"ireturn",
+			"L2:\ticonst_0",
+			"ireturn",
+			".end code",
+			".end method",
+			".method public hashCode : ()I",
+			".code stack 3 locals 1",
+			"iconst_0",
+			"bipush 31",
+			"imul",
+			"aload_0",
+			"getfield Field Item id J",
+			"invokestatic Method java/lang/Long hashCode (J)I",
+			"iadd",
+			"bipush 31",
+			"imul",
+			"aload_0",
+			"getfield Field Item name Ljava/lang/String;",
+			"invokestatic Method java/util/Objects hashCode (Ljava/lang/Object;)I",
+			"iadd",
+			"ireturn",
+			".end code",
+			".end method",
+		)
+	})
+}
+
+func TestKrakatauGen_makeRecordToString(t *testing.T) {
+	fields := []*PropertySymbol{
+		{text.Private | text.Final, FieldSymbol{DataType{PrimitiveShort, false}, "size"}, nil},
+		{text.Private | text.Final, FieldSymbol{DataType{mockDouble.dataType, true}, "values"}, nil},
+	}
+
+	mockKrakatau(func(gen *KrakatauGen) {
+		gen.makeRecordToString("Outer$Stat", "Stat", fields)
+		assertHasSameCodes(t, gen,
+			".method public toString : ()Ljava/lang/String;",
+			".code stack 3 locals 1",
+			"new java/lang/StringBuilder",
+			"dup",
+			`ldc "Stat["`,
+			"invokespecial Method java/lang/StringBuilder <init> (Ljava/lang/String;)V",
+			`ldc "size="`,
+			"invokevirtual Method java/lang/StringBuilder append (Ljava/lang/String;)Ljava/lang/StringBuilder;",
+			"aload_0",
+			"getfield Field Outer$Stat size S",
+			"invokevirtual Method java/lang/StringBuilder append (I)Ljava/lang/StringBuilder;",
+			`ldc ", values="`,
+			"invokevirtual Method java/lang/StringBuilder append (Ljava/lang/String;)Ljava/lang/StringBuilder;",
+			"aload_0",
+			"getfield Field Outer$Stat values [D",
+			"invokevirtual Method java/lang/StringBuilder append (Ljava/lang/Object;)Ljava/lang/StringBuilder;",
+			`ldc "]"`,
+			"invokevirtual Method java/lang/StringBuilder append (Ljava/lang/String;)Ljava/lang/StringBuilder;",
+			"invokevirtual Method java/lang/StringBuilder toString ()Ljava/lang/String;",
+			"areturn",
+			".end code",
+			".end method",
+		)
+	})
+}
+
+func TestKrakatauGen_AssertStatement(t *testing.T) {
+	var less text.Token
+	less.Type = text.LessThan
+
+	lessThan := text.NewBinOp(less, &text.FieldAccess{Name: "i"}, text.Num(10))
+	condition := &lessThan
+	data := []struct {
+		stmt   text.AssertStatement
+		strip  bool
+		expect []string
+	}{
+		{
+			text.AssertStatement{Condition: text.Boolean(false)},
+			false,
+			[]string{
+				"getstatic Field Main $assertionsDisabled Z",
+				"ifne L0",
+				"iconst_0",
+				"ifne L0",
+				"new java/lang/AssertionError",
+				"dup",
+				"invokespecial Method java/lang/AssertionError <init> ()V",
+				"athrow",
+				"L0:\t",
+			},
+		},
+		{
+			text.AssertStatement{Condition: condition, Message: &text.FieldAccess{Name: "i"}},
+			false,
+			[]string{
+				"getstatic Field Main $assertionsDisabled Z",
+				"ifne L0",
+				"iload_1",
+				"bipush 10",
+				"if_icmplt L1",
+				"iconst_0",
+				"goto L2",
+				"L1:\ticonst_1",
+				"L2:\t",
+				"ifne L0",
+				"new java/lang/AssertionError",
+				"dup",
+				"iload_1",
+				"invokespecial Method java/lang/AssertionError <init> (I)V",
+				"athrow",
+				"L0:\t",
+			},
+		},
+		{
+			text.AssertStatement{Condition: text.Boolean(true), Message: text.String("never")},
+			false,
+			[]string{
+				"getstatic Field Main $assertionsDisabled Z",
+				"ifne L0",
+				"iconst_1",
+				"ifne L0",
+				"new java/lang/AssertionError",
+				"dup",
+				`ldc "never"`,
+				"invokespecial Method java/lang/AssertionError <init> (Ljava/lang/Object;)V",
+				"athrow",
+				"L0:\t",
+			},
+		},
+		{
+			text.AssertStatement{Condition: condition, Message: text.String("stripped")},
+			true,
+			[]string{},
+		},
+	}
+
+	for _, d := range data {
+		mockKrakatau(func(gen *KrakatauGen) {
+			table := NewSymbolTable("mock", 0, nil)
+			table.Insert(&FieldSymbol{mockInt, "i"}, 1)
+			gen.scopeIndex = 0
+			gen.symbolTable = []*SymbolTable{&table}
+			gen.currentClass = text.NewEmptyClass("Main", "", "")
+			if d.strip {
+				gen.StripAssertions()
+			}
+
+			d.stmt.Accept(gen)
+			assertHasSameCodes(t, gen, d.expect...)
+			if gen.hasAssertions == d.strip {
+				t.Errorf("Expecting the class to have assertions: %t", !d.strip)
+			}
+
+			if len(gen.typeStack) != 0 {
+				t.Errorf("Expecting an empty type stack but got %v", gen.typeStack)
+			}
+		})
+	}
+}
+
+func TestKrakatauGen_initializeAssertions(t *testing.T) {
+	class := text.NewEmptyClass("Outer$Inner", "", "")
+	mockKrakatau(func(gen *KrakatauGen) {
+		gen.typeTable = getMockTypeTable(class)
+		gen.hasAssertions = true
+		gen.makeStaticInitializer(class)
+		assertHasSameCodes(t, gen,
+			".field static final synthetic $assertionsDisabled Z",
+			".method static <clinit> : ()V",
+			".code stack 1 locals 0",
+			"ldc Class Outer",
+			"invokevirtual Method java/lang/Class desiredAssertionStatus ()Z",
+			"ifne L0",
+			"iconst_1",
+			"goto L1",
+			"L0:\ticonst_0",
+			"L1:\tputstatic Field Outer$Inner $assertionsDisabled Z",
+			"return",
+			".end code",
+			".end method",
+		)
+	})
+
+	mockKrakatau(func(gen *KrakatauGen) {
+		gen.typeTable = getMockTypeTable(class)
+		gen.makeStaticInitializer(class)
+		assertHasSameCodes(t, gen)
+	})
+}
@@ -0,0 +1,132 @@
+package lang
+
+import (
+	"strings"
+
+	"github.com/gumelarme/yava/pkg/text"
+)
+
+var (
+	msgLambdaNotExpected       = "Lambda expression is not expected here."
+	msgNotFunctionalInterface  = "Type %s is not a functional interface."
+	msgLambdaParameterCount    = "Lambda expression expects %d parameters but got %d."
+	msgMethodReferenceNotFound = "Method reference %s::%s is not applicable to (%s)."
+)
+
+// referenceKind tell how the method of a method reference is called
+type referenceKind int
+
+const (
+	staticReference      referenceKind = iota // Type::staticMethod
+	boundReference                            // this::method or variable::method
+	unboundReference                          // Type::method, called on the first parameter
+	constructorReference                      // Type::new
+)
+
+// methodReference is the method called by the class of a method reference,
+// the receiver is the captured variable, nil when the receiver is this.
+type methodReference struct {
+	kind     referenceKind
+	owner    *TypeSymbol
+	method   *MethodSymbol
+	receiver *FieldSymbol
+}
+
+// isLambda check if ex is typed by the functional interface it is assigned into
+func isLambda(ex text.Expression) bool {
+	switch ex.(type) {
+	case *text.Lambda, *text.MethodReference:
+		return true
+	default:
+		return false
+	}
+}
+
+// isStatementExpression check if ex can be used as a statement,
+// which is the only expression allowed as the body of a void lambda.
+func isStatementExpression(ex text.Expression) bool {
+	switch val := ex.(type) {
+	case *text.ObjectCreation:
+		return true
+	case text.NamedValue:
+		return text.IdEndsAs(val) == "MethodCall"
+	default:
+		return false
+	}
+}
+
+// functionalMethod get the only method of a functional interface, with
+// the type arguments of d substituted. nil if d is not one.
+func (d DataType) functionalMethod() *MethodSymbol {
+	if d.dataType == nil || d.isArray {
+		return nil
+	}
+
+	base := d.dataType.base()
+	if base.TypeCategory != Interface || len(base.Methods) != 1 {
+		return nil
+	}
+
+	for _, method := range base.Methods {
+		if len(method.typeParams) > 0 {
+			return nil
+		}
+		return d.dataType.substituteMethod(method)
+	}
+	return nil
+}
+
+// lambdaTargets get the target type of the lambdas in args from methods
+// that accept as many arguments, since a lambda is typed by its target
+// every method must have the same type for it.
+func lambdaTargets(methods []*MethodSymbol, args []text.Expression) map[text.Expression]DataType {
+	targets := make(map[text.Expression]DataType)
+	for i, arg := range args {
+		if !isLambda(arg) {
+			continue
+		}
+
+		var target DataType
+		isAmbiguous := false
+		for _, method := range methods {
+			if len(method.args) != len(args) {
+				continue
+			}
+
+			param := method.args[i]
+			isAmbiguous = isAmbiguous || (target.dataType != nil && !isIdentity(target, param))
+			target = param
+		}
+
+		if target.dataType != nil && !isAmbiguous {
+			targets[arg] = target
+		}
+	}
+	return targets
+}
+
+// isReturnable check if a value of typeof can be returned as returnType
+func isReturnable(typeof, returnType DataType) bool {
+	return isConvertible(typeof, returnType, assignmentContext)
+}
+
+// parameterAddress get the local address of every parameter of method,
+// the first one is right after `this`.
+func parameterAddress(method *MethodSymbol) []int {
+	address := make([]int, len(method.args))
+	next := 1
+	for i, arg := range method.args {
+		address[i] = next
+		next += arg.slotSize()
+	}
+	return address
+}
+
+// typesString join types to be shown in a message, e.g. int, String
+func typesString(types []DataType) string {
+	str := make([]string, len(types))
+	for i, t := range types {
+		str[i] = t.String()
+	}
+	return strings.Join(str, ", ")
+}
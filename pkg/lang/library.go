@@ -0,0 +1,313 @@
+package lang
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gumelarme/yava/pkg/text"
+)
+
+// libraryClass is a class of the JDK that can be used by a yava program, its
+// name is the internal name unless it is in java.lang, e.g. java/io/PrintStream.
+// Its members are written as they are declared in Java without the parameter
+// names, e.g. "static int max(int, int)". A member without a return type
+// is a constructor, one without parentheses is a field, and "extends Reader"
+// declares its superclass.
+type libraryClass struct {
+	name    string
+	isFinal bool
+	members []string
+}
+
+// libraryCatalogue is the curated subset of the JDK known to the compiler,
+// the descriptors are derived from the types of the members.
+var libraryCatalogue = []libraryClass{
+	{"String", true, []string{
+		"int length()",
+		"char charAt(int)",
+		"boolean isEmpty()",
+		"String substring(int)",
+		"String substring(int, int)",
+		"int indexOf(int)",
+		"int indexOf(String)",
+		"int lastIndexOf(int)",
+		"int lastIndexOf(String)",
+		"boolean startsWith(String)",
+		"boolean endsWith(String)",
+		"boolean equalsIgnoreCase(String)",
+		"int compareTo(String)",
+		"String concat(String)",
+		"String replace(char, char)",
+		"String toUpperCase()",
+		"String toLowerCase()",
+		"String trim()",
+		"char[] toCharArray()",
+		"String[] split(String)",
+		"static String valueOf(int)",
+		"static String valueOf(long)",
+		"static String valueOf(char)",
+		"static String valueOf(boolean)",
+		"static String valueOf(double)",
+		"static String valueOf(Object)",
+	}},
+	{"StringBuilder", true, []string{
+		"StringBuilder()",
+		"StringBuilder(String)",
+		"StringBuilder append(String)",
+		"StringBuilder append(int)",
+		"StringBuilder append(long)",
+		"StringBuilder append(char)",
+		"StringBuilder append(boolean)",
+		"StringBuilder append(double)",
+		"StringBuilder append(Object)",
+		"StringBuilder insert(int, String)",
+		"StringBuilder reverse()",
+		"StringBuilder deleteCharAt(int)",
+		"void setCharAt(int, char)",
+		"char charAt(int)",
+		"int length()",
+	}},
+	{"Math", true, []string{
+		"static double PI",
+		"static double E",
+		"static int abs(int)",
+		"static long abs(long)",
+		"static double abs(double)",
+		"static int max(int, int)",
+		"static long max(long, long)",
+		"static double max(double, double)",
+		"static int min(int, int)",
+		"static long min(long, long)",
+		"static double min(double, double)",
+		"static double pow(double, double)",
+		"static double sqrt(double)",
+		"static double floor(double)",
+		"static double ceil(double)",
+		"static long round(double)",
+		"static double random()",
+	}},
+	{"Integer", true, []string{
+		"static int MAX_VALUE",
+		"static int MIN_VALUE",
+		"static int parseInt(String)",
+		"static int compare(int, int)",
+		"static String toString(int)",
+		"static String toBinaryString(int)",
+		"static String toHexString(int)",
+	}},
+	{"Long", true, []string{
+		"static long MAX_VALUE",
+		"static long MIN_VALUE",
+		"static long parseLong(String)",
+		"static String toString(long)",
+	}},
+	{"Double", true, []string{
+		"static double MAX_VALUE",
+		"static double MIN_VALUE",
+		"static double parseDouble(String)",
+		"static String toString(double)",
+	}},
+	{"System", true, []string{
+		"static InputStream in",
+		"static PrintStream out",
+		"static PrintStream err",
+		"static long currentTimeMillis()",
+		"static long nanoTime()",
+		"static void exit(int)",
+	}},
+	// there is no boxing, so printf only accept the arguments that are objects
+	{"java/io/PrintStream", false, []string{
+		"void println()",
+		"void println(boolean)",
+		"void println(char)",
+		"void println(int)",
+		"void println(long)",
+		"void println(float)",
+		"void println(double)",
+		"void println(char[])",
+		"void println(String)",
+		"void println(Object)",
+		"void print(boolean)",
+		"void print(char)",
+		"void print(int)",
+		"void print(long)",
+		"void print(float)",
+		"void print(double)",
+		"void print(char[])",
+		"void print(String)",
+		"void print(Object)",
+		"PrintStream printf(String, Object...)",
+		"void flush()",
+	}},
+	{"java/io/InputStream", false, []string{
+		"int read()",
+		"int available()",
+		"void close()",
+	}},
+	{"java/io/Reader", false, []string{
+		"int read()",
+		"boolean ready()",
+		"void close()",
+	}},
+	{"java/io/InputStreamReader", false, []string{
+		"extends Reader",
+		"InputStreamReader(InputStream)",
+	}},
+	{"java/io/BufferedReader", false, []string{
+		"extends Reader",
+		"BufferedReader(Reader)",
+		"String readLine()",
+	}},
+	{"java/util/Scanner", true, []string{
+		"Scanner(InputStream)",
+		"Scanner(String)",
+		"boolean hasNext()",
+		"boolean hasNextInt()",
+		"boolean hasNextLong()",
+		"boolean hasNextDouble()",
+		"boolean hasNextLine()",
+		"String next()",
+		"int nextInt()",
+		"long nextLong()",
+		"double nextDouble()",
+		"boolean nextBoolean()",
+		"String nextLine()",
+		"void close()",
+	}},
+	{"Character", true, []string{
+		"static boolean isDigit(char)",
+		"static boolean isLetter(char)",
+		"static boolean isLetterOrDigit(char)",
+		"static boolean isWhitespace(char)",
+		"static boolean isUpperCase(char)",
+		"static boolean isLowerCase(char)",
+		"static char toUpperCase(char)",
+		"static char toLowerCase(char)",
+		"static int getNumericValue(char)",
+	}},
+}
+
+// library is the types declared in libraryCatalogue by their simple name
+var library = declareLibrary(libraryCatalogue)
+
+// declareLibrary create the type of every class in catalogue, String is
+// the existing PrimitiveString. The types are created before any member,
+// so a member can refer to any class of the catalogue.
+func declareLibrary(catalogue []libraryClass) TypeTable {
+	types := TypeTable{
+		"int":     PrimitiveInt,
+		"boolean": PrimitiveBoolean,
+		"char":    PrimitiveChar,
+		"byte":    PrimitiveByte,
+		"short":   PrimitiveShort,
+		"long":    PrimitiveLong,
+		"float":   PrimitiveFloat,
+		"double":  PrimitiveDouble,
+		"String":  PrimitiveString,
+		"Object":  javaLangObject,
+	}
+
+	declared := make(TypeTable)
+	for _, class := range catalogue {
+		typeof := PrimitiveString
+		if class.name != "String" {
+			typeof = NewType(class.internalName(), Class)
+		}
+		typeof.isFinal = class.isFinal
+		types[class.simpleName()], declared[class.simpleName()] = typeof, typeof
+	}
+
+	for _, class := range catalogue {
+		for _, member := range class.members {
+			declareLibraryMember(types, types[class.simpleName()], member)
+		}
+	}
+	return declared
+}
+
+func (l libraryClass) simpleName() string {
+	return l.name[strings.LastIndex(l.name, "/")+1:]
+}
+
+func (l libraryClass) internalName() string {
+	if strings.Contains(l.name, "/") {
+		return l.name
+	}
+	return "java/lang/" + l.name
+}
+
+// declareLibraryMember add member into typeof, the types it refer
+// are looked up in types. It panics if member is malformed, since
+// the catalogue is a part of the compiler.
+func declareLibraryMember(types TypeTable, typeof *TypeSymbol, member string) {
+	resolve := func(name string) DataType {
+		if name == "void" {
+			return DataType{NewType("void", Primitive), false}
+		}
+
+		dt := DataType{types[strings.TrimSuffix(name, "[]")], strings.HasSuffix(name, "[]")}
+		if dt.dataType == nil {
+			panic(fmt.Sprintf("Unknown type %s in library member `%s`.", name, member))
+		}
+		return dt
+	}
+
+	head, params := member, ""
+	if i := strings.Index(member, "("); i != -1 {
+		head, params = member[:i], strings.TrimSuffix(member[i+1:], ")")
+	}
+
+	access := text.Public
+	words := strings.Fields(head)
+	if words[0] == "extends" {
+		typeof.extends = resolve(words[1]).dataType
+		return
+	}
+
+	if words[0] == "static" {
+		access |= text.Static
+		words = words[1:]
+	}
+
+	if !strings.Contains(member, "(") {
+		typeof.Properties[words[1]] = &PropertySymbol{
+			access | text.Final,
+			FieldSymbol{resolve(words[0]), words[1]},
+			nil,
+		}
+		return
+	}
+
+	// the last parameter of variable arity is an array, so is in the key
+	isVarargs := strings.HasSuffix(params, "...")
+	if isVarargs {
+		params = strings.TrimSuffix(params, "...") + "[]"
+	}
+
+	var args []DataType
+	for _, param := range strings.Split(params, ",") {
+		if param = strings.TrimSpace(param); len(param) > 0 {
+			args = append(args, resolve(param))
+		}
+	}
+
+	name, returnType := words[len(words)-1], DataType{typeof, false}
+	if len(words) > 1 {
+		returnType = resolve(words[0])
+	}
+
+	method := &MethodSymbol{
+		DataType:  returnType,
+		accessMod: access,
+		name:      name,
+		args:      args,
+		isStatic:  access&text.Static != 0,
+		isVarargs: isVarargs,
+	}
+
+	if len(words) == 1 {
+		typeof.constructors = append(typeof.constructors, method)
+		return
+	}
+	typeof.Methods[fmt.Sprintf("%s(%s)", name, params)] = method
+}
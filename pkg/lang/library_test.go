@@ -0,0 +1,95 @@
+package lang
+
+import (
+	"testing"
+
+	"github.com/gumelarme/yava/pkg/text"
+)
+
+func Test_declareLibrary(t *testing.T) {
+	types := declareLibrary([]libraryClass{
+		{"Counter", false, []string{
+			"static int LIMIT",
+			"Counter()",
+			"Counter(int, Counter)",
+			"Counter next()",
+			"void set(int)",
+			"static int[] split(String, char)",
+		}},
+	})
+
+	counter := types["Counter"]
+	if counter == nil || counter.name != "java/lang/Counter" || counter.isFinal {
+		t.Fatalf("Expecting a non-final java/lang/Counter but got %v", counter)
+	}
+
+	limit := counter.Properties["LIMIT"]
+	if limit == nil || limit.AccessModifier != text.Public|text.Static|text.Final || limit.DataType != mockInt {
+		t.Errorf("Expecting a public static final int LIMIT but got %v", limit)
+	}
+
+	if len(counter.constructors) != 2 || counter.constructors[1].String() != "Counter(int, java/lang/Counter)" {
+		t.Errorf("Expecting 2 constructors but got %v", counter.constructors)
+	}
+
+	data := []struct {
+		key, descriptor string
+		isStatic        bool
+	}{
+		{"next()", "()Ljava/lang/Counter;", false},
+		{"set(int)", "(I)V", false},
+		{"split(String, char)", "(Ljava/lang/String;C)[I", true},
+	}
+
+	for _, d := range data {
+		method := counter.Methods[d.key]
+		if method == nil {
+			t.Errorf("Expecting method %s to be declared", d.key)
+			continue
+		}
+
+		if descriptor := methodDescriptor(method); descriptor != d.descriptor || method.isStatic != d.isStatic {
+			t.Errorf("Expecting %s to be %s static: %v, but got %s static: %v",
+				d.key, d.descriptor, d.isStatic, descriptor, method.isStatic)
+		}
+	}
+}
+
+func Test_declareLibrary_package(t *testing.T) {
+	types := declareLibrary([]libraryClass{
+		{"java/util/Tally", false, []string{
+			"static Tally of(String, int...)",
+		}},
+	})
+
+	tally := types["Tally"]
+	if tally == nil || tally.name != "java/util/Tally" {
+		t.Fatalf("Expecting java/util/Tally by its simple name but got %v", tally)
+	}
+
+	method := tally.Methods["of(String, int[])"]
+	if method == nil || !method.isVarargs || methodDescriptor(method) != "(Ljava/lang/String;[I)Ljava/util/Tally;" {
+		t.Errorf("Expecting a varargs of(String, int...) but got %v", method)
+	}
+}
+
+func Test_declareLibrary_extends(t *testing.T) {
+	types := declareLibrary([]libraryClass{
+		{"java/io/Base", false, []string{"int read()"}},
+		{"java/io/Derived", false, []string{"extends Base", "Derived(Base)"}},
+	})
+
+	derived := types["Derived"]
+	if derived.extends != types["Base"] || derived.LookupMethod("read()") == nil {
+		t.Errorf("Expecting Derived to extend Base and inherit read() but got %v", derived.extends)
+	}
+}
+
+func Test_declareLibrary_panic(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("An unknown type in the catalogue should panic")
+		}
+	}()
+	declareLibrary([]libraryClass{{"Broken", true, []string{"Missing get()"}}})
+}
@@ -18,7 +18,18 @@ var (
 	msgExpectingTypeof          = "Expecting a type of '%s' but got '%s' instead."
 	msgExpectingReturnTypeOf    = "Expecting a return type of '%s' but got '%s' instead."
 	msgVoidDontHaveType         = "The function return type is void, but got '%s'"
-	msgCantBeNull               = "Type of %s can be assigned with null value."
+	msgCannotCast               = "Cannot cast '%s' to '%s'."
+	msgIncomparableTypes        = "Incomparable types '%s' and '%s'."
+	msgConstructorNotFound      = "Constructor %s(%s) not found."
+	msgNonStaticField           = "Non-static field '%s' cannot be referenced from a static context."
+	msgNonStaticMethod          = "Non-static method '%s' cannot be referenced from a static context."
+	msgNotEnumConstant          = "'%s' is not a constant of enum %s."
+	msgCaseMustBeConstant       = "Case label must be a constant expression."
+	msgDuplicateCaseLabel       = "Duplicate case label %s."
+	msgReturnInInitializer      = "Cannot return from an initializer."
+	msgAssertVoidMessage        = "The message of an assertion cannot be void."
+	msgAmbiguousCall            = "Reference to %s is ambiguous, candidates are %s."
+	msgForEachNotApplicable     = "For-each loop can only iterate over an array, but got '%s'."
 )
 
 type TypeStack []DataType
@@ -53,9 +64,39 @@ type NameAnalyzer struct {
 	curField         TypeMember
 	stack            TypeStack
 	localCount       int
-	fieldBuffer      TypeMember
+	fieldBuffer      []TypeMember
 	isInterface      bool
-	isObjectCreation bool
+	pendingCreations []*text.ObjectCreation
+	typeVars         []*TypeSymbol
+	enclosing        []classContext
+	reassigned       map[*FieldSymbol]bool
+	captured         map[*FieldSymbol]bool
+	targets          map[text.Expression]DataType
+	finals           map[*FieldSymbol]bool
+	finality         finalState
+	assignment       *text.AssignmentStatement
+	initializer      *text.Initializer
+	switchValues     []*switchValue
+	// statementStack is the size of the stack before each method call
+	// statement being analyzed
+	statementStack []int
+	// importer import the types referred by a simple name in an expression
+	importer *TypeAnalyzer
+}
+
+// classContext is the state of the analyzer inside a class,
+// which is kept while its nested class is analyzed.
+type classContext struct {
+	stack            TypeStack
+	curField         TypeMember
+	fieldBuffer      []TypeMember
+	localCount       int
+	isInterface      bool
+	pendingCreations []*text.ObjectCreation
+	typeVars         []*TypeSymbol
+	finality         finalState
+	initializer      *text.Initializer
+	switchValues     []*switchValue
 }
 
 func NewNameAnalyzer(table map[string]*TypeSymbol) *NameAnalyzer {
@@ -72,7 +113,19 @@ func NewNameAnalyzer(table map[string]*TypeSymbol) *NameAnalyzer {
 		0,
 		nil,
 		false,
-		false,
+		nil,
+		nil,
+		make([]classContext, 0),
+		make(map[*FieldSymbol]bool),
+		make(map[*FieldSymbol]bool),
+		make(map[text.Expression]DataType),
+		make(map[*FieldSymbol]bool),
+		newFinalState(),
+		nil,
+		nil,
+		make([]*switchValue, 0),
+		make([]int, 0),
+		nil,
 	}
 }
 
@@ -92,11 +145,84 @@ func (n *NameAnalyzer) newScope(name string) {
 	n.Tables = append(n.Tables, &newScope)
 }
 
+// newClassScope create the scope of a class, its owner tell
+// when a lookup goes outside of the class.
+func (n *NameAnalyzer) newClassScope(name string, owner *TypeSymbol) {
+	n.newScope(name)
+	n.scope.owner = owner
+	n.Tables[len(n.Tables)-1].owner = owner
+}
+
+// enterNestedClass keep the state of the enclosing class,
+// so it can be continued after the nested class
+func (n *NameAnalyzer) enterNestedClass() {
+	n.enclosing = append(n.enclosing, classContext{
+		n.stack,
+		n.curField,
+		n.fieldBuffer,
+		n.localCount,
+		n.isInterface,
+		n.pendingCreations,
+		n.typeVars,
+		n.finality,
+		n.initializer,
+		n.switchValues,
+	})
+	n.stack = TypeStack{}
+	n.curField, n.fieldBuffer = nil, nil
+	n.isInterface, n.pendingCreations = false, nil
+	n.finality = newFinalState()
+	n.initializer = nil
+	n.switchValues = nil
+}
+
+func (n *NameAnalyzer) exitNestedClass() {
+	last := len(n.enclosing) - 1
+	context := n.enclosing[last]
+	n.enclosing = n.enclosing[:last]
+	n.stack = context.stack
+	n.curField = context.curField
+	n.fieldBuffer = context.fieldBuffer
+	n.localCount = context.localCount
+	n.isInterface = context.isInterface
+	n.pendingCreations = context.pendingCreations
+	n.typeVars = context.typeVars
+	n.finality = context.finality
+	n.initializer = context.initializer
+	n.switchValues = context.switchValues
+}
+
+// hasThis check if the current instance is available,
+// which is not inside main and static initializer
+func (n *NameAnalyzer) hasThis() bool {
+	this, _ := n.scope.Lookup("this", true)
+	return this != nil
+}
+
+// isInstanceProperty check if member is a property of an instance,
+// an enum constant is a static property even if it is not declared so
+func (n *NameAnalyzer) isInstanceProperty(member TypeMember) bool {
+	prop, ok := member.(*PropertySymbol)
+	return ok && prop.AccessModifier&text.Static == 0 && !isEnumConstant(prop.dataType, prop)
+}
+
+// hasEnclosingInstance check if an instance of typeof can be reached
+// from the current instance through the outer instances.
+func (n *NameAnalyzer) hasEnclosingInstance(typeof *TypeSymbol) bool {
+	this, _ := n.scope.Lookup("this", true)
+	if this == nil {
+		return false
+	}
+
+	_, ok := outerPath(this.Type().dataType, typeof)
+	return ok
+}
+
 func (n *NameAnalyzer) expectLastStackTypeOf(name string, array bool) bool {
 	lastStack, _ := n.stack.Pop()
 	sym := n.typeTable.Lookup(name)
 	expect := DataType{sym, array}
-	if lastStack != expect {
+	if lastStack != expect && !isErrorType(lastStack) {
 		n.AddErrorf(msgExpectingTypeof, expect, lastStack)
 		return false
 	}
@@ -109,33 +235,41 @@ func IsNullOk(dt DataType) bool {
 	}
 
 	switch dt.dataType.name {
-	case "int", "boolean", "char":
+	case "int", "boolean", "char", "byte", "short", "long", "float", "double":
 		return false
 	default:
 		return true
 	}
 }
 
-func (n *NameAnalyzer) getAccess() text.AccessModifier {
-	access := text.Public
-	if n.curField != nil && n.curField.Name() == "this" {
-		access |= text.Protected
-		access |= text.Private
+// resolveType get the data type of named, the type variables
+// of the current type and method are taken into account.
+func (n *NameAnalyzer) resolveType(named text.NamedType) (DataType, bool) {
+	dt, msg := n.typeTable.resolve(named, n.typeVars)
+	if len(msg) > 0 && n.importer != nil {
+		msg = n.importer.notImported(named, msg)
+	}
+
+	if len(msg) > 0 {
+		n.AddError(msg)
+		return dt, false
 	}
-	return access
+	return dt, true
 }
 
 func (n *NameAnalyzer) Insert(member TypeMember) {
 	n.scope.Insert(member, n.localCount)
-	n.localCount += 1
+	n.localCount += member.Type().slotSize()
 }
 
 func (n *NameAnalyzer) VisitProgram(text.Program) {}
+func (n *NameAnalyzer) VisitImport(*text.Import)  {}
 func (n *NameAnalyzer) VisitInterface(i *text.Interface) {
 	n.isScopeCreated = false
 	n.newScope(fmt.Sprintf("interface-%s", i.Name))
 	n.isInterface = true
 	interfaceType := n.typeTable[i.Name]
+	n.typeVars = interfaceType.typeParams
 	n.stack.Push(DataType{
 		interfaceType,
 		false,
@@ -144,20 +278,39 @@ func (n *NameAnalyzer) VisitInterface(i *text.Interface) {
 
 func (n *NameAnalyzer) VisitAfterInterface(*text.Interface) {
 	n.isInterface = false
+	n.typeVars = nil
 	n.popScope()
 	n.stack.Pop()
 }
 
 func (n *NameAnalyzer) VisitClass(class *text.Class) {
+	if class.Kind != text.TopLevelClass {
+		n.enterNestedClass()
+	}
+
 	n.isScopeCreated = false
 	name := fmt.Sprintf("class-%s", class.Name)
-	n.newScope(name)
 	classType := n.typeTable[class.Name]
+	n.newClassScope(name, classType)
+	n.typeVars = classType.typesInScope()
+	if classType.hasInnerSuper() {
+		if _, ok := classType.superOuterPath(); !ok {
+			n.AddErrorf(msgEnclosingInstanceRequired, classType.extends.base().outer.name)
+		}
+	}
+
 	for _, prop := range classType.Properties {
 		n.localCount = 0
 		n.Insert(prop)
 	}
 
+	// the inherited properties are also accessible by their simple name
+	for _, prop := range classType.inheritedProperties() {
+		n.localCount = 0
+		n.Insert(prop)
+	}
+
+	n.finality.enterClass(blankFinalsOf(class, classType))
 	for _, method := range classType.Methods {
 		n.localCount = 0
 		n.Insert(method)
@@ -169,11 +322,70 @@ func (n *NameAnalyzer) VisitClass(class *text.Class) {
 }
 
 // REVIEW: Shold we pop scope here
-func (n *NameAnalyzer) VisitAfterClass(*text.Class) {
+func (n *NameAnalyzer) VisitAfterClass(class *text.Class) {
+	// the default constructor only run the initializers
+	if len(class.Constructor) == 0 {
+		n.checkBlankFinals(n.finality.initialized, false)
+	}
+	n.checkBlankFinals(n.finality.staticInitialized, true)
+
+	n.typeVars = nil
 	n.popScope()
 	n.stack.Pop()
+
+	if class.Kind != text.TopLevelClass {
+		n.exitNestedClass()
+	}
+
+	// a local class can be used in the rest of the block
+	if class.Kind == text.LocalClass {
+		n.typeVars = append([]*TypeSymbol{n.typeTable[class.Name]}, n.typeVars...)
+	}
+}
+
+func (n *NameAnalyzer) VisitEnum(enum *text.Enum) {
+	n.VisitClass(&enum.Class)
+	for _, constant := range enum.Constants {
+		args := make([]DataType, len(constant.Args))
+		for _, arg := range constant.Args {
+			arg.Accept(n)
+		}
+
+		for i := range args {
+			typeof, _ := n.stack.Pop()
+			args[len(args)-i-1] = typeof
+		}
+
+		n.lookupConstructor(n.typeTable[enum.Name], enum.Name, args)
+	}
+}
+
+func (n *NameAnalyzer) VisitAfterEnum(enum *text.Enum) {
+	n.VisitAfterClass(&enum.Class)
+}
+
+// VisitPropertyDeclaration check the value of a constant property, a
+// static final property is not a constant if its value cannot be folded.
+func (n *NameAnalyzer) VisitPropertyDeclaration(prop *text.PropertyDeclaration) {
+	classType := n.stack[0].dataType
+	symbol := classType.Properties[prop.Name]
+	if symbol == nil || symbol.constant == nil {
+		return
+	}
+
+	value := n.typeTable.fold(prop.Value, n.typeVars, classType.lookupConstant)
+	if value == nil {
+		// the value is put by the static initializer instead
+		symbol.constant = nil
+		return
+	}
+
+	if !isConstantAssignable(n.typeTable, value, symbol.DataType) {
+		n.AddErrorf(msgExpectingTypeof, symbol.DataType, literalType(n.typeTable, value))
+		return
+	}
+	symbol.constant.valueOf(n.typeTable, symbol.DataType)
 }
-func (n *NameAnalyzer) VisitPropertyDeclaration(*text.PropertyDeclaration) {}
 
 func (n *NameAnalyzer) VisitMethodSignature(sign *text.MethodSignature) {
 	defer func() {
@@ -186,6 +398,7 @@ func (n *NameAnalyzer) VisitMethodSignature(sign *text.MethodSignature) {
 	n.localCount = 0 // reset
 	n.isScopeCreated = true
 	n.newScope(fmt.Sprintf("method-%s", sign.Signature()))
+	n.finality.enterMethod(nil)
 
 	classType, _ := n.stack.Pop()
 	n.Insert(&FieldSymbol{
@@ -194,6 +407,12 @@ func (n *NameAnalyzer) VisitMethodSignature(sign *text.MethodSignature) {
 	})
 
 	n.stack.Push(classType)
+	classVars := classType.dataType.typesInScope()
+	n.typeVars = classVars
+	if method := classType.dataType.Methods[sign.Signature()]; method != nil {
+		n.typeVars = append(append([]*TypeSymbol{}, method.typeParams...), classVars...)
+	}
+
 	var returnType DataType
 	if sign.ReturnType.Name == "void" {
 		returnType = DataType{
@@ -201,11 +420,7 @@ func (n *NameAnalyzer) VisitMethodSignature(sign *text.MethodSignature) {
 			sign.ReturnType.IsArray,
 		}
 	} else {
-		typeof := n.typeTable.Lookup(sign.ReturnType.Name)
-		returnType = DataType{
-			typeof,
-			sign.ReturnType.IsArray,
-		}
+		returnType, _ = n.typeTable.resolve(sign.ReturnType, n.typeVars)
 	}
 	n.stack.Push(returnType)
 	n.registerParam(sign.ParameterList)
@@ -213,17 +428,21 @@ func (n *NameAnalyzer) VisitMethodSignature(sign *text.MethodSignature) {
 
 func (n *NameAnalyzer) registerParam(params []text.Parameter) {
 	for _, param := range params {
-		typeof := n.typeTable[param.Type.Name]
+		typeof, _ := n.typeTable.resolve(param.Type, n.typeVars)
 
 		if exist, _ := n.scope.Lookup(param.Name, false); exist != nil {
 			n.AddErrorf(msgParameterAlreadyDeclared, param.Name)
 			return
 		}
 
-		n.Insert(&FieldSymbol{
-			DataType{typeof, param.Type.IsArray},
+		symbol := &FieldSymbol{
+			typeof,
 			param.Name,
-		})
+		}
+		n.Insert(symbol)
+		if param.IsFinal {
+			n.finals[symbol] = false
+		}
 	}
 }
 
@@ -238,20 +457,95 @@ func (n *NameAnalyzer) VisitConstructor(con *text.ConstructorDeclaration) {
 		classType,
 		"this",
 	})
+	if classType.dataType.TypeCategory == Enum {
+		// the name and ordinal are passed before the declared parameters
+		n.localCount += 2
+	} else if classType.dataType.isInner {
+		// so does the outer instance of an inner class
+		n.localCount += 1
+	}
 	n.stack.Push(classType)
 	n.stack.Push(DataType{
 		NewType("void", Primitive),
 		false,
 	})
 	n.registerParam(con.ParameterList)
+	n.finality.enterMethod(classType.dataType)
 }
 
 func (n *NameAnalyzer) VisitAfterConstructor(*text.ConstructorDeclaration) {
 	n.stack.Pop()
+	n.checkBlankFinals(n.finality.flow, false)
+	n.finality.constructing = nil
+}
+
+func (n *NameAnalyzer) VisitConstructorCall(*text.ConstructorCallStatement) {}
+
+// VisitAfterConstructorCall check the constructor called with this(...),
+// which assign the blank finals so they cannot be assigned again.
+func (n *NameAnalyzer) VisitAfterConstructorCall(call *text.ConstructorCallStatement) {
+	args := make([]DataType, len(call.Args))
+	for i := range args {
+		typeof, _ := n.stack.Pop()
+		args[len(args)-i-1] = typeof
+	}
+
+	classType := n.stack[0].dataType
+	n.lookupConstructor(classType, classType.name, args)
+	n.finality.delegate()
+}
+
+// VisitMethodCallStatement keep the size of the stack, the value returned
+// by the method is discarded, even if it is not found.
+func (n *NameAnalyzer) VisitMethodCallStatement(*text.MethodCallStatement) {
+	n.statementStack = append(n.statementStack, len(n.stack))
+}
+
+func (n *NameAnalyzer) VisitAfterMethodCallStatement(*text.MethodCallStatement) {
+	last := len(n.statementStack) - 1
+	if size := n.statementStack[last]; len(n.stack) > size {
+		n.stack = n.stack[:size]
+	}
+	n.statementStack = n.statementStack[:last]
+	n.curField = nil
+}
+
+// VisitInitializer analyze an initializer block as a method without
+// parameter, this is not available inside a static initializer.
+func (n *NameAnalyzer) VisitInitializer(init *text.Initializer) {
+	n.localCount = 0
+	n.isScopeCreated = true
+	name := "initializer"
+	if init.IsStatic {
+		name = "static-initializer"
+	}
+	n.newScope(name)
+	n.initializer = init
+
+	classType := n.stack[0]
+	if !init.IsStatic {
+		n.Insert(&FieldSymbol{
+			classType,
+			"this",
+		})
+	}
+
+	n.stack.Push(DataType{
+		NewType("void", Primitive),
+		false,
+	})
+	n.finality.enterInitializer(classType.dataType, init.IsStatic)
+}
+
+func (n *NameAnalyzer) VisitAfterInitializer(*text.Initializer) {
+	n.stack.Pop()
+	n.finality.exitInitializer()
+	n.initializer = nil
 }
 
 func (n *NameAnalyzer) VisitMainMethodDeclaration(*text.MainMethodDeclaration) {
 	n.localCount = 0
+	n.finality.enterMethod(nil)
 	returnType := DataType{
 		NewType("void", Primitive),
 		false,
@@ -261,13 +555,26 @@ func (n *NameAnalyzer) VisitMainMethodDeclaration(*text.MainMethodDeclaration) {
 func (n *NameAnalyzer) VisitAfterMethodDeclaration(*text.MethodDeclaration) {
 	//popping method return type
 	n.stack.Pop()
+	if len(n.stack) > 0 && n.stack[0].dataType != nil {
+		// back to the type variables of the class
+		n.typeVars = n.stack[0].dataType.typesInScope()
+	}
 }
 func (n *NameAnalyzer) VisitVariableDeclaration(varDecl *text.VariableDeclaration) {
-	varName, typeName := varDecl.Name, varDecl.Type.Name
-	typeof := n.typeExist(typeName)
+	varName := varDecl.Name
+	if isVar(varDecl.Type) {
+		if isLambda(varDecl.Value) {
+			n.AddErrorf(msgVarLambdaInitializer, varName)
+		}
+	} else {
+		varType, ok := n.resolveType(varDecl.Type)
+		if !ok {
+			return
+		}
 
-	if typeof == nil {
-		return
+		if isLambda(varDecl.Value) {
+			n.targets[varDecl.Value] = varType
+		}
 	}
 
 	symbol, _ := n.scope.Lookup(varName, false)
@@ -287,27 +594,42 @@ func (n *NameAnalyzer) typeExist(name string) *TypeSymbol {
 }
 
 func (n *NameAnalyzer) VisitAfterVariableDeclaration(varDecl *text.VariableDeclaration) {
-	typeof := n.typeTable.Lookup(varDecl.Type.Name)
+	varType, _ := n.typeTable.resolve(varDecl.Type, n.typeVars)
 	canDeclare := true
 	defer func() {
 		if !canDeclare {
 			return
 		}
 
-		n.Insert(&FieldSymbol{
-			DataType{
-				typeof,
-				varDecl.Type.IsArray,
-			},
+		symbol := &FieldSymbol{
+			varType,
 			varDecl.Name,
-		})
+		}
+		n.Insert(symbol)
+		if !varDecl.IsFinal {
+			return
+		}
+
+		isBlank := varDecl.Value == nil
+		n.finals[symbol] = isBlank
+		if isBlank {
+			n.finality.declareBlank(symbol)
+		}
 	}()
 
+	if isVar(varDecl.Type) {
+		var initType DataType
+		if varDecl.Value != nil {
+			initType, _ = n.stack.Pop()
+		}
+		varType, canDeclare = n.inferVarType(varDecl, initType)
+		return
+	}
+
 	if varDecl.Value == nil {
 		return
 	}
 
-	varType := DataType{typeof, varDecl.Type.IsArray}
 	expressionType, err := n.stack.Pop()
 
 	if err != nil {
@@ -315,13 +637,7 @@ func (n *NameAnalyzer) VisitAfterVariableDeclaration(varDecl *text.VariableDecla
 		return
 	}
 
-	if IsNullOk(varType) && expressionType.Name() == "null" {
-		n.AddErrorf(msgCantBeNull, varType)
-		canDeclare = false
-		return
-	}
-
-	if !isTypeValid(varType, expressionType) {
+	if !isAssignable(varDecl.Value, expressionType, varType) {
 		n.AddErrorf(msgExpectingTypeof, varType, expressionType)
 		canDeclare = false
 		return
@@ -329,26 +645,6 @@ func (n *NameAnalyzer) VisitAfterVariableDeclaration(varDecl *text.VariableDecla
 
 }
 
-func isTypeValid(varType, expressionType DataType) bool {
-	if varType.isArray != expressionType.isArray {
-		return false
-	}
-
-	if varType == expressionType {
-		return true
-	}
-
-	if expressionType.dataType.isDescendantOf(varType.dataType) {
-		return true
-	}
-
-	if expressionType.dataType.isImplementing(varType.dataType) {
-		return true
-	}
-
-	return false
-}
-
 func (n *NameAnalyzer) VisitStatementList(text.StatementList) {
 	if n.isScopeCreated {
 		n.isScopeCreated = false
@@ -360,9 +656,80 @@ func (n *NameAnalyzer) VisitStatementList(text.StatementList) {
 func (n *NameAnalyzer) VisitAfterStatementList() {
 	n.popScope()
 }
-func (n *NameAnalyzer) VisitSwitchStatement(*text.SwitchStatement) {
-	n.stack.Push(n.curField.Type())
+
+// VisitSwitchStatement check every case label against the type of
+// the switch value, which is kept in the stack until the switch end
+func (n *NameAnalyzer) VisitSwitchStatement(s *text.SwitchStatement) {
 	n.curField = nil
+	switchType := n.stack[len(n.stack)-1]
+	if value := n.currentSwitchValue(); value != nil && value.node == s {
+		value.switchType = switchType
+	}
+
+	isEnum := !switchType.isArray && switchType.dataType.TypeCategory == Enum
+	if !isEnum && !isStringSwitch(switchType) &&
+		(!isIntegral(switchType) || switchType.dataType == PrimitiveLong) {
+		n.AddErrorf(msgExpectingTypeof, "char, byte, short, int, String or enum", switchType)
+		return
+	}
+
+	// a string is compared by its value since the hash code may collide
+	keys := make(map[interface{}]bool)
+	for _, c := range s.CaseList {
+		for i := range c.Values {
+			var ok bool
+			if isEnum {
+				ok = n.checkEnumLabel(c.Values[i], switchType)
+			} else {
+				ok = n.checkConstantLabel(&c.Values[i], switchType)
+			}
+
+			if !ok {
+				continue
+			}
+
+			var key interface{}
+			if str, isString := c.Values[i].(text.String); isString {
+				key = str
+			} else {
+				key, _ = caseKey(c.Values[i], switchType)
+			}
+
+			if keys[key] {
+				n.AddErrorf(msgDuplicateCaseLabel, text.PrettyPrint(c.Values[i]))
+			}
+			keys[key] = true
+		}
+	}
+}
+
+func (n *NameAnalyzer) checkEnumLabel(label text.Expression, switchType DataType) bool {
+	if _, ok := caseKey(label, switchType); !ok {
+		n.AddErrorf(msgNotEnumConstant, text.PrettyPrint(label), switchType)
+		return false
+	}
+	return true
+}
+
+// checkConstantLabel check the label of a case, which is replaced
+// by its value if it is a constant expression, e.g. MAX + 1
+func (n *NameAnalyzer) checkConstantLabel(label *text.Expression, switchType DataType) bool {
+	(*label).Accept(n)
+	labelType, _ := n.stack.Pop()
+	if value := n.typeTable.fold(*label, n.typeVars, n.lookupConstant); value != nil {
+		*label = value
+	}
+
+	if _, ok := caseKey(*label, switchType); !ok {
+		n.AddError(msgCaseMustBeConstant)
+		return false
+	}
+
+	if !isAssignable(*label, labelType, switchType) {
+		n.AddErrorf(msgExpectingTypeof, switchType, labelType)
+		return false
+	}
+	return true
 }
 
 func (n *NameAnalyzer) VisitAfterSwitchStatement(*text.SwitchStatement) {
@@ -370,21 +737,80 @@ func (n *NameAnalyzer) VisitAfterSwitchStatement(*text.SwitchStatement) {
 	n.stack.Pop()
 }
 
-func (n *NameAnalyzer) VisitSwitchCase(cs *text.CaseStatement) {
-	caseType, _ := n.stack.Pop()
-	switchType, _ := n.stack.Pop()
-	if caseType != switchType {
-		n.AddErrorf(msgExpectingTypeof, switchType.dataType.name, caseType.dataType.name)
+func (n *NameAnalyzer) VisitSwitchCase(*text.CaseStatement)      {}
+func (n *NameAnalyzer) VisitSwitchDefault(*text.SwitchStatement) {}
+
+// VisitSwitchExpression collect the types yielded by the cases, the
+// value of the switch is of the type which all of them can be put into
+func (n *NameAnalyzer) VisitSwitchExpression(s *text.SwitchExpression) {
+	n.switchValues = append(n.switchValues, &switchValue{node: &s.SwitchStatement})
+}
+
+func (n *NameAnalyzer) VisitAfterSwitchExpression(s *text.SwitchExpression) {
+	last := len(n.switchValues) - 1
+	value := n.switchValues[last]
+	n.switchValues = n.switchValues[:last]
+
+	if !isExhaustive(&s.SwitchStatement, value.switchType) {
+		n.AddError(msgSwitchNotExhaustive)
+	} else if !alwaysYields(&s.SwitchStatement) {
+		n.AddError(msgSwitchWithoutYield)
+	}
+
+	if len(value.yields) == 0 {
+		n.stack.Push(DataType{NewType("void", Primitive), false})
+		return
+	}
+
+	result := value.yields[0]
+	for _, dt := range value.yields[1:] {
+		var ok bool
+		if result, ok = yieldedType(result, dt); !ok {
+			n.AddErrorf(msgIncompatibleYield, result, dt)
+			break
+		}
+	}
+
+	s.Type = namedTypeOf(result)
+	n.stack.Push(result)
+}
+
+func (n *NameAnalyzer) currentSwitchValue() *switchValue {
+	if len(n.switchValues) == 0 {
+		return nil
+	}
+	return n.switchValues[len(n.switchValues)-1]
+}
+
+// yield put the value on top of the stack as a value of the switch expression
+func (n *NameAnalyzer) yield() {
+	dt, err := n.stack.Pop()
+	value := n.currentSwitchValue()
+	if value == nil {
+		n.AddError(msgYieldOutsideSwitch)
+		return
+	}
+
+	if err == nil {
+		value.yields = append(value.yields, dt)
 	}
-	n.stack.Push(switchType)
 }
-func (n *NameAnalyzer) VisitIfStatement(*text.IfStatement) {}
+
+func (n *NameAnalyzer) VisitIfStatement(stmt *text.IfStatement) {
+	n.enterBranch(stmt)
+}
 func (n *NameAnalyzer) VisitAfterIfStatementCondition(*text.IfStatement) {
 	n.expectLastStackTypeOf("boolean", false)
 }
 
-func (n *NameAnalyzer) VisitAfterIfStatementBody(*text.IfStatement)   {}
-func (n *NameAnalyzer) VisitAfterIfStatement(*text.IfStatement)       {}
+func (n *NameAnalyzer) VisitAfterIfStatementBody(*text.IfStatement) {
+	n.enterElse()
+}
+
+func (n *NameAnalyzer) VisitAfterIfStatement(stmt *text.IfStatement) {
+	n.exitBranch(stmt)
+}
+
 func (n *NameAnalyzer) VisitAfterElseStatementBody(*text.IfStatement) {}
 func (n *NameAnalyzer) VisitForStatement(forStmt *text.ForStatement) {
 	if forStmt.Init == nil {
@@ -399,59 +825,245 @@ func (n *NameAnalyzer) VisitForStatement(forStmt *text.ForStatement) {
 	}
 }
 
-func (n *NameAnalyzer) VisitAfterForStatementInit(*text.ForStatement) {}
+func (n *NameAnalyzer) VisitAfterForStatementInit(*text.ForStatement) {
+	n.enterLoop()
+}
+
 func (n *NameAnalyzer) VisitAfterForStatementCondition(forStmt *text.ForStatement) {
 	n.expectLastStackTypeOf("boolean", false)
 }
 func (n *NameAnalyzer) VisitBeforeForStatementUpdate(*text.ForStatement) {}
-func (n *NameAnalyzer) VisitAfterForStatement(*text.ForStatement)        {}
-func (n *NameAnalyzer) VisitWhileStatement(*text.WhileStatement)         {}
-func (n *NameAnalyzer) VisitAfterWhileStatement(*text.WhileStatement)    {}
+func (n *NameAnalyzer) VisitAfterForStatement(*text.ForStatement) {
+	n.exitLoop()
+}
+
+// the array and the index of an enhanced for statement are kept
+// in locals whose names cannot be written in the code
+const (
+	forEachArray = "for-each array"
+	forEachIndex = "for-each index"
+)
+
+func (n *NameAnalyzer) VisitForEachStatement(*text.ForEachStatement) {}
+
+// VisitAfterForEachIterable declare the variable of an enhanced for statement
+// in the scope of its body, after the locals used to iterate over the array.
+func (n *NameAnalyzer) VisitAfterForEachIterable(f *text.ForEachStatement) {
+	iterable, err := n.stack.Pop()
+	n.newScope(fmt.Sprintf("for-each-scope-%d", n.scope.level))
+	n.isScopeCreated = true
+	n.enterLoop()
+	if err != nil || iterable.dataType == nil {
+		return
+	}
+
+	if !iterable.isArray {
+		n.AddErrorf(msgForEachNotApplicable, iterable)
+		return
+	}
+
+	n.Insert(&FieldSymbol{iterable, forEachArray})
+	n.Insert(&FieldSymbol{DataType{PrimitiveInt, false}, forEachIndex})
+	varType, ok := n.forEachVariableType(f.Variable, DataType{iterable.dataType, false})
+	if !ok {
+		return
+	}
+
+	symbol := &FieldSymbol{varType, f.Variable.Name}
+	n.Insert(symbol)
+	if f.Variable.IsFinal {
+		n.finals[symbol] = false
+	}
+}
+
+func (n *NameAnalyzer) VisitAfterForEachStatement(*text.ForEachStatement) {
+	n.exitLoop()
+}
+
+func (n *NameAnalyzer) VisitWhileStatement(*text.WhileStatement) {
+	n.enterLoop()
+}
+
+func (n *NameAnalyzer) VisitAfterWhileStatement(*text.WhileStatement) {
+	n.exitLoop()
+}
+
 func (n *NameAnalyzer) VisitAfterWhileStatementCondition(*text.WhileStatement) {
 	n.expectLastStackTypeOf("boolean", false)
 }
-func (n *NameAnalyzer) VisitAssignmentStatement(*text.AssignmentStatement) {}
-func (n *NameAnalyzer) VisitAfterAssignmentStatement(*text.AssignmentStatement) {
-	targetType, _ := n.stack.Pop()
-	rightType, _ := n.stack.Pop()
 
-	if IsNullOk(targetType) && rightType.Name() == "null" {
+func (n *NameAnalyzer) VisitAssertStatement(*text.AssertStatement) {}
+
+func (n *NameAnalyzer) VisitAfterAssertCondition(*text.AssertStatement) {
+	n.expectLastStackTypeOf("boolean", false)
+}
+
+// VisitAfterAssertStatement check the message, which can be of any type
+// since it is passed into one of the constructors of AssertionError
+func (n *NameAnalyzer) VisitAfterAssertStatement(stmt *text.AssertStatement) {
+	if stmt.Message == nil {
 		return
 	}
 
-	if !rightType.Equals(targetType) {
-		n.AddErrorf(msgExpectingTypeof, targetType, rightType)
+	if message, _ := n.stack.Pop(); message.dataType != nil && message.Name() == "void" {
+		n.AddError(msgAssertVoidMessage)
 	}
 }
 
-func (n *NameAnalyzer) VisitJumpStatement(*text.JumpStatement) {}
-func (n *NameAnalyzer) VisitAfterJumpStatement(jump *text.JumpStatement) {
-	if jump.Type != text.ReturnJump {
+// VisitAssignmentStatement check the assignment of a local variable,
+// which cannot be changed once it is captured by a local or anonymous class
+func (n *NameAnalyzer) VisitAssignmentStatement(assign *text.AssignmentStatement) {
+	// the target is checked against final once it is resolved
+	n.assignment = assign
+	if isLambda(assign.Right) {
+		// the target is the type of the left side, which is visited first
+		n.targets[assign.Right] = DataType{}
+	}
+
+	field, ok := assign.Left.(*text.FieldAccess)
+	if !ok || field.Child != nil {
 		return
 	}
 
-	// return type always at the second index
-	retType := n.stack[1]
-	if retType.dataType.name == "void" {
-		if jump.Exp != nil {
-			val, _ := n.stack.Pop()
-			n.AddErrorf(msgVoidDontHaveType, val)
-		}
+	member, _, crossed := n.scope.lookupCrossing(field.Name)
+	local, isLocal := member.(*FieldSymbol)
+	if !isLocal {
 		return
 	}
 
-	val, _ := n.stack.Pop()
-	if retType != val {
-		n.AddErrorf(msgExpectingReturnTypeOf, retType, val)
+	if isCaptured(member, crossed) || n.captured[local] {
+		n.AddErrorf(msgCapturedMustBeFinal, field.Name)
+		return
+	}
+
+	// a blank final is assigned at most once, so it can be captured
+	if _, isFinal := n.finals[local]; !isFinal {
+		n.reassigned[local] = true
 	}
 }
+func (n *NameAnalyzer) VisitAfterAssignmentStatement(assign *text.AssignmentStatement) {
+	n.assignment = nil
+	// the target is visited first
+	rightType, _ := n.stack.Pop()
+	targetType, _ := n.stack.Pop()
 
-func (n *NameAnalyzer) VisitFieldAccess(field *text.FieldAccess) {
+	if assign.Operator.Type != text.Assignment {
+		n.checkCompoundAssignment(assign.Operator.Type, targetType, rightType)
+		return
+	}
+
+	if !isAssignable(assign.Right, rightType, targetType) {
+		n.AddErrorf(msgExpectingTypeof, targetType, rightType)
+	}
+}
+
+// checkCompoundAssignment check the operands of a compound assignment the
+// same way as its binary operator, the result has an implicit cast to the
+// target type, e.g. `byte b; b += 1.5;` is valid.
+func (n *NameAnalyzer) checkCompoundAssignment(operator text.TokenType, target, right DataType) {
+	if hasErrorType(target, right) {
+		return
+	}
+
+	isBooleanPair := !target.isArray && !right.isArray &&
+		target.Name() == "boolean" && right.Name() == "boolean"
+
+	switch operator {
+	case text.AdditionAssignment:
+		// anything is appended into a String
+		if !target.isArray && target.dataType == PrimitiveString && right.Name() != "void" {
+			return
+		}
+	case text.BitwiseAndAssignment, text.BitwiseOrAssignment, text.BitwiseXorAssignment:
+		if !isBooleanPair {
+			n.checkIntegral(target, right)
+		}
+		return
+	case text.LeftShiftAssignment, text.RightShiftAssignment, text.UnsignedRightShiftAssignment:
+		n.checkIntegral(target, right)
+		return
+	}
+
+	if !IsNumeric(target) || !IsNumeric(right) {
+		n.AddErrorf(msgExpectingTypeof, target, right)
+	}
+}
+
+func (n *NameAnalyzer) VisitJumpStatement(jump *text.JumpStatement) {
+	if jump.Type == text.ReturnJump && isLambda(jump.Exp) {
+		n.targets[jump.Exp] = n.stack[1]
+	}
+}
+func (n *NameAnalyzer) VisitAfterJumpStatement(jump *text.JumpStatement) {
+	if jump.Type == text.YieldJump {
+		n.yield()
+		return
+	}
+
+	if jump.Type != text.ReturnJump {
+		return
+	}
+
+	n.finality.flow.returned = true
+	// the code of an instance initializer is put into the constructors
+	if n.initializer != nil {
+		n.AddError(msgReturnInInitializer)
+		if jump.Exp != nil {
+			n.stack.Pop()
+		}
+		return
+	}
+
+	// return type always at the second index
+	retType := n.stack[1]
+	if retType.dataType.name == "void" {
+		if jump.Exp != nil {
+			val, _ := n.stack.Pop()
+			n.AddErrorf(msgVoidDontHaveType, val)
+		}
+		return
+	}
+
+	val, _ := n.stack.Pop()
+	if !isAssignable(jump.Exp, val, retType) {
+		n.AddErrorf(msgExpectingReturnTypeOf, retType, val)
+	}
+}
+
+func (n *NameAnalyzer) VisitFieldAccess(field *text.FieldAccess) {
 	if n.curField == nil {
-		sym, _ := n.scope.Lookup(field.Name, true)
+		sym, _, crossed := n.scope.lookupCrossing(field.Name)
+		if isCaptured(sym, crossed) {
+			n.captureVariable(sym.(*FieldSymbol), crossed)
+		}
+
+		if sym == nil && field.Child != nil {
+			if typeof := n.lookupType(field.Name); typeof != nil {
+				sym = newTypeReference(typeof)
+			}
+		}
+
 		if sym == nil {
 			n.AddErrorf(msgVariableDoesNotExist, field.Name)
+		} else if n.isInstanceProperty(sym) && !n.hasThis() {
+			n.AddErrorf(msgNonStaticField, field.Name)
+			// the type is known, so the rest of the expression is still checked
+			n.stack.Push(sym.Type())
 		} else {
+			if n.assignment != nil && assignmentTarget(n.assignment) == field {
+				// a property of the current class is accessed through this
+				n.checkFinalAssignment(sym, len(crossed) == 0)
+			} else if len(crossed) == 0 {
+				n.checkFinalRead(sym)
+			}
+
+			if prop, ok := sym.(*PropertySymbol); ok {
+				owner := n.enclosingOwner(func(class *TypeSymbol) *TypeSymbol {
+					return class.propertyOwner(field.Name)
+				})
+				n.useNestMember(field.Name, prop.AccessModifier, owner)
+			}
+
 			if field.Child != nil {
 				n.curField = sym
 			}
@@ -460,24 +1072,43 @@ func (n *NameAnalyzer) VisitFieldAccess(field *text.FieldAccess) {
 		return
 	}
 
+	if isErrorType(n.curField.Type()) {
+		n.pushError(field.Name, field.Child != nil)
+		return
+	}
+
 	if n.curField.Type().isArray {
 		n.AddErrorf(msgExpectArrayAccess, field.Name)
 		return
 	}
 
-	subField := n.curField.Type().dataType.Properties[field.Name]
-	propNotFoundMsg := fmt.Sprintf(msgTypeDoesNotHaveProperty, n.curField.Type().Name(), field.Name)
+	if member := memberTypeOf(n.curField, field); member != nil {
+		n.curField = newTypeReference(member)
+		n.stack.Overwrite(n.curField.Type())
+		return
+	}
+
+	subField := n.curField.Type().dataType.LookupProperty(field.Name)
 	if subField == nil {
-		n.AddError(propNotFoundMsg)
+		n.AddErrorf(msgTypeDoesNotHaveProperty, n.curField.Type().Name(), field.Name)
 		return
 	}
 
-	access := n.getAccess()
-	if n.curField != nil && subField.AccessModifier&access == 0 {
-		n.AddError(propNotFoundMsg)
+	isStatic := subField.AccessModifier&text.Static != 0
+	owner := n.curField.Type().dataType.propertyOwner(field.Name)
+	if n.checkAccess(field.Name, subField.AccessModifier, owner, n.qualifierOf(isStatic)) {
+		n.useNestMember(field.Name, subField.AccessModifier, owner)
+	}
+
+	if isTypeReference(n.curField) && !isStatic && !isEnumConstant(n.curField.Type().dataType, subField) {
+		n.AddErrorf(msgNonStaticField, field.Name)
 		return
 	}
 
+	if n.assignment != nil && assignmentTarget(n.assignment) == field {
+		n.checkFinalAssignment(subField, n.curField.Name() == "this")
+	}
+
 	if field.Child != nil {
 		n.curField = subField
 	} else {
@@ -487,6 +1118,20 @@ func (n *NameAnalyzer) VisitFieldAccess(field *text.FieldAccess) {
 	n.stack.Overwrite(subField.DataType)
 }
 
+// captureVariable pass a local variable into every class crossed
+// to reach it, so it can be used after the method is returned
+func (n *NameAnalyzer) captureVariable(local *FieldSymbol, crossed []*TypeSymbol) {
+	if n.reassigned[local] {
+		n.AddErrorf(msgCapturedMustBeFinal, local.name)
+		return
+	}
+
+	n.captured[local] = true
+	for _, t := range crossed {
+		t.capture(local)
+	}
+}
+
 func (n *NameAnalyzer) VisitArrayAccess(arr *text.ArrayAccess) {
 	if !n.curField.Type().isArray {
 		n.AddErrorf(msgFieldIsNotArray, n.curField.Name())
@@ -494,15 +1139,44 @@ func (n *NameAnalyzer) VisitArrayAccess(arr *text.ArrayAccess) {
 }
 
 func (n *NameAnalyzer) VisitAfterArrayAccess(arr *text.ArrayAccess) {
-	n.expectLastStackTypeOf("int", false)
+	n.expectIndex()
+}
+
+// expectIndex check the last stack as an array index or dimension,
+// which is promoted first so byte, short and char are also allowed.
+func (n *NameAnalyzer) expectIndex() bool {
+	lastStack, _ := n.stack.Pop()
+	if promoted := unaryNumericPromotion(lastStack); promoted.dataType == nil || !isIdentity(promoted, DataType{PrimitiveInt, false}) {
+		n.AddErrorf(msgExpectingTypeof, "int", lastStack)
+		return false
+	}
+	return true
 }
 
 func (n *NameAnalyzer) VisitArrayAccessDelegate(text.NamedValue) {}
-func (n *NameAnalyzer) VisitMethodCall(*text.MethodCall) {
-	n.fieldBuffer = n.curField
+func (n *NameAnalyzer) VisitMethodCall(method *text.MethodCall) {
+	// the constructor call of an object creation, which
+	// may be an argument of the method call being analyzed
+	if n.isConstructorCall(method) {
+		return
+	}
+
+	if n.curField != nil && !n.curField.Type().isArray {
+		n.setLambdaTargets(n.curField.Type().dataType.getMethodsByName(method.Name), method.Args)
+	}
+
+	// the arguments may call another method, so the owner is kept until they are analyzed
+	n.fieldBuffer = append(n.fieldBuffer, n.curField)
 	n.curField = nil
 }
 
+// isConstructorCall check if method is the constructor call of
+// the innermost object creation being analyzed
+func (n *NameAnalyzer) isConstructorCall(method *text.MethodCall) bool {
+	last := len(n.pendingCreations) - 1
+	return last >= 0 && &n.pendingCreations[last].MethodCall == method
+}
+
 func (n *NameAnalyzer) getFittingMethod(name string, args []DataType) (method *MethodSymbol) {
 	argStr := make([]string, len(args))
 	for i, a := range args {
@@ -530,33 +1204,53 @@ func (n *NameAnalyzer) getMethodBySignature(signature string) *MethodSymbol {
 }
 
 func (n *NameAnalyzer) getMethodByArgs(name string, args []DataType) *MethodSymbol {
-	var method TypeMember
+	var candidates []*MethodSymbol
 	if n.curField != nil {
-		method = n.curField.Type().dataType.LookupMethodByArgs(name, args)
+		candidates = n.curField.Type().dataType.MethodCandidates(name, args)
 	} else {
-		method, _ = n.scope.LookupMethod(name, args, true)
+		candidates, _ = n.scope.MethodCandidates(name, args, true)
 	}
 
-	var emptyMethod *MethodSymbol
-	if method == nil || method == emptyMethod {
+	switch len(candidates) {
+	case 0:
 		// FIXME: change to signature
 		n.AddErrorf(msgMethodNotFound, name)
 		return nil
+	case 1:
+		return candidates[0]
+	default:
+		n.AddErrorf(msgAmbiguousCall, name, candidatesString(candidates))
+		return nil
 	}
+}
 
-	return method.(*MethodSymbol)
+// lookupConstructor get the constructor of typeof that accept args,
+// report an error and get nil if there is none or the call is ambiguous.
+func (n *NameAnalyzer) lookupConstructor(typeof *TypeSymbol, name string, args []DataType) *MethodSymbol {
+	candidates := typeof.ConstructorCandidates(args)
+	switch len(candidates) {
+	case 0:
+		argStr := make([]string, len(args))
+		for i, a := range args {
+			argStr[i] = a.String()
+		}
+		n.AddErrorf(msgConstructorNotFound, name, strings.Join(argStr, ", "))
+		return nil
+	case 1:
+		return candidates[0]
+	default:
+		n.AddErrorf(msgAmbiguousCall, name, candidatesString(candidates))
+		return nil
+	}
 }
 
 func (n *NameAnalyzer) VisitAfterMethodCall(method *text.MethodCall) {
-	if n.isObjectCreation {
-		n.isObjectCreation = false
+	if n.isConstructorCall(method) {
 		return
 	}
 
-	if n.fieldBuffer != nil {
-		n.curField = n.fieldBuffer
-		n.fieldBuffer = nil
-	}
+	last := len(n.fieldBuffer) - 1
+	n.curField, n.fieldBuffer = n.fieldBuffer[last], n.fieldBuffer[:last]
 
 	args := make([]DataType, len(method.Args))
 	for i := range args {
@@ -564,16 +1258,40 @@ func (n *NameAnalyzer) VisitAfterMethodCall(method *text.MethodCall) {
 		args[len(method.Args)-i-1] = typeof
 	}
 
+	if (n.curField != nil && isErrorType(n.curField.Type())) || hasErrorType(args...) {
+		n.pushError(method.Name, method.ChildNode() != nil)
+		return
+	}
+
 	methodSym := n.getMethodByArgs(method.Name, args)
-	access := n.getAccess()
-	if methodSym != nil && methodSym.accessMod&access == 0 {
-		argStr := make([]string, len(args))
-		for i, a := range args {
-			argStr[i] = a.String()
+	if methodSym == nil {
+		n.pushError(method.Name, method.ChildNode() != nil)
+		return
+	}
+
+	// a method without a qualifier is one of the enclosing classes
+	if n.curField == nil {
+		owner := n.enclosingOwner(func(class *TypeSymbol) *TypeSymbol {
+			return class.methodOwner(methodSym)
+		})
+		if owner != nil {
+			n.useNestMember(methodKey(owner, methodSym), methodSym.accessMod, owner)
+		}
+	} else if !n.curField.Type().isArray {
+		owner := n.curField.Type().dataType.methodOwner(methodSym)
+		if n.checkAccess(methodSym.String(), methodSym.accessMod, owner, n.qualifierOf(methodSym.isStatic)) && owner != nil {
+			n.useNestMember(methodKey(owner, methodSym), methodSym.accessMod, owner)
 		}
+	}
 
-		signature := fmt.Sprintf("%s(%s)", method.Name, strings.Join(argStr, ", "))
-		n.AddErrorf(msgMethodNotFound, signature)
+	// the method of the super class is called from the class of this,
+	// which is not where the code of a lambda is generated
+	if isSuper(n.curField) && n.enclosingClasses()[0].function != nil {
+		n.AddErrorf(msgSuperCallInLambda, methodSym)
+	}
+
+	if isTypeReference(n.curField) && !methodSym.isStatic {
+		n.AddErrorf(msgNonStaticMethod, methodSym)
 		return
 	}
 
@@ -590,6 +1308,22 @@ func (n *NameAnalyzer) VisitAfterMethodCall(method *text.MethodCall) {
 	}
 }
 
+// pushError put errorType as the type of the expression being analyzed
+// in place of its qualifier, after its error is reported. The rest of
+// the expression, e.g. z.g(1).x, is then skipped without another error.
+func (n *NameAnalyzer) pushError(name string, hasChild bool) {
+	if n.curField == nil {
+		n.stack.Push(errorType)
+	} else {
+		n.stack.Overwrite(errorType)
+	}
+
+	n.curField = nil
+	if hasChild {
+		n.curField = &FieldSymbol{errorType, name}
+	}
+}
+
 func (n *NameAnalyzer) VisitArrayCreation(arr *text.ArrayCreation) {
 	if n.typeExist(arr.Type) == nil {
 		return
@@ -597,7 +1331,7 @@ func (n *NameAnalyzer) VisitArrayCreation(arr *text.ArrayCreation) {
 }
 
 func (n *NameAnalyzer) VisitAfterArrayCreation(arr *text.ArrayCreation) {
-	if !n.expectLastStackTypeOf("int", false) {
+	if !n.expectIndex() {
 		return
 	}
 
@@ -608,19 +1342,97 @@ func (n *NameAnalyzer) VisitAfterArrayCreation(arr *text.ArrayCreation) {
 	})
 }
 
-func (n *NameAnalyzer) VisitObjectCreation(*text.ObjectCreation) {
-	n.isObjectCreation = true
+func (n *NameAnalyzer) VisitObjectCreation(o *text.ObjectCreation) {
+	n.pendingCreations = append(n.pendingCreations, o)
+	created, msg := n.typeTable.resolve(o.Type(), n.typeVars)
+	if len(msg) > 0 {
+		return
+	}
+
+	constructors := make([]*MethodSymbol, len(created.dataType.base().constructors))
+	for i, con := range created.dataType.base().constructors {
+		constructors[i] = created.dataType.substituteMethod(con)
+	}
+	n.setLambdaTargets(constructors, o.Args)
+}
+
+// setLambdaTargets set the target type of the lambdas passed into one of methods
+func (n *NameAnalyzer) setLambdaTargets(methods []*MethodSymbol, args []text.Expression) {
+	for arg, target := range lambdaTargets(methods, args) {
+		n.targets[arg] = target
+	}
 }
 
 func (n *NameAnalyzer) VisitAfterObjectCreation(o *text.ObjectCreation) {
-	//TODO: implement parameterize object creation
-	objectSymbol := n.typeTable[o.Name]
-	if objectSymbol == nil {
-		n.AddErrorf(msgTypeNotExist, o.Name)
+	n.pendingCreations = n.pendingCreations[:len(n.pendingCreations)-1]
+	args := make([]DataType, len(o.Args))
+	for i := range args {
+		typeof, _ := n.stack.Pop()
+		args[len(args)-i-1] = typeof
+	}
+
+	objectType, ok := n.resolveType(o.Type())
+	if objectType.dataType == nil {
 		return
 	}
 
-	n.stack.Push(DataType{objectSymbol, false})
+	if o.Body != nil {
+		n.createAnonymous(o, objectType, args)
+		return
+	}
+
+	if base := objectType.dataType.base(); base.isInner && !n.hasEnclosingInstance(base.outer) {
+		n.AddErrorf(msgEnclosingInstanceRequired, base.outer.name)
+	}
+
+	if ok {
+		if con := n.lookupConstructor(objectType.dataType, objectType.String(), args); con != nil {
+			n.checkAccess(con.String(), con.accessMod, objectType.dataType.base(), objectType.dataType)
+		}
+	}
+
+	n.stack.Push(objectType)
+}
+
+// createAnonymous give the anonymous class a constructor that pass
+// its arguments into the matching constructor of its super class
+func (n *NameAnalyzer) createAnonymous(o *text.ObjectCreation, base DataType, args []DataType) {
+	anonymous := n.typeTable[o.Body.Name]
+	defer n.stack.Push(DataType{anonymous, false})
+
+	// an interface only has the constructor of java.lang.Object
+	if base.dataType.TypeCategory == Interface {
+		if len(args) > 0 {
+			argStr := make([]string, len(args))
+			for i, a := range args {
+				argStr[i] = a.String()
+			}
+			n.AddErrorf(msgConstructorNotFound, base, strings.Join(argStr, ", "))
+		}
+		return
+	}
+
+	super := n.lookupConstructor(base.dataType, base.String(), args)
+	if super == nil {
+		return
+	}
+
+	// the anonymous class is a subclass, which can call a protected constructor
+	if super.accessMod&text.Protected == 0 {
+		n.checkAccess(super.String(), super.accessMod, base.dataType.base(), nil)
+	}
+
+	anonymous.constructors = []*MethodSymbol{{
+		DataType{anonymous, false},
+		text.Public,
+		anonymous.name,
+		super.args,
+		false,
+		nil,
+		super.declared(),
+		false,
+		super.isExpanded,
+	}}
 }
 
 func (n *NameAnalyzer) VisitBinOp(bin *text.BinOp) {}
@@ -651,6 +1463,11 @@ func (n *NameAnalyzer) mustBeTypeof(left, right DataType, types ...string) bool
 func (n *NameAnalyzer) VisitAfterBinOp(bin *text.BinOp) {
 	right, _ := n.stack.Pop()
 	left, _ := n.stack.Pop()
+	if hasErrorType(left, right) {
+		n.stack.Push(errorType)
+		return
+	}
+
 	evaluate := func(being string, types ...string) {
 		if !n.mustBeTypeof(left, right, types...) {
 			return
@@ -662,6 +1479,7 @@ func (n *NameAnalyzer) VisitAfterBinOp(bin *text.BinOp) {
 		})
 	}
 
+	isNumericPair := IsNumeric(left) && IsNumeric(right)
 	operator := bin.GetOperator()
 	switch operator.Type {
 	case text.Addition,
@@ -669,26 +1487,356 @@ func (n *NameAnalyzer) VisitAfterBinOp(bin *text.BinOp) {
 		text.Multiplication,
 		text.Division,
 		text.Modulus:
+		if isNumericPair {
+			n.stack.Push(binaryNumericPromotion(left, right))
+			return
+		}
 		evaluate("int", "int")
 
 	case text.GreaterThan,
 		text.GreaterThanEqual,
 		text.LessThan,
 		text.LessThanEqual:
+		if isNumericPair {
+			n.stack.Push(DataType{n.typeTable.Lookup("boolean"), false})
+			return
+		}
 		evaluate("boolean", "int")
 
 	case text.Or, text.And:
 		evaluate("boolean", "boolean")
 
 	case text.Equal, text.NotEqual:
+		if isNumericPair || isComparableReference(left, right) {
+			n.stack.Push(DataType{n.typeTable.Lookup("boolean"), false})
+			return
+		}
+
+		if isReference(left) && isReference(right) {
+			n.AddErrorf(msgIncomparableTypes, left, right)
+			n.stack.Push(DataType{n.typeTable.Lookup("boolean"), false})
+			return
+		}
 		evaluate("boolean", "boolean", "char", "int")
+
+	case text.BitwiseAnd, text.BitwiseOr, text.BitwiseXor:
+		// non short-circuit logic on booleans, bitwise on integrals
+		if left.Name() == "boolean" && right.Name() == "boolean" {
+			evaluate("boolean", "boolean")
+			return
+		}
+		n.evaluateIntegral(left, right)
+
+	case text.LeftShift, text.RightShift, text.UnsignedRightShift:
+		if n.checkIntegral(left, right) {
+			// type of shift expression only depend on the left operand
+			n.stack.Push(unaryNumericPromotion(left))
+		}
+	}
+}
+
+// checkIntegral check operands of bitwise and shift operator
+func (n *NameAnalyzer) checkIntegral(left, right DataType) bool {
+	for _, operand := range []DataType{left, right} {
+		if !isIntegral(operand) {
+			n.AddErrorf(msgExpectingTypeof, "byte, short, char, int, long", operand)
+			return false
+		}
+	}
+	return true
+}
+
+// evaluateIntegral check operands of bitwise operator,
+// and push the promoted type of both operand.
+func (n *NameAnalyzer) evaluateIntegral(left, right DataType) {
+	if n.checkIntegral(left, right) {
+		n.stack.Push(binaryNumericPromotion(left, right))
+	}
+}
+
+func (n *NameAnalyzer) VisitUnaryOp(*text.UnaryOp) {}
+func (n *NameAnalyzer) VisitAfterUnaryOp(unary *text.UnaryOp) {
+	operand, _ := n.stack.Pop()
+	if isErrorType(operand) {
+		n.stack.Push(errorType)
+		return
+	}
+
+	if !IsNumeric(operand) {
+		n.AddErrorf(msgExpectingTypeof, "numeric", operand)
+		return
+	}
+
+	n.stack.Push(unaryNumericPromotion(operand))
+}
+
+func (n *NameAnalyzer) VisitCast(*text.Cast) {}
+func (n *NameAnalyzer) VisitAfterCast(cast *text.Cast) {
+	operand, _ := n.stack.Pop()
+	target, ok := n.resolveType(cast.Type)
+	if !ok {
+		n.stack.Push(target)
+		return
+	}
+
+	if !isConvertible(operand, target, castingContext) {
+		n.AddErrorf(msgCannotCast, operand, target)
+	}
+
+	n.stack.Push(target)
+}
+
+// VisitLambda declare the class of a lambda, which implement its target
+// type. The body is analyzed as the method of the class, so the local
+// variables used inside are captured like those of a local class.
+func (n *NameAnalyzer) VisitLambda(l *text.Lambda) {
+	target, function := n.lambdaTarget(l)
+	lambda := n.declareLambda(l.Name, target, function)
+	if function != nil && len(function.args) != len(l.Params) {
+		n.AddErrorf(msgLambdaParameterCount, len(function.args), len(l.Params))
+	}
+
+	n.enterNestedClass()
+	n.newClassScope(fmt.Sprintf("lambda-%s", l.Name), lambda)
+	n.newScope(fmt.Sprintf("method-%s", l.Name))
+	n.localCount = 1
+
+	returnType := DataType{NewType("void", Primitive), false}
+	if function != nil {
+		returnType = function.DataType
+	}
+	n.stack.Push(DataType{lambda, false})
+	n.stack.Push(returnType)
+	if body, ok := l.Body.(text.Expression); ok && isLambda(body) {
+		n.targets[body] = returnType
+	}
+
+	for i, param := range l.Params {
+		var typeof DataType
+		if function != nil && i < len(function.args) {
+			typeof = function.args[i]
+		}
+
+		if len(param.Type.Name) > 0 {
+			declared, ok := n.resolveType(param.Type)
+			if ok && typeof.dataType != nil && !isIdentity(declared, typeof) {
+				n.AddErrorf(msgExpectingTypeof, typeof, declared)
+			}
+			typeof = declared
+		}
+
+		if typeof.dataType == nil {
+			typeof = DataType{javaLangObject, false}
+		}
+
+		if exist, _ := n.scope.Lookup(param.Name, false); exist != nil {
+			n.AddErrorf(msgParameterAlreadyDeclared, param.Name)
+			continue
+		}
+		n.Insert(&FieldSymbol{typeof, param.Name})
+	}
+
+	// a block body is the method body
+	n.isScopeCreated = l.IsBlock()
+}
+
+func (n *NameAnalyzer) VisitAfterLambda(l *text.Lambda) {
+	lambda := n.typeTable[l.Name]
+	if !l.IsBlock() {
+		n.checkLambdaBody(l.Body.(text.Expression), lambda.function)
+		n.popScope()
+	}
+
+	n.popScope()
+	n.exitNestedClass()
+	n.pushLambda(lambda)
+}
+
+// checkLambdaBody check the value of an expression body against the return type
+func (n *NameAnalyzer) checkLambdaBody(body text.Expression, function *MethodSymbol) {
+	// the body may push nothing, e.g. System.out.println
+	hasValue := len(n.stack) > 2
+	var value DataType
+	if hasValue {
+		value, _ = n.stack.Pop()
+	}
+
+	if function == nil {
+		return
+	}
+
+	returnType := function.DataType
+	if returnType.Name() == "void" {
+		if hasValue && !isStatementExpression(body) {
+			n.AddErrorf(msgVoidDontHaveType, value)
+		}
+		return
+	}
+
+	if !hasValue || value.Name() == "void" {
+		n.AddErrorf(msgExpectingReturnTypeOf, returnType, "void")
+	} else if !isReturnable(value, returnType) {
+		n.AddErrorf(msgExpectingReturnTypeOf, returnType, value)
+	}
+}
+
+// VisitMethodReference declare the class of a method reference,
+// its method only call the referred method.
+func (n *NameAnalyzer) VisitMethodReference(m *text.MethodReference) {
+	target, function := n.lambdaTarget(m)
+	lambda := n.declareLambda(m.Name, target, function)
+	if function != nil {
+		lambda.reference = n.resolveReference(m, lambda, function)
+	}
+	n.pushLambda(lambda)
+}
+
+// lambdaTarget get the target type of a lambda along with the
+// method it implements, the method is nil if there is none.
+func (n *NameAnalyzer) lambdaTarget(ex text.Expression) (DataType, *MethodSymbol) {
+	target, ok := n.targets[ex]
+	delete(n.targets, ex)
+	if !ok {
+		n.AddError(msgLambdaNotExpected)
+		return target, nil
+	}
+
+	if target.dataType == nil {
+		target = n.stack[len(n.stack)-1]
+	}
+
+	function := target.functionalMethod()
+	if function == nil {
+		n.AddErrorf(msgNotFunctionalInterface, target)
+	}
+	return target, function
+}
+
+// declareLambda create the class of a lambda, which is an inner
+// class when it is declared where this is available.
+func (n *NameAnalyzer) declareLambda(name string, target DataType, function *MethodSymbol) *TypeSymbol {
+	lambda := NewType(name, Class)
+	lambda.implements = target.dataType
+	lambda.function = function
+	if this, _ := n.scope.Lookup("this", true); this != nil {
+		lambda.outer = this.Type().dataType
+		lambda.isInner = true
+	}
+
+	n.typeTable[name] = lambda
+	return lambda
+}
+
+// pushLambda push the type of a lambda, which is its target type
+func (n *NameAnalyzer) pushLambda(lambda *TypeSymbol) {
+	typeof := lambda.implements
+	if typeof == nil {
+		typeof = javaLangObject
+	}
+	n.stack.Push(DataType{typeof, false})
+}
+
+// resolveReference find the method of a method reference that accept
+// the parameters of function, nil if there is none.
+func (n *NameAnalyzer) resolveReference(m *text.MethodReference, lambda *TypeSymbol, function *MethodSymbol) *methodReference {
+	args := function.args
+	ref := &methodReference{}
+	if m.Target == "this" {
+		this, _ := n.scope.Lookup("this", true)
+		if this == nil {
+			n.AddErrorf(msgVariableDoesNotExist, m.Target)
+			return nil
+		}
+
+		ref.kind, ref.owner = boundReference, this.Type().dataType
+		ref.method = ref.owner.LookupMethodByArgs(m.Method, args)
+	} else if member, _, crossed := n.scope.lookupCrossing(m.Target); member != nil {
+		local, isLocal := member.(*FieldSymbol)
+		if !isLocal || local.isArray {
+			n.AddErrorf(msgMethodReferenceNotFound, m.Target, m.Method, typesString(args))
+			return nil
+		}
+
+		// the variable is kept by the class like a captured one
+		n.captureVariable(local, crossed)
+		lambda.capture(local)
+		ref.kind, ref.owner, ref.receiver = boundReference, local.dataType, local
+		ref.method = ref.owner.LookupMethodByArgs(m.Method, args)
+	} else {
+		n.lookupType(m.Target)
+		typeof, ok := n.resolveType(text.NamedType{Name: m.Target})
+		if !ok {
+			return nil
+		}
+
+		ref.owner = typeof.dataType
+		n.resolveTypeReference(m, ref, args)
+	}
+
+	if ref.method == nil {
+		n.AddErrorf(msgMethodReferenceNotFound, m.Target, m.Method, typesString(args))
+		return nil
+	}
+
+	switch ref.kind {
+	case constructorReference:
+		n.checkAccess(ref.method.String(), ref.method.accessMod, ref.owner.base(), ref.owner)
+	case staticReference:
+		owner := ref.owner.methodOwner(ref.method)
+		if n.checkAccess(ref.method.String(), ref.method.accessMod, owner, nil) && owner != nil {
+			n.useNestMember(methodKey(owner, ref.method), ref.method.accessMod, owner)
+		}
+	default:
+		owner := ref.owner.methodOwner(ref.method)
+		if n.checkAccess(ref.method.String(), ref.method.accessMod, owner, ref.owner) && owner != nil {
+			n.useNestMember(methodKey(owner, ref.method), ref.method.accessMod, owner)
+		}
+	}
+
+	returnType, result := function.DataType, ref.method.DataType
+	if ref.kind == constructorReference {
+		result = DataType{ref.owner, false}
+	}
+
+	if returnType.Name() != "void" && !isReturnable(result, returnType) {
+		n.AddErrorf(msgExpectingReturnTypeOf, returnType, result)
+	}
+	return ref
+}
+
+// resolveTypeReference find the method of a method reference whose
+// target is a type, which is either a constructor, a static method,
+// or an instance method that is called on the first parameter.
+func (n *NameAnalyzer) resolveTypeReference(m *text.MethodReference, ref *methodReference, args []DataType) {
+	if m.Method == "new" {
+		ref.kind = constructorReference
+		ref.method = ref.owner.LookupConstructor(args)
+		if base := ref.owner.base(); base.isInner && !n.hasEnclosingInstance(base.outer) {
+			n.AddErrorf(msgEnclosingInstanceRequired, base.outer.name)
+		}
+		return
+	}
+
+	if method := ref.owner.LookupMethodByArgs(m.Method, args); method != nil && method.isStatic {
+		ref.kind, ref.method = staticReference, method
+		return
+	}
+
+	receiver := DataType{ref.owner, false}
+	if len(args) == 0 || args[0].dataType == nil || !isConvertible(args[0], receiver, invocationContext) {
+		return
+	}
+
+	if method := ref.owner.LookupMethodByArgs(m.Method, args[1:]); method != nil && !method.isStatic {
+		ref.kind, ref.method = unboundReference, method
 	}
 }
 
 func (n *NameAnalyzer) VisitConstant(ex text.Expression) {
 	typeof, _ := ex.NodeContent()
 	switch typeof {
-	case "String", "int", "char", "boolean", "null":
+	case "String", "int", "char", "boolean", "null",
+		"long", "float", "double":
 		dataType := DataType{
 			n.typeTable.Lookup(typeof),
 			false,
@@ -696,16 +1844,42 @@ func (n *NameAnalyzer) VisitConstant(ex text.Expression) {
 		n.stack.Push(dataType)
 	case "this":
 		this, _ := n.scope.Lookup("this", true)
+		if qualifier := ex.(*text.This).Qualifier; len(qualifier) > 0 {
+			n.qualifiedThis(this, qualifier)
+			return
+		}
+
 		n.stack.Push(this.Type())
 		n.curField = &FieldSymbol{
 			DataType{this.Type().dataType, false},
 			"this",
 		}
+	case "super":
+		if super := n.superOf(); super != nil {
+			n.stack.Push(DataType{super, false})
+			n.curField = &FieldSymbol{DataType{super, false}, "super"}
+		}
 	}
 }
 
-func (n *NameAnalyzer) VisitSystemOut() {}
-func (n *NameAnalyzer) VisitAfterSystemOut() {
-	n.curField = nil
-	n.stack.Pop()
+// qualifiedThis get the enclosing instance of the qualifier type,
+// the members of an enclosing class are accessible like its own.
+func (n *NameAnalyzer) qualifiedThis(this TypeMember, qualifier string) {
+	typeof, _ := n.resolveType(text.NamedType{Name: qualifier})
+	if typeof.dataType == nil {
+		n.stack.Push(typeof)
+		return
+	}
+
+	ok := false
+	if this != nil {
+		_, ok = outerPath(this.Type().dataType, typeof.dataType)
+	}
+
+	if !ok {
+		n.AddErrorf(msgEnclosingInstanceRequired, typeof)
+	}
+
+	n.stack.Push(typeof)
+	n.curField = &FieldSymbol{typeof, "this"}
 }
@@ -2,8 +2,10 @@ package lang
 
 import (
 	"fmt"
+	"reflect"
 	"testing"
 
+	"github.com/gumelarme/yava/pkg/classfile"
 	"github.com/gumelarme/yava/pkg/text"
 )
 
@@ -13,6 +15,10 @@ var (
 	mockBoolean DataType
 	mockChar    DataType
 	mockString  DataType
+	mockByte    DataType
+	mockLong    DataType
+	mockFloat   DataType
+	mockDouble  DataType
 	mockHuman   DataType
 	mockPerson  DataType
 )
@@ -23,6 +29,10 @@ func init() {
 	mockBoolean = DataType{PrimitiveBoolean, false}
 	mockChar = DataType{PrimitiveChar, false}
 	mockString = DataType{PrimitiveString, false}
+	mockByte = DataType{PrimitiveByte, false}
+	mockLong = DataType{PrimitiveLong, false}
+	mockFloat = DataType{PrimitiveFloat, false}
+	mockDouble = DataType{PrimitiveDouble, false}
 
 	humanClass := NewType("Human", Class)
 	humanClass.Properties["age"] = &PropertySymbol{
@@ -31,6 +41,7 @@ func init() {
 			DataType{PrimitiveInt, false},
 			"age",
 		},
+		nil,
 	}
 	mockHuman = DataType{humanClass, false}
 
@@ -253,6 +264,180 @@ func TestNameAnalyzer_VariableDeclaration_error(t *testing.T) {
 	checkHasError(&human, fmt.Sprintf(msgTypeNotExist, "SomethingDidNotExist"))
 }
 
+func TestNameAnalyzer_VariableDeclaration_primitive(t *testing.T) {
+	data := []struct {
+		varType  string
+		value    text.Expression
+		valueTyp DataType
+		isValid  bool
+	}{
+		{"long", text.Num(1), mockInt, true},
+		{"double", text.Long(1), mockLong, true},
+		{"float", text.Char('a'), mockChar, true},
+		{"byte", text.Num(127), mockInt, true},
+		{"char", text.Num(65), mockInt, true},
+		{"byte", text.Num(128), mockInt, false},
+		{"short", text.Long(1), mockLong, false},
+		{"int", text.Double(1), mockDouble, false},
+		{"float", text.Double(1), mockDouble, false},
+		{"char", text.Num(-1), mockInt, false},
+	}
+
+	for _, d := range data {
+		nameAnalyzer := NewNameAnalyzer(NewTypeAnalyzer().table)
+		nameAnalyzer.newScope("func")
+		nameAnalyzer.stack.Push(d.valueTyp)
+		nameAnalyzer.VisitAfterVariableDeclaration(&text.VariableDeclaration{
+			Type:  text.NamedType{Name: d.varType, IsArray: false},
+			Name:  "a",
+			Value: d.value,
+		})
+
+		if hasError := len(nameAnalyzer.Errors()) > 0; hasError == d.isValid {
+			t.Errorf("Declaring %s with %s should be valid: %v",
+				d.varType, text.PrettyPrint(d.value), d.isValid)
+		}
+	}
+}
+
+func TestNameAnalyzer_VariableDeclaration_slot(t *testing.T) {
+	nameAnalyzer := NewNameAnalyzer(NewTypeAnalyzer().table)
+	nameAnalyzer.newScope("func")
+	for _, name := range []string{"long", "int", "double"} {
+		nameAnalyzer.VisitAfterVariableDeclaration(&text.VariableDeclaration{
+			Type: text.NamedType{Name: name, IsArray: false},
+			Name: name + "Var",
+		})
+	}
+
+	expect := map[string]int{"longVar": 0, "intVar": 2, "doubleVar": 3}
+	for name, address := range expect {
+		if _, addr := nameAnalyzer.scope.Lookup(name, false); addr != address {
+			t.Errorf("Variable %s should be at %d but got %d", name, address, addr)
+		}
+	}
+
+	if nameAnalyzer.localCount != 5 {
+		t.Errorf("Long and double should take two slot, expecting 5 local but got %d", nameAnalyzer.localCount)
+	}
+}
+
+func TestNameAnalyzer_AssignmentStatement_primitive(t *testing.T) {
+	data := []struct {
+		operator text.TokenType
+		target   DataType
+		right    text.Expression
+		rightTyp DataType
+		isValid  bool
+	}{
+		{text.Assignment, mockLong, text.Num(1), mockInt, true},
+		{text.Assignment, mockByte, text.Num(1), mockInt, true},
+		{text.Assignment, mockInt, text.Long(1), mockLong, false},
+		{text.AdditionAssignment, mockInt, text.Double(1.5), mockDouble, true},
+		{text.AdditionAssignment, mockByte, text.Num(1000), mockInt, true},
+		{text.AdditionAssignment, mockBoolean, text.Num(1), mockInt, false},
+		{text.AdditionAssignment, mockString, text.String("b"), mockString, true},
+		{text.AdditionAssignment, mockString, text.Num(1), mockInt, true},
+		{text.SubtractionAssignment, mockString, text.String("b"), mockString, false},
+		{text.BitwiseXorAssignment, mockBoolean, text.Boolean(false), mockBoolean, true},
+		{text.BitwiseAndAssignment, mockBoolean, text.Boolean(true), mockBoolean, true},
+		{text.BitwiseOrAssignment, mockBoolean, text.Boolean(true), mockBoolean, true},
+		{text.BitwiseOrAssignment, mockBoolean, text.Num(1), mockInt, false},
+		{text.MultiplicationAssignment, mockBoolean, text.Boolean(true), mockBoolean, false},
+		{text.LeftShiftAssignment, mockLong, text.Num(1), mockInt, true},
+		{text.LeftShiftAssignment, mockDouble, text.Num(1), mockInt, false},
+		{text.RightShiftAssignment, mockInt, text.Double(1.5), mockDouble, false},
+		{text.BitwiseAndAssignment, mockInt, text.Double(1.5), mockDouble, false},
+		{text.BitwiseXorAssignment, mockFloat, text.Num(1), mockInt, false},
+		{text.BitwiseAndAssignment, mockBoolean, text.Num(1), mockInt, false},
+	}
+
+	for _, d := range data {
+		nameAnalyzer := NewNameAnalyzer(NewTypeAnalyzer().table)
+		nameAnalyzer.stack.Push(d.target)
+		nameAnalyzer.stack.Push(d.rightTyp)
+
+		operator := text.Token{}
+		operator.Type = d.operator
+		nameAnalyzer.VisitAfterAssignmentStatement(&text.AssignmentStatement{
+			Operator: operator,
+			Right:    d.right,
+		})
+
+		if hasError := len(nameAnalyzer.Errors()) > 0; hasError == d.isValid {
+			t.Errorf("Assigning %s %s %s should be valid: %v",
+				d.target, d.operator, text.PrettyPrint(d.right), d.isValid)
+		}
+	}
+}
+
+func TestNameAnalyzer_VisitAfterUnaryOp(t *testing.T) {
+	data := []struct {
+		operand DataType
+		result  DataType
+	}{
+		{mockInt, mockInt},
+		{mockByte, mockInt},
+		{mockChar, mockInt},
+		{mockLong, mockLong},
+		{mockDouble, mockDouble},
+	}
+
+	for _, d := range data {
+		nameAnalyzer := NewNameAnalyzer(NewTypeAnalyzer().table)
+		nameAnalyzer.stack.Push(d.operand)
+		nameAnalyzer.VisitAfterUnaryOp(&text.UnaryOp{})
+
+		if result, _ := nameAnalyzer.stack.Pop(); result != d.result {
+			t.Errorf("Unary operation on %s should result in %s but got %s", d.operand, d.result, result)
+		}
+	}
+
+	for _, invalid := range []DataType{mockBoolean, mockString} {
+		nameAnalyzer := NewNameAnalyzer(NewTypeAnalyzer().table)
+		nameAnalyzer.stack.Push(invalid)
+		nameAnalyzer.VisitAfterUnaryOp(&text.UnaryOp{})
+
+		expect := fmt.Sprintf(msgExpectingTypeof, "numeric", invalid)
+		if err := nameAnalyzer.Errors(); len(err) == 0 || err[0].Error() != expect {
+			t.Errorf("Unary operation on %s should add error of: %s", invalid, expect)
+		}
+	}
+}
+
+func TestNameAnalyzer_VisitAfterCast(t *testing.T) {
+	data := []struct {
+		operand DataType
+		target  string
+		isValid bool
+	}{
+		{mockDouble, "int", true},
+		{mockInt, "byte", true},
+		{mockChar, "long", true},
+		{mockBoolean, "boolean", true},
+		{mockBoolean, "int", false},
+		{mockInt, "boolean", false},
+		{mockString, "int", false},
+	}
+
+	for _, d := range data {
+		nameAnalyzer := NewNameAnalyzer(NewTypeAnalyzer().table)
+		nameAnalyzer.stack.Push(d.operand)
+		nameAnalyzer.VisitAfterCast(&text.Cast{
+			Type: text.NamedType{Name: d.target, IsArray: false},
+			Exp:  nil,
+		})
+
+		if hasError := len(nameAnalyzer.Errors()) > 0; hasError == d.isValid {
+			t.Errorf("Casting %s to %s should be valid: %v", d.operand, d.target, d.isValid)
+		}
+
+		if result, _ := nameAnalyzer.stack.Pop(); result.Name() != d.target {
+			t.Errorf("Casting should result in %s but got %s", d.target, result)
+		}
+	}
+}
+
 func TestNameAnalyzer_ForStatement(t *testing.T) {
 	human := *classHuman
 	newMethodGetAge := *methodGetAge
@@ -316,11 +501,63 @@ func TestNameAnalyzer_VisitBinOp(t *testing.T) {
 			mockBoolean,
 			[]DataType{mockBoolean, mockBoolean},
 		},
+		{
+			[]text.TokenType{text.BitwiseAnd, text.BitwiseOr, text.BitwiseXor},
+			mockBoolean,
+			[]DataType{mockBoolean, mockBoolean},
+		},
+		{
+			[]text.TokenType{text.BitwiseAnd, text.BitwiseOr, text.BitwiseXor},
+			mockInt,
+			[]DataType{mockInt, mockChar},
+		},
+		{
+			[]text.TokenType{text.LeftShift, text.RightShift, text.UnsignedRightShift},
+			mockInt,
+			[]DataType{mockChar, mockInt},
+		},
+		// numeric promotion
+		{
+			[]text.TokenType{text.Addition, text.Subtraction, text.Multiplication, text.Division, text.Modulus},
+			mockInt,
+			[]DataType{mockByte, mockChar},
+		},
+		{
+			[]text.TokenType{text.Addition, text.Subtraction, text.Multiplication, text.Division, text.Modulus},
+			mockLong,
+			[]DataType{mockInt, mockLong},
+		},
+		{
+			[]text.TokenType{text.Addition, text.Subtraction, text.Multiplication, text.Division, text.Modulus},
+			mockDouble,
+			[]DataType{mockFloat, mockDouble},
+		},
+		{
+			[]text.TokenType{text.GreaterThan, text.LessThanEqual, text.Equal, text.NotEqual},
+			mockBoolean,
+			[]DataType{mockLong, mockFloat},
+		},
+		{
+			[]text.TokenType{text.BitwiseAnd, text.BitwiseOr, text.BitwiseXor},
+			mockLong,
+			[]DataType{mockByte, mockLong},
+		},
+		{
+			[]text.TokenType{text.LeftShift, text.RightShift, text.UnsignedRightShift},
+			mockLong,
+			[]DataType{mockLong, mockInt},
+		},
+		{
+			[]text.TokenType{text.LeftShift, text.RightShift, text.UnsignedRightShift},
+			mockInt,
+			[]DataType{mockByte, mockLong},
+		},
 	}
 
+	table := NewTypeAnalyzer().table
 	for _, d := range data {
 		for _, token := range d.tokens {
-			nameAnalyzer := NewNameAnalyzer(nil)
+			nameAnalyzer := NewNameAnalyzer(table)
 			nameAnalyzer.stack = append(nameAnalyzer.stack, d.operandType...)
 
 			operator := text.Token{}
@@ -328,6 +565,44 @@ func TestNameAnalyzer_VisitBinOp(t *testing.T) {
 
 			binop := text.NewBinOp(operator, nil, nil)
 			nameAnalyzer.VisitAfterBinOp(&binop)
+
+			if result, _ := nameAnalyzer.stack.Pop(); result != d.result {
+				t.Errorf("%s should result in %s but got %s", token, d.result, result)
+			}
+		}
+	}
+}
+
+func TestNameAnalyzer_VisitBinOp_bitwiseError(t *testing.T) {
+	data := []struct {
+		token       text.TokenType
+		operandType []DataType
+		invalid     DataType
+	}{
+		{text.BitwiseAnd, []DataType{mockBoolean, mockInt}, mockBoolean},
+		{text.BitwiseXor, []DataType{mockInt, mockString}, mockString},
+		{text.LeftShift, []DataType{mockBoolean, mockBoolean}, mockBoolean},
+		{text.UnsignedRightShift, []DataType{mockInt, mockHuman}, mockHuman},
+	}
+
+	for _, d := range data {
+		nameAnalyzer := NewNameAnalyzer(NewTypeAnalyzer().table)
+		nameAnalyzer.stack = append(nameAnalyzer.stack, d.operandType...)
+
+		operator := text.Token{}
+		operator.Type = d.token
+		binop := text.NewBinOp(operator, nil, nil)
+		nameAnalyzer.VisitAfterBinOp(&binop)
+
+		err := nameAnalyzer.Errors()
+		if len(err) == 0 {
+			t.Errorf("%s on %v should add an error.", d.token, d.operandType)
+			continue
+		}
+
+		expect := fmt.Sprintf(msgExpectingTypeof, "byte, short, char, int, long", d.invalid)
+		if err[0].Error() != expect {
+			t.Errorf("Expecting error of: \n%s \nbut got: \n%s", expect, err[0].Error())
 		}
 	}
 }
@@ -425,6 +700,9 @@ func TestNameAnalyzer_VisitConstant(t *testing.T) {
 		{text.Boolean(true), mockBoolean},
 		{text.Char('a'), mockChar},
 		{text.String("Hello"), mockString},
+		{text.Long(1), mockLong},
+		{text.Float(1.5), mockFloat},
+		{text.Double(1.5), mockDouble},
 	}
 
 	for _, d := range data {
@@ -470,3 +748,1184 @@ func TestNameAnalyzer_VisitConstant(t *testing.T) {
 		t.Errorf("name.curField should contain a field symbol but got %s instead of %s", nameAnalyzer.curField, thisSymbol)
 	}
 }
+
+func withAnalyzedText(content string, do func(*NameAnalyzer)) {
+	lexer := text.NewLexer(text.NewStringScanner(content))
+	parser := text.NewParser(&lexer)
+	ast := parser.Compile()
+	withTypeAnal(ast, do)
+}
+
+// mockError is a statement of a mock program and the first error it should have
+type mockError struct {
+	stmt   string
+	expect string
+}
+
+// checkMockProgram put every statement into the template, a valid statement
+// should not have any error while an invalid one should have the expected error.
+func checkMockProgram(t *testing.T, template string, valid []string, invalid []mockError) {
+	t.Helper()
+	checkMockProgramWith(t, withAnalyzedText, template, valid, invalid)
+}
+
+func checkMockProgramWith(t *testing.T, analyze func(string, func(*NameAnalyzer)), template string, valid []string, invalid []mockError) {
+	t.Helper()
+	for _, stmt := range valid {
+		analyze(fmt.Sprintf(template, stmt), func(nameAnal *NameAnalyzer) {
+			if errors := nameAnal.Errors(); len(errors) != 0 {
+				t.Errorf("%s should not have any error, but got: %s", stmt, errors[0])
+			}
+		})
+	}
+
+	for _, d := range invalid {
+		analyze(fmt.Sprintf(template, d.stmt), func(nameAnal *NameAnalyzer) {
+			errors := nameAnal.Errors()
+			if len(errors) == 0 {
+				t.Errorf("%s should have an error of: \n%s", d.stmt, d.expect)
+				return
+			}
+
+			if errors[0].Error() != d.expect {
+				t.Errorf("%s expected to have an error of: \n%s \nbut got: \n%s", d.stmt, d.expect, errors[0])
+			}
+		})
+	}
+}
+
+var mockColorEnum = `
+enum Color {
+	RED("r"), GREEN("g");
+	private String code;
+	public int weight;
+	Color(String code) {
+		this.code = code;
+	}
+}
+class Painter {
+	public void paint(Color c, int i) {
+		%s
+	}
+}
+`
+
+func TestNameAnalyzer_Enum(t *testing.T) {
+	valid := []string{
+		`Color red = Color.RED;`,
+		`Color[] all = Color.values();`,
+		`int ordinal = c.ordinal();`,
+		`String name = c.name();`,
+		`int ordinal = Color.GREEN.ordinal();`,
+		`switch (c) { case RED: i = 1; case GREEN: i = 2; default: i = 3; }`,
+		`switch (i) { case 1: i = 1; case 'a': i = 2; }`,
+	}
+	invalid := []mockError{
+		{`Color blue = Color.BLUE;`, fmt.Sprintf(msgTypeDoesNotHaveProperty, "Color", "BLUE")},
+		{`int weight = Color.weight;`, fmt.Sprintf(msgNonStaticField, "weight")},
+		{`int ordinal = Color.ordinal();`, fmt.Sprintf(msgNonStaticMethod, "ordinal()")},
+		{`switch (c) { case BLUE: i = 1; }`, fmt.Sprintf(msgNotEnumConstant, "(#field BLUE)", "Color")},
+		{`switch (c) { case 1: i = 1; }`, fmt.Sprintf(msgNotEnumConstant, "(#int 1)", "Color")},
+		{`switch (c) { case RED: case RED: i = 1; }`, fmt.Sprintf(msgDuplicateCaseLabel, "(#field RED)")},
+		{`switch (i) { case 97: case 'a': i = 1; }`, fmt.Sprintf(msgDuplicateCaseLabel, "(#char 'a')")},
+		{`switch (i) { case i: i = 1; }`, msgCaseMustBeConstant},
+		{`switch (i) { case true: i = 1; }`, msgCaseMustBeConstant},
+		{`switch (1 < 2) { case 1: i = 1; }`, fmt.Sprintf(msgExpectingTypeof, "char, byte, short, int, String or enum", "boolean")},
+	}
+	checkMockProgram(t, mockColorEnum, valid, invalid)
+}
+
+func TestNameAnalyzer_Enum_constructorNotFound(t *testing.T) {
+	content := `enum Color { RED, GREEN(1); }`
+	withAnalyzedText(content, func(nameAnal *NameAnalyzer) {
+		expect := fmt.Sprintf(msgConstructorNotFound, "Color", "int")
+		if errors := nameAnal.Errors(); len(errors) != 1 || errors[0].Error() != expect {
+			t.Errorf("Expecting an error of: \n%s \nbut got: \n%v", expect, errors)
+		}
+	})
+}
+
+var mockSwitch = `
+enum Color {
+	RED, GREEN;
+}
+class Painter {
+	public void reset() {}
+	public void paint(Color c, int i, String s, char ch) {
+		%s
+	}
+}
+`
+
+func TestNameAnalyzer_Switch(t *testing.T) {
+	valid := []string{
+		`switch (i) { case 1, 2: i = 1; break; default: i = 3; }`,
+		`switch (i) { case 1, 2 -> i = 1; case 3 -> { i = 2; } default -> this.reset(); }`,
+		`switch (s) { case "a", "b": i = 1; case "Aa", "BB": i = 2; }`,
+		`switch (ch) { case 'a' -> i = 1; case 98 -> i = 2; }`,
+		`int a = switch (i) { case 1, 2 -> 10; default -> 20; };`,
+		`long a = switch (i) { case 1 -> 10; default -> 20L; };`,
+		`String a = switch (c) { case RED -> "r"; case GREEN -> "g"; };`,
+		`String a = switch (s) { case "a" -> "r"; default -> null; };`,
+		`int a = switch (i) { case 1: i = 2; case 2: yield i; default: { yield 3; } };`,
+		`int a = switch (i) { case 1 -> { int b = 2; yield b; } default -> switch (ch) { case 'a' -> 1; default -> 2; }; };`,
+		`int a = 1 + switch (c) { case RED: yield 1; case GREEN: yield 2; };`,
+		`int yield = 1; yield = yield + 1;`,
+	}
+	invalid := []mockError{
+		{`int a = switch (i) { case 1 -> 10; };`, msgSwitchNotExhaustive},
+		{`int a = switch (c) { case RED -> 10; };`, msgSwitchNotExhaustive},
+		{`int a = switch (i) { case 1: yield 1; default: i = 2; };`, msgSwitchWithoutYield},
+		{`int a = switch (i) { case 1 -> { i = 2; } default -> 2; };`, msgSwitchWithoutYield},
+		{`int a = switch (i) { case 1 -> "one"; default -> 2; };`, fmt.Sprintf(msgIncompatibleYield, "String", "int")},
+		{`String a = switch (i) { case 1 -> 1; default -> 2; };`, fmt.Sprintf(msgExpectingTypeof, "String", "int")},
+		{`switch (i) { case 1: yield 2; }`, msgYieldOutsideSwitch},
+		{`switch (s) { case "a": case "a": i = 1; }`, fmt.Sprintf(msgDuplicateCaseLabel, "(#String \"a\")")},
+		{`switch (s) { case 1: i = 1; }`, fmt.Sprintf(msgExpectingTypeof, "String", "int")},
+		{`switch (i) { case 1, 1 -> i = 1; }`, fmt.Sprintf(msgDuplicateCaseLabel, "(#int 1)")},
+	}
+	checkMockProgram(t, mockSwitch, valid, invalid)
+}
+
+var mockRecord = `
+record Point(int x, int y) {
+	public Point {
+		if (x < 0) {
+			x = 0;
+		}
+	}
+
+	public Point(int v) {
+		this(v, v);
+	}
+
+	public String toString() {
+		return "point";
+	}
+}
+class Shape {
+	public record Size(long width, String unit) {}
+	public void draw(Point p, Shape.Size s, int[] arr) {
+		%s
+	}
+}
+`
+
+func TestNameAnalyzer_Record(t *testing.T) {
+	valid := []string{
+		`int a = p.x() + p.y();`,
+		`Point q = new Point(1); q = new Point(1, 2);`,
+		`boolean b = p.equals(p) && p.equals(s) && p.equals(null) && p.equals(arr);`,
+		`int h = p.hashCode() + s.hashCode();`,
+		`String str = p.toString(); str = s.toString(); str = s.unit();`,
+		`Shape.Size size = new Shape.Size(1L, "cm"); long w = size.width();`,
+	}
+	invalid := []mockError{
+		{`int a = p.x;`, fmt.Sprintf(msgPrivateAccess, "x", "Point")},
+		{`boolean b = p.equals(1);`, fmt.Sprintf(msgMethodNotFound, "equals")},
+		{`int a = p.toString();`, fmt.Sprintf(msgExpectingTypeof, "int", "String")},
+		{`Point q = new Point();`, fmt.Sprintf(msgConstructorNotFound, "Point", "")},
+	}
+	checkMockProgram(t, mockRecord, valid, invalid)
+}
+
+var mockAssert = `
+class Account {
+	public int balance;
+	public void close() {}
+	public void withdraw(int amount, String reason) {
+		%s
+	}
+}
+`
+
+func TestNameAnalyzer_Assert(t *testing.T) {
+	valid := []string{
+		`assert amount > 0;`,
+		`assert amount <= this.balance : "insufficient balance";`,
+		`assert amount != this.balance : amount;`,
+		`assert true : null;`,
+		`if (amount > 0) assert false : reason;`,
+	}
+	invalid := []mockError{
+		{`assert amount;`, fmt.Sprintf(msgExpectingTypeof, "boolean", "int")},
+		{`assert reason : "not empty";`, fmt.Sprintf(msgExpectingTypeof, "boolean", "String")},
+		{`assert amount > 0 : this.close();`, msgAssertVoidMessage},
+		{`assert amount > 0 : missing;`, fmt.Sprintf(msgVariableDoesNotExist, "missing")},
+	}
+	checkMockProgram(t, mockAssert, valid, invalid)
+}
+
+var mockGenerics = `
+interface Comparable<T> {
+	public int compareTo(T other);
+}
+class Shape {
+	public int size;
+}
+class Box<T> {
+	public T value;
+	public Box(T value) {
+		this.value = value;
+	}
+	public T get() {
+		return this.value;
+	}
+}
+class Pair<K, V extends Shape> {
+	public K key;
+	public V val;
+}
+class Item implements Comparable<Item> {
+	public int weight;
+	public int compareTo(Item other) {
+		return this.weight - other.weight;
+	}
+}
+class Util {
+	public <T extends Comparable<T>> T max(T a, T b) {
+		return a;
+	}
+	public void run(Box<String> b, Pair<String, Shape> p, Item item) {
+		%s
+	}
+}
+`
+
+func TestNameAnalyzer_Generics(t *testing.T) {
+	valid := []string{
+		`String s = b.get();`,
+		`String s = b.value;`,
+		`Box<Box<String>> bb = new Box<Box<String>>(b);`,
+		`Shape s = p.val;`,
+		`int size = p.val.size;`,
+		`Item i = this.max(item, item);`,
+	}
+	invalid := []mockError{
+		{`Box<int> a = null;`, fmt.Sprintf(msgPrimitiveTypeArgument, "int")},
+		{`Box<String, String> a = null;`, fmt.Sprintf(msgWrongTypeArgumentCount, "Box", 1, 2)},
+		{`Pair<String, String> a = null;`, fmt.Sprintf(msgTypeArgumentNotWithinBound, "String", "V", "Shape")},
+		{`Box<String> a = new Box<String>(1);`, fmt.Sprintf(msgConstructorNotFound, "Box<String>", "int")},
+		{`Box<Shape> a = b;`, fmt.Sprintf(msgExpectingTypeof, "Box<Shape>", "Box<String>")},
+		{`Shape s = b.get();`, fmt.Sprintf(msgExpectingTypeof, "Shape", "String")},
+	}
+	checkMockProgram(t, mockGenerics, valid, invalid)
+}
+
+var mockVarargs = `
+class Point {
+	public Point(String name, int... coords) {}
+}
+class Calc {
+	public int sum(int... values) {
+		return 0;
+	}
+	public String pick(int a) {
+		return "one";
+	}
+	public int pick(int... a) {
+		return 0;
+	}
+	public boolean wide(long a) {
+		return true;
+	}
+	public int wide(int... a) {
+		return 0;
+	}
+	public <T> T first(T head, T... rest) {
+		return head;
+	}
+	public void run(int[] values) {
+		%s
+	}
+}
+`
+
+func TestNameAnalyzer_Varargs(t *testing.T) {
+	valid := []string{
+		`int a = this.sum();`,
+		`int a = this.sum(1);`,
+		`int a = this.sum(1, 2, 'c');`,
+		`int a = this.sum(values);`,
+		`String s = this.pick(1);`,
+		`int a = this.pick(1, 2);`,
+		`boolean b = this.wide(1);`,
+		`String s = this.first("a");`,
+		`String s = this.first("a", "b", "c");`,
+		`Point p = new Point("origin");`,
+		`Point p = new Point("p", 1, 2, 3);`,
+		`Point p = new Point("p", values);`,
+	}
+	invalid := []mockError{
+		{`int a = this.sum(1, "a");`, fmt.Sprintf(msgMethodNotFound, "sum")},
+		{`int a = this.sum(values, 1);`, fmt.Sprintf(msgMethodNotFound, "sum")},
+		{`Point p = new Point();`, fmt.Sprintf(msgConstructorNotFound, "Point", "")},
+		{`Point p = new Point("p", 1.5);`, fmt.Sprintf(msgConstructorNotFound, "Point", "String, double")},
+	}
+	checkMockProgram(t, mockVarargs, valid, invalid)
+}
+
+var mockOverload = `
+class Animal {}
+class Cat extends Animal {}
+class Vet {
+	public Vet() {}
+	public Vet(Animal a, Cat b) {}
+	public Vet(Cat a, Animal b) {}
+	public Vet(Animal a) {}
+	public Vet(Cat c) {}
+	public String f(Animal a) {
+		return "animal";
+	}
+	public int f(Cat c) {
+		return 1;
+	}
+	public boolean h(double d) {
+		return true;
+	}
+	public int h(long l) {
+		return 1;
+	}
+}
+class Clinic extends Vet {
+	public int f(Cat c) {
+		return 2;
+	}
+	public void g(int a, long b) {}
+	public void g(long a, int b) {}
+	public int k(int a, long b) {
+		return 1;
+	}
+	public int k(long a, int b) {
+		return 2;
+	}
+	public void run(Animal a, Cat c) {
+		%s
+	}
+}
+`
+
+func TestNameAnalyzer_Overload(t *testing.T) {
+	valid := []string{
+		`int x = this.f(c);`,
+		`String s = this.f(a);`,
+		`int x = this.h(1);`,
+		`boolean b = this.h(1.5);`,
+		`this.g(1, 2L);`,
+		`this.g(1L, 2);`,
+		`Vet v = new Vet(c);`,
+		`Vet v = new Vet(a);`,
+		`Vet v = new Vet(a, c);`,
+	}
+	invalid := []mockError{
+		{`this.g(1, 2);`, fmt.Sprintf(msgAmbiguousCall, "g", "g(int, long), g(long, int)")},
+		{`Vet v = new Vet(c, c);`, fmt.Sprintf(msgAmbiguousCall, "Vet", "Vet(Animal, Cat), Vet(Cat, Animal)")},
+		{`int x = this.f(a);`, fmt.Sprintf(msgExpectingTypeof, "int", "String")},
+		{`int x = this.k(1, 2) + this.h(1);`, fmt.Sprintf(msgAmbiguousCall, "k", "k(int, long), k(long, int)")},
+	}
+	checkMockProgram(t, mockOverload, valid, invalid)
+
+	// the rest of the expression is analyzed without any other error
+	stmt := `int x = this.k(1, 2) + this.h(1) + this.k(3, 4); String s = this.k(1, 2).x; this.g(null);`
+	ambiguous := fmt.Sprintf(msgAmbiguousCall, "k", "k(int, long), k(long, int)")
+	expect := []string{ambiguous, ambiguous, ambiguous, fmt.Sprintf(msgMethodNotFound, "g")}
+	withAnalyzedText(fmt.Sprintf(mockOverload, stmt), func(nameAnal *NameAnalyzer) {
+		errors := make([]string, len(nameAnal.Errors()))
+		for i, err := range nameAnal.Errors() {
+			errors[i] = err.Error()
+		}
+
+		if !reflect.DeepEqual(errors, expect) {
+			t.Errorf("Expecting the errors of: \n%v \nbut got: \n%v", expect, errors)
+		}
+	})
+}
+
+var mockConversion = `
+interface ISpeak {
+	public void speak();
+}
+class Animal implements ISpeak {
+	public void speak() {}
+}
+class Cat extends Animal {}
+class Shelter {
+	public ISpeak pet;
+	public Animal[] animals;
+	public void adopt(String name, Animal a) {}
+	public String name() {
+		return null;
+	}
+	public byte size() {
+		return 1;
+	}
+	public long count(int c) {
+		return c;
+	}
+	public void run(Cat c, Cat[] cats, char ch) {
+		%s
+	}
+}
+`
+
+func TestNameAnalyzer_Conversion(t *testing.T) {
+	valid := []string{
+		`String s = null;`,
+		`ISpeak s = c;`,
+		`this.pet = c;`,
+		`this.pet = null;`,
+		`this.animals = cats;`,
+		`long l = 0; l = ch;`,
+		`byte b = 0; b = 10;`,
+		`this.adopt(null, c);`,
+		`int[] a = new int[ch];`,
+		`int v = (int) 2.5;`,
+		`Cat a = (Cat) this.pet;`,
+		`Animal[] a = (Animal[]) cats; Cat[] b = (Cat[]) a;`,
+		`Object o = c; String s = (String) o;`,
+		`ISpeak s = (ISpeak) (Object) c;`,
+		`boolean b = c == this.pet; b = cats != this.animals;`,
+		`boolean b = this.name() == null; b = null != c; b = null == null;`,
+	}
+	invalid := []mockError{
+		{`int i = null;`, fmt.Sprintf(msgExpectingTypeof, "int", "null")},
+		{`int i = 0; i = 5L;`, fmt.Sprintf(msgExpectingTypeof, "int", "long")},
+		{`Cat a = this.pet;`, fmt.Sprintf(msgExpectingTypeof, "Cat", "ISpeak")},
+		{`this.pet = cats;`, fmt.Sprintf(msgExpectingTypeof, "ISpeak", "Cat[]")},
+		{`Cat[] a = this.animals;`, fmt.Sprintf(msgExpectingTypeof, "Cat[]", "Animal[]")},
+		{`byte b = 0; b = 1000;`, fmt.Sprintf(msgExpectingTypeof, "byte", "int")},
+		{`int[] a = new int[1.5];`, fmt.Sprintf(msgExpectingTypeof, "int", "double")},
+		{`boolean b = (boolean) 1;`, fmt.Sprintf(msgCannotCast, "int", "boolean")},
+		{`String s = (String) c;`, fmt.Sprintf(msgCannotCast, "Cat", "String")},
+		{`Cat a = (Cat) ch;`, fmt.Sprintf(msgCannotCast, "char", "Cat")},
+		{`Cat a = (Cat) this.animals;`, fmt.Sprintf(msgCannotCast, "Animal[]", "Cat")},
+		{`Cat a = (Kitten) c;`, fmt.Sprintf(msgTypeNotExist, "Kitten")},
+		{`boolean b = c == this.name();`, fmt.Sprintf(msgIncomparableTypes, "Cat", "String")},
+		{`boolean b = cats == this.pet;`, fmt.Sprintf(msgIncomparableTypes, "Cat[]", "ISpeak")},
+		{`boolean b = c == 1;`, fmt.Sprintf(msgExpectingTypeof, "Cat", "int")},
+	}
+	checkMockProgram(t, mockConversion, valid, invalid)
+}
+
+var mockObject = `
+interface INamed {
+	public String toString();
+}
+class Animal implements INamed {
+	public String toString() {
+		return "animal";
+	}
+	public boolean equals(Object o) {
+		return true;
+	}
+}
+class Cat extends Animal {}
+class Rock implements INamed {}
+class Box<T> {
+	public T value;
+	public String show() {
+		return this.value.toString();
+	}
+}
+class Zoo {
+	public void keep(Object o) {}
+	public void run(Cat c, INamed n, String s, int[] arr) {
+		%s
+	}
+}
+`
+
+func TestNameAnalyzer_Object(t *testing.T) {
+	valid := []string{
+		`Object o = c;`,
+		`Object o = arr;`,
+		`Object o = new Object();`,
+		`this.keep(c);`,
+		`this.keep(null);`,
+		`String a = c.toString();`,
+		`boolean b = c.equals(n);`,
+		`int h = c.hashCode();`,
+		`int h = n.hashCode();`,
+		`boolean b = s.equals(c);`,
+		`Object o = c; String a = o.toString();`,
+	}
+	invalid := []mockError{
+		{`Object o = 1;`, fmt.Sprintf(msgExpectingTypeof, "java/lang/Object", "int")},
+		{`Object o = c; Cat d = o;`, fmt.Sprintf(msgExpectingTypeof, "Cat", "java/lang/Object")},
+		{`int a = c.toString();`, fmt.Sprintf(msgExpectingTypeof, "int", "String")},
+		{`boolean b = c.equals();`, fmt.Sprintf(msgMethodNotFound, "equals")},
+	}
+	checkMockProgram(t, mockObject, valid, invalid)
+}
+
+var mockLibrary = `
+class Text {
+	public void run(String s, int a, char c) {
+		%s
+	}
+}
+`
+
+func TestNameAnalyzer_Library(t *testing.T) {
+	valid := []string{
+		`int n = s.length();`,
+		`char first = s.charAt(0);`,
+		`String sub = s.substring(1, a);`,
+		`int i = s.indexOf(c);`,
+		`char[] chars = s.toCharArray();`,
+		`int m = Math.max(a, 2);`,
+		`long l = Math.max(a, 2L);`,
+		`double r = Math.sqrt(a);`,
+		`double pi = Math.PI;`,
+		`int p = Integer.parseInt(s);`,
+		`int big = Integer.MAX_VALUE;`,
+		`String h = Integer.toString(a);`,
+		`boolean d = Character.isDigit(c);`,
+		`String v = String.valueOf(c);`,
+		`StringBuilder sb = new StringBuilder(s); sb.append(c); String out = sb.toString();`,
+	}
+	invalid := []mockError{
+		{`int n = s.length(1);`, fmt.Sprintf(msgMethodNotFound, "length")},
+		{`int m = Math.max(a, 2.5);`, fmt.Sprintf(msgExpectingTypeof, "int", "double")},
+		{`int m = Math.max(s, a);`, fmt.Sprintf(msgMethodNotFound, "max")},
+		{`char u = Character.toUpperCase(a);`, fmt.Sprintf(msgMethodNotFound, "toUpperCase")},
+		{`int n = String.length();`, fmt.Sprintf(msgNonStaticMethod, "length()")},
+		{`StringBuilder sb = new StringBuilder(a);`, fmt.Sprintf(msgConstructorNotFound, "java/lang/StringBuilder", "int")},
+	}
+	checkMockProgram(t, mockLibrary, valid, invalid)
+}
+
+var mockVar = `
+interface Op {
+	public int apply(int a);
+}
+class Box {
+	public int value;
+	public void clear() {}
+	public void run(int[] xs, String[] names) {
+		%s
+	}
+}
+`
+
+func TestNameAnalyzer_Var(t *testing.T) {
+	valid := []string{
+		`var a = 1; int b = a;`,
+		`var s = "text"; String t = s;`,
+		`var b = new Box(); b.clear();`,
+		`var l = 1L; long m = l;`,
+		`final var a = 'c'; char c = a;`,
+		`for (var i = 0; i < 10; i = i + 1) { int j = i; }`,
+		`int var = 1; var = var + 1;`,
+		`var var = 1; int a = var;`,
+		`Op op = (int a) -> a; var f = op; int r = f.apply(2);`,
+		`for (var x : xs) { int y = x; }`,
+		`for (int x : xs) { long y = x; } for (long x : xs) {}`,
+		`for (var s : names) { String t = s; } int s = 1;`,
+		`for (final String s : names) { if (s == null) break; }`,
+		`for (Object o : names) for (var x : xs) continue;`,
+	}
+	invalid := []mockError{
+		{`var a;`, fmt.Sprintf(msgVarWithoutInitializer, "a")},
+		{`var a = null;`, fmt.Sprintf(msgVarNullInitializer, "a")},
+		{`var a = this.clear();`, fmt.Sprintf(msgVarVoidInitializer, "a")},
+		{`var f = (int a) -> a;`, fmt.Sprintf(msgVarLambdaInitializer, "f")},
+		{`var<String> a = "text";`, msgVarNotAllowed},
+		{`var a = 1; String s = a;`, fmt.Sprintf(msgExpectingTypeof, "String", "int")},
+		{`var a = 1; var a = 2;`, fmt.Sprintf(msgVariableAlreadyDeclared, "a")},
+		{`for (var x : this.value) {}`, fmt.Sprintf(msgForEachNotApplicable, "int")},
+		{`for (String s : xs) {}`, fmt.Sprintf(msgExpectingTypeof, "String", "int")},
+		{`for (var[] s : names) {}`, msgVarNotAllowed},
+		{`for (var x : xs) {} int y = x;`, fmt.Sprintf(msgVariableDoesNotExist, "x")},
+		{`for (final int x : xs) { x = 1; }`, fmt.Sprintf(msgCannotAssignFinal, "x")},
+	}
+	checkMockProgram(t, mockVar, valid, invalid)
+}
+
+var mockNested = `
+interface ISpeak {
+	public void speak();
+}
+class Base {
+	public int n;
+	public Base(int n) {
+		this.n = n;
+	}
+}
+class Outer {
+	private int value;
+	class Inner {
+		public int get() {
+			return Outer.this.value;
+		}
+	}
+	static class Nested {
+		public int x;
+	}
+	public void run(int base) {
+		int step = 2;
+		%s
+	}
+	public static void main(String[] args) {
+		%s
+	}
+}
+`
+
+func TestNameAnalyzer_Nested(t *testing.T) {
+	inMethod, inMain := fmt.Sprintf(mockNested, "%s", ""), fmt.Sprintf(mockNested, "", "%s")
+	valid := []string{
+		`Inner i = new Inner();`,
+		`Outer.Inner i = new Outer.Inner();`,
+		`Nested n = new Nested(); int x = n.x;`,
+		`class Counter { public int next() { return step; } } Counter c = new Counter();`,
+		`ISpeak s = new ISpeak() { public void speak() { System.out.println(base); } };`,
+		`Base b = new Base(step) { public int twice() { return this.n * 2; } };`,
+	}
+	invalid := []mockError{
+		{
+			`step = 3; class Counter { public int next() { return step; } }`,
+			fmt.Sprintf(msgCapturedMustBeFinal, "step"),
+		},
+		{
+			`ISpeak s = new ISpeak() { public void speak() { System.out.println(step); } }; step = 3;`,
+			fmt.Sprintf(msgCapturedMustBeFinal, "step"),
+		},
+		{`ISpeak s = new ISpeak(1) { public void speak() {} };`, fmt.Sprintf(msgConstructorNotFound, "ISpeak", "int")},
+	}
+	checkMockProgram(t, inMethod, valid, invalid)
+
+	// there is no enclosing instance in a static method
+	checkMockProgram(t, inMain, nil, []mockError{
+		{`Inner i = new Inner();`, fmt.Sprintf(msgEnclosingInstanceRequired, "Outer")},
+		{`Outer o = Outer.this.value;`, fmt.Sprintf(msgEnclosingInstanceRequired, "Outer")},
+	})
+}
+
+var mockLambda = `
+interface Op {
+	public int apply(int a, int b);
+}
+interface Action {
+	public void run();
+}
+interface Fn<T, R> {
+	public R apply(T t);
+}
+class Box {
+	public int size;
+	public Box(int size) {
+		this.size = size;
+	}
+	public int getSize() {
+		return this.size;
+	}
+	public int plus(int n) {
+		return this.size + n;
+	}
+}
+interface Sizer {
+	public int size(Box b);
+}
+interface Maker {
+	public Box make(int size);
+}
+interface IntOp {
+	public int apply(int n);
+}
+class Main {
+	private int base;
+	public void stop() {}
+	public int take(Op op) {
+		return op.apply(1, 2);
+	}
+	public Op make() {
+		return (a, b) -> a - b;
+	}
+	public void run(int k) {
+		int step = 2;
+		Box box = new Box(1);
+		%s
+	}
+}
+`
+
+func TestNameAnalyzer_Lambda(t *testing.T) {
+	valid := []string{
+		`Op add = (a, b) -> a + b + step + k;`,
+		`Op mul = (int a, int b) -> { int c = a * b; return c + this.base; };`,
+		`Action act = () -> System.out.println(step);`,
+		`Action act = () -> box.getSize();`,
+		`int n = this.take((a, b) -> a * b);`,
+		`Op op = this.make(); op = (a, b) -> b;`,
+		`Fn<String, Box> f = s -> new Box(step);`,
+		`Fn<Box, Fn<Box, Box>> f = a -> b -> a;`,
+		`Sizer s = Box::getSize;`,
+		`Maker m = Box::new;`,
+		`IntOp op = box::plus;`,
+		`Action act = this::stop;`,
+	}
+	invalid := []mockError{
+		{`System.out.println(() -> 1);`, msgLambdaNotExpected},
+		{`Box b = () -> 1;`, fmt.Sprintf(msgNotFunctionalInterface, "Box")},
+		{`Op op = a -> a;`, fmt.Sprintf(msgLambdaParameterCount, 2, 1)},
+		{`Op op = (String a, int b) -> b;`, fmt.Sprintf(msgExpectingTypeof, "int", "String")},
+		{`Op op = (a, b) -> true;`, fmt.Sprintf(msgExpectingReturnTypeOf, "int", "boolean")},
+		{`Op op = (a, b) -> System.out.println(a);`, fmt.Sprintf(msgExpectingReturnTypeOf, "int", "void")},
+		{`Action act = () -> 1;`, fmt.Sprintf(msgVoidDontHaveType, "int")},
+		{`Op op = (a, b) -> a + step; step = 3;`, fmt.Sprintf(msgCapturedMustBeFinal, "step")},
+		{`step = 3; Action act = () -> System.out.println(step);`, fmt.Sprintf(msgCapturedMustBeFinal, "step")},
+		{`Sizer s = Box::nope;`, fmt.Sprintf(msgMethodReferenceNotFound, "Box", "nope", "Box")},
+		{`Action act = Box::new;`, fmt.Sprintf(msgMethodReferenceNotFound, "Box", "new", "")},
+	}
+	checkMockProgram(t, mockLambda, valid, invalid)
+}
+
+var mockFinal = `
+class Limits {
+	public static final int MAX = 10;
+	public static final int NEXT = MAX + 1;
+	public static final char FIRST = 'a';
+	public static final long BIG = MAX * 2;
+	public static final String NAME = "max" + MAX;
+}
+class Point {
+	public final int x;
+	public final int y = 1;
+	public Point(int x) {
+		if (x > 0) {
+			this.x = x;
+		} else {
+			this.x = 0;
+		}
+	}
+	public void move(final int d) {
+		int n = 3;
+		%s
+	}
+}
+`
+
+func TestNameAnalyzer_Final(t *testing.T) {
+	valid := []string{
+		`final int a = 1; int b = a + d;`,
+		`final int a; a = 1;`,
+		`final int a; if (n > 1) { a = 1; } else if (n > 2) { a = 2; } else { a = 3; }`,
+		`while (n > 0) { final int a; a = n; n = n - 1; }`,
+		`switch (n) { case Limits.MAX: n = 1; break; case Limits.NEXT + 1: n = 2; break; }`,
+		`char c = 'b'; switch (c) { case Limits.FIRST: n = 1; break; case Limits.FIRST + 1: n = 2; break; }`,
+		`long l = Limits.BIG; String s = Limits.NAME;`,
+		`final int a; a = 1; int b = a + 1;`,
+		`final int a; if (n > 1) { a = 1; } else { a = 2; } int b = a;`,
+		`final int a; if (n > 1) { a = 1; } else { return; } int b = a;`,
+	}
+	invalid := []mockError{
+		{`d = 2;`, fmt.Sprintf(msgCannotAssignFinal, "d")},
+		{`final int y; int z = y;`, fmt.Sprintf(msgFinalNotInitialized, "y")},
+		{`final int a; if (n > 1) { a = 1; } int b = a;`, fmt.Sprintf(msgFinalNotInitialized, "a")},
+		{`final int a; while (n > 0) { n = a; }`, fmt.Sprintf(msgFinalNotInitialized, "a")},
+		{`final int a = 1; a = 2;`, fmt.Sprintf(msgCannotAssignFinal, "a")},
+		{`final int a; a = 1; a = 2;`, fmt.Sprintf(msgFinalMaybeAssigned, "a")},
+		{`final int a; if (n > 1) { a = 1; } a = 2;`, fmt.Sprintf(msgFinalMaybeAssigned, "a")},
+		{`final int a; a += 1;`, fmt.Sprintf(msgFinalNotInitialized, "a")},
+		{`final int a; while (n > 0) { a = n; }`, fmt.Sprintf(msgFinalAssignedInLoop, "a")},
+		{`this.x = 1;`, fmt.Sprintf(msgCannotAssignFinal, "x")},
+		{`Point p = new Point(1); p.y = 2;`, fmt.Sprintf(msgCannotAssignFinal, "y")},
+		{`switch (n) { case n: n = 1; break; }`, msgCaseMustBeConstant},
+		{`switch (n) { case Limits.MAX: n = 1; break; case 5 + 5: n = 2; break; }`, fmt.Sprintf(msgDuplicateCaseLabel, "(#int 10)")},
+	}
+	checkMockProgram(t, mockFinal, valid, invalid)
+}
+
+func TestNameAnalyzer_FinalProperty_error(t *testing.T) {
+	// the statements are whole programs
+	invalid := []mockError{
+		{`class A { public final int x; }`, fmt.Sprintf(msgFinalNotInitialized, "x")},
+		{`class A { public final int x; public A(int n) { if (n > 0) { this.x = n; } } }`, fmt.Sprintf(msgFinalNotInitialized, "x")},
+		{`class A { public final int x; public A() { this.x = 1; this.x = 2; } }`, fmt.Sprintf(msgFinalMaybeAssigned, "x")},
+		{`class A { public final int x = 1; public A() { this.x = 2; } }`, fmt.Sprintf(msgCannotAssignFinal, "x")},
+		{`class A { public static final byte B = 200; }`, fmt.Sprintf(msgExpectingTypeof, "byte", "int")},
+	}
+	checkMockProgram(t, "%s", nil, invalid)
+}
+
+func TestNameAnalyzer_Initializer(t *testing.T) {
+	// the statements are whole programs
+	valid := []string{
+		`class A { public static int count = 1; static { count = count + 1; } }`,
+		`class A { public static final int MAX; static { MAX = 10; } }`,
+		`class A { public final int x; { this.x = 1; } }`,
+		`class A { public final int x; { x = 1; } public A() { int y = x; } }`,
+		`class A { public int x; { int y = 2; x = y; } public A(int n) { this(); x = n; } public A() {} }`,
+		`class A { public final int x; public A() { this(1); } public A(int n) { this.x = n; } }`,
+		`class A { public static int count; public int id; { id = count; count += 1; } }`,
+	}
+	invalid := []mockError{
+		{`class A { { return; } }`, msgReturnInInitializer},
+		{`class A { public int x; static { x = 1; } }`, fmt.Sprintf(msgNonStaticField, "x")},
+		{`class A { public static final int MAX; }`, fmt.Sprintf(msgFinalNotInitialized, "MAX")},
+		{`class A { public static final int MAX; public A() { MAX = 1; } }`, fmt.Sprintf(msgCannotAssignFinal, "MAX")},
+		{`class A { public final int x; { x = 1; } public A() { this.x = 2; } }`, fmt.Sprintf(msgFinalMaybeAssigned, "x")},
+		{`class A { public final int x; public A() { this.x = 1; } public A(int n) { this(); this.x = n; } }`, fmt.Sprintf(msgFinalMaybeAssigned, "x")},
+		{`class A { public A(int n) { this(true); } }`, fmt.Sprintf(msgConstructorNotFound, "A", "boolean")},
+	}
+	checkMockProgram(t, "%s", valid, invalid)
+}
+
+var mockClasspath = `
+import com.acme.*;
+import com.acme.shapes.Square;
+
+class Circle implements Shape {
+	public double area() { return 1.0; }
+}
+class Loud extends Greeter {
+	public String shout() { return this.greet("hey"); }
+}
+class Main {
+	public Greeter Loud;
+	public void run(Greeter g, String s) {
+		%s
+	}
+}
+`
+
+func withClasspathText(t *testing.T, content string, do func(*NameAnalyzer)) {
+	lexer := text.NewLexer(text.NewStringScanner(content))
+	parser := text.NewParser(&lexer)
+	ast := parser.Compile()
+
+	cp, err := classfile.NewClasspath(testClasspath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cp.Close()
+
+	typeAnal := NewTypeAnalyzer()
+	typeAnal.UseClasspath(cp)
+	ast.Accept(typeAnal)
+	nameAnal := NewNameAnalyzer(typeAnal.GetTypeTable())
+	nameAnal.ImportFrom(typeAnal)
+	ast.Accept(nameAnal)
+	do(nameAnal)
+}
+
+// checkClasspathProgram is checkMockProgram with the types of testClasspath
+func checkClasspathProgram(t *testing.T, template string, valid []string, invalid []mockError) {
+	t.Helper()
+	withClasspath := func(content string, do func(*NameAnalyzer)) {
+		withClasspathText(t, content, do)
+	}
+	checkMockProgramWith(t, withClasspath, template, valid, invalid)
+}
+
+func TestNameAnalyzer_Classpath(t *testing.T) {
+	valid := []string{
+		`Greeter other = new Greeter(s);`,
+		`String hi = g.greet(s);`,
+		`int n = Greeter.twice(Greeter.VERSION);`,
+		`int sum = Greeter.sum(1, 2, 3);`,
+		`Shape shape = g.shape(); double area = shape.area();`,
+		`Shape unit = Shape.unit();`,
+		`Shape square = new Square(2.0);`,
+		`Shape circle = new Circle();`,
+		`Greeter loud = new Loud(); String out = loud.greet(s);`,
+		`StringBuilder sb = g.builder(); sb.append(s);`,
+		`g.name = s;`,
+		// a local or a field shadow a type of the same name
+		`String Greeter = s; int n = Greeter.length();`,
+		`String hi = Loud.greet(s);`,
+	}
+	invalid := []mockError{
+		{`int n = g.twice(s);`, fmt.Sprintf(msgMethodNotFound, "twice")},
+		{`String hi = Greeter.greet(s);`, fmt.Sprintf(msgNonStaticMethod, "greet(String)")},
+		{`Greeter other = new Greeter(1);`, fmt.Sprintf(msgConstructorNotFound, "com/acme/Greeter", "int")},
+		{`Square square = g.shape();`, fmt.Sprintf(msgExpectingTypeof, "com/acme/shapes/Square", "com/acme/Shape")},
+		{`Gone gone = null;`, fmt.Sprintf(msgTypeNotExist, "Gone")},
+		{`long n = g.secret;`, fmt.Sprintf(msgPrivateAccess, "secret", "com/acme/Greeter")},
+	}
+	checkClasspathProgram(t, mockClasspath, valid, invalid)
+
+	// only java.lang and the imported classes are visible
+	checkClasspathProgram(t, "class Main { public void run() { %s } }", nil, []mockError{
+		{`int n = Greeter.twice(1);`, fmt.Sprintf(msgVariableDoesNotExist, "Greeter")},
+		{`Greeter g = null;`, fmt.Sprintf(msgClassNotImported, "Greeter", "com.acme.Greeter")},
+	})
+}
+
+var mockAccess = `
+class Account {
+	private int balance;
+	protected int limit;
+	int branch;
+	private static int count;
+	private Account() {}
+	public Account(int balance) { this.balance = balance; }
+	private int audit() { return this.balance; }
+	protected void close() {}
+	public boolean same(Account other) { return other.balance == this.balance && other.audit() == 0; }
+	public Account copy() { return new Account(); }
+	class Statement {
+		public int total(Account a) { return a.balance + a.audit() + Account.count; }
+	}
+}
+class Saving extends Account {
+	public Saving() {}
+}
+class Bank {
+	public void run(Account a, Saving s) {
+		%s
+	}
+}
+`
+
+func TestNameAnalyzer_Access(t *testing.T) {
+	valid := []string{
+		`int n = a.limit + a.branch;`,
+		`a.close(); s.close();`,
+		`int n = s.limit;`,
+		`Account other = new Account(1);`,
+		`Object o = new Account(2) { public int peek() { return 1; } };`,
+	}
+	invalid := []mockError{
+		{`int n = a.balance;`, fmt.Sprintf(msgPrivateAccess, "balance", "Account")},
+		{`a.balance = 10;`, fmt.Sprintf(msgPrivateAccess, "balance", "Account")},
+		{`int n = s.balance;`, fmt.Sprintf(msgPrivateAccess, "balance", "Account")},
+		{`int n = a.audit();`, fmt.Sprintf(msgPrivateAccess, "audit()", "Account")},
+		{`int n = Account.count;`, fmt.Sprintf(msgPrivateAccess, "count", "Account")},
+		{`Account other = new Account();`, fmt.Sprintf(msgPrivateAccess, "Account()", "Account")},
+		{`Object o = new Account() {};`, fmt.Sprintf(msgPrivateAccess, "Account()", "Account")},
+		// the type of the expression is still known after the error
+		{`String n = a.balance;`, fmt.Sprintf(msgPrivateAccess, "balance", "Account")},
+	}
+	checkMockProgram(t, mockAccess, valid, invalid)
+}
+
+func TestNameAnalyzer_NestAccess(t *testing.T) {
+	content := `interface Action { void run(); }
+	class Outer {
+		private int used;
+		private int unused;
+		private int count() { return 1; }
+		private int size() { return 2; }
+		public void run(Outer o) {
+			Action a = () -> this.count();
+			int n = this.size() + this.unused;
+		}
+		class Inner {
+			private int mine;
+			public int peek(Outer o) { return used + o.count() + this.mine; }
+		}
+	}`
+
+	withAnalyzedText(content, func(nameAnal *NameAnalyzer) {
+		if errors := nameAnal.Errors(); len(errors) != 0 {
+			t.Fatalf("Should not have any error, but got: %v", errors)
+		}
+
+		// only the members used by the nested classes and lambdas lose their private access
+		outer := nameAnal.typeTable.Lookup("Outer")
+		expect := map[string]bool{"used": true, "count()": true}
+		if !reflect.DeepEqual(outer.nestMembers, expect) {
+			t.Errorf("Expecting %v to be used by the nested classes but got %v", expect, outer.nestMembers)
+		}
+
+		data := []struct {
+			key    string
+			expect string
+		}{
+			{"used", ""},
+			{"count()", ""},
+			{"unused", "private "},
+			{"size()", "private "},
+		}
+
+		for _, d := range data {
+			if access := memberAccess(outer, d.key, text.Private); access != d.expect {
+				t.Errorf("Expecting the access of %s to be %q but got %q", d.key, d.expect, access)
+			}
+		}
+	})
+}
+
+var mockProtectedAccess = `
+import com.acme.Widget;
+
+class Knob extends Widget {
+	public Knob() {}
+	public void run(Widget w, Knob k) {
+		%s
+	}
+}
+`
+
+func TestNameAnalyzer_ProtectedAccess(t *testing.T) {
+	valid := []string{
+		`int n = this.size;`,
+		`this.resize(1);`,
+		`int n = k.size; k.resize(n);`,
+		`int n = Widget.count();`,
+		`Widget x = new Widget(3);`,
+		`Widget x = new Widget() {};`,
+		`int n = super.size; super.resize(n);`,
+	}
+	invalid := []mockError{
+		{`int n = w.size;`, fmt.Sprintf(msgProtectedAccess, "size", "com/acme/Widget")},
+		{`w.resize(1);`, fmt.Sprintf(msgProtectedAccess, "resize(int)", "com/acme/Widget")},
+		{`Widget x = new Widget();`, fmt.Sprintf(msgProtectedAccess, "Widget()", "com/acme/Widget")},
+		{`String s = k.owner;`, fmt.Sprintf(msgPackageAccess, "owner", "com/acme/Widget")},
+		{`k.internal();`, fmt.Sprintf(msgPackageAccess, "internal()", "com/acme/Widget")},
+	}
+	checkClasspathProgram(t, mockProtectedAccess, valid, invalid)
+
+	// a class which is not a subclass cannot use it even through a subclass
+	content := fmt.Sprintf(mockProtectedAccess, "") + `class Other { public int peek(Knob k) { return k.size; } }`
+	withClasspathText(t, content, func(nameAnal *NameAnalyzer) {
+		expect := fmt.Sprintf(msgProtectedAccess, "size", "com/acme/Widget")
+		if errors := nameAnal.Errors(); len(errors) == 0 || errors[0].Error() != expect {
+			t.Errorf("Expecting an error of: \n%s \nbut got: \n%v", expect, errors)
+		}
+	})
+}
+
+var mockFieldHiding = `
+interface Action { void run(); }
+class A {
+	public int x;
+	public String name;
+	protected int count;
+	private int secret;
+	public String describe() { return "A"; }
+}
+class B extends A {
+	public String x;
+}
+class C extends B {
+	public boolean x;
+	public void run(A a, B b, C c) {
+		%s
+	}
+	public String describe() { return "C"; }
+}
+`
+
+func TestNameAnalyzer_FieldHiding(t *testing.T) {
+	// a field is looked up by the static type of the expression
+	valid := []string{
+		`boolean n = x;`,
+		`boolean n = this.x;`,
+		`boolean n = c.x;`,
+		`String n = b.x;`,
+		`int n = a.x;`,
+		`A up = c; int n = up.x;`,
+		`String n = super.x;`,
+		`String n = super.name; String m = name;`,
+		`super.x = "b"; super.count += 1; count = count + 1;`,
+		`String n = super.describe();`,
+	}
+	invalid := []mockError{
+		{`int n = super.x;`, fmt.Sprintf(msgExpectingTypeof, "int", "String")},
+		{`int n = super.secret;`, fmt.Sprintf(msgPrivateAccess, "secret", "A")},
+		{`Action r = () -> super.describe();`, fmt.Sprintf(msgSuperCallInLambda, "describe()")},
+	}
+	checkMockProgram(t, mockFieldHiding, valid, invalid)
+
+	classes := []struct {
+		content string
+		expect  string
+	}{
+		{
+			// a private field is not inherited
+			`class A { private int secret; } class D extends A { public int leak() { return secret; } }`,
+			fmt.Sprintf(msgVariableDoesNotExist, "secret"),
+		},
+		{
+			`class A { public int x; } class Main extends A {
+				public static void main(String[] args) { int n = super.x; }
+			}`,
+			msgSuperInStatic,
+		},
+	}
+
+	for _, d := range classes {
+		withAnalyzedText(d.content, func(nameAnal *NameAnalyzer) {
+			if errors := nameAnal.Errors(); len(errors) == 0 || errors[0].Error() != d.expect {
+				t.Errorf("Expecting an error of: \n%s \nbut got: \n%v", d.expect, errors)
+			}
+		})
+	}
+}
+
+var mockPrinting = `
+import java.io.PrintStream;
+
+class Report {
+	public void run(String s, int a, byte b, char[] chars, Report r) {
+		%s
+	}
+	public String title() {
+		return this.toString();
+	}
+}
+`
+
+func TestNameAnalyzer_Printing(t *testing.T) {
+	valid := []string{
+		`System.out.println(s);`,
+		`System.out.println(a);`,
+		`System.out.println(b);`,
+		`System.out.println(chars);`,
+		`System.out.println(r);`,
+		`System.out.println();`,
+		`System.out.print(s);`,
+		`System.out.print(2.5);`,
+		`System.err.println(s);`,
+		`System.err.print('c');`,
+		`System.out.printf("%s %s", s, r);`,
+		`System.out.printf("done");`,
+		`System.out.flush();`,
+		`PrintStream out = System.out; out.println(a);`,
+		`long now = System.currentTimeMillis();`,
+		`System.out.println(a); System.out.printf("%s", s).println(r); String t = this.title();`,
+	}
+	invalid := []mockError{
+		{`System.out.println(s, a);`, fmt.Sprintf(msgMethodNotFound, "println")},
+		{`System.out.print();`, fmt.Sprintf(msgMethodNotFound, "print")},
+		{`System.out.printf(a);`, fmt.Sprintf(msgMethodNotFound, "printf")},
+		{`System.out.printf("%d", a);`, fmt.Sprintf(msgMethodNotFound, "printf")},
+		{`System.in.println(s);`, fmt.Sprintf(msgMethodNotFound, "println")},
+		{`System.out.in.read();`, fmt.Sprintf(msgTypeDoesNotHaveProperty, "java/io/PrintStream", "in")},
+		{`System.out.write(a);`, fmt.Sprintf(msgMethodNotFound, "write")},
+	}
+	checkMockProgram(t, mockPrinting, valid, invalid)
+}
+
+var mockStandardInput = `
+import java.util.Scanner;
+import java.io.*;
+
+class Reading {
+	public void run(String s) {
+		%s
+	}
+}
+`
+
+func TestNameAnalyzer_StandardInput(t *testing.T) {
+	valid := []string{
+		`Scanner in = new Scanner(System.in); int n = in.nextInt(); String line = in.nextLine();`,
+		`Scanner in = new Scanner(s); while (in.hasNext()) { System.out.println(in.next()); }`,
+		`Scanner in = new Scanner(System.in); double d = in.nextDouble() + in.nextInt(); in.close();`,
+		`BufferedReader reader = new BufferedReader(new InputStreamReader(System.in)); String line = reader.readLine();`,
+		`Reader reader = new InputStreamReader(System.in); int c = reader.read();`,
+		`InputStream stream = System.in; int b = stream.read();`,
+	}
+	invalid := []mockError{
+		{`Scanner in = new Scanner();`, fmt.Sprintf(msgConstructorNotFound, "java/util/Scanner", "")},
+		{`Scanner in = new Scanner(System.in); String n = in.nextInt();`, fmt.Sprintf(msgExpectingTypeof, "String", "int")},
+		{`Scanner in = new Scanner(System.in); in.readLine();`, fmt.Sprintf(msgMethodNotFound, "readLine")},
+		{`BufferedReader reader = new BufferedReader(System.in);`, fmt.Sprintf(msgConstructorNotFound, "java/io/BufferedReader", "java/io/InputStream")},
+		{`Reader reader = new Reader();`, fmt.Sprintf(msgConstructorNotFound, "java/io/Reader", "")},
+	}
+	checkMockProgram(t, mockStandardInput, valid, invalid)
+}
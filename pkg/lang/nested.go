@@ -0,0 +1,118 @@
+package lang
+
+import (
+	"strings"
+)
+
+var (
+	msgEnclosingInstanceRequired = "An enclosing instance of %s is required."
+	msgCapturedMustBeFinal       = "Variable %s is captured by an inner class, it must be effectively final."
+)
+
+// simpleName get the name of a type as it is declared,
+// e.g. Inner of Outer$Inner and Local of Outer$1Local
+func (t *TypeSymbol) simpleName() string {
+	name := t.name[strings.LastIndex(t.name, "$")+1:]
+	return strings.TrimLeft(name, "0123456789")
+}
+
+// typesInScope get the types that can be referred by their simple name
+// inside t, the inner most come first so it shadow the outer ones.
+func (t *TypeSymbol) typesInScope() []*TypeSymbol {
+	types := append(append([]*TypeSymbol{}, t.typeParams...), t)
+	types = append(types, t.members...)
+
+	// local and anonymous class see the block where it is declared
+	if t.declaredScope != nil {
+		return append(types, t.declaredScope...)
+	}
+
+	if t.outer != nil {
+		return append(types, t.outer.typesInScope()...)
+	}
+	return types
+}
+
+// lookupQualified get the type of a name that may be qualified by its
+// enclosing types, e.g. Outer.Inner, nil if there is none.
+func (t TypeTable) lookupQualified(name string, scope []*TypeSymbol) *TypeSymbol {
+	names := strings.Split(name, ".")
+	typeof := lookupTypeVariable(scope, names[0])
+	if typeof == nil {
+		typeof = t.Lookup(names[0])
+	}
+
+	for _, member := range names[1:] {
+		if typeof == nil {
+			return nil
+		}
+		typeof = lookupTypeVariable(typeof.base().members, member)
+	}
+	return typeof
+}
+
+// capture add v into the variables captured by t,
+// which are passed into its constructor when it is created.
+func (t *TypeSymbol) capture(v *FieldSymbol) {
+	for _, captured := range t.captures {
+		if captured == v {
+			return
+		}
+	}
+	t.captures = append(t.captures, v)
+}
+
+// outerPath get the types whose outer instance are loaded in turn to get
+// from an instance of from into an instance of target, false if unreachable.
+func outerPath(from, target *TypeSymbol) ([]*TypeSymbol, bool) {
+	var path []*TypeSymbol
+	for current := from; current != nil; current = current.outer {
+		if current == target || current.isDescendantOf(target) {
+			return path, true
+		}
+
+		if !current.isInner {
+			break
+		}
+		path = append(path, current)
+	}
+	return nil, false
+}
+
+// superOuterPath get the path from the outer instance of t into the one
+// that is required by its super class, which is an inner class.
+func (t *TypeSymbol) superOuterPath() ([]*TypeSymbol, bool) {
+	var from *TypeSymbol
+	if t.isInner {
+		from = t.outer
+	}
+	return outerPath(from, t.extends.base().outer)
+}
+
+// hasInnerSuper check if the super class of t need an outer instance
+func (t *TypeSymbol) hasInnerSuper() bool {
+	return t.extends != nil && t.extends.base().isInner
+}
+
+// lookupCrossing find name like a deep Lookup, along with
+// the types whose scope is crossed before it is found.
+func (s *SymbolTable) lookupCrossing(name string) (TypeMember, int, []*TypeSymbol) {
+	var crossed []*TypeSymbol
+	for table := s; table != nil; table = table.parent {
+		if val, found := table.table[name]; found {
+			return val.Member, val.address, crossed
+		}
+
+		if table.owner != nil {
+			crossed = append(crossed, table.owner)
+		}
+	}
+	return nil, -1, crossed
+}
+
+// isCaptured check if a member found by lookupCrossing is a local
+// variable of a method outside of the class it is looked up from
+func isCaptured(member TypeMember, crossed []*TypeSymbol) bool {
+	_, isLocal := member.(*FieldSymbol)
+	return isLocal && len(crossed) > 0
+}
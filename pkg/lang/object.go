@@ -0,0 +1,49 @@
+package lang
+
+import "github.com/gumelarme/yava/pkg/text"
+
+// javaLangObject is the root of every class and interface,
+// it is also the erasure of an unbounded type variable.
+var javaLangObject = newJavaLangObject()
+
+// newJavaLangObject declare java.lang.Object along with
+// its public methods that can be called or overridden.
+func newJavaLangObject() *TypeSymbol {
+	object := NewType("java/lang/Object", Class)
+	self := DataType{object, false}
+	method := func(name string, returnType DataType, args ...DataType) *MethodSymbol {
+		return &MethodSymbol{DataType: returnType, accessMod: text.Public, name: name, args: args}
+	}
+
+	// the methods are keyed by their signature as it is written
+	object.Methods["toString()"] = method("toString", DataType{PrimitiveString, false})
+	object.Methods["equals(Object)"] = method("equals", DataType{PrimitiveBoolean, false}, self)
+	object.Methods["hashCode()"] = method("hashCode", DataType{PrimitiveInt, false})
+
+	object.constructors = []*MethodSymbol{method(object.name, self)}
+	return object
+}
+
+// super get the direct super class of t, java.lang.Object for a
+// class or interface that does not extend any. nil for Object itself
+// and the primitive types.
+func (t *TypeSymbol) super() *TypeSymbol {
+	if t.extends != nil || t == javaLangObject {
+		return t.extends
+	}
+
+	if t.TypeCategory == Primitive && t.name != "String" {
+		return nil
+	}
+	return javaLangObject
+}
+
+// isObjectMethod check if method is one of the methods declared by java.lang.Object
+func isObjectMethod(method *MethodSymbol) bool {
+	for _, m := range javaLangObject.Methods {
+		if m == method.declared() {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,93 @@
+package lang
+
+import (
+	"sort"
+	"strings"
+)
+
+// applicableMethod get the most specific of methods that accept args.
+// nil if none accept them or the call is ambiguous.
+func applicableMethod(methods []*MethodSymbol, args []DataType) *MethodSymbol {
+	if candidates := mostSpecific(methods, args); len(candidates) == 1 {
+		return candidates[0]
+	}
+	return nil
+}
+
+// mostSpecific get the maximally specific of methods that accept args,
+// in the earliest phase any of them does. More than one method is
+// returned when none of them is more specific than the others.
+func mostSpecific(methods []*MethodSymbol, args []DataType) []*MethodSymbol {
+	for _, phase := range invocationPhases {
+		var applicable []*MethodSymbol
+		for _, method := range methods {
+			m := method.applicableIn(args, phase)
+			// an overridden method is found again in the parent, keep the first
+			if m != nil && !containsSameParameters(applicable, m) {
+				applicable = append(applicable, m)
+			}
+		}
+
+		if len(applicable) > 0 {
+			return maximallySpecific(applicable)
+		}
+	}
+	return nil
+}
+
+// maximallySpecific get the methods that no other method is strictly more specific than
+func maximallySpecific(methods []*MethodSymbol) []*MethodSymbol {
+	var maximal []*MethodSymbol
+	for _, m := range methods {
+		isMaximal := true
+		for _, other := range methods {
+			if other != m && other.isMoreSpecific(m) && !m.isMoreSpecific(other) {
+				isMaximal = false
+				break
+			}
+		}
+
+		if isMaximal {
+			maximal = append(maximal, m)
+		}
+	}
+	return maximal
+}
+
+// isMoreSpecific check if every parameter of m can be passed into other,
+// both has been made applicable to the same arguments.
+func (m *MethodSymbol) isMoreSpecific(other *MethodSymbol) bool {
+	return other.CanAccept(m.args)
+}
+
+func (m *MethodSymbol) hasSameParameters(other *MethodSymbol) bool {
+	if len(m.args) != len(other.args) {
+		return false
+	}
+
+	for i, arg := range m.args {
+		if !isIdentity(arg, other.args[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func containsSameParameters(methods []*MethodSymbol, method *MethodSymbol) bool {
+	for _, m := range methods {
+		if m.hasSameParameters(method) {
+			return true
+		}
+	}
+	return false
+}
+
+// candidatesString list the methods as they are declared, sorted to be stable
+func candidatesString(methods []*MethodSymbol) string {
+	names := make([]string, len(methods))
+	for i, m := range methods {
+		names[i] = m.declared().String()
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
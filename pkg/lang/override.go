@@ -0,0 +1,131 @@
+package lang
+
+import "github.com/gumelarme/yava/pkg/text"
+
+var (
+	msgMethodDoesNotOverride  = "Method %s does not override or implement a method from a super type."
+	msgUnknownAnnotation      = "Annotation @%s is not found."
+	msgIncompatibleReturnType = "Method %s cannot override the method of %s, return type %s is not compatible with %s."
+	msgWeakerAccess           = "Method %s cannot override the method of %s with a weaker access."
+	msgOverrideStatic         = "Method %s cannot override the static method of %s."
+	msgHideInstance           = "Static method %s cannot hide the instance method of %s."
+)
+
+// methodAnnotations are the annotations which can be written on a method
+var methodAnnotations = map[string]bool{
+	"Override":   true,
+	"Deprecated": true,
+}
+
+// checkOverride check every method declared in the current type against
+// the method it override in the nearest super class, which is Object for
+// an interface. The methods of an implemented interface are checked in
+// VisitAfterClass, since they can also be implemented by a super class.
+func (t *TypeAnalyzer) checkOverride(signatures []*text.MethodSignature) {
+	for _, signature := range signatures {
+		isOverride := false
+		for _, name := range signature.Annotations {
+			if !methodAnnotations[name] {
+				t.AddErrorf(msgUnknownAnnotation, name)
+			}
+			isOverride = isOverride || name == "Override"
+		}
+
+		key := signature.Signature()
+		method := t.current.Methods[key]
+		// its types are not resolved, which is already reported
+		if method == nil {
+			continue
+		}
+
+		if overridden, parent := t.current.overriddenMethod(key); overridden != nil {
+			t.checkOverriding(key, method, overridden, parent)
+		} else if implemented, _ := t.current.implementedMethod(key); isOverride && implemented == nil {
+			t.AddErrorf(msgMethodDoesNotOverride, key)
+		}
+	}
+}
+
+// checkOverriding report an error if method of the current class cannot
+// override the method of parent, a reference type can be returned as its
+// subtype, but a primitive type and void should be returned as it is.
+func (t *TypeAnalyzer) checkOverriding(key string, method, overridden *MethodSymbol, parent *TypeSymbol) {
+	name := parent.base().name
+	switch {
+	case method.isStatic && !overridden.isStatic:
+		t.AddErrorf(msgHideInstance, key, name)
+	case !method.isStatic && overridden.isStatic:
+		t.AddErrorf(msgOverrideStatic, key, name)
+	case !isIdentity(method.DataType, overridden.DataType) &&
+		!isWideningReference(method.DataType, overridden.DataType):
+		t.AddErrorf(msgIncompatibleReturnType, key, name, method.DataType, overridden.DataType)
+	case accessLevel(method.accessMod, t.current) < accessLevel(overridden.accessMod, parent):
+		t.AddErrorf(msgWeakerAccess, key, name)
+	}
+}
+
+// accessLevel rank the access of a member of t from private to public,
+// the methods of an interface are always public.
+func accessLevel(access text.AccessModifier, t *TypeSymbol) int {
+	switch {
+	case access&text.Public != 0 || t.TypeCategory == Interface:
+		return 3
+	case access&text.Protected != 0:
+		return 2
+	case access&text.Private != 0:
+		return 0
+	default:
+		return 1
+	}
+}
+
+// overriddenMethod get the method whose signature is key from the
+// nearest super class of t that declare it, along with that class.
+func (t *TypeSymbol) overriddenMethod(key string) (*MethodSymbol, *TypeSymbol) {
+	for parent := t.super(); parent != nil; parent = parent.super() {
+		if method := parent.inheritedMethod(key, t); method != nil {
+			return method, parent
+		}
+	}
+	return nil, nil
+}
+
+// implementedMethod get the method whose signature is key from the interface
+// implemented by t or its super classes, along with that interface.
+func (t *TypeSymbol) implementedMethod(key string) (*MethodSymbol, *TypeSymbol) {
+	for typeof := t; typeof != nil; typeof = typeof.super() {
+		if inf := typeof.implements; inf != nil {
+			if method := inf.inheritedMethod(key, t); method != nil {
+				return method, inf
+			}
+		}
+	}
+	return nil, nil
+}
+
+// inheritedMethod get the method of t whose signature is key, substituted
+// by the type arguments of t, if it is inherited by its subtype from. The
+// constructors, private methods and static methods of an interface are not
+// inherited, and neither are the package members of another package.
+func (t *TypeSymbol) inheritedMethod(key string, from *TypeSymbol) *MethodSymbol {
+	for name, method := range t.base().Methods {
+		if t.generic != nil {
+			method = t.substituteMethod(method)
+			name = method.String()
+		}
+
+		if name != key || method.name == t.base().name || method.accessMod&text.Private != 0 {
+			continue
+		}
+
+		if t.TypeCategory == Interface {
+			if method.isStatic {
+				continue
+			}
+		} else if method.accessMod&(text.Public|text.Protected) == 0 && packageOf(t) != packageOf(from) {
+			continue
+		}
+		return method
+	}
+	return nil
+}
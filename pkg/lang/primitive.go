@@ -0,0 +1,80 @@
+package lang
+
+import "github.com/gumelarme/yava/pkg/text"
+
+// numericRank order numeric types by its widening direction,
+// a type can be widened into any type with higher rank,
+// except char which can only be widened into int and above.
+var numericRank = map[string]int{
+	"byte":   1,
+	"short":  2,
+	"char":   2,
+	"int":    3,
+	"long":   4,
+	"float":  5,
+	"double": 6,
+}
+
+// IsNumeric check if dt is a non-array numeric primitive,
+// char is considered numeric.
+func IsNumeric(dt DataType) bool {
+	if dt.dataType == nil || dt.isArray {
+		return false
+	}
+
+	_, ok := numericRank[dt.Name()]
+	return ok
+}
+
+// isIntegral check if dt is a non-array integral primitive
+func isIntegral(dt DataType) bool {
+	return IsNumeric(dt) && dt.Name() != "float" && dt.Name() != "double"
+}
+
+// slotSize get the number of local variable or operand stack slot
+// a value of typename takes, long and double takes two slot.
+func slotSize(typename string, isArray bool) int {
+	if !isArray && (typename == "long" || typename == "double") {
+		return 2
+	}
+	return 1
+}
+
+func (d DataType) slotSize() int {
+	if d.dataType == nil {
+		return 1
+	}
+	return slotSize(d.Name(), d.isArray)
+}
+
+// isConstantRepresentable check if an int constant can be
+// implicitly narrowed into byte, short or char
+func isConstantRepresentable(value int64, to DataType) bool {
+	if to.isArray || to.dataType == nil {
+		return false
+	}
+
+	switch to.Name() {
+	case "byte":
+		return value >= -128 && value <= 127
+	case "short":
+		return value >= -32768 && value <= 32767
+	case "char":
+		return value >= 0 && value <= 65535
+	default:
+		return false
+	}
+}
+
+// isConstantNarrowing check if exp is an int or char literal
+// that fit into the narrower target type.
+func isConstantNarrowing(exp text.Expression, target DataType) bool {
+	switch val := exp.(type) {
+	case text.Num:
+		return isConstantRepresentable(int64(val), target)
+	case text.Char:
+		return isConstantRepresentable(int64(val), target)
+	default:
+		return false
+	}
+}
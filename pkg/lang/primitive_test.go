@@ -0,0 +1,135 @@
+package lang
+
+import (
+	"testing"
+
+	"github.com/gumelarme/yava/pkg/text"
+)
+
+func primitive(sym *TypeSymbol) DataType {
+	return DataType{sym, false}
+}
+
+func Test_isWideningPrimitive(t *testing.T) {
+	data := []struct {
+		from, to *TypeSymbol
+		expect   bool
+	}{
+		{PrimitiveByte, PrimitiveShort, true},
+		{PrimitiveByte, PrimitiveInt, true},
+		{PrimitiveShort, PrimitiveLong, true},
+		{PrimitiveChar, PrimitiveInt, true},
+		{PrimitiveInt, PrimitiveLong, true},
+		{PrimitiveInt, PrimitiveFloat, true},
+		{PrimitiveLong, PrimitiveFloat, true},
+		{PrimitiveFloat, PrimitiveDouble, true},
+		{PrimitiveInt, PrimitiveInt, false},
+		{PrimitiveByte, PrimitiveChar, false},
+		{PrimitiveShort, PrimitiveChar, false},
+		{PrimitiveChar, PrimitiveShort, false},
+		{PrimitiveLong, PrimitiveInt, false},
+		{PrimitiveDouble, PrimitiveFloat, false},
+		{PrimitiveBoolean, PrimitiveInt, false},
+		{PrimitiveInt, PrimitiveString, false},
+	}
+
+	for _, d := range data {
+		if res := isWideningPrimitive(primitive(d.from), primitive(d.to)); res != d.expect {
+			t.Errorf("Widening %s to %s expected to be %v", d.from.name, d.to.name, d.expect)
+		}
+	}
+
+	arrayInt := DataType{PrimitiveInt, true}
+	if isWideningPrimitive(arrayInt, primitive(PrimitiveLong)) {
+		t.Errorf("Array should never be widened")
+	}
+}
+
+func Test_binaryNumericPromotion(t *testing.T) {
+	data := []struct {
+		left, right, expect *TypeSymbol
+	}{
+		{PrimitiveByte, PrimitiveByte, PrimitiveInt},
+		{PrimitiveShort, PrimitiveChar, PrimitiveInt},
+		{PrimitiveInt, PrimitiveInt, PrimitiveInt},
+		{PrimitiveInt, PrimitiveLong, PrimitiveLong},
+		{PrimitiveLong, PrimitiveFloat, PrimitiveFloat},
+		{PrimitiveDouble, PrimitiveChar, PrimitiveDouble},
+		{PrimitiveFloat, PrimitiveDouble, PrimitiveDouble},
+	}
+
+	for _, d := range data {
+		res := binaryNumericPromotion(primitive(d.left), primitive(d.right))
+		if res != primitive(d.expect) {
+			t.Errorf("Promoting %s and %s expected to be %s but got %s",
+				d.left.name, d.right.name, d.expect.name, res)
+		}
+	}
+}
+
+func Test_unaryNumericPromotion(t *testing.T) {
+	data := []struct {
+		from, expect *TypeSymbol
+	}{
+		{PrimitiveByte, PrimitiveInt},
+		{PrimitiveShort, PrimitiveInt},
+		{PrimitiveChar, PrimitiveInt},
+		{PrimitiveInt, PrimitiveInt},
+		{PrimitiveLong, PrimitiveLong},
+		{PrimitiveDouble, PrimitiveDouble},
+	}
+
+	for _, d := range data {
+		if res := unaryNumericPromotion(primitive(d.from)); res != primitive(d.expect) {
+			t.Errorf("Promoting %s expected to be %s but got %s", d.from.name, d.expect.name, res)
+		}
+	}
+}
+
+func Test_slotSize(t *testing.T) {
+	data := []struct {
+		name    string
+		isArray bool
+		expect  int
+	}{
+		{"int", false, 1},
+		{"boolean", false, 1},
+		{"String", false, 1},
+		{"long", false, 2},
+		{"double", false, 2},
+		{"long", true, 1},
+		{"double", true, 1},
+	}
+
+	for _, d := range data {
+		if res := slotSize(d.name, d.isArray); res != d.expect {
+			t.Errorf("Slot size of %s (array: %v) expected to be %d but got %d",
+				d.name, d.isArray, d.expect, res)
+		}
+	}
+}
+
+func Test_isConstantNarrowing(t *testing.T) {
+	data := []struct {
+		exp    text.Expression
+		to     *TypeSymbol
+		expect bool
+	}{
+		{text.Num(127), PrimitiveByte, true},
+		{text.Num(-128), PrimitiveByte, true},
+		{text.Num(128), PrimitiveByte, false},
+		{text.Num(32767), PrimitiveShort, true},
+		{text.Num(32768), PrimitiveShort, false},
+		{text.Num(65535), PrimitiveChar, true},
+		{text.Num(-1), PrimitiveChar, false},
+		{text.Char('a'), PrimitiveByte, true},
+		{text.Num(1), PrimitiveLong, false},
+		{text.Long(1), PrimitiveByte, false},
+	}
+
+	for _, d := range data {
+		if res := isConstantNarrowing(d.exp, primitive(d.to)); res != d.expect {
+			t.Errorf("Narrowing %s into %s expected to be %v", text.PrettyPrint(d.exp), d.to.name, d.expect)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package lang
+
+import (
+	"unicode/utf16"
+
+	"github.com/gumelarme/yava/pkg/text"
+)
+
+var (
+	msgYieldOutsideSwitch  = "Yield outside of a switch expression."
+	msgSwitchNotExhaustive = "The switch expression does not cover all possible input values."
+	msgSwitchWithoutYield  = "A case of a switch expression must end by yielding a value."
+	msgIncompatibleYield   = "Incompatible types yielded by the switch expression, '%s' and '%s'."
+)
+
+// switchValue is the state of a switch expression while its cases are
+// analyzed, node is the switch whose value is of switchType
+type switchValue struct {
+	node       *text.SwitchStatement
+	switchType DataType
+	yields     []DataType
+}
+
+// stringHash get the hash code of s the same way java.lang.String does,
+// which is computed out of its UTF-16 code units.
+func stringHash(s string) int32 {
+	var hash int32
+	for _, unit := range utf16.Encode([]rune(s)) {
+		hash = 31*hash + int32(unit)
+	}
+	return hash
+}
+
+// isStringSwitch check if the switch value is a String,
+// whose case is looked up by its hash code first
+func isStringSwitch(switchType DataType) bool {
+	return !switchType.isArray && switchType.dataType == PrimitiveString
+}
+
+// isExhaustive check if there is a case for every possible value of the
+// switch, which is only possible without default if every enum constant
+// has a case.
+func isExhaustive(s *text.SwitchStatement, switchType DataType) bool {
+	if s.DefaultCase != nil {
+		return true
+	}
+
+	if switchType.isArray || switchType.dataType.TypeCategory != Enum {
+		return false
+	}
+
+	covered := make(map[int]bool)
+	for _, c := range s.CaseList {
+		for _, label := range c.Values {
+			if key, ok := caseKey(label, switchType); ok {
+				covered[key] = true
+			}
+		}
+	}
+	return len(covered) == len(switchType.dataType.constants)
+}
+
+// alwaysYields check that a switch expression cannot complete without
+// yielding a value. An arrow case never fall through so every one of
+// them must yield, while a colon case may fall through the next one.
+func alwaysYields(s *text.SwitchStatement) bool {
+	var bodies [][]text.Statement
+	for _, c := range s.CaseList {
+		bodies = append(bodies, c.StatementList)
+	}
+
+	if s.DefaultCase != nil {
+		bodies = append(bodies, s.DefaultCase)
+	}
+
+	if !s.IsArrow && len(bodies) > 0 {
+		bodies = bodies[len(bodies)-1:]
+	}
+
+	for _, body := range bodies {
+		if !endsWithYield(body) {
+			return false
+		}
+	}
+	return true
+}
+
+func endsWithYield(stmts []text.Statement) bool {
+	if len(stmts) == 0 {
+		return false
+	}
+
+	switch last := stmts[len(stmts)-1].(type) {
+	case *text.JumpStatement:
+		return last.Type == text.YieldJump
+	case text.StatementList:
+		return endsWithYield(last)
+	}
+	return false
+}
+
+// yieldedType get the type of a switch expression that yield both result
+// and dt. Numeric types are promoted into the wider one, other types
+// must be assignable into one of them.
+func yieldedType(result, dt DataType) (DataType, bool) {
+	switch {
+	case dt == result:
+	case IsNumeric(dt) && IsNumeric(result):
+		result = binaryNumericPromotion(result, dt)
+	case isConvertible(dt, result, assignmentContext):
+	case isConvertible(result, dt, assignmentContext):
+		result = dt
+	default:
+		return result, false
+	}
+	return result, true
+}
+
+// namedTypeOf get the type as it is written, a type variable is
+// written as its erasure since a variable cannot be resolved later
+func namedTypeOf(dt DataType) text.NamedType {
+	return text.NamedType{Name: dt.dataType.erasure().name, IsArray: dt.isArray}
+}
@@ -25,6 +25,18 @@ func (e *ErrorCollector) AddErrorf(err string, i ...interface{}) {
 	(*e) = append(*e, fmt.Sprintf(err, i...))
 }
 
+// WarningCollector keep the messages of a code which is valid, but is
+// likely a mistake, unlike an error it does not stop the compilation.
+type WarningCollector []string
+
+func (w WarningCollector) Warnings() []string {
+	return w
+}
+
+func (w *WarningCollector) AddWarningf(msg string, i ...interface{}) {
+	(*w) = append(*w, fmt.Sprintf(msg, i...))
+}
+
 type SymbolCategory int
 
 const (
@@ -57,15 +69,35 @@ const (
 	Primitive TypeCategory = iota
 	Class
 	Interface
+	Enum
+	TypeVariable
 )
 
 type TypeSymbol struct {
-	name         string
-	extends      *TypeSymbol
-	implements   *TypeSymbol
-	Properties   map[string]*PropertySymbol
-	Methods      map[string]*MethodSymbol
-	TypeCategory TypeCategory
+	name          string
+	extends       *TypeSymbol
+	implements    *TypeSymbol
+	Properties    map[string]*PropertySymbol
+	Methods       map[string]*MethodSymbol
+	TypeCategory  TypeCategory
+	constants     []string
+	typeParams    []*TypeSymbol
+	generic       *TypeSymbol
+	typeArgs      []DataType
+	instances     map[string]*TypeSymbol
+	constructors  []*MethodSymbol
+	outer         *TypeSymbol
+	isInner       bool
+	members       []*TypeSymbol
+	declaredScope []*TypeSymbol
+	captures      []*FieldSymbol
+	function      *MethodSymbol
+	reference     *methodReference
+	isFinal       bool
+	isRecord      bool
+	// nestMembers are the private members used by the other classes of
+	// the same top level class, e.g. a nested class or a lambda
+	nestMembers map[string]bool
 }
 
 func NewType(name string, category TypeCategory) *TypeSymbol {
@@ -73,11 +105,37 @@ func NewType(name string, category TypeCategory) *TypeSymbol {
 		make(map[string]*PropertySymbol),
 		make(map[string]*MethodSymbol),
 		category,
+		nil,
+		nil,
+		nil,
+		nil,
+		make(map[string]*TypeSymbol),
+		nil,
+		nil,
+		false,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		false,
+		false,
+		nil,
 	}
 }
 
+// ordinalOf get the position of an enum constant, -1 if not exist
+func (t *TypeSymbol) ordinalOf(name string) int {
+	for i, constant := range t.constants {
+		if constant == name {
+			return i
+		}
+	}
+	return -1
+}
+
 func (t *TypeSymbol) isDescendantOf(val *TypeSymbol) bool {
-	for parent := t.extends; parent != nil; parent = parent.extends {
+	for parent := t.super(); parent != nil; parent = parent.super() {
 		if val == parent {
 			return true
 		}
@@ -114,8 +172,25 @@ func (t TypeSymbol) String() string {
 	return fmt.Sprintf("<%s>", t.name)
 }
 
+// LookupProperty get the property name of t, or of its nearest super class
+// which declare it. The property is looked up from the static type of the
+// expression it is accessed through, so a property declared in a subclass
+// hides the one of its super class, e.g. the property of A is read from
+// a variable of type A even if it refer to a subclass of A.
 func (t *TypeSymbol) LookupProperty(name string) *PropertySymbol {
-	//FIXME: Java get the parent first, but here we get the child prop first
+	if t.generic != nil {
+		prop := t.generic.LookupProperty(name)
+		if prop == nil {
+			return nil
+		}
+
+		return &PropertySymbol{
+			prop.AccessModifier,
+			FieldSymbol{t.substitute(prop.DataType), prop.name},
+			prop.constant,
+		}
+	}
+
 	prop := t.Properties[name]
 	if prop != nil {
 		return prop
@@ -128,50 +203,81 @@ func (t *TypeSymbol) LookupProperty(name string) *PropertySymbol {
 }
 
 func (t *TypeSymbol) LookupMethod(signature string) *MethodSymbol {
+	if t.generic != nil {
+		name := strings.SplitN(signature, "(", 2)[0]
+		for _, method := range t.getMethodsByName(name) {
+			if method.String() == signature {
+				return method
+			}
+		}
+		return nil
+	}
+
 	method := t.Methods[signature]
 	if method != nil {
 		return method
 	}
 
-	if t.extends != nil {
-		return t.extends.LookupMethod(signature)
+	if super := t.super(); super != nil {
+		return super.LookupMethod(signature)
 	}
 	return nil
 }
 
 func (t *TypeSymbol) LookupMethodByArgs(name string, args []DataType) *MethodSymbol {
-	sameNameMethods := t.getMethodsByName(name)
-
-	//filter by number of args
-	argLen := len(args)
-	var sameNameSameArgCount []*MethodSymbol
-	for _, method := range sameNameMethods {
-		if argLen == len(method.args) {
-			sameNameSameArgCount = append(sameNameSameArgCount, method)
-		}
-	}
+	return applicableMethod(t.getMethodsByName(name), args)
+}
 
-	for _, method := range sameNameSameArgCount {
-		if method.CanAccept(args) {
-			return method
-		}
+// MethodCandidates get the most specific methods named name that accept args,
+// more than one means the call is ambiguous.
+func (t *TypeSymbol) MethodCandidates(name string, args []DataType) []*MethodSymbol {
+	return mostSpecific(t.getMethodsByName(name), args)
+}
+
+// LookupConstructor get the constructor that accept args,
+// the parameters of a generic type are substituted first.
+func (t *TypeSymbol) LookupConstructor(args []DataType) *MethodSymbol {
+	if candidates := t.ConstructorCandidates(args); len(candidates) == 1 {
+		return candidates[0]
 	}
 	return nil
 }
 
+// ConstructorCandidates get the most specific constructors that accept args,
+// more than one means the call is ambiguous.
+func (t *TypeSymbol) ConstructorCandidates(args []DataType) []*MethodSymbol {
+	constructors := make([]*MethodSymbol, len(t.base().constructors))
+	for i, con := range t.base().constructors {
+		constructors[i] = t.substituteMethod(con)
+	}
+	return mostSpecific(constructors, args)
+}
+
 func (t *TypeSymbol) getMethodsByName(name string) []*MethodSymbol {
 	var methods []*MethodSymbol
+	if t.generic != nil {
+		for _, m := range t.generic.getMethodsByName(name) {
+			methods = append(methods, t.substituteMethod(m))
+		}
+		return methods
+	}
+
 	for _, m := range t.Methods {
 		if m.name == name {
 			methods = append(methods, m)
 		}
 	}
 
-	if t.extends != nil {
-		parentMethods := t.extends.getMethodsByName(name)
+	if super := t.super(); super != nil {
+		parentMethods := super.getMethodsByName(name)
 		methods = append(methods, parentMethods...)
 	}
 
+	// a type variable bounded by an interface has all the interface methods
+	if t.TypeCategory == TypeVariable && t.implements != nil {
+		methods = append(methods, t.implements.getMethodsByName(name)...)
+	}
+
 	return methods
 }
 
@@ -181,7 +287,7 @@ func IsPrimitive(dt DataType) bool {
 	}
 
 	switch dt.Name() {
-	case "int", "boolean", "char":
+	case "int", "boolean", "char", "byte", "short", "long", "float", "double":
 		return true
 	default:
 		return false
@@ -232,6 +338,7 @@ func (f *FieldSymbol) String() string {
 type PropertySymbol struct {
 	text.AccessModifier
 	FieldSymbol
+	constant *constantVariable
 }
 
 func (p PropertySymbol) String() string {
@@ -240,9 +347,16 @@ func (p PropertySymbol) String() string {
 
 type MethodSymbol struct {
 	DataType
-	accessMod text.AccessModifier
-	name      string
-	args      []DataType
+	accessMod  text.AccessModifier
+	name       string
+	args       []DataType
+	isStatic   bool
+	typeParams []*TypeSymbol
+	origin     *MethodSymbol
+	// isVarargs is set if the last parameter has variable arity,
+	// isExpanded if it is replaced by the arguments passed into it
+	isVarargs  bool
+	isExpanded bool
 }
 
 func NewMethodSymbol(signature text.MethodSignature, returnType TypeSymbol) *MethodSymbol {
@@ -254,6 +368,11 @@ func NewMethodSymbol(signature text.MethodSignature, returnType TypeSymbol) *Met
 		signature.AccessModifier,
 		signature.Name,
 		make([]DataType, 0),
+		false,
+		nil,
+		nil,
+		false,
+		false,
 	}
 }
 
@@ -269,27 +388,17 @@ func (m *MethodSymbol) Category() SymbolCategory {
 	return Method
 }
 
+// CanAccept check if every argument can be passed
+// into the parameter at the same position.
 func (m *MethodSymbol) CanAccept(args []DataType) bool {
 	if len(m.args) != len(args) {
 		return false
 	}
 
-	for i, mArg := range m.args {
-		expect := args[i]
-		if expect == mArg {
-			continue //	accepted
+	for i, param := range m.args {
+		if !isConvertible(args[i], param, invocationContext) {
+			return false
 		}
-
-		if expect.dataType.isDescendantOf(mArg.dataType) &&
-			expect.isArray == mArg.isArray {
-			continue //	accepted
-		}
-
-		if expect.dataType.isImplementing(mArg.dataType) &&
-			expect.isArray == mArg.isArray {
-			continue //	accepted
-		}
-		return false
 	}
 	return true
 }
@@ -315,6 +424,7 @@ type SymbolTable struct {
 	table     map[string]Local
 	parent    *SymbolTable
 	isVerbose bool
+	owner     *TypeSymbol
 }
 
 func NewSymbolTable(name string, level int, parent *SymbolTable) SymbolTable {
@@ -324,6 +434,7 @@ func NewSymbolTable(name string, level int, parent *SymbolTable) SymbolTable {
 		make(map[string]Local),
 		parent,
 		false,
+		nil,
 	}
 }
 
@@ -356,18 +467,30 @@ func (s *SymbolTable) LookupMethod(name string, args []DataType, deep bool) (*Me
 		fmt.Printf("Lookup %s @%s\n", name, s.name)
 	}
 
+	candidates, scope := s.MethodCandidates(name, args, deep)
+	if len(candidates) != 1 {
+		return nil, -1
+	}
+	return candidates[0], scope.table[candidates[0].declared().String()].address
+}
+
+// MethodCandidates get the most specific methods named name that accept args
+// in the innermost scope that has any, along with the scope.
+func (s *SymbolTable) MethodCandidates(name string, args []DataType, deep bool) ([]*MethodSymbol, *SymbolTable) {
+	var methods []*MethodSymbol
 	for _, local := range s.table {
 		if local.Member.Category() == Method && local.Member.Name() == name {
-			m := local.Member.(*MethodSymbol)
-			if m.CanAccept(args) {
-				return m, local.address
-			}
+			methods = append(methods, local.Member.(*MethodSymbol))
 		}
 	}
 
+	if candidates := mostSpecific(methods, args); len(candidates) > 0 {
+		return candidates, s
+	}
+
 	if s.parent != nil && deep {
-		return s.parent.LookupMethod(name, args, deep)
+		return s.parent.MethodCandidates(name, args, deep)
 	}
 
-	return nil, -1
+	return nil, nil
 }
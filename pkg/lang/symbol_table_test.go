@@ -45,3 +45,43 @@ func Test_isDescendantOf(t *testing.T) {
 	}
 
 }
+
+func Test_mostSpecific(t *testing.T) {
+	animal := DataType{NewType("Animal", Class), false}
+	cat := DataType{NewType("Cat", Class), false}
+	cat.dataType.extends = animal.dataType
+
+	method := func(args ...DataType) *MethodSymbol {
+		return &MethodSymbol{name: "f", args: args}
+	}
+
+	byAnimal, byCat := method(animal), method(cat)
+	wideLeft, wideRight := method(mockLong, mockInt), method(mockInt, mockLong)
+	data := []struct {
+		methods []*MethodSymbol
+		args    []DataType
+		expect  []*MethodSymbol
+	}{
+		{[]*MethodSymbol{byAnimal, byCat}, []DataType{cat}, []*MethodSymbol{byCat}},
+		{[]*MethodSymbol{byCat, byAnimal}, []DataType{cat}, []*MethodSymbol{byCat}},
+		{[]*MethodSymbol{byAnimal, byCat}, []DataType{animal}, []*MethodSymbol{byAnimal}},
+		{[]*MethodSymbol{byCat, method(cat)}, []DataType{cat}, []*MethodSymbol{byCat}},
+		{[]*MethodSymbol{byCat}, []DataType{animal}, nil},
+		{[]*MethodSymbol{wideLeft, wideRight}, []DataType{mockInt, mockInt}, []*MethodSymbol{wideLeft, wideRight}},
+		{[]*MethodSymbol{wideLeft, wideRight}, []DataType{mockLong, mockInt}, []*MethodSymbol{wideLeft}},
+	}
+
+	for i, d := range data {
+		got := mostSpecific(d.methods, d.args)
+		if len(got) != len(d.expect) {
+			t.Errorf("#%d expected %d candidates but got %d", i, len(d.expect), len(got))
+			continue
+		}
+
+		for j := range got {
+			if got[j] != d.expect[j] {
+				t.Errorf("#%d expected %s but got %s", i, d.expect[j], got[j])
+			}
+		}
+	}
+}
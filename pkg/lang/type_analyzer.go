@@ -1,8 +1,10 @@
 package lang
 
 import (
+	"path"
 	"strings"
 
+	"github.com/gumelarme/yava/pkg/classfile"
 	"github.com/gumelarme/yava/pkg/text"
 )
 
@@ -15,6 +17,18 @@ var (
 	msgMustImplementMethod          = "Must implement %s method."
 	msgPropertyAlreadyDeclared      = "Property %#v is already exist in class %s."
 	msgMethodIsAlreadyDeclared      = "Method %s is already exist."
+	msgEnumCantBeExtended           = "Enum %s cannot be extended."
+	msgWrongTypeArgumentCount       = "Type %s expect %d type arguments but got %d."
+	msgPrimitiveTypeArgument        = "Type argument cannot be a primitive type, got %s."
+	msgTypeArgumentNotWithinBound   = "Type argument %s is not within the bound of %s, expecting %s."
+	msgInvalidTypeBound             = "Type %s cannot be used as a bound."
+	msgCyclicInheritance            = "Cyclic inheritance involving %s."
+	msgCannotExtendFinal            = "Cannot inherit from final class %s."
+	msgCannotOverrideFinal          = "Method %s cannot override the final method of %s."
+	msgCannotImportClass            = "Cannot import class %s, %v."
+	msgImportNotFound               = "Imported class %s is not found."
+	msgClassNotImported             = "Type %s is not imported, it can be imported as %s."
+	msgInterfacesIgnored            = "Class %s only implements %s, the interfaces %s are ignored."
 )
 
 type TypeTable map[string]*TypeSymbol
@@ -29,8 +43,28 @@ func (t TypeTable) Lookup(name string) *TypeSymbol {
 
 type TypeAnalyzer struct {
 	ErrorCollector
-	current *TypeSymbol
-	table   TypeTable
+	WarningCollector
+	current    *TypeSymbol
+	table      TypeTable
+	methodVars []*TypeSymbol
+	locals     []*TypeSymbol
+	isStatic   bool
+	enclosing  []typeContext
+	classpath  *classfile.Classpath
+	// imports are the classes imported from the classpath by their internal
+	// name, nil if it is not found, so it is only searched once
+	imports map[string]*TypeSymbol
+	// packages are imported on demand, e.g. java.util.*, in internal form
+	packages []string
+}
+
+// typeContext is the state of the analyzer inside a class,
+// which is kept while its nested class is analyzed.
+type typeContext struct {
+	current    *TypeSymbol
+	methodVars []*TypeSymbol
+	locals     []*TypeSymbol
+	isStatic   bool
 }
 
 var (
@@ -38,20 +72,48 @@ var (
 	PrimitiveInt     = NewType("int", Primitive)
 	PrimitiveBoolean = NewType("boolean", Primitive)
 	PrimitiveChar    = NewType("char", Primitive)
+	PrimitiveByte    = NewType("byte", Primitive)
+	PrimitiveShort   = NewType("short", Primitive)
+	PrimitiveLong    = NewType("long", Primitive)
+	PrimitiveFloat   = NewType("float", Primitive)
+	PrimitiveDouble  = NewType("double", Primitive)
 	PrimitiveString  = NewType("String", Primitive)
 )
 
 func NewTypeAnalyzer() *TypeAnalyzer {
+	table := TypeTable{
+		"null":    PrimitiveNull,
+		"int":     PrimitiveInt,
+		"boolean": PrimitiveBoolean,
+		"char":    PrimitiveChar,
+		"byte":    PrimitiveByte,
+		"short":   PrimitiveShort,
+		"long":    PrimitiveLong,
+		"float":   PrimitiveFloat,
+		"double":  PrimitiveDouble,
+		"String":  PrimitiveString,
+		"Object":  javaLangObject,
+	}
+
+	// only the classes of java.lang can be used without being imported
+	for name, typeof := range library {
+		if typeof == PrimitiveString || path.Dir(typeof.name) == "java/lang" {
+			table[name] = typeof
+		}
+	}
+
 	return &TypeAnalyzer{
 		make([]string, 0),
 		nil,
-		TypeTable{
-			"null":    PrimitiveNull,
-			"int":     PrimitiveInt,
-			"boolean": PrimitiveBoolean,
-			"char":    PrimitiveChar,
-			"String":  PrimitiveString,
-		},
+		nil,
+		table,
+		nil,
+		nil,
+		false,
+		make([]typeContext, 0),
+		nil,
+		make(map[string]*TypeSymbol),
+		nil,
 	}
 }
 
@@ -69,8 +131,8 @@ func (t *TypeAnalyzer) typeExist(name string) bool {
 //FIXME: Code duplicate here, but if removed will be too unreadable
 //fix it
 func (t *TypeAnalyzer) VisitClass(class *text.Class) {
-	notExistMsg := func(name string) {
-		t.AddErrorf(msgTypeNotExist, name)
+	if class.Kind != text.TopLevelClass {
+		t.enterNestedClass()
 	}
 
 	if _, exist := t.table[class.Name]; exist {
@@ -79,16 +141,42 @@ func (t *TypeAnalyzer) VisitClass(class *text.Class) {
 	}
 
 	newClass := NewType(class.Name, Class)
-	declareClass := func(name string, cat TypeCategory) {
+	newClass.isFinal = class.Access&text.Final != 0
+	// registered early, so the class can refer to itself, e.g. Node implements Comparable<Node>
+	t.table[newClass.name] = newClass
+	if class.Kind != text.TopLevelClass {
+		t.declareNested(class.Kind, newClass)
+	}
+
+	newClass.typeParams = t.declareTypeParams(class.TypeParams, newClass.typesInScope())
+	declareClass := func(named text.NamedType, cat TypeCategory) {
+		name := named.Name
 		if len(name) == 0 {
 			return
 		}
-		if !t.typeExist(name) {
-			notExistMsg(name)
+
+		resolved, msg := t.resolve(named, newClass.typesInScope())
+		if len(msg) > 0 {
+			t.AddError(msg)
+			return
+		}
+
+		val := resolved.dataType
+		if val.base() == newClass {
+			t.AddErrorf(msgCyclicInheritance, name)
+			return
+		}
+
+		if val.TypeCategory == Enum && cat == Class {
+			t.AddErrorf(msgEnumCantBeExtended, name)
+			return
+		}
+
+		if val.base().isFinal && cat == Class {
+			t.AddErrorf(msgCannotExtendFinal, name)
 			return
 		}
 
-		val := t.table[name]
 		if val.TypeCategory != cat {
 			msg := msgExtendShouldBeOnClass
 			if cat == Interface {
@@ -105,25 +193,188 @@ func (t *TypeAnalyzer) VisitClass(class *text.Class) {
 		}
 	}
 
-	declareClass(class.Extend, Class)
-	declareClass(class.Implement, Interface)
+	extend, implement := class.ExtendType(), class.ImplementType()
+	if class.Kind == text.AnonymousClass {
+		// the created type of an anonymous class can be an interface
+		base, _ := t.resolve(extend, newClass.typesInScope())
+		if base.dataType != nil && base.dataType.TypeCategory == Interface {
+			extend, implement = text.NamedType{}, extend
+		}
+	}
+
+	declareClass(extend, Class)
+	declareClass(implement, Interface)
 
 	t.current = newClass
-	t.table[newClass.name] = newClass
+	if class.IsRecord() {
+		t.addRecordMethods(class)
+	}
+}
 
+// enterNestedClass keep the state of the enclosing class,
+// so it can be continued after the nested class
+func (t *TypeAnalyzer) enterNestedClass() {
+	t.enclosing = append(t.enclosing, typeContext{
+		t.current,
+		t.methodVars,
+		t.locals,
+		t.isStatic,
+	})
+	t.methodVars, t.locals, t.isStatic = nil, nil, false
 }
+
+func (t *TypeAnalyzer) exitNestedClass() {
+	last := len(t.enclosing) - 1
+	context := t.enclosing[last]
+	t.enclosing = t.enclosing[:last]
+	t.current = context.current
+	t.methodVars = context.methodVars
+	t.locals = context.locals
+	t.isStatic = context.isStatic
+}
+
+// declareNested relate a nested class with the class that enclose it,
+// a local or anonymous class also see the types declared in its block.
+func (t *TypeAnalyzer) declareNested(kind text.ClassKind, nested *TypeSymbol) {
+	context := &t.enclosing[len(t.enclosing)-1]
+	outer := context.current
+	nested.outer = outer
+	if kind == text.NestedClass || kind == text.InnerClass {
+		nested.isInner = kind == text.InnerClass
+		outer.members = append(outer.members, nested)
+		return
+	}
+
+	// the enclosing instance does not exist in a static method
+	nested.isInner = !context.isStatic
+	if kind == text.LocalClass {
+		context.locals = append([]*TypeSymbol{nested}, context.locals...)
+	}
+
+	scope := append(append([]*TypeSymbol{}, context.locals...), context.methodVars...)
+	nested.declaredScope = append(scope, outer.typesInScope()...)
+}
+
+// declareTypeParams create the type variables, their bounds are resolved
+// after all of them are declared since it may refer to each other.
+func (t *TypeAnalyzer) declareTypeParams(params []text.TypeParameter, outer []*TypeSymbol) []*TypeSymbol {
+	vars := make([]*TypeSymbol, 0, len(params))
+	for _, param := range params {
+		if lookupTypeVariable(vars, param.Name) != nil {
+			t.AddErrorf(msgTypeAlreadyDeclared, param.Name)
+			continue
+		}
+		vars = append(vars, NewType(param.Name, TypeVariable))
+	}
+
+	scope := append(append([]*TypeSymbol{}, vars...), outer...)
+	for _, param := range params {
+		if param.Bound == nil {
+			continue
+		}
+
+		bound, msg := t.resolve(*param.Bound, scope)
+		if len(msg) > 0 {
+			t.AddError(msg)
+			continue
+		}
+
+		if IsPrimitive(bound) || bound.isArray {
+			t.AddErrorf(msgInvalidTypeBound, bound)
+			continue
+		}
+
+		v := lookupTypeVariable(vars, param.Name)
+		if bound.dataType.TypeCategory == Interface {
+			v.implements = bound.dataType
+		} else {
+			v.extends = bound.dataType
+		}
+	}
+	return vars
+}
+
+// typeVariables get every type variable and nested type in scope,
+// those of the method shadow the one of the current type.
+func (t *TypeAnalyzer) typeVariables(methodVars []*TypeSymbol) []*TypeSymbol {
+	return append(append([]*TypeSymbol{}, methodVars...), t.current.typesInScope()...)
+}
+
+// resolve get the data type of named, the types it refer which are
+// not declared are imported from the classpath beforehand.
+func (t *TypeAnalyzer) resolve(named text.NamedType, vars []*TypeSymbol) (DataType, string) {
+	t.importNamed(named)
+	dt, msg := t.table.resolve(named, vars)
+	if len(msg) > 0 {
+		msg = t.notImported(named, msg)
+	}
+	return dt, msg
+}
+
+// resolveType get the data type of named along with the type variables
+// of the current type and method, the error is collected if any.
+func (t *TypeAnalyzer) resolveType(named text.NamedType, methodVars []*TypeSymbol) (DataType, bool) {
+	dt, msg := t.resolve(named, t.typeVariables(methodVars))
+	if len(msg) > 0 {
+		t.AddError(msg)
+		return dt, false
+	}
+	return dt, true
+}
+
 func (t *TypeAnalyzer) VisitAfterClass(class *text.Class) {
+	if class.Kind != text.TopLevelClass {
+		defer t.exitNestedClass()
+	}
+
 	t.addConstructorIfEmpty(class.Name)
+	t.checkFinalOverride()
+	signatures := make([]*text.MethodSignature, len(class.Methods))
+	for i, method := range class.Methods {
+		signatures[i] = &method.MethodSignature
+	}
+	t.checkOverride(signatures)
+	t.checkFieldHiding(class.Properties)
+
 	inf := t.current.implements
 	if inf == nil {
 		return
 	}
 
-	// check for lack of implemented methods
-	for key := range inf.Methods {
-		_, exist := t.current.Methods[key]
-		if !exist {
+	// check for lack of implemented methods, the methods of a generic
+	// interface are compared after its type variables is substituted
+	for key, method := range inf.base().Methods {
+		// static methods of an interface are not inherited
+		if method.isStatic {
+			continue
+		}
+
+		if inf.generic != nil {
+			key = inf.substituteMethod(method).String()
+		}
+
+		// the method can also be inherited, e.g. toString of java.lang.Object
+		if implemented := t.current.LookupMethod(key); implemented == nil {
 			t.AddErrorf(msgMustImplementMethod, key)
+		} else {
+			t.checkOverriding(key, implemented, inf.substituteMethod(method), inf)
+		}
+	}
+}
+
+// checkFinalOverride report every method of the current class
+// that override a final method of its super classes
+func (t *TypeAnalyzer) checkFinalOverride() {
+	for parent := t.current.extends; parent != nil; parent = parent.extends {
+		for _, method := range parent.base().Methods {
+			if method.accessMod&text.Final == 0 || method.accessMod&text.Private != 0 {
+				continue
+			}
+
+			key := parent.substituteMethod(method).String()
+			if _, exist := t.current.Methods[key]; exist {
+				t.AddErrorf(msgCannotOverrideFinal, key, parent.base().name)
+			}
 		}
 	}
 }
@@ -136,13 +387,21 @@ func (t *TypeAnalyzer) addConstructorIfEmpty(name string) {
 	}
 
 	signature := name + "()"
-	t.table[name].Methods[signature] = &MethodSymbol{
+	constructor := &MethodSymbol{
 		DataType{t.table[name], false},
 		text.Public,
 		name,
 		make([]DataType, 0),
+		false,
+		nil,
+		nil,
+		false,
+		false,
+	}
+	t.table[name].Methods[signature] = constructor
+	if len(t.table[name].constructors) == 0 {
+		t.table[name].constructors = []*MethodSymbol{constructor}
 	}
-
 }
 
 func (t *TypeAnalyzer) VisitInterface(inf *text.Interface) {
@@ -153,12 +412,96 @@ func (t *TypeAnalyzer) VisitInterface(inf *text.Interface) {
 
 	t.current = NewType(inf.Name, Interface)
 	t.table[inf.Name] = t.current
+	t.current.typeParams = t.declareTypeParams(inf.TypeParams, nil)
+}
+func (t *TypeAnalyzer) VisitAfterInterface(inf *text.Interface) {
+	t.checkOverride(inf.Methods)
+}
+
+func (t *TypeAnalyzer) VisitEnum(enum *text.Enum) {
+	if t.typeExist(enum.Name) {
+		t.AddErrorf(msgTypeAlreadyDeclared, enum.Name)
+		return
+	}
+
+	t.VisitClass(&enum.Class)
+	t.current.TypeCategory = Enum
+	self := DataType{t.current, false}
+	for _, constant := range enum.Constants {
+		t.current.constants = append(t.current.constants, constant.Name)
+		t.current.Properties[constant.Name] = &PropertySymbol{
+			text.Public,
+			FieldSymbol{self, constant.Name},
+			nil,
+		}
+	}
+
+	// implicit members of every enum
+	t.addImplicitMethod("values", DataType{t.current, true}, true)
+	t.addImplicitMethod("ordinal", DataType{PrimitiveInt, false}, false)
+	t.addImplicitMethod("name", DataType{PrimitiveString, false}, false)
+}
+
+// addRecordMethods declare the methods every record has,
+// which are generated unless they are declared explicitly
+func (t *TypeAnalyzer) addRecordMethods(class *text.Class) {
+	t.current.isRecord = true
+	if class.DeclaredMethod("equals", "Object") == nil {
+		t.addImplicitMethod("equals", DataType{PrimitiveBoolean, false}, false, DataType{javaLangObject, false})
+	}
+
+	if class.DeclaredMethod("hashCode") == nil {
+		t.addImplicitMethod("hashCode", DataType{PrimitiveInt, false}, false)
+	}
+
+	if class.DeclaredMethod("toString") == nil {
+		t.addImplicitMethod("toString", DataType{PrimitiveString, false}, false)
+	}
+}
+
+func (t *TypeAnalyzer) addImplicitMethod(name string, returnType DataType, isStatic bool, args ...DataType) {
+	if args == nil {
+		args = make([]DataType, 0)
+	}
+
+	t.current.Methods[name+"("+typesString(args)+")"] = &MethodSymbol{
+		returnType,
+		text.Public,
+		name,
+		args,
+		isStatic,
+		nil,
+		nil,
+		false,
+		false,
+	}
+}
+
+// VisitConstructor register the constructor, so the arguments of
+// object creation can be checked and converted into its parameters
+func (t *TypeAnalyzer) VisitConstructor(con *text.ConstructorDeclaration) {
+	typeParams := t.declareTypeParams(con.TypeParams, t.current.typesInScope())
+	t.methodVars = typeParams
+	t.current.constructors = append(t.current.constructors, &MethodSymbol{
+		DataType{t.current, false},
+		con.AccessModifier,
+		con.Name,
+		t.resolveParameters(con.ParameterList, typeParams),
+		false,
+		typeParams,
+		nil,
+		hasVarargs(con.ParameterList),
+		false,
+	})
+}
+
+func (t *TypeAnalyzer) VisitAfterEnum(enum *text.Enum) {
+	t.VisitAfterClass(&enum.Class)
 }
-func (t *TypeAnalyzer) VisitAfterInterface(inf *text.Interface) {}
 
 func (t *TypeAnalyzer) VisitPropertyDeclaration(prop *text.PropertyDeclaration) {
-	if !t.typeExist(prop.Type.Name) {
-		t.AddErrorf(msgTypeNotExist, prop.Type.Name)
+	propType, ok := t.resolveType(prop.Type, nil)
+	if !ok {
 		return
 	}
 
@@ -167,66 +510,114 @@ func (t *TypeAnalyzer) VisitPropertyDeclaration(prop *text.PropertyDeclaration)
 		return
 	}
 
+	var constant *constantVariable
+	isConstant := prop.AccessModifier&(text.Static|text.Final) == text.Static|text.Final
+	if isConstant && prop.Value != nil && isConstantType(propType) {
+		constant = &constantVariable{t.current, prop.Value, nil, false}
+	}
+
 	t.current.Properties[prop.Name] = &PropertySymbol{
 		prop.AccessModifier,
 		FieldSymbol{
-			DataType{t.table[prop.Type.Name], prop.Type.IsArray},
+			propType,
 			prop.Name,
 		},
+		constant,
 	}
 }
 
 func (t *TypeAnalyzer) VisitMethodSignature(signature *text.MethodSignature) {
-	var typeof *TypeSymbol
+	var returnType DataType
 
-	if _, exist := t.current.Properties[signature.Name]; exist {
+	// the accessor of a record is named after its field
+	if _, exist := t.current.Properties[signature.Name]; exist && !t.current.isRecord {
 		t.AddErrorf(msgMethodAlreadyDeclaredAsProp, signature.Name)
 	} else if _, exist := t.current.Methods[signature.Signature()]; exist {
 		t.AddErrorf(msgMethodIsAlreadyDeclared, signature.Signature())
 	}
 
+	typeParams := t.declareTypeParams(signature.TypeParams, t.current.typesInScope())
+	t.methodVars = typeParams
 	if signature.ReturnType.Name == "void" {
-		typeof = NewType("void", Primitive)
-	} else if !t.typeExist(signature.ReturnType.Name) {
-		t.AddErrorf(msgTypeNotExist, signature.ReturnType.Name)
+		returnType = DataType{NewType("void", Primitive), signature.ReturnType.IsArray}
+	} else if dt, ok := t.resolveType(signature.ReturnType, typeParams); !ok {
 		return
 	} else {
-		typeof = t.table[signature.ReturnType.Name]
+		returnType = dt
+	}
+
+	method := &MethodSymbol{
+		returnType,
+		signature.AccessModifier,
+		signature.Name,
+		t.resolveParameters(signature.ParameterList, typeParams),
+		false,
+		typeParams,
+		nil,
+		hasVarargs(signature.ParameterList),
+		false,
 	}
+	t.current.Methods[signature.Signature()] = method
+}
 
-	parameters := make([]DataType, len(signature.ParameterList))
-	for i, param := range signature.ParameterList {
-		if !t.typeExist(param.Type.Name) {
+func (t *TypeAnalyzer) resolveParameters(params []text.Parameter, typeParams []*TypeSymbol) []DataType {
+	parameters := make([]DataType, len(params))
+	for i, param := range params {
+		dt, msg := t.resolve(param.Type, t.typeVariables(typeParams))
+		if dt.dataType == nil {
 			t.AddErrorf(msgTypeNotExist, param.Name)
 			continue
 		}
 
-		parameters[i] = DataType{
-			t.table[param.Type.Name],
-			param.Type.IsArray,
+		if len(msg) > 0 {
+			t.AddError(msg)
 		}
+		parameters[i] = dt
 	}
+	return parameters
+}
 
-	t.current.Methods[signature.Signature()] = &MethodSymbol{
-		DataType{typeof, signature.ReturnType.IsArray},
-		signature.AccessModifier,
-		signature.Name,
-		parameters,
-	}
+func (t *TypeAnalyzer) VisitMainMethodDeclaration(*text.MainMethodDeclaration) {
+	t.isStatic = true
+}
+
+func (t *TypeAnalyzer) VisitMethodDeclaration(*text.MethodDeclaration) {}
+
+// VisitAfterMethodDeclaration forget the type variables and local
+// classes of the method, it is also called after the main method
+func (t *TypeAnalyzer) VisitAfterMethodDeclaration(*text.MethodDeclaration) {
+	t.methodVars, t.locals, t.isStatic = nil, nil, false
+}
+
+func (t *TypeAnalyzer) VisitAfterConstructor(*text.ConstructorDeclaration) {
+	t.methodVars, t.locals = nil, nil
+}
+
+func (t *TypeAnalyzer) VisitConstructorCall(*text.ConstructorCallStatement)      {}
+func (t *TypeAnalyzer) VisitAfterConstructorCall(*text.ConstructorCallStatement) {}
+
+func (t *TypeAnalyzer) VisitMethodCallStatement(*text.MethodCallStatement)      {}
+func (t *TypeAnalyzer) VisitAfterMethodCallStatement(*text.MethodCallStatement) {}
+
+// VisitInitializer start an initializer block, a static
+// block does not have an enclosing instance like main
+func (t *TypeAnalyzer) VisitInitializer(init *text.Initializer) {
+	t.isStatic = init.IsStatic
+}
+
+func (t *TypeAnalyzer) VisitAfterInitializer(*text.Initializer) {
+	t.methodVars, t.locals, t.isStatic = nil, nil, false
 }
 
-func (t *TypeAnalyzer) VisitMainMethodDeclaration(*text.MainMethodDeclaration)  {}
-func (t *TypeAnalyzer) VisitMethodDeclaration(*text.MethodDeclaration)          {}
-func (t *TypeAnalyzer) VisitAfterMethodDeclaration(*text.MethodDeclaration)     {}
-func (t *TypeAnalyzer) VisitConstructor(*text.ConstructorDeclaration)           {}
-func (t *TypeAnalyzer) VisitAfterConstructor(*text.ConstructorDeclaration)      {}
-func (t *TypeAnalyzer) VisitVariableDeclaration(*text.VariableDeclaration)      {}
 func (t *TypeAnalyzer) VisitAfterVariableDeclaration(*text.VariableDeclaration) {}
 func (t *TypeAnalyzer) VisitStatementList(text.StatementList)                   {}
 func (t *TypeAnalyzer) VisitAfterStatementList()                                {}
 func (t *TypeAnalyzer) VisitSwitchStatement(*text.SwitchStatement)              {}
 func (t *TypeAnalyzer) VisitAfterSwitchStatement(*text.SwitchStatement)         {}
 func (t *TypeAnalyzer) VisitSwitchCase(*text.CaseStatement)                     {}
+func (t *TypeAnalyzer) VisitSwitchDefault(*text.SwitchStatement)                {}
+func (t *TypeAnalyzer) VisitSwitchExpression(*text.SwitchExpression)            {}
+func (t *TypeAnalyzer) VisitAfterSwitchExpression(*text.SwitchExpression)       {}
 func (t *TypeAnalyzer) VisitIfStatement(*text.IfStatement)                      {}
 func (t *TypeAnalyzer) VisitAfterIfStatementCondition(*text.IfStatement)        {}
 func (t *TypeAnalyzer) VisitAfterIfStatementBody(*text.IfStatement)             {}
@@ -237,25 +628,31 @@ func (t *TypeAnalyzer) VisitAfterForStatementInit(*text.ForStatement)
 func (t *TypeAnalyzer) VisitAfterForStatementCondition(*text.ForStatement)      {}
 func (t *TypeAnalyzer) VisitBeforeForStatementUpdate(*text.ForStatement)        {}
 func (t *TypeAnalyzer) VisitAfterForStatement(*text.ForStatement)               {}
+func (t *TypeAnalyzer) VisitForEachStatement(*text.ForEachStatement)            {}
+func (t *TypeAnalyzer) VisitAfterForEachIterable(*text.ForEachStatement)        {}
+func (t *TypeAnalyzer) VisitAfterForEachStatement(*text.ForEachStatement)       {}
 func (t *TypeAnalyzer) VisitWhileStatement(*text.WhileStatement)                {}
 func (t *TypeAnalyzer) VisitAfterWhileStatementCondition(*text.WhileStatement)  {}
 func (t *TypeAnalyzer) VisitAfterWhileStatement(*text.WhileStatement)           {}
+func (t *TypeAnalyzer) VisitAssertStatement(*text.AssertStatement)              {}
+func (t *TypeAnalyzer) VisitAfterAssertCondition(*text.AssertStatement)         {}
+func (t *TypeAnalyzer) VisitAfterAssertStatement(*text.AssertStatement)         {}
 func (t *TypeAnalyzer) VisitAssignmentStatement(*text.AssignmentStatement)      {}
 func (t *TypeAnalyzer) VisitAfterAssignmentStatement(*text.AssignmentStatement) {}
 func (t *TypeAnalyzer) VisitJumpStatement(*text.JumpStatement)                  {}
 func (t *TypeAnalyzer) VisitAfterJumpStatement(*text.JumpStatement)             {}
-func (t *TypeAnalyzer) VisitFieldAccess(*text.FieldAccess)                      {}
 func (t *TypeAnalyzer) VisitArrayAccess(*text.ArrayAccess)                      {}
 func (t *TypeAnalyzer) VisitAfterArrayAccess(*text.ArrayAccess)                 {}
 func (t *TypeAnalyzer) VisitArrayAccessDelegate(text.NamedValue)                {}
 func (t *TypeAnalyzer) VisitMethodCall(*text.MethodCall)                        {}
 func (t *TypeAnalyzer) VisitAfterMethodCall(*text.MethodCall)                   {}
-func (t *TypeAnalyzer) VisitArrayCreation(*text.ArrayCreation)                  {}
 func (t *TypeAnalyzer) VisitAfterArrayCreation(*text.ArrayCreation)             {}
-func (t *TypeAnalyzer) VisitObjectCreation(*text.ObjectCreation)                {}
 func (t *TypeAnalyzer) VisitAfterObjectCreation(*text.ObjectCreation)           {}
 func (t *TypeAnalyzer) VisitBinOp(*text.BinOp)                                  {}
 func (t *TypeAnalyzer) VisitAfterBinOp(*text.BinOp)                             {}
+func (t *TypeAnalyzer) VisitUnaryOp(*text.UnaryOp)                              {}
+func (t *TypeAnalyzer) VisitAfterUnaryOp(*text.UnaryOp)                         {}
+func (t *TypeAnalyzer) VisitAfterCast(*text.Cast)                               {}
+func (t *TypeAnalyzer) VisitLambda(*text.Lambda)                                {}
+func (t *TypeAnalyzer) VisitAfterLambda(*text.Lambda)                           {}
 func (t *TypeAnalyzer) VisitConstant(text.Expression)                           {}
-func (t *TypeAnalyzer) VisitSystemOut()                                         {}
-func (t *TypeAnalyzer) VisitAfterSystemOut()                                    {}
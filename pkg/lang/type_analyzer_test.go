@@ -2,8 +2,10 @@ package lang
 
 import (
 	"fmt"
+	"reflect"
 	"testing"
 
+	"github.com/gumelarme/yava/pkg/classfile"
 	"github.com/gumelarme/yava/pkg/text"
 )
 
@@ -199,6 +201,88 @@ func TestTypeAnalyzer_Interface_alreadyDeclared(t *testing.T) {
 	}
 }
 
+func TestTypeAnalyzer_Enum(t *testing.T) {
+	color := text.NewEmptyEnum("Color", "")
+	color.AddConstant(&text.EnumConstant{Name: "RED"})
+	color.AddConstant(&text.EnumConstant{Name: "GREEN"})
+	color.AddDeclaration(propAge)
+
+	engine := NewTypeAnalyzer()
+	color.Accept(engine)
+	if errors := engine.Errors(); len(errors) != 0 {
+		t.Fatalf("Expected no error, but got `%s`", errors[0])
+	}
+
+	colorType := engine.table["Color"]
+	if colorType == nil || colorType.TypeCategory != Enum {
+		t.Fatalf("Expecting 'Color' to be present in the table as enum")
+	}
+
+	for i, name := range []string{"RED", "GREEN"} {
+		if ordinal := colorType.ordinalOf(name); ordinal != i {
+			t.Errorf("Expecting %s to have ordinal %d but got %d", name, i, ordinal)
+		}
+
+		prop := colorType.Properties[name]
+		if !isEnumConstant(colorType, prop) {
+			t.Errorf("Expecting %s to be an enum constant", name)
+		}
+	}
+
+	if isEnumConstant(colorType, colorType.Properties["age"]) {
+		t.Errorf("Property age should not be an enum constant")
+	}
+
+	methods := []struct {
+		signature  string
+		returnType DataType
+		isStatic   bool
+	}{
+		{"values()", DataType{colorType, true}, true},
+		{"ordinal()", DataType{PrimitiveInt, false}, false},
+		{"name()", DataType{PrimitiveString, false}, false},
+	}
+
+	for _, m := range methods {
+		method := colorType.Methods[m.signature]
+		if method == nil {
+			t.Errorf("Expecting enum to have implicit method %s", m.signature)
+			continue
+		}
+
+		if method.DataType != m.returnType || method.isStatic != m.isStatic {
+			t.Errorf("Expecting %s to return %s (static: %v), but got %s (static: %v)",
+				m.signature, m.returnType, m.isStatic, method.DataType, method.isStatic)
+		}
+	}
+}
+
+func TestTypeAnalyzer_Enum_errors(t *testing.T) {
+	color := text.NewEmptyEnum("Color", "")
+	color.AddConstant(&text.EnumConstant{Name: "RED"})
+
+	engine := NewTypeAnalyzer()
+	color.Accept(engine)
+	text.NewEmptyClass("Hue", "Color", "").Accept(engine)
+	color.Accept(engine)
+
+	expect := []string{
+		fmt.Sprintf(msgEnumCantBeExtended, "Color"),
+		fmt.Sprintf(msgTypeAlreadyDeclared, "Color"),
+	}
+
+	errors := engine.Errors()
+	if len(errors) != len(expect) {
+		t.Fatalf("Expecting %d errors but got %v", len(expect), errors)
+	}
+
+	for i, msg := range expect {
+		if errors[i].Error() != msg {
+			t.Errorf("Expecting error `%s` but got `%s`", msg, errors[i])
+		}
+	}
+}
+
 func TestTypeAnalyzer_AfterClass(t *testing.T) {
 	callable := *interfaceCallable
 	callable.AddMethod(&methodGetAge.MethodSignature)
@@ -239,6 +323,7 @@ func TestTypeAnalyzer_PropertyDeclaration(t *testing.T) {
 			},
 			name: "age",
 		},
+		nil,
 	}
 
 	expect := map[string]*TypeSymbol{
@@ -414,3 +499,460 @@ func TestTypeAnalyzer_MethodDeclaration_error(t *testing.T) {
 		t.Errorf("Should have got error of :\n\t%s", msg)
 	}
 }
+
+// analyzeTypes run a type analyzer on the program in content
+func analyzeTypes(t *testing.T, content string) *TypeAnalyzer {
+	lexer := text.NewLexer(text.NewStringScanner(content))
+	parser := text.NewParser(&lexer)
+	engine := NewTypeAnalyzer()
+	parser.Compile().Accept(engine)
+	return engine
+}
+
+// typeError is a program and the only error it should have
+type typeError struct {
+	content string
+	expect  string
+}
+
+// checkTypeErrors analyze every program with analyze,
+// each one should only have the expected error.
+func checkTypeErrors(t *testing.T, analyze func(*testing.T, string) *TypeAnalyzer, data []typeError) {
+	t.Helper()
+	for _, d := range data {
+		errors := analyze(t, d.content).Errors()
+		if len(errors) != 1 || errors[0].Error() != d.expect {
+			t.Errorf("%s expected to have an error of: \n%s \nbut got: \n%v", d.content, d.expect, errors)
+		}
+	}
+}
+
+func TestTypeAnalyzer_Generics_errors(t *testing.T) {
+	data := []typeError{
+		{
+			`class Box<T, T> {}`,
+			fmt.Sprintf(msgTypeAlreadyDeclared, "T"),
+		},
+		{
+			`class Box<T extends Nice> {}`,
+			fmt.Sprintf(msgTypeNotExist, "Nice"),
+		},
+		{
+			`class Base<T> {} class Box extends Base<int> {}`,
+			fmt.Sprintf(msgPrimitiveTypeArgument, "int"),
+		},
+		{
+			`class A extends A {}`,
+			fmt.Sprintf(msgCyclicInheritance, "A"),
+		},
+		{
+			`interface Comparable<T> { public int compareTo(T other); }
+			class Item implements Comparable<Item> { public int compareTo(String other) { return 0; } }`,
+			fmt.Sprintf(msgMustImplementMethod, "compareTo(Item)"),
+		},
+	}
+	checkTypeErrors(t, analyzeTypes, data)
+}
+
+func TestTypeAnalyzer_Object(t *testing.T) {
+	content := `interface INamed { public String toString(); public boolean equals(Object o); }
+	class Rock implements INamed {}
+	class Stone extends Rock {
+		public boolean equals(Object other) { return true; }
+	}`
+	engine := analyzeTypes(t, content)
+
+	if errors := engine.Errors(); len(errors) != 0 {
+		t.Fatalf("Should not have any error, but got: %s", errors[0])
+	}
+
+	rock, stone := engine.table["Rock"], engine.table["Stone"]
+	if !stone.isDescendantOf(javaLangObject) || !engine.table["INamed"].isDescendantOf(javaLangObject) {
+		t.Errorf("Every class and interface should be a descendant of %s", javaLangObject.name)
+	}
+
+	if method := rock.LookupMethod("toString()"); method != javaLangObject.Methods["toString()"] {
+		t.Errorf("Rock should inherit toString() of %s, but got %v", javaLangObject.name, method)
+	}
+
+	if method := stone.LookupMethod("equals(Object)"); method == nil || method != stone.Methods["equals(Object)"] {
+		t.Errorf("Stone should override equals(Object), but got %v", method)
+	}
+}
+
+func TestTypeAnalyzer_NestedClass(t *testing.T) {
+	content := `class Outer {
+		class Inner {}
+		static class Nested {
+			public Inner inner;
+		}
+	}`
+	engine := analyzeTypes(t, content)
+
+	if errors := engine.Errors(); len(errors) != 0 {
+		t.Fatalf("Should not have any error, but got: %s", errors[0])
+	}
+
+	outer, table := engine.table["Outer"], engine.table
+	data := []struct {
+		name    string
+		isInner bool
+	}{
+		{"Outer$Inner", true},
+		{"Outer$Nested", false},
+	}
+
+	for _, d := range data {
+		typeof := table[d.name]
+		if typeof == nil {
+			t.Errorf("Type %s should be declared", d.name)
+			continue
+		}
+
+		if typeof.outer != outer || typeof.isInner != d.isInner {
+			t.Errorf("Type %s should be nested in Outer with isInner of %v", d.name, d.isInner)
+		}
+	}
+
+	if prop := table["Outer$Nested"].Properties["inner"]; prop == nil || prop.dataType != table["Outer$Inner"] {
+		t.Errorf("Property inner should be resolved into Outer$Inner")
+	}
+}
+
+func TestTypeAnalyzer_Final_errors(t *testing.T) {
+	data := []typeError{
+		{
+			`final class Leaf {} class Sub extends Leaf {}`,
+			fmt.Sprintf(msgCannotExtendFinal, "Leaf"),
+		},
+		{
+			`class Base { public final int get() { return 1; } }
+			class Derived extends Base { public int get() { return 2; } }`,
+			fmt.Sprintf(msgCannotOverrideFinal, "get()", "Base"),
+		},
+		{
+			`class Base { public final int get() { return 1; } }
+			class Middle extends Base {}
+			class Derived extends Middle { public int get() { return 2; } }`,
+			fmt.Sprintf(msgCannotOverrideFinal, "get()", "Base"),
+		},
+	}
+	checkTypeErrors(t, analyzeTypes, data)
+}
+
+func TestTypeAnalyzer_Override(t *testing.T) {
+	content := `interface Shape { Shape copy(); }
+	class Base {
+		public Object make() { return null; }
+		public Object[] all() { return null; }
+		protected int size() { return 0; }
+		void reset() {}
+		private int secret() { return 0; }
+	}
+	class Sub extends Base {
+		@Override public String make() { return ""; }
+		@Override public String[] all() { return null; }
+		@Override public int size() { return 1; }
+		@Override protected void reset() {}
+		public String secret() { return ""; }
+		@Override @Deprecated public String toString() { return ""; }
+	}
+	class Square implements Shape {
+		@Override public Square copy() { return null; }
+	}
+	class Box<T> { public T get() { return null; } }
+	class Names extends Box<String> { @Override public String get() { return ""; } }
+	interface Named { @Override String toString(); }`
+
+	engine := analyzeTypes(t, content)
+
+	if errors := engine.Errors(); len(errors) != 0 {
+		t.Errorf("Should not have any error, but got: %v", errors)
+	}
+}
+
+func TestTypeAnalyzer_Override_errors(t *testing.T) {
+	data := []typeError{
+		{
+			`class Base { public Object make() { return null; } }
+			class Sub extends Base { public int make() { return 1; } }`,
+			fmt.Sprintf(msgIncompatibleReturnType, "make()", "Base", "int", "java/lang/Object"),
+		},
+		{
+			`class Base { public long count() { return 1; } }
+			class Sub extends Base { public int count() { return 1; } }`,
+			fmt.Sprintf(msgIncompatibleReturnType, "count()", "Base", "int", "long"),
+		},
+		{
+			`class Base { public void run() {} }
+			class Middle extends Base {}
+			class Sub extends Middle { public int run() { return 1; } }`,
+			fmt.Sprintf(msgIncompatibleReturnType, "run()", "Base", "int", "void"),
+		},
+		{
+			`class Item {} class Box<T> { public T get() { return null; } }
+			class Names extends Box<String> { public Item get() { return null; } }`,
+			fmt.Sprintf(msgIncompatibleReturnType, "get()", "Box", "Item", "String"),
+		},
+		{
+			`class Count { public long hashCode() { return 1; } }`,
+			fmt.Sprintf(msgIncompatibleReturnType, "hashCode()", "java/lang/Object", "long", "int"),
+		},
+		{
+			`class Base { public void run() {} }
+			class Sub extends Base { private void run() {} }`,
+			fmt.Sprintf(msgWeakerAccess, "run()", "Base"),
+		},
+		{
+			`class Base { protected void run() {} }
+			class Sub extends Base { void run() {} }`,
+			fmt.Sprintf(msgWeakerAccess, "run()", "Base"),
+		},
+		{
+			`class Name { String toString() { return ""; } }`,
+			fmt.Sprintf(msgWeakerAccess, "toString()", "java/lang/Object"),
+		},
+		{
+			`class Base { public void run() {} }
+			class Sub extends Base { @Override public void walk() {} }`,
+			fmt.Sprintf(msgMethodDoesNotOverride, "walk()"),
+		},
+		{
+			`class Base { private void run() {} }
+			class Sub extends Base { @Override public void run() {} }`,
+			fmt.Sprintf(msgMethodDoesNotOverride, "run()"),
+		},
+		{
+			`class Base { public void run(int times) {} }
+			class Sub extends Base { @Override public void run(long times) {} }`,
+			fmt.Sprintf(msgMethodDoesNotOverride, "run(long)"),
+		},
+		{
+			`interface Named { @Override String name(); }`,
+			fmt.Sprintf(msgMethodDoesNotOverride, "name()"),
+		},
+		{
+			`class Sub { @Overide public String toString() { return ""; } }`,
+			fmt.Sprintf(msgUnknownAnnotation, "Overide"),
+		},
+		{
+			`interface Shape { double area(); }
+			class Square implements Shape { public int area() { return 1; } }`,
+			fmt.Sprintf(msgIncompatibleReturnType, "area()", "Shape", "int", "double"),
+		},
+		{
+			`interface Shape { double area(); }
+			class Square implements Shape { double area() { return 1.0; } }`,
+			fmt.Sprintf(msgWeakerAccess, "area()", "Shape"),
+		},
+	}
+	checkTypeErrors(t, analyzeTypes, data)
+}
+
+func TestTypeAnalyzer_FieldHiding(t *testing.T) {
+	content := `class A { public int x; protected String y; private int secret; int z; }
+	class B extends A { public long x; private int secret; }
+	class C extends B { public int y; public int secret; }
+	class D extends C { public int x; public int w; }
+	class Box<T> { public T item; }
+	class Names extends Box<String> { public int item; }`
+
+	engine := analyzeTypes(t, content)
+
+	if errors := engine.Errors(); len(errors) != 0 {
+		t.Errorf("Should not have any error, but got: %v", errors)
+	}
+
+	// the owner is the nearest class above which declare the field,
+	// a private field is not inherited, so it is not hidden
+	expect := []string{
+		fmt.Sprintf(msgFieldHides, "x", "A"),
+		fmt.Sprintf(msgFieldHides, "y", "A"),
+		fmt.Sprintf(msgFieldHides, "x", "B"),
+		fmt.Sprintf(msgFieldHides, "item", "Box"),
+	}
+
+	if warnings := engine.Warnings(); !reflect.DeepEqual(warnings, expect) {
+		t.Errorf("Expecting warnings: \n%v \nbut got: \n%v", expect, warnings)
+	}
+}
+
+var testClasspath = "../classfile/testdata/helpers.jar"
+
+func analyzeWithClasspath(t *testing.T, content string) *TypeAnalyzer {
+	cp, err := classfile.NewClasspath(testClasspath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { cp.Close() })
+
+	lexer := text.NewLexer(text.NewStringScanner(content))
+	parser := text.NewParser(&lexer)
+	engine := NewTypeAnalyzer()
+	engine.UseClasspath(cp)
+	parser.Compile().Accept(engine)
+	return engine
+}
+
+func TestTypeAnalyzer_Classpath(t *testing.T) {
+	content := `import com.acme.*;
+	class Circle implements Shape {
+		public Greeter greeter;
+		public double area() { return 1.0; }
+	}`
+	engine := analyzeWithClasspath(t, content)
+	if errors := engine.Errors(); len(errors) != 0 {
+		t.Fatalf("Should not have any error, but got: %s", errors[0])
+	}
+
+	greeter, shape := engine.table["Greeter"], engine.table["Shape"]
+	if greeter == nil || greeter.name != "com/acme/Greeter" || greeter.TypeCategory != Class {
+		t.Fatalf("Greeter should be imported as com/acme/Greeter, but got %v", greeter)
+	}
+
+	if shape == nil || shape.TypeCategory != Interface || engine.table["Circle"].implements != shape {
+		t.Fatalf("Circle should implement the imported interface Shape, but got %v", shape)
+	}
+
+	// a type is only imported when it is referred
+	if square := engine.table["Square"]; square != nil {
+		t.Errorf("Square should not be imported, but got %v", square)
+	}
+
+	if version := greeter.Properties["VERSION"]; version == nil ||
+		version.AccessModifier != text.Public|text.Static|text.Final || version.DataType != mockInt {
+		t.Errorf("VERSION should be a public static final int, but got %v", version)
+	}
+
+	if grid := greeter.Properties["grid"]; grid != nil {
+		t.Errorf("A field of a type which cannot be written should be left out, but got %v", grid)
+	}
+
+	data := []struct {
+		key, descriptor    string
+		isStatic, isVarags bool
+	}{
+		{"greet(String)", "(Ljava/lang/String;)Ljava/lang/String;", false, false},
+		{"twice(int)", "(I)I", true, false},
+		{"sum(int[])", "([I)I", true, true},
+		{"self()", "()Lcom/acme/Greeter;", false, false},
+		{"shape()", "()Lcom/acme/Shape;", false, false},
+		{"builder()", "()Ljava/lang/StringBuilder;", false, false},
+	}
+
+	for _, d := range data {
+		method := greeter.Methods[d.key]
+		if method == nil {
+			t.Errorf("Expecting method %s to be imported", d.key)
+			continue
+		}
+
+		if descriptor := methodDescriptor(method); descriptor != d.descriptor ||
+			method.isStatic != d.isStatic || method.isVarargs != d.isVarags {
+			t.Errorf("Expecting %s to be %s static: %v varargs: %v, but got %s static: %v varargs: %v",
+				d.key, d.descriptor, d.isStatic, d.isVarags, descriptor, method.isStatic, method.isVarargs)
+		}
+	}
+
+	if len(greeter.Methods) != len(data) || len(greeter.constructors) != 2 {
+		t.Errorf("Expecting only %d methods and 2 constructors, but got %v and %v",
+			len(data), greeter.Methods, greeter.constructors)
+	}
+}
+
+func TestTypeAnalyzer_Classpath_interfaces(t *testing.T) {
+	engine := analyzeWithClasspath(t, "import com.acme.*; class Box { public Tile tile; }")
+	if errors := engine.Errors(); len(errors) != 0 {
+		t.Fatalf("Should not have any error, but got: %s", errors[0])
+	}
+
+	tile := engine.table["Tile"]
+	if tile == nil || tile.implements == nil || tile.implements.name != "com/acme/Shape" {
+		t.Fatalf("Tile should implement com/acme/Shape, but got %v", tile)
+	}
+
+	expect := []string{fmt.Sprintf(msgInterfacesIgnored, "com.acme.Tile", "com.acme.Shape", "com.acme.shapes.Shape")}
+	if warnings := engine.Warnings(); !reflect.DeepEqual(warnings, expect) {
+		t.Errorf("Expecting warnings %v, but got %v", expect, warnings)
+	}
+
+	// the parameters only differ by their package
+	for _, key := range []string{"fit(com/acme/Shape)", "fit(com/acme/shapes/Shape)"} {
+		if method := tile.Methods[key]; method == nil {
+			t.Errorf("Expecting method %s to be imported, but got %v", key, tile.Methods)
+		}
+	}
+}
+
+func TestTypeAnalyzer_Classpath_error(t *testing.T) {
+	data := []typeError{
+		{`class Blob implements Shape {}`, fmt.Sprintf(msgMustImplementMethod, "area()")},
+		{`class Box extends Square {}`, fmt.Sprintf(msgCannotExtendFinal, "Square")},
+		{`class Box { public Gone gone; }`, fmt.Sprintf(msgTypeNotExist, "Gone")},
+		{`class Box implements Greeter {}`, msgImplementShouldBeOnInterface},
+		{
+			`class Knob extends Widget { protected int count() { return 1; } }`,
+			fmt.Sprintf(msgOverrideStatic, "count()", "com/acme/Widget"),
+		},
+		{
+			`class Knob extends Widget { void resize(int size) {} }`,
+			fmt.Sprintf(msgWeakerAccess, "resize(int)", "com/acme/Widget"),
+		},
+		{
+			`class Knob extends Widget { @Override void internal() {} }`,
+			fmt.Sprintf(msgMethodDoesNotOverride, "internal()"),
+		},
+	}
+
+	checkTypeErrors(t, func(t *testing.T, content string) *TypeAnalyzer {
+		return analyzeWithClasspath(t, "import com.acme.*; import com.acme.shapes.Square;\n"+content)
+	}, data)
+}
+
+func TestTypeAnalyzer_Import(t *testing.T) {
+	content := `import java.util.Scanner;
+	import java.io.*;
+	import com.acme.shapes.Square;
+	class Box {
+		public Scanner in;
+		public BufferedReader reader;
+		public Square square;
+	}`
+	engine := analyzeWithClasspath(t, content)
+	if errors := engine.Errors(); len(errors) != 0 {
+		t.Fatalf("Should not have any error, but got: %s", errors[0])
+	}
+
+	data := map[string]string{
+		"Scanner":        "java/util/Scanner",
+		"BufferedReader": "java/io/BufferedReader",
+		"Square":         "com/acme/shapes/Square",
+	}
+
+	for simple, name := range data {
+		if typeof := engine.table[simple]; typeof == nil || typeof.name != name {
+			t.Errorf("%s should be imported as %s, but got %v", simple, name, typeof)
+		}
+	}
+
+	// a package imported on demand only declare the referred classes
+	if reader := engine.table["InputStreamReader"]; reader != nil {
+		t.Errorf("InputStreamReader should not be imported, but got %v", reader)
+	}
+
+	if scanner := NewTypeAnalyzer().table["Scanner"]; scanner != nil {
+		t.Errorf("Scanner should not be declared without an import, but got %v", scanner)
+	}
+}
+
+func TestTypeAnalyzer_Import_error(t *testing.T) {
+	data := []typeError{
+		{`import java.util.Missing; class Box {}`, fmt.Sprintf(msgImportNotFound, "java.util.Missing")},
+		{`import java.util.*; class Box { public Missing m; }`, fmt.Sprintf(msgTypeNotExist, "Missing")},
+		{`import java.util.Scanner; class Box { public BufferedReader r; }`, fmt.Sprintf(msgTypeNotExist, "BufferedReader")},
+		// only java.lang is visible without an import
+		{`class Box { public Greeter g; }`, fmt.Sprintf(msgClassNotImported, "Greeter", "com.acme.Greeter")},
+		{`import com.acme.*; class Box extends Square {}`, fmt.Sprintf(msgClassNotImported, "Square", "com.acme.shapes.Square")},
+	}
+	checkTypeErrors(t, analyzeWithClasspath, data)
+}
@@ -0,0 +1,60 @@
+package lang
+
+import (
+	"github.com/gumelarme/yava/pkg/text"
+)
+
+var (
+	msgVarWithoutInitializer = "Cannot infer type of local variable %s, 'var' needs an initializer."
+	msgVarNullInitializer    = "Cannot infer type of local variable %s, its initializer is null."
+	msgVarVoidInitializer    = "Cannot infer type of local variable %s, its initializer is void."
+	msgVarLambdaInitializer  = "Cannot infer type of local variable %s, a lambda needs an explicit target type."
+	msgVarNotAllowed         = "'var' is not allowed as an array or a generic type."
+)
+
+// isVar check if the type of a local variable is declared with var,
+// which is not a keyword so it is still a valid name of anything else.
+func isVar(ty text.NamedType) bool {
+	return ty.Name == "var"
+}
+
+// inferVarType get the type of a local variable declared with var from its
+// initializer, which is already popped out of the stack as initType.
+func (n *NameAnalyzer) inferVarType(varDecl *text.VariableDeclaration, initType DataType) (DataType, bool) {
+	switch {
+	case varDecl.Type.IsArray || varDecl.Type.TypeArgs != nil:
+		n.AddError(msgVarNotAllowed)
+	case varDecl.Value == nil:
+		n.AddErrorf(msgVarWithoutInitializer, varDecl.Name)
+	case isLambda(varDecl.Value):
+		// already reported before the lambda is visited
+	case initType.dataType == nil:
+		// the initializer already has an error
+	case initType.Name() == "null":
+		n.AddErrorf(msgVarNullInitializer, varDecl.Name)
+	case initType.Name() == "void":
+		n.AddErrorf(msgVarVoidInitializer, varDecl.Name)
+	default:
+		return initType, true
+	}
+	return initType, false
+}
+
+// forEachVariableType get the type of the variable of an enhanced for
+// statement, which is the type of the element if it is declared with var.
+func (n *NameAnalyzer) forEachVariableType(varDecl *text.VariableDeclaration, element DataType) (DataType, bool) {
+	if isVar(varDecl.Type) {
+		if varDecl.Type.IsArray || varDecl.Type.TypeArgs != nil {
+			n.AddError(msgVarNotAllowed)
+			return element, false
+		}
+		return element, true
+	}
+
+	varType, ok := n.resolveType(varDecl.Type)
+	if ok && !isConvertible(element, varType, assignmentContext) {
+		n.AddErrorf(msgExpectingTypeof, varType, element)
+		return varType, false
+	}
+	return varType, ok
+}
@@ -0,0 +1,77 @@
+package lang
+
+import (
+	"github.com/gumelarme/yava/pkg/text"
+)
+
+// invocationPhase is one of the phases a method is looked up in,
+// a later phase is only tried if no method is found in the earlier ones.
+// They follow the phases of JLS 15.12.2, with an extra phase at first.
+type invocationPhase int
+
+const (
+	// identityInvocation pass every argument as it is, it is not a phase of
+	// the JLS, the exact match is taken before any conversion is tried
+	identityInvocation invocationPhase = iota
+	// wideningInvocation allow the arguments to be converted into the parameters
+	// like an invocation context, it is the strict and the loose phase of
+	// JLS 15.12.2.2 and 15.12.2.3, which are not told apart
+	wideningInvocation
+	// varargsInvocation pass the trailing arguments into the variable arity
+	// parameter, the phase of JLS 15.12.2.4
+	varargsInvocation
+)
+
+var invocationPhases = []invocationPhase{identityInvocation, wideningInvocation, varargsInvocation}
+
+// hasVarargs check if the last parameter has variable arity
+func hasVarargs(params []text.Parameter) bool {
+	return len(params) > 0 && params[len(params)-1].IsVarargs
+}
+
+// acceptIn check if every argument can be passed into the parameter
+// at the same position without any conversion in identityInvocation.
+func (m *MethodSymbol) acceptIn(args []DataType, phase invocationPhase) bool {
+	if phase != identityInvocation {
+		return m.CanAccept(args)
+	}
+
+	if len(m.args) != len(args) {
+		return false
+	}
+
+	for i, arg := range args {
+		if arg.dataType == nil || !isIdentity(arg, m.args[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// expand get the method with its variable arity parameter replaced by
+// as many parameters of its component type to accept count arguments.
+func (m *MethodSymbol) expand(count int) *MethodSymbol {
+	fixed := len(m.args) - 1
+	component := m.args[fixed]
+	component.isArray = false
+
+	args := make([]DataType, count)
+	copy(args, m.args[:fixed])
+	for i := fixed; i < count; i++ {
+		args[i] = component
+	}
+
+	expanded := *m
+	expanded.args = args
+	expanded.origin = m.declared()
+	expanded.isExpanded = true
+	return &expanded
+}
+
+// varargsComponent get the type of the elements of the array
+// that is passed into the variable arity parameter, as it is declared.
+func (m *MethodSymbol) varargsComponent() DataType {
+	declared := m.declared()
+	component := declared.args[len(declared.args)-1]
+	return DataType{component.dataType.erasure(), false}
+}
@@ -71,6 +71,24 @@ const (
 	DivisionAssignment       // /=
 	ModulusAssignment        // %=
 	Colon
+	// Bitwise
+	BitwiseAnd                   // &
+	BitwiseOr                    // |
+	BitwiseXor                   // ^
+	LeftShift                    // <<
+	RightShift                   // >>
+	UnsignedRightShift           // >>>
+	BitwiseAndAssignment         // &=
+	BitwiseOrAssignment          // |=
+	BitwiseXorAssignment         // ^=
+	LeftShiftAssignment          // <<=
+	RightShiftAssignment         // >>=
+	UnsignedRightShiftAssignment // >>>=
+	FloatingPointLiteral
+	Arrow       // ->
+	DoubleColon // ::
+	Ellipsis    // ...
+	At          // @
 )
 
 // return the string representation of the TokenType
@@ -120,6 +138,24 @@ func (t TokenType) String() string {
 		"DivisionAssignment",
 		"ModAssignment",
 		"Colon",
+		// Bitwise
+		"BitwiseAnd",
+		"BitwiseOr",
+		"BitwiseXor",
+		"LeftShift",
+		"RightShift",
+		"UnsignedRightShift",
+		"BitwiseAndAssignment",
+		"BitwiseOrAssignment",
+		"BitwiseXorAssignment",
+		"LeftShiftAssignment",
+		"RightShiftAssignment",
+		"UnsignedRightShiftAssignment",
+		"FloatingPointLiteral",
+		"Arrow",
+		"DoubleColon",
+		"Ellipsis",
+		"At",
 	}[t]
 }
 
@@ -337,6 +373,8 @@ type Lexer struct {
 	startPos     Position
 	tokenBuffer  *Token
 	errorBuffer  error
+	lookahead    []Token // tokens peeked after the tokenBuffer
+	lookaheadErr []error
 }
 
 // NewLexer create a new object of lexer
@@ -568,17 +606,44 @@ func (lx *Lexer) NextToken() (Token, error) {
 		tok, err := *lx.tokenBuffer, lx.errorBuffer
 		lx.tokenBuffer = nil
 		lx.errorBuffer = nil
+		if len(lx.lookahead) > 0 {
+			next := lx.lookahead[0]
+			lx.tokenBuffer, lx.errorBuffer = &next, lx.lookaheadErr[0]
+			lx.lookahead, lx.lookaheadErr = lx.lookahead[1:], lx.lookaheadErr[1:]
+		}
 		return tok, err
 	}
 	return lx.getNext()
 }
 
 func (lx *Lexer) PeekToken() (Token, error) {
+	if lx.tokenBuffer != nil {
+		return *lx.tokenBuffer, lx.errorBuffer
+	}
+
 	tok, err := lx.NextToken()
 	lx.tokenBuffer, lx.errorBuffer = &tok, err
 	return tok, err
 }
 
+// PeekTokenAt get the token n places after the next token without
+// consuming any of them, PeekTokenAt(0) is the same as PeekToken
+func (lx *Lexer) PeekTokenAt(n int) (Token, error) {
+	if n == 0 || lx.tokenBuffer == nil {
+		tok, err := lx.PeekToken()
+		if n == 0 {
+			return tok, err
+		}
+	}
+
+	for len(lx.lookahead) < n {
+		tok, err := lx.getNext()
+		lx.lookahead = append(lx.lookahead, tok)
+		lx.lookaheadErr = append(lx.lookaheadErr, err)
+	}
+	return lx.lookahead[n-1], lx.lookaheadErr[n-1]
+}
+
 // comment recognize the comment pattern in Java,
 // both the single line and multiline are recognized.
 func (lx *Lexer) comment() Token {
@@ -744,9 +809,75 @@ func (lx *Lexer) numeralLiteral() Token {
 		}
 	}
 
+	lx.numeralTail()
 	return lx.returnAndReset()
 }
 
+// numeralTail recognize the fraction, exponent and type suffix
+// after the integer part. Token will be changed to a FloatingPointLiteral
+// if any of the fraction, exponent or the F and D suffix is matched.
+// Hex floating point should always have a binary exponent (p or P).
+func (lx *Lexer) numeralTail() {
+	if lx.token.Sub == Binary {
+		lx.longSuffix()
+		return
+	}
+
+	isHex := lx.token.Sub == Hex
+	digit, exponent := Matcher(IsDigit), "Ee"
+	if isHex {
+		digit, exponent = IsHexDigit, "Pp"
+	}
+
+	if p, _ := lx.peekChar(); p == '.' {
+		lx.toFloatingPoint()
+		lx.consume()
+		lx.separatedByUnderscore(digit, false)
+
+		// 0x. is not followed by any digit
+		if isHex && len(lx.token.Value()) == 3 {
+			panic("Should at least match one digit.")
+		}
+	}
+
+	if p, _ := lx.peekChar(); IsRuneIn(p, exponent) {
+		lx.toFloatingPoint()
+		lx.consume()
+		if p, _ := lx.peekChar(); IsRuneIn(p, "+-") {
+			lx.consume()
+		}
+		lx.separatedByUnderscore(IsDigit, true)
+	} else if isHex && lx.token.Type == FloatingPointLiteral {
+		panic("Hex floating point should have a binary exponent.")
+	}
+
+	if p, _ := lx.peekChar(); IsRuneIn(p, "FfDd") {
+		lx.toFloatingPoint()
+		lx.consume()
+		return
+	}
+
+	if lx.token.Type == IntegerLiteral {
+		lx.longSuffix()
+	}
+}
+
+// toFloatingPoint change the current token into FloatingPointLiteral,
+// a number with leading zero is decimal when its a floating point.
+func (lx *Lexer) toFloatingPoint() {
+	lx.token.Type = FloatingPointLiteral
+	if lx.token.Sub == Octal {
+		lx.token.Sub = Decimal
+	}
+}
+
+// longSuffix match the optional L suffix of a long integer
+func (lx *Lexer) longSuffix() {
+	if p, _ := lx.peekChar(); IsRuneIn(p, "Ll") {
+		lx.consume()
+	}
+}
+
 // separatedByUnderscore will match the provided matcher with
 // zero or more underscore in between.
 // example of isDigit as matcher:
@@ -987,17 +1118,39 @@ var separatorMap = map[rune]TokenType{
 	']': RightSquareBracket,
 	'{': LeftCurlyBracket,
 	'}': RightCurlyBracket,
+	'@': At,
 }
 
 // separator match Java separator
 // can redirect to numeralLiteral if a dot followed by a digit
 func (lx *Lexer) separator() Token {
 	r, _ := lx.nextChar()
+	if p, _ := lx.peekChar(); r == '.' && IsDigit(p) {
+		// put back the dot, it is a fraction such as .5
+		lx.rawPos.Column -= 1
+		lx.unicodeQueue.Queue(r)
+		return lx.numeralLiteral()
+	}
+
 	lx.token.writeRune(r)
 	lx.token.Type = separatorMap[r]
+	if p, _ := lx.peekChar(); r == '.' && p == '.' {
+		lx.ellipsis()
+	}
 	return lx.returnAndReset()
 }
 
+// ellipsis match the rest of ... after the first dot
+func (lx *Lexer) ellipsis() {
+	for i := 0; i < 2; i++ {
+		if r, _ := lx.nextChar(); r != '.' {
+			panic(lx.errf("Invalid separator, expected to be '...'"))
+		}
+		lx.token.writeRune('.')
+	}
+	lx.token.Type = Ellipsis
+}
+
 var operatorMap = map[string]TokenType{
 	":": Colon,
 	"+": Addition,
@@ -1023,6 +1176,22 @@ var operatorMap = map[string]TokenType{
 	"*=": MultiplicationAssignment,
 	"/=": DivisionAssignment,
 	"%=": ModulusAssignment,
+	// Bitwise
+	"&":    BitwiseAnd,
+	"|":    BitwiseOr,
+	"^":    BitwiseXor,
+	"<<":   LeftShift,
+	">>":   RightShift,
+	">>>":  UnsignedRightShift,
+	"&=":   BitwiseAndAssignment,
+	"|=":   BitwiseOrAssignment,
+	"^=":   BitwiseXorAssignment,
+	"<<=":  LeftShiftAssignment,
+	">>=":  RightShiftAssignment,
+	">>>=": UnsignedRightShiftAssignment,
+	// Lambda and method reference
+	"->": Arrow,
+	"::": DoubleColon,
 }
 
 // operator match Java operator
@@ -1039,9 +1208,9 @@ func (lx *Lexer) operator() Token {
 
 	lx.token.writeRune(r)
 
-	if IsRuneIn(r, "+-=&|<>") {
-		// double
-		if p, _ := lx.peekChar(); p == r {
+	if IsRuneIn(r, "+-=&|<>:") {
+		// double, or the arrow of lambda
+		if p, _ := lx.peekChar(); p == r || (r == '-' && p == '>') {
 			lx.nextChar()
 			lx.token.writeRune(p)
 		}
@@ -210,6 +210,25 @@ func TestLexer_PeekToken(t *testing.T) {
 	})
 }
 
+func TestLexer_PeekTokenAt(t *testing.T) {
+	withLexer("(a, b) -> a", func(lx *Lexer) {
+		if tok, _ := lx.PeekTokenAt(5); tok.Type != Arrow {
+			t.Errorf("Expecting the sixth token to be an Arrow but got %s", tok.Type)
+		}
+
+		if tok, _ := lx.PeekTokenAt(1); tok.Value() != "a" {
+			t.Errorf("Expecting a but got %s", tok.Value())
+		}
+
+		expected := []string{"(", "a", ",", "b", ")", "->", "a"}
+		for _, value := range expected {
+			if tok, _ := lx.NextToken(); tok.Value() != value {
+				t.Errorf("Peeking should not consume the token %s, got %s instead", value, tok.Value())
+			}
+		}
+	})
+}
+
 func TestLexer_lineTerminator(t *testing.T) {
 	data := []struct {
 		str      string
@@ -454,6 +473,26 @@ func TestLexer_numeralLiteral(t *testing.T) {
 		{"0b1001", IntegerLiteral},
 		{"0b111_0000_1111", IntegerLiteral},
 		{"0b111__0000__1111", IntegerLiteral},
+		//long suffix
+		{"10L", IntegerLiteral},
+		{"10l", IntegerLiteral},
+		{"0xFFL", IntegerLiteral},
+		{"017L", IntegerLiteral},
+		{"0b11L", IntegerLiteral},
+		//decimal floating point
+		{"1.5", FloatingPointLiteral},
+		{"1.", FloatingPointLiteral},
+		{"1_000.000_1", FloatingPointLiteral},
+		{"1e10", FloatingPointLiteral},
+		{"1E+10", FloatingPointLiteral},
+		{"1.5e-3f", FloatingPointLiteral},
+		{"1f", FloatingPointLiteral},
+		{"1D", FloatingPointLiteral},
+		{"017.5", FloatingPointLiteral},
+		//hex floating point
+		{"0x1.8p1", FloatingPointLiteral},
+		{"0x.8P-2d", FloatingPointLiteral},
+		{"0xFp0F", FloatingPointLiteral},
 	}
 	for _, d := range data {
 		withLexer(d.str, func(lx *Lexer) {
@@ -486,6 +525,12 @@ func TestLexer_numeralLiteral_panic(t *testing.T) {
 		"0b_",
 		"0b1_",
 		"0b_1",
+		// exponent should have digit
+		"1e",
+		"1e+",
+		// hex floating point need binary exponent
+		"0x1.8",
+		"0x.p1",
 	}
 
 	for _, str := range data {
@@ -789,6 +834,11 @@ func TestLexer_separator(t *testing.T) {
 		{"]", RightSquareBracket},
 		{"{", LeftCurlyBracket},
 		{"}", RightCurlyBracket},
+		{"...", Ellipsis},
+		{"@", At},
+		// dot followed by digit is a floating point
+		{".5", FloatingPointLiteral},
+		{".5e1f", FloatingPointLiteral},
 	}
 
 	for _, d := range data {
@@ -809,6 +859,13 @@ func TestLexer_separator(t *testing.T) {
 	}
 }
 
+func TestLexer_separator_panic(t *testing.T) {
+	withLexer("..", func(lx *Lexer) {
+		defer assertPanic(t, "Should panic on an incomplete ellipsis.")
+		lx.separator()
+	})
+}
+
 func TestLexer_operator(t *testing.T) {
 	data := []struct {
 		str string
@@ -827,7 +884,19 @@ func TestLexer_operator(t *testing.T) {
 		{"==", Equal},
 		{"!=", NotEqual},
 		// Bitwise
-		//
+		{"&", BitwiseAnd},
+		{"|", BitwiseOr},
+		{"^", BitwiseXor},
+		{"<<", LeftShift},
+		{">>", RightShift},
+		{">>>", UnsignedRightShift},
+		{"&=", BitwiseAndAssignment},
+		{"|=", BitwiseOrAssignment},
+		{"^=", BitwiseXorAssignment},
+		{"<<=", LeftShiftAssignment},
+		{">>=", RightShiftAssignment},
+		{">>>=", UnsignedRightShiftAssignment},
+		// Logical
 		{"&&", And},
 		{"||", Or},
 		{"!", Not},
@@ -838,6 +907,9 @@ func TestLexer_operator(t *testing.T) {
 		{"*=", MultiplicationAssignment},
 		{"/=", DivisionAssignment},
 		{"%=", ModulusAssignment},
+		// Lambda and method reference
+		{"->", Arrow},
+		{"::", DoubleColon},
 	}
 
 	for _, d := range data {
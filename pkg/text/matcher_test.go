@@ -83,6 +83,7 @@ func TestIsSeparatorStart(t *testing.T) {
 		{'}', true},
 		{'+', false},
 		{'\u003b', true}, //semicolon
+		{'@', true},
 	}
 	testMatcher(t, IsSeparator, data)
 
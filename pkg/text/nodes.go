@@ -10,10 +10,13 @@ import (
 
 type Visitor interface {
 	VisitProgram(program Program)
+	VisitImport(*Import)
 	VisitClass(*Class)
 	VisitAfterClass(*Class)
 	VisitInterface(*Interface)
 	VisitAfterInterface(*Interface)
+	VisitEnum(*Enum)
+	VisitAfterEnum(*Enum)
 	VisitPropertyDeclaration(*PropertyDeclaration)
 	VisitMethodSignature(*MethodSignature)
 	VisitMainMethodDeclaration(*MainMethodDeclaration)
@@ -21,13 +24,22 @@ type Visitor interface {
 	VisitAfterMethodDeclaration(*MethodDeclaration)
 	VisitConstructor(*ConstructorDeclaration)
 	VisitAfterConstructor(*ConstructorDeclaration)
+	VisitConstructorCall(*ConstructorCallStatement)
+	VisitAfterConstructorCall(*ConstructorCallStatement)
+	VisitMethodCallStatement(*MethodCallStatement)
+	VisitAfterMethodCallStatement(*MethodCallStatement)
+	VisitInitializer(*Initializer)
+	VisitAfterInitializer(*Initializer)
 	VisitVariableDeclaration(*VariableDeclaration)
 	VisitAfterVariableDeclaration(*VariableDeclaration)
 	VisitStatementList(StatementList)
 	VisitAfterStatementList()
 	VisitSwitchStatement(*SwitchStatement)
 	VisitSwitchCase(*CaseStatement)
+	VisitSwitchDefault(*SwitchStatement)
 	VisitAfterSwitchStatement(*SwitchStatement)
+	VisitSwitchExpression(*SwitchExpression)
+	VisitAfterSwitchExpression(*SwitchExpression)
 	VisitIfStatement(*IfStatement)
 	VisitAfterIfStatementCondition(*IfStatement)
 	VisitAfterIfStatementBody(*IfStatement)
@@ -38,9 +50,15 @@ type Visitor interface {
 	VisitAfterForStatementInit(*ForStatement)
 	VisitAfterForStatementCondition(*ForStatement)
 	VisitBeforeForStatementUpdate(*ForStatement)
+	VisitForEachStatement(*ForEachStatement)
+	VisitAfterForEachIterable(*ForEachStatement)
+	VisitAfterForEachStatement(*ForEachStatement)
 	VisitWhileStatement(*WhileStatement)
 	VisitAfterWhileStatementCondition(*WhileStatement)
 	VisitAfterWhileStatement(*WhileStatement)
+	VisitAssertStatement(*AssertStatement)
+	VisitAfterAssertCondition(*AssertStatement)
+	VisitAfterAssertStatement(*AssertStatement)
 	VisitAssignmentStatement(*AssignmentStatement)
 	VisitAfterAssignmentStatement(*AssignmentStatement)
 	VisitJumpStatement(*JumpStatement)
@@ -57,9 +75,14 @@ type Visitor interface {
 	VisitAfterObjectCreation(*ObjectCreation)
 	VisitBinOp(*BinOp)
 	VisitAfterBinOp(*BinOp)
+	VisitUnaryOp(*UnaryOp)
+	VisitAfterUnaryOp(*UnaryOp)
+	VisitCast(*Cast)
+	VisitAfterCast(*Cast)
+	VisitLambda(*Lambda)
+	VisitAfterLambda(*Lambda)
+	VisitMethodReference(*MethodReference)
 	VisitConstant(Expression)
-	VisitSystemOut()
-	VisitAfterSystemOut()
 }
 
 // Node represent a basic AST Node
@@ -90,26 +113,91 @@ func PrettyPrint(node INode) string {
 }
 
 type NamedType struct {
-	Name    string
-	IsArray bool
+	Name     string
+	IsArray  bool
+	TypeArgs []NamedType
 }
 
 func (n NamedType) String() string {
 	s := n.Name
+	if len(n.TypeArgs) > 0 {
+		args := make([]string, len(n.TypeArgs))
+		for i, arg := range n.TypeArgs {
+			args[i] = arg.String()
+		}
+		s += "<" + strings.Join(args, ", ") + ">"
+	}
+
 	if n.IsArray {
 		s += "[]"
 	}
 	return s
 }
 
+func (n NamedType) Equal(val NamedType) bool {
+	if n.Name != val.Name || n.IsArray != val.IsArray ||
+		len(n.TypeArgs) != len(val.TypeArgs) {
+		return false
+	}
+
+	for i, arg := range n.TypeArgs {
+		if !arg.Equal(val.TypeArgs[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// TypeParameter represent a type variable declaration,
+// e.g. T or T extends Comparable<T>
+type TypeParameter struct {
+	Name  string
+	Bound *NamedType
+}
+
+func (t TypeParameter) String() string {
+	if t.Bound == nil {
+		return t.Name
+	}
+	return fmt.Sprintf("%s extends %s", t.Name, t.Bound)
+}
+
+func typeParamsString(params []TypeParameter) string {
+	if len(params) == 0 {
+		return ""
+	}
+
+	str := make([]string, len(params))
+	for i, param := range params {
+		str[i] = param.String()
+	}
+	return "<" + strings.Join(str, ", ") + ">"
+}
+
 type PrimitiveType string
 
 const (
 	IntType     PrimitiveType = "int"
 	BooleanType PrimitiveType = "boolean"
 	CharType    PrimitiveType = "char"
+	ByteType    PrimitiveType = "byte"
+	ShortType   PrimitiveType = "short"
+	LongType    PrimitiveType = "long"
+	FloatType   PrimitiveType = "float"
+	DoubleType  PrimitiveType = "double"
 )
 
+// IsPrimitiveTypeName check if name is one of java primitive type
+func IsPrimitiveTypeName(name string) bool {
+	switch PrimitiveType(name) {
+	case IntType, BooleanType, CharType,
+		ByteType, ShortType, LongType, FloatType, DoubleType:
+		return true
+	default:
+		return false
+	}
+}
+
 type PrimitiveLiteral interface {
 	Expression
 	GetType() PrimitiveType
@@ -140,17 +228,112 @@ func (n Null) Accept(visitor Visitor) {
 	visitor.VisitConstant(&n)
 }
 
+// literalSubType guess the SubType of an integer literal from its prefix
+func literalSubType(str string) SubType {
+	lower := strings.ToLower(str)
+	switch {
+	case strings.HasPrefix(lower, "0x"):
+		return Hex
+	case strings.HasPrefix(lower, "0b"):
+		return Binary
+	case len(strings.TrimRight(lower, "l")) > 1 && lower[0] == '0':
+		return Octal
+	default:
+		return Decimal
+	}
+}
+
+// IntegerFromToken evaluate an integer literal according to its SubType,
+// negated should be true if the literal is the operand of unary minus,
+// since only then 2147483648 and 9223372036854775808L are allowed.
+func IntegerFromToken(tok Token, negated bool) (PrimitiveLiteral, error) {
+	value := tok.Value()
+	clean := strings.ReplaceAll(value, "_", "")
+	isLong := strings.HasSuffix(clean, "L") || strings.HasSuffix(clean, "l")
+	clean = strings.TrimRight(clean, "Ll")
+
+	base := 10
+	switch tok.Sub {
+	case Hex:
+		base, clean = 16, clean[2:]
+	case Binary:
+		base, clean = 2, clean[2:]
+	case Octal:
+		base = 8
+	}
+
+	bitSize := 32
+	if isLong {
+		bitSize = 64
+	}
+
+	tooLarge := fmt.Errorf("|%s| Integer number too large: %s", tok.Position, value)
+	magnitude, err := strconv.ParseUint(clean, base, bitSize)
+	if err != nil {
+		return nil, tooLarge
+	}
+
+	// decimal literal can not overflow into negative number,
+	// except the minimum value which only valid after unary minus
+	if tok.Sub == Decimal || tok.Sub == None {
+		limit := uint64(1)<<(bitSize-1) - 1
+		if negated {
+			limit += 1
+		}
+
+		if magnitude > limit {
+			return nil, tooLarge
+		}
+	}
+
+	if isLong {
+		num := int64(magnitude)
+		if negated {
+			num = -num
+		}
+		return Long(num), nil
+	}
+
+	num := int32(uint32(magnitude))
+	if negated {
+		num = -num
+	}
+	return Num(num), nil
+}
+
+// FloatingPointFromToken evaluate a float or double literal
+func FloatingPointFromToken(tok Token) (PrimitiveLiteral, error) {
+	value := tok.Value()
+	isFloat := strings.HasSuffix(value, "F") || strings.HasSuffix(value, "f")
+	bitSize := 64
+	if isFloat {
+		bitSize = 32
+	}
+
+	clean := strings.TrimRight(strings.ReplaceAll(value, "_", ""), "FfDd")
+	num, err := strconv.ParseFloat(clean, bitSize)
+	if err != nil {
+		return nil, fmt.Errorf("|%s| Floating point number too large: %s", tok.Position, value)
+	}
+
+	if isFloat {
+		return Float(num), nil
+	}
+	return Double(num), nil
+}
+
 type Num int
 
 func NumFromStr(str string) Num {
-	num, e := strconv.Atoi(str)
+	tok := newTokenSub(0, 0, str, IntegerLiteral, literalSubType(str))
+	num, e := IntegerFromToken(tok, false)
 
-	if e != nil {
+	if e != nil || num.GetType() != IntType {
 		msg := fmt.Sprintf("`%s` is not an integer.", str)
 		panic(msg)
 	}
 
-	return Num(num)
+	return num.(Num)
 }
 
 func (n Num) NodeContent() (string, string) {
@@ -173,6 +356,102 @@ func (n Num) Accept(v Visitor) {
 	v.VisitConstant(n)
 }
 
+type Long int64
+
+func LongFromStr(str string) Long {
+	tok := newTokenSub(0, 0, str, IntegerLiteral, literalSubType(str))
+	num, e := IntegerFromToken(tok, false)
+
+	if e != nil || num.GetType() != LongType {
+		msg := fmt.Sprintf("`%s` is not a long.", str)
+		panic(msg)
+	}
+
+	return num.(Long)
+}
+
+func (l Long) NodeContent() (string, string) {
+	return "long", fmt.Sprintf("%d", int64(l))
+}
+
+func (Long) ChildNode() INode {
+	return nil
+}
+
+func (Long) IsExpression() bool {
+	return true
+}
+
+func (Long) GetType() PrimitiveType {
+	return LongType
+}
+
+func (l Long) Accept(v Visitor) {
+	v.VisitConstant(l)
+}
+
+type Float float32
+
+func FloatFromStr(str string) Float {
+	num, e := FloatingPointFromToken(newToken(0, 0, str, FloatingPointLiteral))
+	if e != nil || num.GetType() != FloatType {
+		msg := fmt.Sprintf("`%s` is not a float.", str)
+		panic(msg)
+	}
+	return num.(Float)
+}
+
+func (f Float) NodeContent() (string, string) {
+	return "float", strconv.FormatFloat(float64(f), 'g', -1, 32)
+}
+
+func (Float) ChildNode() INode {
+	return nil
+}
+
+func (Float) IsExpression() bool {
+	return true
+}
+
+func (Float) GetType() PrimitiveType {
+	return FloatType
+}
+
+func (f Float) Accept(v Visitor) {
+	v.VisitConstant(f)
+}
+
+type Double float64
+
+func DoubleFromStr(str string) Double {
+	num, e := FloatingPointFromToken(newToken(0, 0, str, FloatingPointLiteral))
+	if e != nil || num.GetType() != DoubleType {
+		msg := fmt.Sprintf("`%s` is not a double.", str)
+		panic(msg)
+	}
+	return num.(Double)
+}
+
+func (d Double) NodeContent() (string, string) {
+	return "double", strconv.FormatFloat(float64(d), 'g', -1, 64)
+}
+
+func (Double) ChildNode() INode {
+	return nil
+}
+
+func (Double) IsExpression() bool {
+	return true
+}
+
+func (Double) GetType() PrimitiveType {
+	return DoubleType
+}
+
+func (d Double) Accept(v Visitor) {
+	v.VisitConstant(d)
+}
+
 type Boolean bool
 
 func NewBoolean(s string) Boolean {
@@ -263,12 +542,16 @@ type NamedValue interface {
 	Expression
 	GetChild() NamedValue
 }
+
+// This is the current instance, or the instance of an enclosing
+// class if it is qualified, e.g. Outer.this
 type This struct {
-	Child NamedValue
+	Child     NamedValue
+	Qualifier string
 }
 
 func (t *This) NodeContent() (string, string) {
-	return "this", ""
+	return "this", t.Qualifier
 }
 
 func (t *This) ChildNode() INode {
@@ -289,6 +572,34 @@ func (t *This) Accept(v Visitor) {
 	t.Child.Accept(v)
 }
 
+// Super is the current instance seen as its super class, a field
+// is looked up from the super class and a method is not overridden,
+// e.g. super.name, super.toString()
+type Super struct {
+	Child NamedValue
+}
+
+func (s *Super) NodeContent() (string, string) {
+	return "super", ""
+}
+
+func (s *Super) ChildNode() INode {
+	return s.Child
+}
+
+func (s *Super) GetChild() NamedValue {
+	return s.Child
+}
+
+func (s *Super) IsExpression() bool {
+	return true
+}
+
+func (s *Super) Accept(v Visitor) {
+	v.VisitConstant(s)
+	s.Child.Accept(v)
+}
+
 type FieldAccess struct {
 	Name  string
 	Child NamedValue
@@ -311,18 +622,6 @@ func (FieldAccess) IsExpression() bool {
 }
 
 func (f *FieldAccess) Accept(v Visitor) {
-	if f.Name == "System" && f.Child != nil {
-		// assume that println always called after this
-		v.VisitSystemOut()
-		// out -> println
-		println := f.ChildNode().ChildNode().(*MethodCall)
-		for _, arg := range println.Args {
-			arg.Accept(v)
-		}
-		v.VisitAfterSystemOut()
-		return
-	}
-
 	v.VisitFieldAccess(f)
 	if f.Child == nil {
 		return
@@ -462,19 +761,148 @@ func (b *BinOp) Accept(v Visitor) {
 	v.VisitAfterBinOp(b)
 }
 
+// UnaryOp represent a prefix unary operation, e.g. -a
+type UnaryOp struct {
+	Operator Token
+	Exp      Expression
+}
+
+func (u *UnaryOp) NodeContent() (string, string) {
+	return "unary", fmt.Sprintf("%s :exp %s", u.Operator.Value(), PrettyPrint(u.Exp))
+}
+
+func (u *UnaryOp) ChildNode() INode {
+	return nil
+}
+
+func (u *UnaryOp) IsExpression() bool {
+	return true
+}
+
+func (u *UnaryOp) Accept(v Visitor) {
+	v.VisitUnaryOp(u)
+	u.Exp.Accept(v)
+	v.VisitAfterUnaryOp(u)
+}
+
+// Cast represent a primitive type casting, e.g. (int) 3.5
+type Cast struct {
+	Type NamedType
+	Exp  Expression
+}
+
+func (c *Cast) NodeContent() (string, string) {
+	return "cast", fmt.Sprintf("%s :exp %s", c.Type, PrettyPrint(c.Exp))
+}
+
+func (c *Cast) ChildNode() INode {
+	return nil
+}
+
+func (c *Cast) IsExpression() bool {
+	return true
+}
+
+func (c *Cast) Accept(v Visitor) {
+	v.VisitCast(c)
+	c.Exp.Accept(v)
+	v.VisitAfterCast(c)
+}
+
+// Lambda is an anonymous function, e.g. (a, b) -> a + b. The type of a
+// parameter is empty when it is inferred, and the Body is either an
+// Expression or a StatementList. It is generated as a class named Name.
+type Lambda struct {
+	Name   string
+	Params []Parameter
+	Body   INode
+}
+
+func (l *Lambda) NodeContent() (string, string) {
+	params := make([]string, len(l.Params))
+	for i, p := range l.Params {
+		params[i] = p.Name
+		if len(p.Type.Name) > 0 {
+			params[i] = fmt.Sprintf("%s %s", p.Type, p.Name)
+		}
+	}
+	return "lambda", fmt.Sprintf("(%s) :body %s", strings.Join(params, ", "), PrettyPrint(l.Body))
+}
+
+func (l *Lambda) ChildNode() INode {
+	return nil
+}
+
+func (l *Lambda) IsExpression() bool {
+	return true
+}
+
+// IsBlock check if the body is a StatementList instead of an Expression
+func (l *Lambda) IsBlock() bool {
+	_, ok := l.Body.(StatementList)
+	return ok
+}
+
+func (l *Lambda) Accept(v Visitor) {
+	v.VisitLambda(l)
+	l.Body.Accept(v)
+	v.VisitAfterLambda(l)
+}
+
+// MethodReference refer to a method as a lambda, e.g. this::greet. The
+// Target is this, a variable or a type, the Method is new for a constructor.
+type MethodReference struct {
+	Name   string
+	Target string
+	Method string
+}
+
+func (m *MethodReference) NodeContent() (string, string) {
+	return "method-ref", fmt.Sprintf("%s::%s", m.Target, m.Method)
+}
+
+func (m *MethodReference) ChildNode() INode {
+	return nil
+}
+
+func (m *MethodReference) IsExpression() bool {
+	return true
+}
+
+func (m *MethodReference) Accept(v Visitor) {
+	v.VisitMethodReference(m)
+}
+
 //TODO: create proper object creation struct
 type ObjectCreation struct {
 	MethodCall
+	TypeArgs []NamedType
+	Body     *Class
+}
+
+// Type get the created type along with its type arguments
+func (o *ObjectCreation) Type() NamedType {
+	return NamedType{o.Name, false, o.TypeArgs}
 }
 
 func (o *ObjectCreation) Accept(visitor Visitor) {
 	visitor.VisitObjectCreation(o)
 	o.MethodCall.Accept(visitor)
+	if o.Body != nil {
+		o.Body.Accept(visitor)
+	}
 	visitor.VisitAfterObjectCreation(o)
 }
 
 func (o *ObjectCreation) NodeContent() (string, string) {
 	_, argStr := o.MethodCall.NodeContent()
+	if len(o.TypeArgs) > 0 {
+		argStr = o.Type().String() + strings.TrimPrefix(argStr, o.Name)
+	}
+
+	if o.Body != nil {
+		argStr += " :body " + PrettyPrint(o.Body)
+	}
 	return "object-creation", argStr
 }
 
@@ -549,6 +977,7 @@ const (
 	ReturnJump JumpType = iota
 	BreakJump
 	ContinueJump
+	YieldJump
 )
 
 func (j JumpType) String() string {
@@ -556,6 +985,7 @@ func (j JumpType) String() string {
 		"return",
 		"break",
 		"continue",
+		"yield",
 	}[j]
 }
 
@@ -625,14 +1055,20 @@ func (a *AssignmentStatement) Accept(v Visitor) {
 	v.VisitAfterAssignmentStatement(a)
 }
 
+// CaseStatement is a single case of a switch, each of the Values is
+// either a constant or a bare name of an enum constant
 type CaseStatement struct {
-	Value         PrimitiveLiteral
+	Values        []Expression
 	StatementList StatementList
 }
 
 func (c *CaseStatement) String() string {
 	stmtStr := "(#case %s :do %s"
-	args := []interface{}{PrettyPrint(c.Value), c.StatementList.String()}
+	values := make([]string, len(c.Values))
+	for i, value := range c.Values {
+		values[i] = PrettyPrint(value)
+	}
+	args := []interface{}{strings.Join(values, ", "), c.StatementList.String()}
 
 	// for _, s := range c.StatementList {
 	// 	stmtStr += "%s"
@@ -643,10 +1079,13 @@ func (c *CaseStatement) String() string {
 	return fmt.Sprintf(stmtStr, args...)
 }
 
+// SwitchStatement jump into the case matching its value, the cases
+// of an arrow switch (case 1 -> ...) does not fall through the next one
 type SwitchStatement struct {
 	ValueToCompare Expression
 	CaseList       []*CaseStatement
 	DefaultCase    []Statement
+	IsArrow        bool
 }
 
 func (s *SwitchStatement) NodeContent() (string, string) {
@@ -672,6 +1111,10 @@ func (s *SwitchStatement) NodeContent() (string, string) {
 		str += "]"
 	}
 
+	if s.IsArrow {
+		str += " :arrow"
+	}
+
 	return "switch", fmt.Sprintf(str, args...)
 }
 
@@ -686,16 +1129,40 @@ func (s *SwitchStatement) IsStatement() bool {
 func (s *SwitchStatement) Accept(v Visitor) {
 	s.ValueToCompare.Accept(v)
 	v.VisitSwitchStatement(s)
+	// case value is left to the visitor, since an enum constant
+	// name can only be resolved against the type of the switch
 	for _, c := range s.CaseList {
-		c.Value.Accept(v)
 		v.VisitSwitchCase(c)
 		c.StatementList.Accept(v)
 	}
 
+	v.VisitSwitchDefault(s)
 	for _, d := range s.DefaultCase {
 		d.Accept(v)
 	}
+	v.VisitAfterSwitchStatement(s)
+}
+
+// SwitchExpression is a switch that yield a value out of its cases,
+// Type is the type of the value which is set once it is analyzed
+type SwitchExpression struct {
+	SwitchStatement
+	Type NamedType
+}
 
+func (s *SwitchExpression) NodeContent() (string, string) {
+	_, content := s.SwitchStatement.NodeContent()
+	return "switch-exp", content
+}
+
+func (s *SwitchExpression) IsExpression() bool {
+	return true
+}
+
+func (s *SwitchExpression) Accept(v Visitor) {
+	v.VisitSwitchExpression(s)
+	s.SwitchStatement.Accept(v)
+	v.VisitAfterSwitchExpression(s)
 }
 
 type IfStatement struct {
@@ -771,6 +1238,41 @@ func (w *WhileStatement) Accept(v Visitor) {
 	v.VisitAfterWhileStatement(w)
 }
 
+// AssertStatement fail with an AssertionError if the condition is
+// false while assertions are enabled, Message is nil if not given.
+type AssertStatement struct {
+	Condition Expression
+	Message   Expression
+}
+
+func (a *AssertStatement) NodeContent() (string, string) {
+	content := PrettyPrint(a.Condition)
+	if a.Message != nil {
+		content += " :message " + PrettyPrint(a.Message)
+	}
+	return "assert", content
+}
+
+func (a *AssertStatement) ChildNode() INode {
+	return nil
+}
+
+func (a *AssertStatement) IsStatement() bool {
+	return true
+}
+
+// Accept visit the message only if the condition is false,
+// which is the only time it is evaluated.
+func (a *AssertStatement) Accept(v Visitor) {
+	v.VisitAssertStatement(a)
+	a.Condition.Accept(v)
+	v.VisitAfterAssertCondition(a)
+	if a.Message != nil {
+		a.Message.Accept(v)
+	}
+	v.VisitAfterAssertStatement(a)
+}
+
 type MethodCallStatement struct {
 	Method NamedValue
 }
@@ -787,14 +1289,49 @@ func (m *MethodCallStatement) IsStatement() bool {
 	return true
 }
 
+// Accept visit the called method, the value it returns is discarded after
 func (m *MethodCallStatement) Accept(v Visitor) {
+	v.VisitMethodCallStatement(m)
 	m.Method.Accept(v)
+	v.VisitAfterMethodCallStatement(m)
+}
+
+// ConstructorCallStatement is the call of another constructor of
+// the same class with this(...), only allowed first in a constructor
+type ConstructorCallStatement struct {
+	Args []Expression
+}
+
+func (c *ConstructorCallStatement) NodeContent() (string, string) {
+	strArg := make([]string, len(c.Args))
+	for i, arg := range c.Args {
+		strArg[i] = PrettyPrint(arg)
+	}
+
+	return "this-call", fmt.Sprintf(":args [%s]", strings.Join(strArg, ", "))
+}
+
+func (c *ConstructorCallStatement) ChildNode() INode {
+	return nil
+}
+
+func (c *ConstructorCallStatement) IsStatement() bool {
+	return true
+}
+
+func (c *ConstructorCallStatement) Accept(v Visitor) {
+	v.VisitConstructorCall(c)
+	for _, arg := range c.Args {
+		arg.Accept(v)
+	}
+	v.VisitAfterConstructorCall(c)
 }
 
 type VariableDeclaration struct {
-	Type  NamedType
-	Name  string
-	Value Expression
+	Type    NamedType
+	Name    string
+	Value   Expression
+	IsFinal bool
 }
 
 func (v *VariableDeclaration) NodeContent() (string, string) {
@@ -803,6 +1340,10 @@ func (v *VariableDeclaration) NodeContent() (string, string) {
 		format += "[]"
 	}
 
+	if v.IsFinal {
+		format += " :final"
+	}
+
 	return "var-decl", fmt.Sprintf(format, v.Name, v.Type.Name)
 }
 
@@ -914,6 +1455,49 @@ func (f *ForStatement) Accept(v Visitor) {
 	}
 }
 
+// ForEachStatement iterate over the elements of Iterable, Variable
+// is declared without a value and assigned at each iteration.
+type ForEachStatement struct {
+	Variable *VariableDeclaration
+	Iterable Expression
+	Body     Statement
+}
+
+func (f *ForEachStatement) NodeContent() (string, string) {
+	return "for-each", fmt.Sprintf("%s :iterable %s :body",
+		PrettyPrint(f.Variable),
+		PrettyPrint(f.Iterable),
+	)
+}
+
+func (f *ForEachStatement) ChildNode() INode {
+	return f.Body
+}
+
+func (f *ForEachStatement) IsStatement() bool {
+	return true
+}
+
+// Accept visit the body as a block, so the variable is declared
+// in the same scope as the statements of the body.
+func (f *ForEachStatement) Accept(v Visitor) {
+	v.VisitForEachStatement(f)
+	f.Iterable.Accept(v)
+	v.VisitAfterForEachIterable(f)
+
+	body, ok := f.Body.(StatementList)
+	if !ok {
+		body = StatementList{f.Body}
+	}
+
+	v.VisitStatementList(body)
+	for _, stmt := range body {
+		stmt.Accept(v)
+	}
+	v.VisitAfterForEachStatement(f)
+	v.VisitAfterStatementList()
+}
+
 // ----------------- END OF STATEMENTS
 
 type DeclarationType int
@@ -923,6 +1507,8 @@ const (
 	Property
 	Constructor
 	MainMethod
+	MemberClass
+	InitializerBlock
 )
 
 func (d DeclarationType) String() string {
@@ -931,6 +1517,8 @@ func (d DeclarationType) String() string {
 		"Property",
 		"Constructor",
 		"MainMethod",
+		"MemberClass",
+		"InitializerBlock",
 	}[d]
 }
 
@@ -948,14 +1536,29 @@ const (
 	Public    AccessModifier = 1
 	Protected                = 2
 	Private                  = 4
+
+	// Static and Final are not an access level, they are kept
+	// along with it like the access flags of a class file.
+	Static AccessModifier = 8
+	Final  AccessModifier = 16
 )
 
 func (a AccessModifier) String() string {
-	return []string{
+	access := a & (Public | Protected | Private)
+	str := []string{
 		"public",
 		"protected",
 		"private",
-	}[a>>1]
+	}[access>>1]
+
+	if a&Static != 0 {
+		str += " static"
+	}
+
+	if a&Final != 0 {
+		str += " final"
+	}
+	return str
 }
 
 type PropertyDeclaration struct {
@@ -992,8 +1595,12 @@ func (p *PropertyDeclaration) Accept(visitor Visitor) {
 }
 
 type Parameter struct {
-	Type NamedType
-	Name string
+	Type    NamedType
+	Name    string
+	IsFinal bool
+	// IsVarargs is set on the last parameter if it is declared
+	// with ..., its type is an array of the declared type.
+	IsVarargs bool
 }
 
 type MethodSignature struct {
@@ -1001,12 +1608,14 @@ type MethodSignature struct {
 	ReturnType    NamedType
 	Name          string
 	ParameterList []Parameter
+	TypeParams    []TypeParameter
+	Annotations   []string
 }
 
 func (m *MethodSignature) Equal(val MethodSignature) bool {
 	result := m.Name == val.Name &&
 		m.AccessModifier == val.AccessModifier &&
-		m.ReturnType == val.ReturnType
+		m.ReturnType.Equal(val.ReturnType)
 
 	if !result {
 		return false
@@ -1017,7 +1626,8 @@ func (m *MethodSignature) Equal(val MethodSignature) bool {
 	}
 
 	for i, sign := range m.ParameterList {
-		if sign != val.ParameterList[i] {
+		if sign.Name != val.ParameterList[i].Name ||
+			!sign.Type.Equal(val.ParameterList[i].Type) {
 			return false
 		}
 	}
@@ -1045,7 +1655,7 @@ func (m *MethodSignature) NodeContent() (string, string) {
 	format += "]"
 	return "method-signature", fmt.Sprintf(format,
 		m.AccessModifier,
-		m.Name,
+		typeParamsString(m.TypeParams)+m.Name,
 		m.ReturnType,
 	)
 }
@@ -1082,7 +1692,7 @@ func NewMethodDeclaration(
 	body StatementList,
 ) *MethodDeclaration {
 	return &MethodDeclaration{
-		MethodSignature{accessMod, rettype, name, param},
+		MethodSignature{accessMod, rettype, name, param, nil, nil},
 		body,
 	}
 }
@@ -1149,9 +1759,11 @@ func NewConstructor(acc AccessModifier, name string, param []Parameter, body Sta
 		MethodDeclaration{
 			MethodSignature{
 				acc,
-				NamedType{name, false},
+				NamedType{name, false, nil},
 				name,
 				param,
+				nil,
+				nil,
 			},
 			body,
 		}}
@@ -1167,7 +1779,7 @@ func (c *ConstructorDeclaration) DeclType() DeclarationType {
 }
 
 func (c *ConstructorDeclaration) TypeOf() NamedType {
-	return NamedType{c.Name, false}
+	return NamedType{c.Name, false, nil}
 }
 
 func (c *ConstructorDeclaration) Accept(v Visitor) {
@@ -1176,6 +1788,60 @@ func (c *ConstructorDeclaration) Accept(v Visitor) {
 	v.VisitAfterConstructor(c)
 }
 
+// Delegate get the call of another constructor that start
+// the body, nil if the super constructor is called instead
+func (c *ConstructorDeclaration) Delegate() *ConstructorCallStatement {
+	if len(c.Body) == 0 {
+		return nil
+	}
+
+	call, _ := c.Body[0].(*ConstructorCallStatement)
+	return call
+}
+
+// Initializer is a block run when an instance is created, along with
+// the property values, or once when the class is loaded if it is static
+type Initializer struct {
+	IsStatic bool
+	Body     StatementList
+}
+
+func (i *Initializer) NodeContent() (string, string) {
+	if i.IsStatic {
+		return "static-initializer", i.Body.ContentString()
+	}
+	return "initializer", i.Body.ContentString()
+}
+
+func (i *Initializer) ChildNode() INode {
+	return nil
+}
+
+func (i *Initializer) GetName() string {
+	return ""
+}
+
+func (i *Initializer) DeclType() DeclarationType {
+	return InitializerBlock
+}
+
+func (i *Initializer) TypeOf() NamedType {
+	return NamedType{"void", false, nil}
+}
+
+func (i *Initializer) GetAccessModifier() AccessModifier {
+	if i.IsStatic {
+		return Static
+	}
+	return 0
+}
+
+func (i *Initializer) Accept(v Visitor) {
+	v.VisitInitializer(i)
+	i.Body.Accept(v)
+	v.VisitAfterInitializer(i)
+}
+
 // ----------------- END OF DECLARATIONS
 
 type Template interface {
@@ -1185,14 +1851,16 @@ type Template interface {
 }
 
 type Interface struct {
-	Name    string
-	Methods []*MethodSignature
+	Name       string
+	Methods    []*MethodSignature
+	TypeParams []TypeParameter
 }
 
 func NewInterface(name string) *Interface {
 	return &Interface{
 		name,
 		make([]*MethodSignature, 0),
+		nil,
 	}
 }
 
@@ -1216,7 +1884,7 @@ func (i *Interface) NodeContent() (string, string) {
 
 	return "interface",
 		fmt.Sprintf("%s \n\t:methods [%s]",
-			i.Name,
+			i.Name+typeParamsString(i.TypeParams),
 			strings.Join(methods, ", "),
 		)
 }
@@ -1243,14 +1911,71 @@ func (i *Interface) Accept(v Visitor) {
 	v.VisitAfterInterface(i)
 }
 
+// Import is an import declaration, Name is the qualified name of the
+// type, or of the package if all of its types are imported with .*
+type Import struct {
+	Name       string
+	IsOnDemand bool
+}
+
+func (i *Import) Describe() (string, string) {
+	return "import", i.Name
+}
+
+func (i *Import) NodeContent() (string, string) {
+	name := i.Name
+	if i.IsOnDemand {
+		name += ".*"
+	}
+	return "import", name
+}
+
+func (i *Import) ChildNode() INode {
+	return nil
+}
+
+func (i *Import) Members() []Declaration {
+	return nil
+}
+
+func (i *Import) Accept(v Visitor) {
+	v.VisitImport(i)
+}
+
+// ClassKind tell where a class is declared
+type ClassKind int
+
+const (
+	TopLevelClass ClassKind = iota
+	// NestedClass is a static member class
+	NestedClass
+	// InnerClass is a member class that has an instance of its enclosing class
+	InnerClass
+	LocalClass
+	AnonymousClass
+)
+
+// Class is a class declaration, the name of a class declared inside
+// another one is its binary name, e.g. Outer$Inner or Outer$1
 type Class struct {
-	Name        string
-	Extend      string
-	Implement   string
-	MainMethod  *MainMethodDeclaration
-	Properties  []*PropertyDeclaration
-	Methods     []*MethodDeclaration
-	Constructor map[string]*ConstructorDeclaration
+	Name          string
+	Extend        string
+	Implement     string
+	MainMethod    *MainMethodDeclaration
+	Properties    []*PropertyDeclaration
+	Methods       []*MethodDeclaration
+	Constructor   map[string]*ConstructorDeclaration
+	TypeParams    []TypeParameter
+	ExtendArgs    []NamedType
+	ImplementArgs []NamedType
+	Kind          ClassKind
+	Access        AccessModifier
+	Classes       []*Class
+	// Initializers are the properties and initializer blocks
+	// in the order they are declared, which is the order they are run
+	Initializers []Declaration
+	// Components are the header of a record, nil if it is not a record
+	Components []Parameter
 }
 
 func NewEmptyClass(name string, extend string, implementing string) *Class {
@@ -1262,33 +1987,123 @@ func NewEmptyClass(name string, extend string, implementing string) *Class {
 		make([]*PropertyDeclaration, 0),
 		make([]*MethodDeclaration, 0),
 		make(map[string]*ConstructorDeclaration),
+		nil,
+		nil,
+		nil,
+		TopLevelClass,
+		0,
+		make([]*Class, 0),
+		nil,
+		nil,
+	}
+}
+
+// SimpleName get the name of the class as it is declared,
+// it is empty for an anonymous class.
+func (c *Class) SimpleName() string {
+	name := c.Name[strings.LastIndex(c.Name, "$")+1:]
+	return strings.TrimLeft(name, "0123456789")
+}
+
+func (c *Class) GetName() string {
+	return c.Name
+}
+
+func (c *Class) DeclType() DeclarationType {
+	return MemberClass
+}
+
+func (c *Class) TypeOf() NamedType {
+	return NamedType{c.Name, false, nil}
+}
+
+func (c *Class) GetAccessModifier() AccessModifier {
+	return c.Access
+}
+
+// IsRecord is true for a class declared as a record, even without components
+func (c *Class) IsRecord() bool {
+	return c.Components != nil
+}
+
+// DeclaredMethod get the method of the name declared with parameters of
+// the types as they are written, nil if there is none.
+func (c *Class) DeclaredMethod(name string, params ...string) *MethodDeclaration {
+	for _, method := range c.Methods {
+		if method.Name == name && strings.Join(method.ParamSignature(), ", ") == strings.Join(params, ", ") {
+			return method
+		}
 	}
+	return nil
+}
+
+// IsStatement is true for a local class, which is declared in a block
+func (c *Class) IsStatement() bool {
+	return c.Kind == LocalClass
+}
+
+// ExtendType get the super class along with its type arguments
+func (c *Class) ExtendType() NamedType {
+	return NamedType{c.Extend, false, c.ExtendArgs}
+}
+
+// ImplementType get the interface along with its type arguments
+func (c *Class) ImplementType() NamedType {
+	return NamedType{c.Implement, false, c.ImplementArgs}
 }
 
 func (c *Class) Accept(visitor Visitor) {
 	visitor.VisitClass(c)
+	c.acceptMembers(visitor)
+	visitor.VisitAfterClass(c)
+}
+
+func (c *Class) acceptMembers(visitor Visitor) {
+	// member classes come first, so the other members can refer to them
+	for _, class := range c.Classes {
+		class.Accept(visitor)
+	}
+
 	for _, prop := range c.Properties {
 		prop.Accept(visitor)
 	}
 
+	for _, init := range c.InitializerBlocks() {
+		init.Accept(visitor)
+	}
+
 	for _, method := range c.Methods {
 		method.Accept(visitor)
 	}
 
-	for _, cons := range c.Constructor {
+	for _, cons := range c.Constructors() {
 		cons.Accept(visitor)
 	}
 
 	if c.MainMethod != nil {
 		c.MainMethod.Accept(visitor)
 	}
-
-	visitor.VisitAfterClass(c)
 }
 func (c *Class) Describe() (string, string) {
 	return "class", c.Name
 }
 
+// Constructors get the constructors sorted by their signature, so every
+// visitor visit them in the same order, e.g. to follow the same scopes.
+func (c *Class) Constructors() []*ConstructorDeclaration {
+	signatures := make([]string, 0, len(c.Constructor))
+	for signature := range c.Constructor {
+		signatures = append(signatures, signature)
+	}
+	sort.Strings(signatures)
+
+	constructors := make([]*ConstructorDeclaration, len(signatures))
+	for i, signature := range signatures {
+		constructors[i] = c.Constructor[signature]
+	}
+	return constructors
+}
+
 func (c *Class) propertiesString() []string {
 	propStr := make([]string, len(c.Properties))
 	for i, prop := range c.Properties {
@@ -1339,14 +2154,17 @@ func (c *Class) constructorString() []string {
 
 func (c *Class) NodeContent() (string, string) {
 	format := "%s"
-	args := []interface{}{c.Name}
+	args := []interface{}{c.Name + typeParamsString(c.TypeParams)}
+	if c.IsRecord() {
+		format += " :record"
+	}
 
 	if len(c.Extend) > 0 {
 		format += " :extend %s"
-		args = append(args, c.Extend)
+		args = append(args, c.ExtendType())
 	} else if len(c.Implement) > 0 {
 		format += " :implement %s"
-		args = append(args, c.Implement)
+		args = append(args, c.ImplementType())
 	}
 
 	format += "\n\t:props [%s] \n\t:methods [%s] \n\t:constructor [%s]"
@@ -1354,6 +2172,24 @@ func (c *Class) NodeContent() (string, string) {
 	args = append(args, strings.Join(c.methodsString(), ", "))
 	args = append(args, strings.Join(c.constructorString(), ", "))
 
+	if len(c.Classes) > 0 {
+		classes := make([]string, len(c.Classes))
+		for i, class := range c.Classes {
+			classes[i] = PrettyPrint(class)
+		}
+		format += "\n\t:classes [%s]"
+		args = append(args, strings.Join(classes, ", "))
+	}
+
+	if blocks := c.InitializerBlocks(); len(blocks) > 0 {
+		inits := make([]string, len(blocks))
+		for i, init := range blocks {
+			inits[i] = PrettyPrint(init)
+		}
+		format += "\n\t:initializers [%s]"
+		args = append(args, strings.Join(inits, ", "))
+	}
+
 	if c.MainMethod != nil {
 		format += "\n\t:main %s"
 		args = append(args, PrettyPrint(c.MainMethod))
@@ -1369,11 +2205,23 @@ func (c *Class) ChildNode() INode {
 func (c *Class) addProperty(decl Declaration) {
 	prop := decl.(*PropertyDeclaration)
 	c.Properties = append(c.Properties, prop)
+	c.Initializers = append(c.Initializers, prop)
+}
+
+// InitializerBlocks get the initializer blocks of the class in order
+func (c *Class) InitializerBlocks() []*Initializer {
+	var blocks []*Initializer
+	for _, decl := range c.Initializers {
+		if init, ok := decl.(*Initializer); ok {
+			blocks = append(blocks, init)
+		}
+	}
+	return blocks
 }
 
 func (c *Class) addConstructor(decl Declaration) {
 	con := decl.(*ConstructorDeclaration)
-	if c.Name != con.Name {
+	if c.SimpleName() != con.Name {
 		panic("Method should have a return type.")
 	}
 
@@ -1401,6 +2249,10 @@ func (c *Class) AddDeclaration(decl Declaration) {
 			panic("Main method is already defined.")
 		}
 		c.MainMethod = decl.(*MainMethodDeclaration)
+	case MemberClass:
+		c.Classes = append(c.Classes, decl.(*Class))
+	case InitializerBlock:
+		c.Initializers = append(c.Initializers, decl)
 	}
 }
 
@@ -1426,6 +2278,66 @@ func (c *Class) Members() []Declaration {
 	return members
 }
 
+type EnumConstant struct {
+	Name string
+	Args []Expression
+}
+
+func (e *EnumConstant) String() string {
+	if len(e.Args) == 0 {
+		return e.Name
+	}
+
+	args := make([]string, len(e.Args))
+	for i, arg := range e.Args {
+		args[i] = PrettyPrint(arg)
+	}
+	return fmt.Sprintf("%s(%s)", e.Name, strings.Join(args, ", "))
+}
+
+// Enum is a class with a fixed set of instance, the members
+// other than the constants are declared just like a class
+type Enum struct {
+	Class
+	Constants []*EnumConstant
+}
+
+func NewEmptyEnum(name string, implementing string) *Enum {
+	return &Enum{
+		*NewEmptyClass(name, "", implementing),
+		make([]*EnumConstant, 0),
+	}
+}
+
+func (e *Enum) AddConstant(constant *EnumConstant) {
+	for _, c := range e.Constants {
+		if c.Name == constant.Name {
+			panic(fmt.Sprintf("Enum constant %s is already defined.", constant.Name))
+		}
+	}
+	e.Constants = append(e.Constants, constant)
+}
+
+func (e *Enum) Accept(visitor Visitor) {
+	visitor.VisitEnum(e)
+	e.acceptMembers(visitor)
+	visitor.VisitAfterEnum(e)
+}
+
+func (e *Enum) Describe() (string, string) {
+	return "enum", e.Name
+}
+
+func (e *Enum) NodeContent() (string, string) {
+	constants := make([]string, len(e.Constants))
+	for i, c := range e.Constants {
+		constants[i] = c.String()
+	}
+
+	_, content := e.Class.NodeContent()
+	return "enum", fmt.Sprintf("%s\n\t:constants [%s]", content, strings.Join(constants, ", "))
+}
+
 type Program []Template
 
 func (p Program) Equal(val Program) bool {
@@ -1463,6 +2375,10 @@ func (p Program) Accept(visitor Visitor) {
 	visitor.VisitProgram(p)
 	for _, decl := range p {
 		name, _ := decl.NodeContent()
+		if name == "import" {
+			decl.Accept(visitor)
+		}
+
 		if name == "class" {
 			class := decl.(*Class)
 			class.Accept(visitor)
@@ -1473,5 +2389,10 @@ func (p Program) Accept(visitor Visitor) {
 			inf.Accept(visitor)
 
 		}
+
+		if name == "enum" {
+			enum := decl.(*Enum)
+			enum.Accept(visitor)
+		}
 	}
 }
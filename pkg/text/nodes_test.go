@@ -114,6 +114,9 @@ func TestBasicType_PrettyPrint(t *testing.T) {
 		obj Expression
 	}{
 		{`(#int 123)`, NumFromStr("123")},
+		{`(#int 255)`, NumFromStr("0xFF")},
+		{`(#int 1000)`, NumFromStr("1_000")},
+		{`(#long 10)`, LongFromStr("0b1010L")},
 		{`(#boolean true)`, NewBoolean("true")},
 		{`(#boolean false)`, NewBoolean("false")},
 		{`(#char 'c')`, NewChar("c")},
@@ -134,6 +137,119 @@ func TestBasicType_PrettyPrint(t *testing.T) {
 	}
 }
 
+func TestIntegerFromToken(t *testing.T) {
+	data := []struct {
+		str     string
+		sub     SubType
+		negated bool
+		expect  PrimitiveLiteral
+	}{
+		{"0", Decimal, false, Num(0)},
+		{"1_000", Decimal, false, Num(1000)},
+		{"2147483647", Decimal, false, Num(2147483647)},
+		{"2147483648", Decimal, true, Num(-2147483648)},
+		{"5", Decimal, true, Num(-5)},
+		{"0xFF", Hex, false, Num(255)},
+		{"0x7fff_ffff", Hex, false, Num(2147483647)},
+		{"0xFFFFFFFF", Hex, false, Num(-1)},
+		{"0x80000000", Hex, false, Num(-2147483648)},
+		{"0xFFFFFFFF", Hex, true, Num(1)},
+		{"017", Octal, false, Num(15)},
+		{"037777777777", Octal, false, Num(-1)},
+		{"0b1010", Binary, false, Num(10)},
+		{"0b1111_0000", Binary, false, Num(240)},
+		{"10L", Decimal, false, Long(10)},
+		{"2147483648l", Decimal, false, Long(2147483648)},
+		{"9223372036854775807L", Decimal, false, Long(9223372036854775807)},
+		{"9223372036854775808L", Decimal, true, Long(-9223372036854775808)},
+		{"0xFFFF_FFFF_FFFF_FFFFL", Hex, false, Long(-1)},
+		{"0b11L", Binary, false, Long(3)},
+		{"0777L", Octal, false, Long(511)},
+	}
+
+	for _, d := range data {
+		result, err := IntegerFromToken(newTokenSub(1, 0, d.str, IntegerLiteral, d.sub), d.negated)
+		if err != nil {
+			t.Errorf("%s (negated: %v) should be valid, but got error: %s", d.str, d.negated, err)
+			continue
+		}
+
+		if PrettyPrint(result) != PrettyPrint(d.expect) {
+			t.Errorf("%s (negated: %v) expected to be %s but got %s",
+				d.str,
+				d.negated,
+				PrettyPrint(d.expect),
+				PrettyPrint(result),
+			)
+		}
+	}
+}
+
+func TestIntegerFromToken_outOfRange(t *testing.T) {
+	data := []struct {
+		str     string
+		sub     SubType
+		negated bool
+	}{
+		{"2147483648", Decimal, false},
+		{"2147483649", Decimal, true},
+		{"99999999999", Decimal, false},
+		{"0x1_0000_0000", Hex, false},
+		{"0b1_0000_0000_0000_0000_0000_0000_0000_0000", Binary, false},
+		{"040000000000", Octal, false},
+		{"9223372036854775808L", Decimal, false},
+		{"9223372036854775809L", Decimal, true},
+		{"0x1_0000_0000_0000_0000L", Hex, false},
+	}
+
+	for _, d := range data {
+		tok := newTokenSub(3, 7, d.str, IntegerLiteral, d.sub)
+		_, err := IntegerFromToken(tok, d.negated)
+		if err == nil {
+			t.Errorf("%s (negated: %v) should be out of range", d.str, d.negated)
+			continue
+		}
+
+		expect := fmt.Sprintf("|3:7| Integer number too large: %s", d.str)
+		if err.Error() != expect {
+			t.Errorf("Expecting error of %#v but got %#v", expect, err.Error())
+		}
+	}
+}
+
+func TestFloatingPointFromToken(t *testing.T) {
+	data := []struct {
+		str    string
+		expect PrimitiveLiteral
+	}{
+		{"1.5", Double(1.5)},
+		{"1_000.5", Double(1000.5)},
+		{"1e3", Double(1000)},
+		{"1.5f", Float(1.5)},
+		{"2D", Double(2)},
+		{"0x1.8p1", Double(3)},
+		{"0x1p-1f", Float(0.5)},
+	}
+
+	for _, d := range data {
+		result, err := FloatingPointFromToken(newToken(1, 0, d.str, FloatingPointLiteral))
+		if err != nil {
+			t.Errorf("%s should be valid, but got error: %s", d.str, err)
+			continue
+		}
+
+		if PrettyPrint(result) != PrettyPrint(d.expect) {
+			t.Errorf("%s expected to be %s but got %s", d.str, PrettyPrint(d.expect), PrettyPrint(result))
+		}
+	}
+
+	for _, str := range []string{"1e400", "1e39f"} {
+		if _, err := FloatingPointFromToken(newToken(1, 0, str, FloatingPointLiteral)); err == nil {
+			t.Errorf("%s should be out of range", str)
+		}
+	}
+}
+
 func TestNewBoolean_panic(t *testing.T) {
 	data := "nice"
 	msg := fmt.Sprintf("NewBoolean should panic on %#v", data)
@@ -155,15 +271,15 @@ func TestCaseStatement_String(t *testing.T) {
 	}{
 		{
 			"(#case (#int 12) :do [])",
-			CaseStatement{Num(12), []Statement{}},
+			CaseStatement{[]Expression{Num(12)}, []Statement{}},
 		},
 		{
 			"(#case (#int 12) :do [(#return)])",
-			CaseStatement{Num(12), []Statement{&JumpStatement{ReturnJump, nil}}},
+			CaseStatement{[]Expression{Num(12)}, []Statement{&JumpStatement{ReturnJump, nil}}},
 		},
 		{
 			"(#case (#char 'c') :do [(#break)])",
-			CaseStatement{Char('c'), []Statement{&JumpStatement{BreakJump, nil}}},
+			CaseStatement{[]Expression{Char('c')}, []Statement{&JumpStatement{BreakJump, nil}}},
 		},
 	}
 	for _, d := range data {
@@ -182,9 +298,10 @@ func TestSwitchStatement_PrettyPrint(t *testing.T) {
 			"(#switch (#field age) :case [(#case (#int 12) :do [(#return)])])",
 			&SwitchStatement{&FieldAccess{"age", nil},
 				[]*CaseStatement{
-					{Num(12), []Statement{&JumpStatement{ReturnJump, nil}}},
+					{[]Expression{Num(12)}, []Statement{&JumpStatement{ReturnJump, nil}}},
 				},
 				nil,
+				false,
 			},
 		},
 		{
@@ -192,6 +309,7 @@ func TestSwitchStatement_PrettyPrint(t *testing.T) {
 			&SwitchStatement{&FieldAccess{"age", nil},
 				[]*CaseStatement{},
 				[]Statement{&JumpStatement{ReturnJump, nil}},
+				false,
 			},
 		},
 	}
@@ -232,36 +350,68 @@ func TestIfStatement_PrettyPrint(t *testing.T) {
 	}
 }
 
+func TestNamedType_String(t *testing.T) {
+	box := NamedType{"Box", false, []NamedType{{"String", false, nil}}}
+	data := []struct {
+		str  string
+		node NamedType
+	}{
+		{"int[]", NamedType{"int", true, nil}},
+		{"Box<String>", box},
+		{"Pair<Box<String>, T[]>[]", NamedType{"Pair", true, []NamedType{box, {"T", true, nil}}}},
+	}
+	for _, d := range data {
+		if res := d.node.String(); res != d.str {
+			t.Errorf("Expecting \n%s but got \n%s", d.str, res)
+		}
+	}
+}
+
+func TestNamedType_Equal(t *testing.T) {
+	box := NamedType{"Box", false, []NamedType{{"String", false, nil}}}
+	if !box.Equal(NamedType{"Box", false, []NamedType{{"String", false, nil}}}) {
+		t.Errorf("Named type with the same type arguments should be equal")
+	}
+
+	if box.Equal(NamedType{"Box", false, []NamedType{{"T", false, nil}}}) {
+		t.Errorf("Named type with different type arguments should be unequal")
+	}
+
+	if box.Equal(NamedType{"Box", false, nil}) {
+		t.Errorf("Named type with different number of type arguments should be unequal")
+	}
+}
+
 //TODO: Do more equality test
 func TestMethodSignature_Equal(t *testing.T) {
-	m1 := MethodSignature{Public, NamedType{"void", false}, "Hello", []Parameter{}}
-	m2 := MethodSignature{Public, NamedType{"void", false}, "Hello", []Parameter{}}
+	m1 := MethodSignature{Public, NamedType{"void", false, nil}, "Hello", []Parameter{}, nil, nil}
+	m2 := MethodSignature{Public, NamedType{"void", false, nil}, "Hello", []Parameter{}, nil, nil}
 
 	if !m2.Equal(m1) {
 		t.Errorf("Method signature should be equal")
 	}
 
-	m3 := MethodSignature{Public, NamedType{"int", true}, "getAge", []Parameter{}}
-	m4 := MethodSignature{Public, NamedType{"int", true}, "getAge", []Parameter{{NamedType{"int", false}, "a"}}}
+	m3 := MethodSignature{Public, NamedType{"int", true, nil}, "getAge", []Parameter{}, nil, nil}
+	m4 := MethodSignature{Public, NamedType{"int", true, nil}, "getAge", []Parameter{{NamedType{"int", false, nil}, "a", false, false}}, nil, nil}
 
 	if m3.Equal(m4) {
 		t.Errorf("Method signature with different parameter count should be unequal")
 	}
 
-	m5 := MethodSignature{Public, NamedType{"int", true}, "getName", []Parameter{}}
-	m6 := MethodSignature{Public, NamedType{"int", true}, "getAge", []Parameter{}}
+	m5 := MethodSignature{Public, NamedType{"int", true, nil}, "getName", []Parameter{}, nil, nil}
+	m6 := MethodSignature{Public, NamedType{"int", true, nil}, "getAge", []Parameter{}, nil, nil}
 
 	if m5.Equal(m6) {
 		t.Errorf("Method signature with different name should be unequal")
 	}
 
-	m7 := MethodSignature{Public, NamedType{"int", true}, "getAge", []Parameter{
-		{NamedType{"int", false}, "a"},
-	}}
+	m7 := MethodSignature{Public, NamedType{"int", true, nil}, "getAge", []Parameter{
+		{NamedType{"int", false, nil}, "a", false, false},
+	}, nil, nil}
 
-	m8 := MethodSignature{Public, NamedType{"int", true}, "getAge", []Parameter{
-		{NamedType{"char", false}, "a"},
-	}}
+	m8 := MethodSignature{Public, NamedType{"int", true, nil}, "getAge", []Parameter{
+		{NamedType{"char", false, nil}, "a", false, false},
+	}, nil, nil}
 
 	if m7.Equal(m8) {
 		t.Errorf("Method signature with different parameter list should be unequal")
@@ -273,18 +423,21 @@ func TestClass_Members(t *testing.T) {
 	prop := &PropertyDeclaration{
 		Public,
 		VariableDeclaration{
-			NamedType{"int", false},
+			NamedType{"int", false, nil},
 			"age",
 			nil,
+			false,
 		},
 	}
 
 	method := &MethodDeclaration{
 		MethodSignature{
 			Public,
-			NamedType{"int", false},
+			NamedType{"int", false, nil},
 			"getAge",
 			[]Parameter{},
+			nil,
+			nil,
 		},
 		nil,
 	}
@@ -294,11 +447,13 @@ func TestClass_Members(t *testing.T) {
 	method2 := &MethodDeclaration{
 		MethodSignature{
 			Public,
-			NamedType{"int", false},
+			NamedType{"int", false, nil},
 			"getAge",
 			[]Parameter{
-				{NamedType{"int", false}, "a"},
+				{NamedType{"int", false, nil}, "a", false, false},
 			},
+			nil,
+			nil,
 		},
 		nil,
 	}
@@ -316,7 +471,7 @@ func TestClass_Members(t *testing.T) {
 		if mem.GetName() != ex.GetName() ||
 			mem.GetAccessModifier() != ex.GetAccessModifier() ||
 			mem.DeclType() != ex.DeclType() ||
-			mem.TypeOf() != ex.TypeOf() {
+			!mem.TypeOf().Equal(ex.TypeOf()) {
 
 			t.Errorf("Members are not equal")
 
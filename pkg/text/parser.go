@@ -3,6 +3,7 @@ package text
 import (
 	"fmt"
 	"io"
+	"strings"
 )
 
 // Parser represent a parser engine
@@ -11,6 +12,9 @@ type Parser struct {
 	curToken *Token
 	EOF      bool
 	program  Program
+	errors   []error
+	classes  []*Class
+	counters map[string]int
 }
 
 func KeywordEqualTo(token Token, str string) bool {
@@ -25,9 +29,25 @@ func NewParser(lexer *Lexer) Parser {
 		&tok,
 		false,
 		make(Program, 0),
+		make([]error, 0),
+		make([]*Class, 0),
+		make(map[string]int),
 	}
 }
 
+// Errors return non fatal errors found while parsing,
+// such as literal that is out of range
+func (p *Parser) Errors() []error {
+	return p.errors
+}
+
+// addErrorf report a non fatal error found at tok,
+// the parsing continue so the other errors are also found.
+func (p *Parser) addErrorf(tok Token, format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	p.errors = append(p.errors, fmt.Errorf("|%s| %s", tok.Position, msg))
+}
+
 func (p *Parser) match(token TokenType) string {
 	if p.curToken == nil {
 		panic("EOF")
@@ -56,20 +76,42 @@ var accessModMap = map[string]AccessModifier{
 	"public":    Public,
 	"protected": Protected,
 	"private":   Private,
+	"final":     Final,
 }
 
 func (p *Parser) Compile() Program {
+	// the imports come before any type declaration
+	for KeywordEqualTo(*p.curToken, "import") {
+		p.program.AddTemplate(p.importDeclaration())
+	}
+
 	for !p.EOF {
+		if p.isRecord() {
+			p.program.AddTemplate(p.recordDeclaration(TopLevelClass, 0))
+			continue
+		}
+
 		if p.curToken.Type != Keyword {
 			panic(fmt.Sprintf(
-				"Expecting class or interface declaration but got %s",
+				"Expecting class, interface or enum declaration but got %s",
 				p.curToken,
 			))
 		}
 
 		var t Template
-		if p.curToken.Value() == "class" {
+		if p.curToken.Value() == "final" {
+			p.match(Keyword)
+			if p.isRecord() {
+				t = p.recordDeclaration(TopLevelClass, 0)
+			} else {
+				class := p.classDeclaration()
+				class.Access = Final
+				t = class
+			}
+		} else if p.curToken.Value() == "class" {
 			t = p.classDeclaration()
+		} else if p.curToken.Value() == "enum" {
+			t = p.enumDeclaration()
 		} else {
 			t = p.interfaceDeclaration()
 		}
@@ -78,10 +120,31 @@ func (p *Parser) Compile() Program {
 	return p.program
 }
 
+func (p *Parser) importDeclaration() *Import {
+	var i Import
+	p.match(Keyword) // import
+	i.Name = p.match(Id)
+	for p.curToken.Type == Dot {
+		p.match(Dot)
+		if p.curToken.Type == Multiplication {
+			p.match(Multiplication)
+			i.IsOnDemand = true
+			break
+		}
+		i.Name += "." + p.match(Id)
+	}
+	p.match(Semicolon)
+	return &i
+}
+
 func (p *Parser) interfaceDeclaration() *Interface {
 	var i Interface
 	p.match(Keyword) // interface
 	i.Name = p.match(Id)
+	if p.curToken.Type == LessThan {
+		i.TypeParams = p.typeParameters()
+	}
+
 	p.match(LeftCurlyBracket)
 	for p.curToken.Type != RightCurlyBracket {
 		signature := p.methodSignature()
@@ -93,7 +156,12 @@ func (p *Parser) interfaceDeclaration() *Interface {
 
 func (p *Parser) methodSignature() *MethodSignature {
 	var method MethodSignature
-	method.AccessModifier = p.accessModifier()
+	method.Annotations = p.annotations()
+	method.AccessModifier = p.modifiers()
+	if p.curToken.Type == LessThan {
+		method.TypeParams = p.typeParameters()
+	}
+
 	method.ReturnType = p.declarationType()
 	method.Name = p.match(Id)
 	method.ParameterList = p.parameterList()
@@ -104,29 +172,299 @@ func (p *Parser) methodSignature() *MethodSignature {
 func (p *Parser) classDeclaration() *Class {
 	p.match(Keyword)
 	class := NewEmptyClass(p.match(Id), "", "")
+	p.classHeader(class)
+	p.classBody(class)
+	return class
+}
+
+// nestedClass parse a class declared inside another class or a block,
+// it is named after the enclosing class, e.g. Outer$Inner
+func (p *Parser) nestedClass(kind ClassKind, access AccessModifier) *Class {
+	p.match(Keyword) // class
+	class := NewEmptyClass(p.nestedName(kind, p.match(Id)), "", "")
+	class.Kind = kind
+	class.Access = access
+	p.classHeader(class)
+	p.classBody(class)
+	return class
+}
+
+// anonymousClass parse the body of an anonymous class,
+// which extends or implements the created type
+func (p *Parser) anonymousClass(base string, args []NamedType) *Class {
+	class := NewEmptyClass(p.nestedName(AnonymousClass, ""), base, "")
+	class.Kind = AnonymousClass
+	class.ExtendArgs = args
+	p.classBody(class)
+	return class
+}
+
+// nestedName get the binary name of a nested class, local and anonymous
+// classes are numbered since the same name can be declared in many blocks
+func (p *Parser) nestedName(kind ClassKind, name string) string {
+	outer := p.enclosingName()
+	if kind == LocalClass || kind == AnonymousClass {
+		key := outer + "$" + name
+		p.counters[key] += 1
+		return fmt.Sprintf("%s$%d%s", outer, p.counters[key], name)
+	}
+	return outer + "$" + name
+}
+
+// enclosingName get the name of the class being parsed
+func (p *Parser) enclosingName() string {
+	if len(p.classes) == 0 {
+		return ""
+	}
+	return p.classes[len(p.classes)-1].Name
+}
+
+func (p *Parser) classHeader(class *Class) {
+	if p.curToken.Type == LessThan {
+		class.TypeParams = p.typeParameters()
+	}
+
 	if key := p.curToken.Value(); p.curToken.Type == Keyword &&
 		key == "extends" ||
 		key == "implements" {
 		p.classExtends(class)
 	}
+}
+
+func (p *Parser) classBody(class *Class) {
+	p.classes = append(p.classes, class)
+	defer func() { p.classes = p.classes[:len(p.classes)-1] }()
+
+	p.match(LeftCurlyBracket)
+	for p.curToken.Type != RightCurlyBracket {
+		decl := p.declaration()
+		class.AddDeclaration(decl)
+	}
+	p.match(RightCurlyBracket)
+}
+
+func (p *Parser) enumDeclaration() *Enum {
+	p.match(Keyword) // enum
+	enum := NewEmptyEnum(p.match(Id), "")
+	if KeywordEqualTo(*p.curToken, "implements") {
+		p.match(Keyword)
+		enum.Implement = p.match(Id)
+	}
+
+	p.classes = append(p.classes, &enum.Class)
+	defer func() { p.classes = p.classes[:len(p.classes)-1] }()
+
+	p.match(LeftCurlyBracket)
+	for p.curToken.Type == Id {
+		enum.AddConstant(p.enumConstant())
+		if p.curToken.Type != Comma {
+			break
+		}
+		p.match(Comma)
+	}
+
+	// the rest of the body is optional, but must be separated by semicolon
+	if p.curToken.Type == Semicolon {
+		p.match(Semicolon)
+		for p.curToken.Type != RightCurlyBracket {
+			tok := *p.curToken
+			decl := p.declaration()
+			if decl.DeclType() == Constructor && decl.GetAccessModifier()&(Public|Protected) != 0 {
+				p.addErrorf(tok, "Enum constructor can only be private.")
+			}
+			enum.AddDeclaration(decl)
+		}
+	}
+	p.match(RightCurlyBracket)
+
+	return enum
+}
+
+// isRecord check if the current token start a record declaration, record
+// is not a keyword so it can still be used as a name, e.g. record = 1;
+func (p *Parser) isRecord() bool {
+	if p.curToken.Type != Id || p.curToken.Value() != "record" {
+		return false
+	}
+
+	name, _ := p.lexer.PeekTokenAt(0)
+	header, _ := p.lexer.PeekTokenAt(1)
+	return name.Type == Id && header.IsOfType(LeftParenthesis, LessThan)
+}
+
+// recordDeclaration parse a record, which is desugared into a final class
+// whose components are private final fields, see desugarRecord.
+func (p *Parser) recordDeclaration(kind ClassKind, access AccessModifier) *Class {
+	p.match(Id) // record
+	simpleName := p.match(Id)
+	name := simpleName
+	if kind != TopLevelClass {
+		name = p.nestedName(kind, simpleName)
+	}
+
+	class := NewEmptyClass(name, "", "")
+	class.Kind = kind
+	class.Access = access | Final
+	if p.curToken.Type == LessThan {
+		class.TypeParams = p.typeParameters()
+	}
+
+	// never nil, even if the record has no component
+	class.Components = append([]Parameter{}, p.parameterList()...)
+	if KeywordEqualTo(*p.curToken, "extends") {
+		p.addErrorf(*p.curToken, "Record cannot extend a class.")
+		p.classExtends(class)
+	}
+
+	if KeywordEqualTo(*p.curToken, "implements") {
+		p.classExtends(class)
+	}
 
+	p.classes = append(p.classes, class)
+	defer func() { p.classes = p.classes[:len(p.classes)-1] }()
+
+	var compact *ConstructorDeclaration
+	canonical := NewConstructor(Public, simpleName, class.Components, nil).Signature()
 	p.match(LeftCurlyBracket)
 	for p.curToken.Type != RightCurlyBracket {
+		tok := *p.curToken
+		if p.isCompactConstructor(simpleName) {
+			if compact != nil {
+				p.addErrorf(tok, "Compact constructor is already declared.")
+			}
+			compact = p.compactConstructor()
+			continue
+		}
+
 		decl := p.declaration()
+		switch decl.DeclType() {
+		case Property:
+			if decl.GetAccessModifier()&Static == 0 {
+				p.addErrorf(tok, "Record cannot declare an instance field.")
+			}
+		case InitializerBlock:
+			if !decl.(*Initializer).IsStatic {
+				p.addErrorf(tok, "Record cannot declare an instance initializer.")
+			}
+		case Constructor:
+			// only the canonical constructor assign the fields
+			con := decl.(*ConstructorDeclaration)
+			if con.Signature() != canonical && !isDelegating(con) {
+				p.addErrorf(tok, "Non-canonical record constructor must invoke another constructor first.")
+			}
+		}
 		class.AddDeclaration(decl)
 	}
 	p.match(RightCurlyBracket)
 
+	desugarRecord(class, compact)
 	return class
 }
 
+// isCompactConstructor check if the current declaration is a constructor
+// declared without its parameters, e.g. public Point { ... }
+func (p *Parser) isCompactConstructor(name string) bool {
+	tok, i := *p.curToken, 0
+	for tok.Type == Keyword {
+		if _, ok := accessModMap[tok.Value()]; !ok {
+			return false
+		}
+		tok, _ = p.lexer.PeekTokenAt(i)
+		i++
+	}
+
+	next, _ := p.lexer.PeekTokenAt(i)
+	return tok.Type == Id && tok.Value() == name && next.Type == LeftCurlyBracket
+}
+
+// compactConstructor parse the body of a compact constructor,
+// its parameters are the components of the record.
+func (p *Parser) compactConstructor() *ConstructorDeclaration {
+	tok := *p.curToken
+	accessMod := p.modifiers()
+	if accessMod&Final != 0 {
+		p.addErrorf(tok, "Constructor cannot be final.")
+	}
+
+	name := p.match(Id)
+	body := p.constructorBody()
+	if len(body) > 0 {
+		if _, ok := body[0].(*ConstructorCallStatement); ok {
+			p.addErrorf(tok, "Compact constructor cannot invoke another constructor.")
+		}
+	}
+	return NewConstructor(accessMod, name, nil, body)
+}
+
+// isDelegating check if con invoke another constructor first
+func isDelegating(con *ConstructorDeclaration) bool {
+	if len(con.Body) == 0 {
+		return false
+	}
+
+	_, ok := con.Body[0].(*ConstructorCallStatement)
+	return ok
+}
+
+// desugarRecord add the members implied by the components of a record,
+// a private final field and an accessor for each of them, along with the
+// canonical constructor that assign all of them unless it is declared.
+// The body of a compact constructor run before the fields are assigned.
+func desugarRecord(class *Class, compact *ConstructorDeclaration) {
+	var assigns StatementList
+	for _, component := range class.Components {
+		class.AddDeclaration(&PropertyDeclaration{
+			Private | Final,
+			VariableDeclaration{component.Type, component.Name, nil, false},
+		})
+
+		assigns = append(assigns, &AssignmentStatement{
+			newToken(0, 0, "=", Assignment),
+			&This{&FieldAccess{component.Name, nil}, ""},
+			&FieldAccess{component.Name, nil},
+		})
+
+		if class.DeclaredMethod(component.Name) == nil {
+			class.AddDeclaration(NewMethodDeclaration(
+				Public,
+				component.Type,
+				component.Name,
+				nil,
+				StatementList{&JumpStatement{ReturnJump, &This{&FieldAccess{component.Name, nil}, ""}}},
+			))
+		}
+	}
+
+	canonical := NewConstructor(Public, class.SimpleName(), class.Components, assigns)
+	if compact != nil {
+		compact.ParameterList = class.Components
+		compact.Body = append(compact.Body, assigns...)
+		class.AddDeclaration(compact)
+	} else if _, ok := class.Constructor[canonical.Signature()]; !ok {
+		class.AddDeclaration(canonical)
+	}
+}
+
+func (p *Parser) enumConstant() *EnumConstant {
+	constant := &EnumConstant{p.match(Id), nil}
+	if p.curToken.Type == LeftParenthesis {
+		constant.Args = p.argumentList()
+	}
+	return constant
+}
+
 func (p *Parser) classExtends(class *Class) {
 	key := p.match(Keyword)
-	name := p.match(Id)
+	name := p.qualifiedName()
+	var args []NamedType
+	if p.curToken.Type == LessThan {
+		args = p.typeArguments()
+	}
+
 	if key == "extends" {
-		class.Extend = name
+		class.Extend, class.ExtendArgs = name, args
 	} else if key == "implements" {
-		class.Implement = name
+		class.Implement, class.ImplementArgs = name, args
 	} else {
 		panic("Expect `extends` or `implements` keyword.")
 	}
@@ -134,35 +472,177 @@ func (p *Parser) classExtends(class *Class) {
 }
 
 func (p *Parser) declaration() (decl Declaration) {
-	accessMod := p.accessModifier()
+	if p.curToken.Type == LeftCurlyBracket {
+		return p.initializer(false)
+	}
+
+	if KeywordEqualTo(*p.curToken, "static") {
+		if peek, _ := p.lexer.PeekToken(); peek.Type == LeftCurlyBracket {
+			p.match(Keyword)
+			return p.initializer(true)
+		}
+	}
+
+	at := *p.curToken
+	if annotations := p.annotations(); len(annotations) > 0 {
+		decl := p.declaration()
+		method, ok := decl.(*MethodDeclaration)
+		if !ok {
+			p.addErrorf(at, "Annotation is only allowed on a method.")
+			return decl
+		}
+		method.Annotations = annotations
+		return method
+	}
+
+	accessMod := p.modifiers()
+	if KeywordEqualTo(*p.curToken, "class") {
+		return p.nestedClass(InnerClass, accessMod)
+	}
+
+	// a member record is implicitly static
+	if p.isRecord() {
+		return p.recordDeclaration(NestedClass, accessMod)
+	}
+
 	if p.curToken.Value() == "static" {
-		if accessMod != Public {
-			panic("Expected to be a main method!")
+		if peek, _ := p.lexer.PeekToken(); KeywordEqualTo(peek, "void") {
+			if accessMod != Public {
+				panic("Expected to be a main method!")
+			}
+			return p.mainMethodDeclaration()
 		}
-		return p.mainMethodDeclaration()
+
+		p.match(Keyword) // static
+		accessMod |= p.modifiers()
+		if KeywordEqualTo(*p.curToken, "class") {
+			return p.nestedClass(NestedClass, accessMod)
+		}
+
+		if p.isRecord() {
+			return p.recordDeclaration(NestedClass, accessMod)
+		}
+		accessMod |= Static
+	}
+
+	var typeParams []TypeParameter
+	typeParamsToken := *p.curToken
+	if p.curToken.Type == LessThan {
+		typeParams = p.typeParameters()
 	}
 
+	tok := *p.curToken
 	ty := p.declarationType()
+	peek, _ := p.lexer.PeekToken()
+	isMethod := p.curToken.Type == LeftParenthesis || peek.Type == LeftParenthesis
+	if isMethod && accessMod&Static != 0 {
+		p.addErrorf(tok, "Only the main method can be static.")
+	}
+
 	// its certainly a constructor, and the type is actually a name
 	if p.curToken.Type == LeftParenthesis {
-		return p.constructorDeclaration(accessMod, ty.Name)
-	}
+		if accessMod&Final != 0 {
+			p.addErrorf(tok, "Constructor cannot be final.")
+		}
 
-	peek, _ := p.lexer.PeekToken()
+		con := p.constructorDeclaration(accessMod, ty.Name)
+		con.TypeParams = typeParams
+		return con
+	}
 
 	if peek.Type == LeftParenthesis {
-		return p.methodDeclaration(accessMod, ty)
-	} else {
-		return p.propertyDeclaration(accessMod, ty)
+		method := p.methodDeclaration(accessMod, ty)
+		method.TypeParams = typeParams
+		return method
 	}
+
+	if len(typeParams) > 0 {
+		p.addErrorf(typeParamsToken, "Type parameters are only allowed on method or constructor.")
+	}
+	return p.propertyDeclaration(accessMod, ty)
 }
 
-func (p *Parser) accessModifier() (acc AccessModifier) {
-	if val := p.curToken.Value(); val == "public" ||
-		val == "private" || val == "protected" {
+// typeParameters parse the type variables declaration,
+// e.g. <K, V extends Comparable<V>>
+func (p *Parser) typeParameters() (params []TypeParameter) {
+	p.match(LessThan)
+	for {
+		param := TypeParameter{p.match(Id), nil}
+		if KeywordEqualTo(*p.curToken, "extends") {
+			p.match(Keyword)
+			bound := p.typeArray(p.qualifiedName())
+			param.Bound = &bound
+		}
+
+		params = append(params, param)
+		if p.curToken.Type != Comma {
+			break
+		}
+		p.match(Comma)
+	}
+	p.closeTypeArguments()
+	return
+}
 
-		key := p.match(Keyword)
-		acc = accessModMap[key]
+// typeArguments parse the type arguments of a type, e.g. <String, Box<T>>
+func (p *Parser) typeArguments() (args []NamedType) {
+	p.match(LessThan)
+	for {
+		args = append(args, p.declarationType())
+		if p.curToken.Type != Comma {
+			break
+		}
+		p.match(Comma)
+	}
+	p.closeTypeArguments()
+	return
+}
+
+// closeTypeArguments match a single >, the shift operator is
+// splitted since the lexer can not tell it apart from nested type
+// arguments, e.g. Box<Box<T>>
+func (p *Parser) closeTypeArguments() {
+	tok := *p.curToken
+	switch tok.Type {
+	case RightShift:
+		rest := newToken(tok.Linum, tok.Column+1, ">", GreaterThan)
+		p.curToken = &rest
+	case UnsignedRightShift:
+		rest := newToken(tok.Linum, tok.Column+1, ">>", RightShift)
+		p.curToken = &rest
+	default:
+		p.match(GreaterThan)
+	}
+}
+
+// annotations parse the marker annotations written before a method,
+// e.g. @Override, an annotation cannot have any argument.
+func (p *Parser) annotations() (names []string) {
+	for p.curToken.Type == At {
+		p.match(At)
+		names = append(names, p.match(Id))
+	}
+	return
+}
+
+// modifiers parse the access modifier along with final, which may be
+// written in any order, static is left to the caller to decide.
+func (p *Parser) modifiers() (acc AccessModifier) {
+	const access = Public | Protected | Private
+	for p.curToken.Type == Keyword {
+		mod, ok := accessModMap[p.curToken.Value()]
+		if !ok {
+			break
+		}
+
+		if acc&mod != 0 {
+			p.addErrorf(*p.curToken, "Repeated modifier %s", p.curToken.Value())
+		} else if mod&access != 0 && acc&access != 0 {
+			p.addErrorf(*p.curToken, "Expecting only one access modifier.")
+		}
+
+		p.match(Keyword)
+		acc |= mod
 	}
 	return
 }
@@ -177,35 +657,67 @@ func (p *Parser) declarationType() NamedType {
 			name = "void"
 		}
 	} else {
-		name = p.match(Id)
+		name = p.qualifiedName()
 	}
 	return p.typeArray(name)
 }
 
+// qualifiedName parse a type name, which may be qualified
+// by its enclosing types, e.g. Outer.Inner
+func (p *Parser) qualifiedName() string {
+	name := p.match(Id)
+	for p.curToken.Type == Dot {
+		p.match(Dot)
+		name += "." + p.match(Id)
+	}
+	return name
+}
+
 func (p *Parser) parameterList() (params []Parameter) {
 	isType := func() bool {
 		if p.curToken.Type == Id {
 			return true
 		}
 
-		if val := p.curToken.Value(); p.curToken.Type == Keyword &&
-			(val == "int" || val == "boolean" || val == "char") {
+		if p.curToken.Type == Keyword && IsPrimitiveTypeName(p.curToken.Value()) {
 			return true
 		}
 
-		return false
+		return KeywordEqualTo(*p.curToken, "final")
 	}
 
 	p.match(LeftParenthesis)
 	for isType() {
-		ty := p.typeArray(p.match(p.curToken.Type))
-		name := p.match(Id)
-		params = append(params, Parameter{ty, name})
-		if p.curToken.Type == Comma {
-			p.match(Comma)
+		isFinal := KeywordEqualTo(*p.curToken, "final")
+		if isFinal {
+			p.match(Keyword)
+		}
+
+		var ty NamedType
+		if p.curToken.Type == Id {
+			ty = p.typeArray(p.qualifiedName())
 		} else {
+			ty = p.typeArray(p.match(Keyword))
+		}
+		isVarargs := p.curToken.Type == Ellipsis
+		if isVarargs {
+			if ty.IsArray {
+				p.addErrorf(*p.curToken, "Varargs parameter of an array type is not supported.")
+			}
+			p.match(Ellipsis)
+			ty.IsArray = true
+		}
+
+		name := p.match(Id)
+		params = append(params, Parameter{ty, name, isFinal, isVarargs})
+		if p.curToken.Type != Comma {
 			break
 		}
+
+		if isVarargs {
+			p.addErrorf(*p.curToken, "Varargs parameter must be the last parameter.")
+		}
+		p.match(Comma)
 	}
 	p.match(RightParenthesis)
 	return
@@ -234,15 +746,51 @@ func (p *Parser) mainMethodDeclaration() *MainMethodDeclaration {
 	p.match(RightParenthesis)
 
 	main.AccessModifier = Public
-	main.ReturnType = NamedType{"void", false}
+	main.ReturnType = NamedType{"void", false, nil}
 	main.Name = "main"
-	main.ParameterList = []Parameter{{ty, arg}}
+	main.ParameterList = []Parameter{{ty, arg, false, false}}
 	main.Body = p.statementList()
 
 	return &main
 }
+
+// initializer parse the body of an initializer block,
+// the static keyword is already matched if it is static
+func (p *Parser) initializer(isStatic bool) *Initializer {
+	return &Initializer{isStatic, p.statementList()}
+}
+
 func (p *Parser) constructorDeclaration(accessMod AccessModifier, name string) *ConstructorDeclaration {
-	return NewConstructor(accessMod, name, p.parameterList(), p.statementList())
+	params := p.parameterList()
+	return NewConstructor(accessMod, name, params, p.constructorBody())
+}
+
+// constructorBody parse the statements of a constructor,
+// the first one may invoke another constructor.
+func (p *Parser) constructorBody() (body StatementList) {
+	p.match(LeftCurlyBracket)
+	if p.isConstructorCall() {
+		body = append(body, p.constructorCall())
+	}
+
+	for p.curToken.Type != RightCurlyBracket {
+		body = append(body, p.statement())
+	}
+	p.match(RightCurlyBracket)
+	return body
+}
+
+// isConstructorCall check if the current statement is this(...)
+func (p *Parser) isConstructorCall() bool {
+	peek, _ := p.lexer.PeekToken()
+	return KeywordEqualTo(*p.curToken, "this") && peek.Type == LeftParenthesis
+}
+
+func (p *Parser) constructorCall() *ConstructorCallStatement {
+	p.match(Keyword) // this
+	call := &ConstructorCallStatement{p.argumentList()}
+	p.match(Semicolon)
+	return call
 }
 
 func (p *Parser) methodDeclaration(accessMod AccessModifier, typename NamedType) *MethodDeclaration {
@@ -263,7 +811,19 @@ func (p *Parser) propertyDeclaration(acc AccessModifier, ty NamedType) *Property
 
 	if p.curToken.Type == Assignment {
 		p.match(Assignment)
+		// the value is evaluated in every constructor,
+		// so an anonymous class would be declared many times
+		key, lambdaKey := p.enclosingName()+"$", p.enclosingName()+lambdaSuffix
+		anonymousCount, lambdaCount := p.counters[key], p.counters[lambdaKey]
+		valueToken := *p.curToken
 		prop.Value = p.expression()
+		if p.counters[key] != anonymousCount {
+			p.addErrorf(valueToken, "Anonymous class is not allowed as a property value.")
+		}
+
+		if p.counters[lambdaKey] != lambdaCount {
+			p.addErrorf(valueToken, "Lambda is not allowed as a property value.")
+		}
 	}
 	p.match(Semicolon)
 
@@ -298,13 +858,33 @@ func (p *Parser) statement() (stmt Statement) {
 			stmt = p.whileStmt()
 		case "for":
 			stmt = p.forStmt()
-		case "this":
+		case "assert":
+			stmt = p.assertStmt()
+		case "class":
+			stmt = p.nestedClass(LocalClass, 0)
+		case "final":
+			p.match(Keyword)
+			if KeywordEqualTo(*p.curToken, "class") {
+				return p.nestedClass(LocalClass, Final)
+			}
+
+			varDecl := p.variableDeclaration(p.declarationType())
+			varDecl.IsFinal = true
+			stmt = varDecl
+			p.match(Semicolon)
+		case "this", "super":
+			if p.isConstructorCall() {
+				p.addErrorf(*p.curToken, "Constructor call must be the first statement in a constructor.")
+				return p.constructorCall()
+			}
 			stmt = p.varDeclarationOrMethodOrAssignment()
 			p.match(Semicolon)
-		case "int", "boolean", "char":
+		case "int", "boolean", "char", "byte", "short", "long", "float", "double":
 			stmt = p.primitiveTypeVarDeclaration()
 			p.match(Semicolon)
 		}
+	} else if p.isYield() {
+		stmt = p.yieldStmt()
 	} else if p.curToken.Type == Id {
 		stmt = p.varDeclarationOrMethodOrAssignment()
 		p.match(Semicolon)
@@ -331,8 +911,14 @@ func (p *Parser) primitiveTypeVarDeclaration() *VariableDeclaration {
 	return p.variableDeclaration(ty)
 }
 
+// typeArray parse the rest of a type after its name,
+// which are the optional type arguments and array brackets
 func (p *Parser) typeArray(name string) NamedType {
-	ty := NamedType{name, false}
+	ty := NamedType{name, false, nil}
+	if p.curToken.Type == LessThan {
+		ty.TypeArgs = p.typeArguments()
+	}
+
 	if p.curToken.Type == LeftSquareBracket {
 		p.match(LeftSquareBracket)
 		p.match(RightSquareBracket)
@@ -350,7 +936,8 @@ func (p *Parser) varDeclarationOrMethodOrAssignment() (s Statement) {
 	} else {
 		// ID here is ambiguous, either a type or var
 		peek, _ := p.lexer.PeekToken()
-		if peek.Type == Id {
+		if peek.Type == Id || peek.Type == LessThan {
+			// a name followed by < must be a generic type, e.g. Box<T> box
 			ty := p.typeArray(p.match(Id))
 			return p.variableDeclaration(ty)
 
@@ -366,11 +953,32 @@ func (p *Parser) varDeclarationOrMethodOrAssignment() (s Statement) {
 		} else {
 			// possibly a dot or a LeftParen
 			namedVal = p.validName()
+			if t := p.curToken.Type; t == Id || t == LessThan {
+				// the name is a qualified type, e.g. Outer.Inner inner
+				if name := typeNameOf(namedVal); len(name) > 0 {
+					return p.variableDeclaration(p.typeArray(name))
+				}
+			}
 		}
 	}
 	return p.methodOrAssignment(namedVal)
 }
 
+// typeNameOf join a chain of field access into a qualified type name,
+// it is empty if there is anything else in the chain
+func typeNameOf(val NamedValue) string {
+	var names []string
+	for val != nil {
+		field, ok := val.(*FieldAccess)
+		if !ok {
+			return ""
+		}
+		names = append(names, field.Name)
+		val = field.Child
+	}
+	return strings.Join(names, ".")
+}
+
 func (p *Parser) methodOrAssignment(namedVal NamedValue) (s Statement) {
 	end := IdEndsAs(namedVal)
 	if end == "MethodCall" {
@@ -389,22 +997,28 @@ func (p *Parser) assignmentStmt(left NamedValue) *AssignmentStatement {
 		t == SubtractionAssignment ||
 		t == MultiplicationAssignment ||
 		t == DivisionAssignment ||
-		t == ModulusAssignment {
+		t == ModulusAssignment ||
+		t == BitwiseAndAssignment ||
+		t == BitwiseOrAssignment ||
+		t == BitwiseXorAssignment ||
+		t == LeftShiftAssignment ||
+		t == RightShiftAssignment ||
+		t == UnsignedRightShiftAssignment {
 		token := *p.curToken
 		p.match(t)
 		assig.Operator = token
 	}
 
-	assig.Right = p.conditionalOrExp()
+	assig.Right = p.expression()
 	return &assig
 }
 
 func (p *Parser) varDeclarationOrAssignment() (stmt Statement) {
 	if p.curToken.Type == Keyword {
 		switch p.curToken.Value() {
-		case "int", "boolean", "char":
+		case "int", "boolean", "char", "byte", "short", "long", "float", "double":
 			stmt = p.primitiveTypeVarDeclaration()
-		case "this":
+		case "this", "super":
 			stmt = p.varDeclarationOrMethodOrAssignment()
 		}
 	} else if p.curToken.Type == Id {
@@ -421,12 +1035,25 @@ func (p *Parser) forUpdate() (stmt Statement) {
 	return p.methodOrAssignment(namedVal)
 }
 
-func (p *Parser) forStmt() *ForStatement {
+// forStmt parse a basic for statement, or an enhanced for statement
+// if the init is a variable without value followed by a colon.
+func (p *Parser) forStmt() Statement {
 	var f ForStatement
 	p.match(Keyword)
 	p.match(LeftParenthesis)
 
-	f.Init = p.varDeclarationOrAssignment()
+	if KeywordEqualTo(*p.curToken, "final") {
+		p.match(Keyword)
+		varDecl := p.variableDeclaration(p.declarationType())
+		varDecl.IsFinal = true
+		f.Init = varDecl
+	} else {
+		f.Init = p.varDeclarationOrAssignment()
+	}
+
+	if varDecl, ok := f.Init.(*VariableDeclaration); ok && varDecl.Value == nil && p.curToken.Type == Colon {
+		return p.forEachStmt(varDecl)
+	}
 	p.match(Semicolon)
 
 	if p.curToken.Type != Semicolon {
@@ -441,6 +1068,16 @@ func (p *Parser) forStmt() *ForStatement {
 	return &f
 }
 
+func (p *Parser) forEachStmt(varDecl *VariableDeclaration) *ForEachStatement {
+	var f ForEachStatement
+	f.Variable = varDecl
+	p.match(Colon)
+	f.Iterable = p.expression()
+	p.match(RightParenthesis)
+	f.Body = p.statement()
+	return &f
+}
+
 func (p *Parser) whileStmt() *WhileStatement {
 	var whileStmt WhileStatement
 	p.match(Keyword)
@@ -473,33 +1110,89 @@ func (p *Parser) ifStmt() *IfStatement {
 }
 
 func (p *Parser) switchStmt() *SwitchStatement {
+	return p.switchBlock(false)
+}
+
+// switchExp parse a switch used as an expression, the
+// value of an arrow case is yielded out of the switch
+func (p *Parser) switchExp() *SwitchExpression {
+	return &SwitchExpression{*p.switchBlock(true), NamedType{}}
+}
+
+// switchBlock parse the value and the cases of a switch, every case
+// must either be an arrow case or a colon case but not both of them
+func (p *Parser) switchBlock(yields bool) *SwitchStatement {
 	p.match(Keyword)
 	p.match(LeftParenthesis)
 	exp := p.expression()
 	p.match(RightParenthesis)
 
 	p.match(LeftCurlyBracket)
+	const mixedCases = "Different case kinds used in the switch."
 	var cases []*CaseStatement
+	isArrow := p.isArrowCase()
 	for KeywordEqualTo(*p.curToken, "case") {
-		cases = append(cases, p.caseStmt())
+		if p.isArrowCase() != isArrow {
+			p.addErrorf(*p.curToken, mixedCases)
+		}
+		cases = append(cases, p.caseStmt(yields))
 	}
 
 	var defaults []Statement
 	if KeywordEqualTo(*p.curToken, "default") {
+		if p.isArrowCase() != isArrow {
+			p.addErrorf(*p.curToken, mixedCases)
+		}
+
 		p.match(Keyword)
-		p.match(Colon)
-		for p.curToken.Type != RightCurlyBracket {
-			defaults = append(defaults, p.statement())
+		if p.curToken.Type == Arrow {
+			p.match(Arrow)
+			defaults = []Statement{p.arrowCaseBody(yields)}
+		} else {
+			p.match(Colon)
+			for p.curToken.Type != RightCurlyBracket {
+				defaults = append(defaults, p.statement())
+			}
 		}
 	}
 	p.match(RightCurlyBracket)
 
-	return &SwitchStatement{exp, cases, defaults}
+	return &SwitchStatement{exp, cases, defaults, isArrow}
+}
+
+// isArrowCase check if the labels of the current case is followed by
+// an arrow, the labels are only scanned without being parsed.
+func (p *Parser) isArrowCase() bool {
+	if !KeywordEqualTo(*p.curToken, "case") && !KeywordEqualTo(*p.curToken, "default") {
+		return false
+	}
+
+	for i := 0; ; i++ {
+		tok, err := p.lexer.PeekTokenAt(i)
+		if err != nil || tok.IsOfType(Colon, Semicolon, LeftCurlyBracket, RightCurlyBracket) {
+			return false
+		}
+
+		if tok.Type == Arrow {
+			return true
+		}
+	}
 }
 
-func (p *Parser) caseStmt() *CaseStatement {
+func (p *Parser) caseStmt(yields bool) *CaseStatement {
 	p.match(Keyword)
-	constant := p.primitiveLiteral()
+	// an arrow cannot be parsed as a lambda here, e.g. case RED -> ...
+	constants := []Expression{p.conditionalOrExp()}
+	for p.curToken.Type == Comma {
+		p.match(Comma)
+		constants = append(constants, p.conditionalOrExp())
+	}
+
+	if p.curToken.Type == Arrow {
+		p.match(Arrow)
+		return &CaseStatement{constants, p.arrowCaseBody(yields)}
+	}
+
 	p.match(Colon)
 	var stmtList StatementList
 	val := p.curToken.Value()
@@ -511,7 +1204,69 @@ func (p *Parser) caseStmt() *CaseStatement {
 		stmtList = append(stmtList, stmt)
 		val = p.curToken.Value()
 	}
-	return &CaseStatement{constant, stmtList}
+	return &CaseStatement{constants, stmtList}
+}
+
+// arrowCaseBody parse the body of an arrow case, which is either a block
+// or a single statement. The single expression of a switch expression is
+// the value it yields, e.g. case 1 -> "one";
+func (p *Parser) arrowCaseBody(yields bool) StatementList {
+	if p.curToken.Type == LeftCurlyBracket {
+		return p.statementList()
+	}
+
+	if !yields {
+		stmt := p.statement()
+		if stmt == nil {
+			panic(fmt.Sprintf("Unexpected: %s", p.curToken))
+		}
+		return StatementList{stmt}
+	}
+
+	exp := p.expression()
+	p.match(Semicolon)
+	return StatementList{&JumpStatement{YieldJump, exp}}
+}
+
+// isYield check if the current token start a yield statement, yield
+// is not a keyword so it can still be used as a name, e.g. yield = 1;
+func (p *Parser) isYield() bool {
+	if p.curToken.Type != Id || p.curToken.Value() != "yield" {
+		return false
+	}
+
+	peek, _ := p.lexer.PeekToken()
+	return peek.IsOfType(
+		IntegerLiteral,
+		FloatingPointLiteral,
+		BooleanLiteral,
+		CharLiteral,
+		StringLiteral,
+		NullLiteral,
+		Id,
+		Keyword,
+		LeftParenthesis,
+		Addition,
+		Subtraction,
+	)
+}
+
+func (p *Parser) yieldStmt() *JumpStatement {
+	p.match(Id)
+	stmt := &JumpStatement{YieldJump, p.expression()}
+	p.match(Semicolon)
+	return stmt
+}
+
+func (p *Parser) assertStmt() *AssertStatement {
+	p.match(Keyword) // assert
+	stmt := &AssertStatement{p.expression(), nil}
+	if p.curToken.Type == Colon {
+		p.match(Colon)
+		stmt.Message = p.expression()
+	}
+	p.match(Semicolon)
+	return stmt
 }
 
 func (p *Parser) jumpStmt() Statement {
@@ -532,11 +1287,131 @@ func (p *Parser) jumpStmt() Statement {
 func (p *Parser) expression() Expression {
 	if KeywordEqualTo(*p.curToken, "new") {
 		return p.objectInitialization()
+	} else if p.isLambda() {
+		return p.lambda()
+	} else if peek, _ := p.lexer.PeekToken(); peek.Type == DoubleColon {
+		return p.methodReference()
 	} else {
 		return p.conditionalOrExp()
 	}
 }
 
+// lambdaSuffix is put between the enclosing class and
+// the number of a lambda to name its class, e.g. Main$$Lambda$1
+const lambdaSuffix = "$$Lambda$"
+
+func (p *Parser) lambdaName() string {
+	key := p.enclosingName() + lambdaSuffix
+	p.counters[key] += 1
+	return fmt.Sprintf("%s%d", key, p.counters[key])
+}
+
+// isLambda check if a lambda start at the current token,
+// the parameters in parentheses are skipped to find the arrow.
+func (p *Parser) isLambda() bool {
+	switch p.curToken.Type {
+	case Id:
+		peek, _ := p.lexer.PeekToken()
+		return peek.Type == Arrow
+	case LeftParenthesis:
+		depth := 1
+		for i := 0; ; i++ {
+			tok, err := p.lexer.PeekTokenAt(i)
+			switch tok.Type {
+			case LeftParenthesis:
+				depth += 1
+			case RightParenthesis:
+				depth -= 1
+			}
+
+			if depth == 0 {
+				next, _ := p.lexer.PeekTokenAt(i + 1)
+				return next.Type == Arrow
+			}
+
+			if err != nil {
+				return false
+			}
+		}
+	}
+	return false
+}
+
+func (p *Parser) lambda() *Lambda {
+	var params []Parameter
+	if p.curToken.Type == Id {
+		params = []Parameter{{NamedType{}, p.match(Id), false, false}}
+	} else {
+		params = p.lambdaParameters()
+	}
+
+	p.match(Arrow)
+	lambda := &Lambda{p.lambdaName(), params, nil}
+	if p.curToken.Type == LeftCurlyBracket {
+		lambda.Body = p.statementList()
+	} else {
+		lambda.Body = p.expression()
+	}
+	return lambda
+}
+
+// lambdaParameters parse the parameters of a lambda in parentheses,
+// either all of them are typed or all of them are inferred.
+func (p *Parser) lambdaParameters() (params []Parameter) {
+	isInferred := func() bool {
+		peek, _ := p.lexer.PeekToken()
+		return p.curToken.Type == Id && (peek.Type == Comma || peek.Type == RightParenthesis)
+	}
+
+	if peek, _ := p.lexer.PeekToken(); peek.Type == RightParenthesis {
+		p.match(LeftParenthesis)
+		p.match(RightParenthesis)
+		return
+	}
+
+	p.match(LeftParenthesis)
+	inferred := isInferred()
+	for {
+		isParamInferred := isInferred()
+		if inferred != isParamInferred {
+			p.addErrorf(*p.curToken, "Lambda parameters should be either all typed or all inferred.")
+		}
+
+		ty := NamedType{}
+		if !isParamInferred {
+			ty = p.declarationType()
+		}
+		params = append(params, Parameter{ty, p.match(Id), false, false})
+
+		if p.curToken.Type != Comma {
+			break
+		}
+		p.match(Comma)
+	}
+	p.match(RightParenthesis)
+	return
+}
+
+// methodReference parse a method reference, the target
+// is either this, a variable or a type, e.g. Person::new
+func (p *Parser) methodReference() *MethodReference {
+	var target string
+	if KeywordEqualTo(*p.curToken, "this") {
+		target = p.match(Keyword)
+	} else {
+		target = p.match(Id)
+	}
+
+	p.match(DoubleColon)
+	var method string
+	if KeywordEqualTo(*p.curToken, "new") {
+		method = p.match(Keyword)
+	} else {
+		method = p.match(Id)
+	}
+	return &MethodReference{p.lambdaName(), target, method}
+}
+
 func (p *Parser) conditionalOrExp() Expression {
 	left := p.conditionalAndExp()
 	for p.curToken.Type == Or {
@@ -549,7 +1424,7 @@ func (p *Parser) conditionalOrExp() Expression {
 }
 
 func (p *Parser) conditionalAndExp() Expression {
-	left := p.relationalExp()
+	left := p.inclusiveOrExp()
 	for p.curToken.Type == And {
 		andToken := *p.curToken
 		p.match(And)
@@ -559,8 +1434,41 @@ func (p *Parser) conditionalAndExp() Expression {
 	return left
 }
 
+// inclusiveOrExp, exclusiveOrExp and andExp parse the bitwise
+// operators, each one binding tighter than the previous.
+// Unlike the arithmetic operators they are left associative.
+func (p *Parser) inclusiveOrExp() Expression {
+	left := p.exclusiveOrExp()
+	for tok := *p.curToken; tok.Type == BitwiseOr; tok = *p.curToken {
+		p.match(BitwiseOr)
+		right := p.exclusiveOrExp()
+		left = &BinOp{tok, left, right}
+	}
+	return left
+}
+
+func (p *Parser) exclusiveOrExp() Expression {
+	left := p.andExp()
+	for tok := *p.curToken; tok.Type == BitwiseXor; tok = *p.curToken {
+		p.match(BitwiseXor)
+		right := p.andExp()
+		left = &BinOp{tok, left, right}
+	}
+	return left
+}
+
+func (p *Parser) andExp() Expression {
+	left := p.relationalExp()
+	for tok := *p.curToken; tok.Type == BitwiseAnd; tok = *p.curToken {
+		p.match(BitwiseAnd)
+		right := p.relationalExp()
+		left = &BinOp{tok, left, right}
+	}
+	return left
+}
+
 func (p *Parser) relationalExp() (exp Expression) {
-	exp = p.additiveExp()
+	exp = p.shiftExp()
 
 	operators := []TokenType{
 		Equal,
@@ -574,12 +1482,25 @@ func (p *Parser) relationalExp() (exp Expression) {
 	if p.curToken.IsOfType(operators...) {
 		tok := *p.curToken
 		p.match(p.curToken.Type)
-		right := p.additiveExp()
+		right := p.shiftExp()
 		exp = &BinOp{tok, exp, right}
 	}
 	return
 }
 
+func (p *Parser) shiftExp() Expression {
+	left := p.additiveExp()
+
+	operators := []TokenType{LeftShift, RightShift, UnsignedRightShift}
+	for tok := *p.curToken; tok.IsOfType(operators...); tok = *p.curToken {
+		p.match(tok.Type)
+		right := p.additiveExp()
+		left = &BinOp{tok, left, right}
+	}
+
+	return left
+}
+
 func (p *Parser) objectInitialization() Expression {
 	arr := func(name string) *ArrayCreation {
 		p.match(LeftSquareBracket)
@@ -592,37 +1513,30 @@ func (p *Parser) objectInitialization() Expression {
 	if ty := p.curToken.Type; ty == Keyword {
 		typename := p.primitiveType()
 		return arr(typename)
-	} else {
-		// Here its should be an ID
-		peek, _ := p.lexer.PeekToken()
-		if peek.Type == LeftParenthesis {
-			method := p.methodCall()
-			obj := ObjectCreation{*method}
-			return &obj
-		} else {
-			typename := p.match(Id)
-			return arr(typename)
-		}
 	}
-}
 
-func (p *Parser) primitiveType() string {
-	isOneOf := func(tok Token) bool {
-		if tok.Type != Keyword {
-			return false
-		}
+	name := p.qualifiedName()
+	var typeArgs []NamedType
+	if p.curToken.Type == LessThan {
+		typeArgs = p.typeArguments()
+	}
 
-		ty := []string{"int", "char", "boolean"}
-		for _, name := range ty {
-			if tok.Value() == name {
-				return true
-			}
-		}
+	if p.curToken.Type != LeftParenthesis {
+		return arr(name)
+	}
 
-		return false
+	args := p.argumentList()
+	if p.curToken.Type == LeftCurlyBracket {
+		body := p.anonymousClass(name, typeArgs)
+		return &ObjectCreation{MethodCall{name, args, nil}, typeArgs, body}
 	}
 
-	if !isOneOf(*p.curToken) {
+	method := &MethodCall{name, args, p.methodCallTail()}
+	return &ObjectCreation{*method, typeArgs, nil}
+}
+
+func (p *Parser) primitiveType() string {
+	if p.curToken.Type != Keyword || !IsPrimitiveTypeName(p.curToken.Value()) {
 		msg := fmt.Sprintf("Expecting a type instead of %s", p.curToken)
 		panic(msg)
 	}
@@ -646,7 +1560,7 @@ func (p *Parser) additiveExp() Expression {
 }
 
 func (p *Parser) multiplicativeExp() Expression {
-	left := p.primaryExp()
+	left := p.unaryExp()
 
 	operators := []TokenType{Division, Multiplication, Modulus}
 	for tok := *p.curToken; tok.IsOfType(operators...); tok = *p.curToken {
@@ -661,7 +1575,7 @@ func (p *Parser) multiplicativeExp() Expression {
 // primaryExp parse literal, field-access, and method-call
 func (p *Parser) primaryExp() (ex Expression) {
 	switch p.curToken.Type {
-	case IntegerLiteral, BooleanLiteral, CharLiteral:
+	case IntegerLiteral, FloatingPointLiteral, BooleanLiteral, CharLiteral:
 		ex = p.primitiveLiteral()
 	case StringLiteral:
 		value := p.match(StringLiteral)
@@ -672,8 +1586,15 @@ func (p *Parser) primaryExp() (ex Expression) {
 	case Id:
 		fallthrough
 	case Keyword:
+		if KeywordEqualTo(*p.curToken, "switch") {
+			return p.switchExp()
+		}
 		ex = p.validName()
 	case LeftParenthesis:
+		if peek, _ := p.lexer.PeekToken(); peek.Type == Keyword && IsPrimitiveTypeName(peek.Value()) || p.isReferenceCast() {
+			return p.castExp()
+		}
+
 		p.match(LeftParenthesis)
 		ex = p.conditionalOrExp()
 		p.match(RightParenthesis)
@@ -685,11 +1606,117 @@ func (p *Parser) primaryExp() (ex Expression) {
 	return
 }
 
+// unaryExp parse prefix + and -, negative integer literal
+// is evaluated directly as a negative constant.
+func (p *Parser) unaryExp() Expression {
+	tok := *p.curToken
+	if !tok.IsOfType(Addition, Subtraction) {
+		return p.primaryExp()
+	}
+
+	p.match(tok.Type)
+	if tok.Type == Subtraction && p.curToken.Type == IntegerLiteral {
+		return p.integerLiteral(true)
+	}
+
+	return &UnaryOp{tok, p.unaryExp()}
+}
+
+// castExp parse a cast into a primitive or reference type,
+// e.g. (long) x or (List<String>) o
+func (p *Parser) castExp() *Cast {
+	p.match(LeftParenthesis)
+	var ty NamedType
+	if p.curToken.Type == Keyword {
+		ty = p.typeArray(p.primitiveType())
+	} else {
+		ty = p.typeArray(p.qualifiedName())
+	}
+	p.match(RightParenthesis)
+	return &Cast{ty, p.unaryExp()}
+}
+
+// isReferenceCast check if a reference type in parentheses start at the
+// current token and is followed by its operand. A name in parentheses is
+// ambiguous, like java it is a cast only if what follows cannot continue
+// an expression, e.g. (Shape) s is a cast but (a) + b is not.
+func (p *Parser) isReferenceCast() bool {
+	depth := 0
+	for i := 0; ; i++ {
+		tok, err := p.lexer.PeekTokenAt(i)
+		if err != nil {
+			return false
+		}
+
+		switch tok.Type {
+		case Id, Dot, Comma, LeftSquareBracket, RightSquareBracket:
+		case Keyword:
+			// only the type arguments or the component of an array
+			if i == 0 || !IsPrimitiveTypeName(tok.Value()) {
+				return false
+			}
+		case LessThan:
+			depth += 1
+		case GreaterThan:
+			depth -= 1
+		case RightShift:
+			depth -= 2
+		case UnsignedRightShift:
+			depth -= 3
+		case RightParenthesis:
+			next, _ := p.lexer.PeekTokenAt(i + 1)
+			return i > 0 && depth == 0 && isOperandStart(next)
+		default:
+			return false
+		}
+
+		if depth < 0 {
+			return false
+		}
+	}
+}
+
+// isOperandStart check if tok can start the operand of a cast, the
+// operand of a reference cast cannot start with a prefix + or -.
+func isOperandStart(tok Token) bool {
+	switch tok.Type {
+	case Id, IntegerLiteral, FloatingPointLiteral, BooleanLiteral, CharLiteral,
+		StringLiteral, NullLiteral, LeftParenthesis:
+		return true
+	case Keyword:
+		return !IsPrimitiveTypeName(tok.Value())
+	default:
+		return false
+	}
+}
+
+// integerLiteral evaluate the current integer literal,
+// an out of range literal is reported and evaluated as zero.
+func (p *Parser) integerLiteral(negated bool) PrimitiveLiteral {
+	tok := *p.curToken
+	p.match(IntegerLiteral)
+
+	num, err := IntegerFromToken(tok, negated)
+	if err != nil {
+		p.errors = append(p.errors, err)
+		return Num(0)
+	}
+	return num
+}
+
 func (p *Parser) primitiveLiteral() (ex PrimitiveLiteral) {
 	switch p.curToken.Type {
 	case IntegerLiteral:
-		value := p.match(IntegerLiteral)
-		ex = NumFromStr(value)
+		ex = p.integerLiteral(false)
+	case FloatingPointLiteral:
+		tok := *p.curToken
+		p.match(FloatingPointLiteral)
+
+		var err error
+		if ex, err = FloatingPointFromToken(tok); err != nil {
+			p.errors = append(p.errors, err)
+			ex = Double(0)
+		}
 	case BooleanLiteral:
 		value := p.match(BooleanLiteral)
 		ex = NewBoolean(value)
@@ -704,7 +1731,11 @@ func (p *Parser) validName() (val NamedValue) {
 	if KeywordEqualTo(*p.curToken, "this") {
 		p.match(Keyword)
 		p.match(Dot)
-		val = &This{p.fieldAccess()}
+		val = &This{p.fieldAccess(), ""}
+	} else if KeywordEqualTo(*p.curToken, "super") {
+		p.match(Keyword)
+		p.match(Dot)
+		val = &Super{p.fieldAccess()}
 	} else {
 		val = p.fieldAccess()
 	}
@@ -717,7 +1748,7 @@ func (p *Parser) fieldAccessFrom(name string) (val NamedValue) {
 		child := p.methodCallTail()
 		val = &MethodCall{name, args, child}
 	} else {
-		val = &FieldAccess{name, p.fieldAccessTail()}
+		val = qualifyThis(name, p.fieldAccessTail())
 	}
 	return
 }
@@ -728,15 +1759,35 @@ func (p *Parser) fieldAccess() (val NamedValue) {
 		val = p.methodCall()
 	} else {
 		name := p.match(Id)
-		val = &FieldAccess{name, p.fieldAccessTail()}
+		val = qualifyThis(name, p.fieldAccessTail())
 	}
 	return
 }
 
+// qualifyThis create a field access of name, unless it is
+// followed by this which is then qualified, e.g. Outer.this
+func qualifyThis(name string, tail NamedValue) NamedValue {
+	this, ok := tail.(*This)
+	if !ok {
+		return &FieldAccess{name, tail}
+	}
+
+	if len(this.Qualifier) > 0 {
+		name += "." + this.Qualifier
+	}
+	return &This{this.Child, name}
+}
+
 func (p *Parser) fieldAccessTail() (val NamedValue) {
 	if t := p.curToken.Type; t == Dot {
 		p.match(Dot)
-		val = p.fieldAccess()
+		if KeywordEqualTo(*p.curToken, "this") {
+			p.match(Keyword)
+			p.match(Dot)
+			val = &This{p.fieldAccess(), ""}
+		} else {
+			val = p.fieldAccess()
+		}
 	} else if t == LeftSquareBracket {
 		val = p.arrayAccess()
 	}
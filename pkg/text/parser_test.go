@@ -2,6 +2,8 @@ package text
 
 import (
 	"fmt"
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -14,7 +16,7 @@ func withParser(s string, parserAction func(p *Parser)) {
 
 func TestParser_program(t *testing.T) {
 	helloClass := NewEmptyClass("Hello", "", "")
-	greetInterface := &Interface{"Greet", nil}
+	greetInterface := &Interface{"Greet", nil, nil}
 	expect := Program{helloClass, greetInterface}
 	str := `class Hello {} interface Greet {}`
 	withParser(str, func(p *Parser) {
@@ -24,9 +26,37 @@ func TestParser_program(t *testing.T) {
 		}
 	})
 }
+
+func TestParser_import(t *testing.T) {
+	str := `import java.util.Scanner; import java.io.*; class Hello {}`
+	expect := Program{
+		&Import{"java.util.Scanner", false},
+		&Import{"java.io", true},
+		NewEmptyClass("Hello", "", ""),
+	}
+
+	withParser(str, func(p *Parser) {
+		program := p.Compile()
+		if !expect.Equal(program) {
+			t.Errorf("Expecting \n%s \n----but got----\n%s", PrettyPrint(expect), PrettyPrint(program))
+		}
+	})
+
+	for _, str := range []string{`import java.util.*.Scanner;`, `import Scanner`} {
+		withParser(str, func(p *Parser) {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s should be an invalid import", str)
+				}
+			}()
+			p.Compile()
+		})
+	}
+}
+
 func TestParser_interface(t *testing.T) {
-	method1 := MethodSignature{Public, NamedType{"int", false}, "Count", []Parameter{}}
-	method2 := MethodSignature{Public, NamedType{"String", false}, "Quack", []Parameter{}}
+	method1 := MethodSignature{Public, NamedType{"int", false, nil}, "Count", []Parameter{}, nil, nil}
+	method2 := MethodSignature{Public, NamedType{"String", false, nil}, "Quack", []Parameter{}, nil, nil}
 	int1 := NewInterface("Something")
 
 	int2 := NewInterface("Something")
@@ -71,12 +101,55 @@ func TestParser_interface(t *testing.T) {
 	}
 }
 
+func TestParser_annotation(t *testing.T) {
+	str := `interface Named { @Deprecated String name(); }
+	class Person implements Named {
+		@Override public String name() { return ""; }
+		@Override @Deprecated
+		int age() { return 0; }
+		void walk() {}
+	}`
+
+	withParser(str, func(p *Parser) {
+		program := p.Compile()
+		named, person := program[0].(*Interface), program[1].(*Class)
+		if !reflect.DeepEqual(named.Methods[0].Annotations, []string{"Deprecated"}) {
+			t.Errorf("Expecting @Deprecated on %s but got %v", named.Methods[0].Name, named.Methods[0].Annotations)
+		}
+
+		expect := [][]string{{"Override"}, {"Override", "Deprecated"}, nil}
+		for i, method := range person.Methods {
+			if !reflect.DeepEqual(method.Annotations, expect[i]) {
+				t.Errorf("Expecting %s annotated with %v but got %v", method.Name, expect[i], method.Annotations)
+			}
+		}
+	})
+
+	data := []string{
+		`class A { @Override int x; }`,
+		`class A { @Override A() {} }`,
+		`class A { @Override class B {} }`,
+		`class A { @Override { } }`,
+		`class A { @Override public static void main(String[] args) {} }`,
+	}
+
+	for _, str := range data {
+		assertReported(t, str, "Annotation is only allowed on a method.")
+	}
+
+	str = `class A { @Override(true) void run() {} }`
+	withParser(str, func(p *Parser) {
+		defer assertPanic(t, fmt.Sprintf("Should panic on %s", str))
+		p.Compile()
+	})
+}
+
 func TestParser_classExtends(t *testing.T) {
 	classA := NewEmptyClass("A", "", "")
 	classB := NewEmptyClass("B", "A", "")
 	program1 := Program{classA, classB}
 
-	interfaceA := Interface{"A", nil}
+	interfaceA := Interface{"A", nil, nil}
 	classC := NewEmptyClass("C", "", "A")
 	program2 := Program{&interfaceA, classC}
 
@@ -98,13 +171,239 @@ func TestParser_classExtends(t *testing.T) {
 	})
 }
 
+func TestParser_finalClass(t *testing.T) {
+	str := `final class A { final class B {} public void run() { final class C {} } }`
+
+	withParser(str, func(p *Parser) {
+		class := p.Compile()[0].(*Class)
+		if class.Access != Final {
+			t.Errorf("Expecting class A to be final but got %s", class.Access)
+		}
+
+		if inner := class.Classes[0]; inner.Access&Final == 0 {
+			t.Errorf("Expecting class %s to be final but got %s", inner.Name, inner.Access)
+		}
+
+		local := class.Methods[0].Body[0].(*Class)
+		if local.Access&Final == 0 {
+			t.Errorf("Expecting class %s to be final but got %s", local.Name, local.Access)
+		}
+	})
+}
+
+func TestParser_initializer(t *testing.T) {
+	str := `class A {
+		int a = 1;
+		static { b = 2; }
+		{ a = 3; }
+		int c;
+		A(int x) { a = x; }
+		A() { this(4); c = 5; }
+	}`
+
+	withParser(str, func(p *Parser) {
+		class := p.Compile()[0].(*Class)
+		expect := []string{"a", "static-initializer", "initializer", "c"}
+		if len(class.Initializers) != len(expect) {
+			t.Fatalf("Expecting %d initializers but got %d", len(expect), len(class.Initializers))
+		}
+
+		for i, decl := range class.Initializers {
+			name := decl.GetName()
+			if init, ok := decl.(*Initializer); ok {
+				name, _ = init.NodeContent()
+			}
+
+			if name != expect[i] {
+				t.Errorf("Expecting initializer %d to be %s but got %s", i, expect[i], name)
+			}
+		}
+
+		if blocks := class.InitializerBlocks(); len(blocks) != 2 || !blocks[0].IsStatic || blocks[1].IsStatic {
+			t.Errorf("Expecting a static then an instance initializer block but got %#v", blocks)
+		}
+
+		if delegate := class.Constructor["A(int)"].Delegate(); delegate != nil {
+			t.Errorf("Expecting A(int) to not delegate but got %s", PrettyPrint(delegate))
+		}
+
+		delegate := class.Constructor["A()"].Delegate()
+		if delegate == nil || len(delegate.Args) != 1 || delegate.Args[0] != Num(4) {
+			t.Errorf("Expecting A() to delegate with (4) but got %#v", delegate)
+		}
+	})
+}
+
+func TestParser_constructorCall_error(t *testing.T) {
+	data := []string{
+		"class A { A() { int a = 1; this(a); } }",
+		"class A { public void run() { this(); } }",
+		"class A { { this(); } }",
+	}
+
+	for _, str := range data {
+		assertReported(t, str, "Constructor call must be the first statement in a constructor.")
+	}
+}
+
+func TestParser_enum(t *testing.T) {
+	enum1 := NewEmptyEnum("Color", "")
+	enum1.AddConstant(&EnumConstant{"RED", nil})
+	enum1.AddConstant(&EnumConstant{"GREEN", nil})
+
+	enum2 := NewEmptyEnum("Color", "Named")
+	enum2.AddConstant(&EnumConstant{"RED", []Expression{Num(1), String("red")}})
+
+	enum3 := NewEmptyEnum("Color", "")
+	enum3.AddConstant(&EnumConstant{"RED", []Expression{Num(1)}})
+	enum3.AddConstant(&EnumConstant{"BLUE", []Expression{Num(2)}})
+	enum3.AddDeclaration(&PropertyDeclaration{Private,
+		VariableDeclaration{NamedType{"int", false, nil}, "code", nil, false},
+	})
+	enum3.AddDeclaration(NewConstructor(
+		0,
+		"Color",
+		[]Parameter{{NamedType{"int", false, nil}, "code", false, false}},
+		StatementList{},
+	))
+
+	data := []struct {
+		str    string
+		expect *Enum
+	}{
+		{`enum Color {RED, GREEN}`, enum1},
+		{`enum Color {RED, GREEN,}`, enum1},
+		{`enum Color {RED, GREEN;}`, enum1},
+		{`enum Color implements Named {RED(1, "red")}`, enum2},
+		{`enum Color {
+			RED(1), BLUE(2);
+			private int code;
+			Color(int code) {}
+		}`, enum3},
+	}
+
+	for _, d := range data {
+		withParser(d.str, func(p *Parser) {
+			program := p.Compile()
+			expect := Program{d.expect}
+			if res, exp := PrettyPrint(program), PrettyPrint(expect); res != exp {
+				t.Errorf("Expecting \n%s \n----but got----\n%s", exp, res)
+			}
+		})
+	}
+}
+
+func TestParser_enum_error(t *testing.T) {
+	assertReported(t, `enum Color {RED; public Color() {}}`, "Enum constructor can only be private.")
+	assertReported(t, `enum Color {RED; protected Color() {}}`, "Enum constructor can only be private.")
+}
+
+func TestParser_enum_panic(t *testing.T) {
+	data := []string{
+		`enum Color {RED, RED}`,
+		`enum Color extends Base {RED}`,
+	}
+
+	for _, str := range data {
+		withParser(str, func(p *Parser) {
+			defer func() {
+				if r := recover(); r == nil {
+					t.Errorf("Expecting %s to panic", str)
+				}
+			}()
+			p.Compile()
+		})
+	}
+}
+
+func TestParser_generics(t *testing.T) {
+	comparable := NewInterface("Comparable")
+	comparable.TypeParams = []TypeParameter{{"T", nil}}
+	comparable.AddMethod(&MethodSignature{
+		Public,
+		NamedType{"int", false, nil},
+		"compareTo",
+		[]Parameter{{NamedType{"T", false, nil}, "other", false, false}},
+		nil,
+		nil,
+	})
+
+	tBound := NamedType{"Comparable", false, []NamedType{{"T", false, nil}}}
+	box := NewEmptyClass("Box", "Base", "")
+	box.TypeParams = []TypeParameter{{"T", &tBound}, {"U", nil}}
+	box.ExtendArgs = []NamedType{{"U", false, nil}}
+	box.AddDeclaration(&PropertyDeclaration{Public,
+		VariableDeclaration{NamedType{"T", false, nil}, "value", nil, false},
+	})
+
+	method := NewMethodDeclaration(
+		Public,
+		NamedType{"Box", false, []NamedType{{"R", false, nil}, {"U", false, nil}}},
+		"map",
+		[]Parameter{{NamedType{"R", true, nil}, "items", false, false}},
+		StatementList{},
+	)
+	method.TypeParams = []TypeParameter{{"R", nil}}
+	box.AddDeclaration(method)
+
+	item := NewEmptyClass("Item", "", "Comparable")
+	item.ImplementArgs = []NamedType{{"Item", false, nil}}
+
+	data := []struct {
+		str    string
+		expect Template
+	}{
+		{`interface Comparable<T> { public int compareTo(T other); }`, comparable},
+		{`class Box<T extends Comparable<T>, U> extends Base<U> {
+			public T value;
+			public <R> Box<R, U> map(R[] items) {}
+		}`, box},
+		{`class Item implements Comparable<Item> {}`, item},
+	}
+
+	for _, d := range data {
+		withParser(d.str, func(p *Parser) {
+			program := p.Compile()
+			expect := Program{d.expect}
+			if res, exp := PrettyPrint(program), PrettyPrint(expect); res != exp {
+				t.Errorf("Expecting \n%s \n----but got----\n%s", exp, res)
+			}
+		})
+	}
+}
+
+func TestParser_generics_error(t *testing.T) {
+	assertReported(t, `class Box { public <T> T value; }`,
+		"Type parameters are only allowed on method or constructor.")
+}
+
+func TestParser_generics_panic(t *testing.T) {
+	data := []string{
+		`class Box<> {}`,
+		`class Box<T {}`,
+		`class Box<T extends> {}`,
+	}
+
+	for _, str := range data {
+		withParser(str, func(p *Parser) {
+			defer func() {
+				if r := recover(); r == nil {
+					t.Errorf("Expecting %s to panic", str)
+				}
+			}()
+			p.Compile()
+		})
+	}
+}
+
 func TestParser_class(t *testing.T) {
 	class1 := NewEmptyClass("Hello", "", "")
 
 	class2 := NewEmptyClass("Hello", "", "")
 	class2Prop := PropertyDeclaration{Public,
 		VariableDeclaration{
-			NamedType{"int", false}, "a", Num(20),
+			NamedType{"int", false, nil}, "a", Num(20),
+			false,
 		},
 	}
 	class2.Properties = []*PropertyDeclaration{
@@ -114,7 +413,7 @@ func TestParser_class(t *testing.T) {
 	class3 := NewEmptyClass("Hello", "", "")
 	class3Method := NewMethodDeclaration(
 		Private,
-		NamedType{"void", false},
+		NamedType{"void", false, nil},
 		"Nothing",
 		[]Parameter{},
 		StatementList{
@@ -126,7 +425,7 @@ func TestParser_class(t *testing.T) {
 	class4 := NewEmptyClass("Hello", "", "")
 	class4Constructor := ConstructorDeclaration{*NewMethodDeclaration(
 		Private,
-		NamedType{"Hello", false},
+		NamedType{"Hello", false, nil},
 		"Hello",
 		[]Parameter{},
 		StatementList{
@@ -138,10 +437,10 @@ func TestParser_class(t *testing.T) {
 	class5 := NewEmptyClass("Hello", "", "")
 	class5Main := MainMethodDeclaration{*NewMethodDeclaration(
 		Public,
-		NamedType{"void", false},
+		NamedType{"void", false, nil},
 		"main",
 		[]Parameter{
-			{NamedType{"String", true}, "args"},
+			{NamedType{"String", true, nil}, "args", false, false},
 		},
 		StatementList{
 			&JumpStatement{ReturnJump, nil},
@@ -163,10 +462,10 @@ func TestParser_class(t *testing.T) {
 	classOverloading.Methods = []*MethodDeclaration{class3Method}
 	overLoadMethod := NewMethodDeclaration(
 		Private,
-		NamedType{"void", false},
+		NamedType{"void", false, nil},
 		"Nothing",
 		[]Parameter{
-			{NamedType{"int", false}, "a"},
+			{NamedType{"int", false, nil}, "a", false, false},
 		},
 		StatementList{
 			&JumpStatement{ReturnJump, nil},
@@ -329,47 +628,81 @@ func TestParser_declaration(t *testing.T) {
 		{
 			"int a;",
 			&PropertyDeclaration{Public, VariableDeclaration{
-				NamedType{"int", false},
+				NamedType{"int", false, nil},
 				"a",
 				nil,
+				false,
 			}},
 		},
 		{
 			"int[] a;",
 			&PropertyDeclaration{Public, VariableDeclaration{
-				NamedType{"int", true},
+				NamedType{"int", true, nil},
 				"a",
 				nil,
+				false,
 			}},
 		},
 		{
 			"public int a;",
 			&PropertyDeclaration{Public, VariableDeclaration{
-				NamedType{"int", false},
+				NamedType{"int", false, nil},
 				"a",
 				nil,
+				false,
 			}},
 		},
 		{
 			"private int a = 1;",
 			&PropertyDeclaration{Private, VariableDeclaration{
-				NamedType{"int", false},
+				NamedType{"int", false, nil},
 				"a",
 				Num(1),
+				false,
 			}},
 		},
 		{
 			`String a = "Hello";`,
 			&PropertyDeclaration{Public, VariableDeclaration{
-				NamedType{"String", false},
+				NamedType{"String", false, nil},
 				"a",
 				String("Hello"),
+				false,
+			}},
+		},
+		{
+			"public static final int MAX = 10;",
+			&PropertyDeclaration{Public | Static | Final, VariableDeclaration{
+				NamedType{"int", false, nil},
+				"MAX",
+				Num(10),
+				false,
+			}},
+		},
+		{
+			"final private String name;",
+			&PropertyDeclaration{Private | Final, VariableDeclaration{
+				NamedType{"String", false, nil},
+				"name",
+				nil,
+				false,
 			}},
 		},
+		{
+			`public final int foo(final int a){}`,
+			NewMethodDeclaration(Public|Final,
+				NamedType{"int", false, nil},
+				"foo",
+				[]Parameter{
+					{NamedType{"int", false, nil}, "a", true, false},
+				},
+				StatementList{},
+			),
+		},
 		{
 			`void foo(){}`,
 			NewMethodDeclaration(Public,
-				NamedType{"void", false},
+				NamedType{"void", false, nil},
 				"foo",
 				[]Parameter{},
 				StatementList{},
@@ -378,7 +711,7 @@ func TestParser_declaration(t *testing.T) {
 		{
 			`private void foo(){}`,
 			NewMethodDeclaration(Private,
-				NamedType{"void", false},
+				NamedType{"void", false, nil},
 				"foo",
 				[]Parameter{},
 				StatementList{},
@@ -387,10 +720,10 @@ func TestParser_declaration(t *testing.T) {
 		{
 			`private int foo(int a){}`,
 			NewMethodDeclaration(Private,
-				NamedType{"int", false},
+				NamedType{"int", false, nil},
 				"foo",
 				[]Parameter{
-					{NamedType{"int", false}, "a"},
+					{NamedType{"int", false, nil}, "a", false, false},
 				},
 				StatementList{},
 			),
@@ -400,11 +733,11 @@ func TestParser_declaration(t *testing.T) {
 return 1;
 }`,
 			NewMethodDeclaration(Private,
-				NamedType{"String", false},
+				NamedType{"String", false, nil},
 				"foo",
 				[]Parameter{
-					{NamedType{"int", false}, "a"},
-					{NamedType{"String", true}, "list"},
+					{NamedType{"int", false, nil}, "a", false, false},
+					{NamedType{"String", true, nil}, "list", false, false},
 				},
 				StatementList{
 					&JumpStatement{ReturnJump, Num(1)},
@@ -416,10 +749,10 @@ return 1;
 return;
 }`,
 			&MainMethodDeclaration{*NewMethodDeclaration(Public,
-				NamedType{"void", false},
+				NamedType{"void", false, nil},
 				"main",
 				[]Parameter{
-					{NamedType{"String", true}, "args"},
+					{NamedType{"String", true, nil}, "args", false, false},
 				},
 				StatementList{
 					&JumpStatement{ReturnJump, nil},
@@ -436,7 +769,7 @@ return;
 				Public,
 				"Hello",
 				[]Parameter{
-					{NamedType{"int", false}, "who"},
+					{NamedType{"int", false, nil}, "who", false, false},
 				},
 				StatementList{
 					&JumpStatement{ReturnJump, Num(1)},
@@ -471,6 +804,86 @@ func TestParser_MainMethod(t *testing.T) {
 	}
 }
 
+func TestParser_declaration_error(t *testing.T) {
+	data := []struct {
+		str    string
+		expect string
+	}{
+		{"public private int a;", "Expecting only one access modifier."},
+		{"final final int a;", "Repeated modifier final"},
+		{"static int foo(){}", "Only the main method can be static."},
+		{"static Hello(){}", "Only the main method can be static."},
+		{"final Hello(){}", "Constructor cannot be final."},
+	}
+
+	for _, d := range data {
+		assertReported(t, fmt.Sprintf("class Hello { %s }", d.str), d.expect)
+	}
+}
+
+func TestParser_declaration_panic(t *testing.T) {
+	data := []string{
+		"private static void main(String[] args){}",
+	}
+
+	for _, str := range data {
+		withParser(str, func(p *Parser) {
+			defer assertPanic(t, fmt.Sprintf("Should panic on %s", str))
+			p.declaration()
+		})
+	}
+}
+
+func TestParser_parameterList(t *testing.T) {
+	data := []struct {
+		str    string
+		expect []Parameter
+	}{
+		{"(int... values)", []Parameter{{NamedType{"int", true, nil}, "values", false, true}}},
+		{"(String sep, final String... parts)", []Parameter{
+			{NamedType{"String", false, nil}, "sep", false, false},
+			{NamedType{"String", true, nil}, "parts", true, true},
+		}},
+		{"(int[] values)", []Parameter{{NamedType{"int", true, nil}, "values", false, false}}},
+	}
+
+	for _, d := range data {
+		withParser(d.str, func(p *Parser) {
+			params := p.parameterList()
+			if res, ex := fmt.Sprintf("%#v", params), fmt.Sprintf("%#v", d.expect); res != ex {
+				t.Errorf("From `%s` Expecting \n%s but got \n%s", d.str, ex, res)
+			}
+		})
+	}
+}
+
+func TestParser_parameterList_error(t *testing.T) {
+	data := []struct {
+		str    string
+		expect string
+	}{
+		{"(int... a, int b)", "Varargs parameter must be the last parameter."},
+		{"(int[]... a)", "Varargs parameter of an array type is not supported."},
+	}
+
+	for _, d := range data {
+		assertReported(t, fmt.Sprintf("class A { void run%s {} }", d.str), d.expect)
+	}
+}
+
+func TestParser_parameterList_panic(t *testing.T) {
+	data := []string{
+		"(int.. a)",
+	}
+
+	for _, str := range data {
+		withParser(str, func(p *Parser) {
+			defer assertPanic(t, fmt.Sprintf("Should panic on %s", str))
+			p.parameterList()
+		})
+	}
+}
+
 func TestParser_statement(t *testing.T) {
 	data := []struct {
 		str    string
@@ -478,26 +891,65 @@ func TestParser_statement(t *testing.T) {
 	}{
 		{
 			"int a = 20;",
-			&VariableDeclaration{NamedType{"int", false}, "a", Num(20)},
+			&VariableDeclaration{NamedType{"int", false, nil}, "a", Num(20), false},
 		},
 		{
 			"int[] a = new int[20];",
-			&VariableDeclaration{NamedType{"int", true},
+			&VariableDeclaration{NamedType{"int", true, nil},
 				"a",
 				&ArrayCreation{"int", Num(20)},
+				false,
 			},
 		},
+		{
+			"final int a = 20;",
+			&VariableDeclaration{NamedType{"int", false, nil}, "a", Num(20), true},
+		},
 		{
 			`String a = "nice";`,
-			&VariableDeclaration{NamedType{"String", false}, "a", String("nice")},
+			&VariableDeclaration{NamedType{"String", false, nil}, "a", String("nice"), false},
+		},
+		{
+			`Box<String> a = b;`,
+			&VariableDeclaration{
+				NamedType{"Box", false, []NamedType{{"String", false, nil}}},
+				"a",
+				&FieldAccess{"b", nil},
+				false,
+			},
+		},
+		{
+			`Pair<Box<String>, Box<Box<T>>>[] a;`,
+			&VariableDeclaration{
+				NamedType{"Pair", true, []NamedType{
+					{"Box", false, []NamedType{{"String", false, nil}}},
+					{"Box", false, []NamedType{
+						{"Box", false, []NamedType{{"T", false, nil}}},
+					}},
+				}},
+				"a",
+				nil,
+				false,
+			},
 		},
 		{
 			`this.a = nice;`,
 			&AssignmentStatement{fakeToken("=", Assignment),
-				&This{&FieldAccess{"a", nil}},
+				&This{&FieldAccess{"a", nil}, ""},
 				&FieldAccess{"nice", nil},
 			},
 		},
+		{
+			`super.a += 1;`,
+			&AssignmentStatement{fakeToken("+=", AdditionAssignment),
+				&Super{&FieldAccess{"a", nil}},
+				Num(1),
+			},
+		},
+		{
+			`super.run();`,
+			&MethodCallStatement{&Super{&MethodCall{"run", []Expression{}, nil}}},
+		},
 		{
 			`switch(a){
 		case 2:
@@ -508,7 +960,7 @@ func TestParser_statement(t *testing.T) {
 			&SwitchStatement{&FieldAccess{"a", nil},
 				[]*CaseStatement{
 					{
-						Num(2),
+						[]Expression{Num(2)},
 						[]Statement{
 							&IfStatement{
 								&BinOp{fakeToken(">", GreaterThan),
@@ -525,6 +977,7 @@ func TestParser_statement(t *testing.T) {
 					},
 				},
 				nil,
+				false,
 			},
 		},
 	}
@@ -545,15 +998,27 @@ func TestParser_primitiveTypeVarDeclaration(t *testing.T) {
 	}{
 		{
 			"int a = 20;",
-			&VariableDeclaration{NamedType{"int", false}, "a", Num(20)},
+			&VariableDeclaration{NamedType{"int", false, nil}, "a", Num(20), false},
 		},
 		{
 			"int[] a = 20;",
-			&VariableDeclaration{NamedType{"int", true}, "a", Num(20)},
+			&VariableDeclaration{NamedType{"int", true, nil}, "a", Num(20), false},
 		},
 		{
 			"boolean a = true;",
-			&VariableDeclaration{NamedType{"boolean", false}, "a", Boolean(true)},
+			&VariableDeclaration{NamedType{"boolean", false, nil}, "a", Boolean(true), false},
+		},
+		{
+			"long a = 20L;",
+			&VariableDeclaration{NamedType{"long", false, nil}, "a", Long(20), false},
+		},
+		{
+			"double[] a;",
+			&VariableDeclaration{NamedType{"double", true, nil}, "a", nil, false},
+		},
+		{
+			"byte a = 1;",
+			&VariableDeclaration{NamedType{"byte", false, nil}, "a", Num(1), false},
 		},
 	}
 
@@ -586,7 +1051,7 @@ func TestParser_methodOrField(t *testing.T) {
 		{
 			"this.name = nice;",
 			&AssignmentStatement{fakeToken("=", Assignment),
-				&This{&FieldAccess{"name", nil}},
+				&This{&FieldAccess{"name", nil}, ""},
 				&FieldAccess{"nice", nil},
 			},
 		},
@@ -601,19 +1066,22 @@ func TestParser_methodOrField(t *testing.T) {
 					&FieldAccess{"person",
 						&MethodCall{"hello", []Expression{}, nil},
 					},
+					"",
 				},
 			},
 		},
 		{
 			"Something a = new Something();",
-			&VariableDeclaration{NamedType{"Something", false}, "a",
-				&ObjectCreation{MethodCall{"Something", []Expression{}, nil}},
+			&VariableDeclaration{NamedType{"Something", false, nil}, "a",
+				&ObjectCreation{MethodCall{"Something", []Expression{}, nil}, nil, nil},
+				false,
 			},
 		},
 		{
 			"Something[] a = new Something[4];",
-			&VariableDeclaration{NamedType{"Something", true}, "a",
+			&VariableDeclaration{NamedType{"Something", true, nil}, "a",
 				&ArrayCreation{"Something", Num(4)},
+				false,
 			},
 		},
 		{
@@ -663,7 +1131,7 @@ a += 1;
 		{
 			`for(int i = 0; i > 0; i += 1){}`,
 			ForStatement{
-				&VariableDeclaration{NamedType{"int", false}, "i", Num(0)},
+				&VariableDeclaration{NamedType{"int", false, nil}, "i", Num(0), false},
 				&BinOp{fakeToken(">", GreaterThan), &FieldAccess{"i", nil}, Num(0)},
 				&AssignmentStatement{fakeToken("+=", AdditionAssignment), &FieldAccess{"i", nil}, Num(1)},
 				StatementList{},
@@ -681,7 +1149,7 @@ a += 1;
 		{
 			`for(this.i = 0; ; i += 1){}`,
 			ForStatement{
-				&AssignmentStatement{fakeToken("=", Assignment), &This{&FieldAccess{"i", nil}}, Num(0)},
+				&AssignmentStatement{fakeToken("=", Assignment), &This{&FieldAccess{"i", nil}, ""}, Num(0)},
 				nil,
 				&AssignmentStatement{fakeToken("+=", AdditionAssignment), &FieldAccess{"i", nil}, Num(1)},
 				StatementList{},
@@ -700,6 +1168,51 @@ a += 1;
 
 }
 
+func TestParser_forEachStmt(t *testing.T) {
+	data := []struct {
+		str    string
+		expect ForEachStatement
+	}{
+		{
+			`for(int x : xs){}`,
+			ForEachStatement{
+				&VariableDeclaration{NamedType{"int", false, nil}, "x", nil, false},
+				&FieldAccess{"xs", nil},
+				StatementList{},
+			},
+		},
+		{
+			`for(var name : this.names()) sum += 1;`,
+			ForEachStatement{
+				&VariableDeclaration{NamedType{"var", false, nil}, "name", nil, false},
+				&This{&MethodCall{"names", []Expression{}, nil}, ""},
+				&AssignmentStatement{fakeToken("+=", AdditionAssignment), &FieldAccess{"sum", nil}, Num(1)},
+			},
+		},
+		{
+			`for(final String[] row : table) for(String s : row) break;`,
+			ForEachStatement{
+				&VariableDeclaration{NamedType{"String", true, nil}, "row", nil, true},
+				&FieldAccess{"table", nil},
+				&ForEachStatement{
+					&VariableDeclaration{NamedType{"String", false, nil}, "s", nil, false},
+					&FieldAccess{"row", nil},
+					&JumpStatement{BreakJump, nil},
+				},
+			},
+		},
+	}
+
+	for _, d := range data {
+		withParser(d.str, func(p *Parser) {
+			stmt := p.statement()
+			if res, ex := PrettyPrint(stmt), PrettyPrint(&d.expect); res != ex {
+				t.Errorf("Expecting \n%s but got \n%s", ex, res)
+			}
+		})
+	}
+}
+
 func TestParser_whileStmt(t *testing.T) {
 	data := []struct {
 		str    string
@@ -838,7 +1351,7 @@ func TestParser_switchStmt(t *testing.T) {
 	}{
 		{
 			`switch(age){}`,
-			SwitchStatement{&FieldAccess{"age", nil}, nil, nil},
+			SwitchStatement{&FieldAccess{"age", nil}, nil, nil, false},
 		},
 
 		{
@@ -848,9 +1361,10 @@ return 20;
 }`,
 			SwitchStatement{&FieldAccess{"age", nil},
 				[]*CaseStatement{
-					{Num(12), []Statement{&JumpStatement{ReturnJump, Num(20)}}},
+					{[]Expression{Num(12)}, []Statement{&JumpStatement{ReturnJump, Num(20)}}},
 				},
 				nil,
+				false,
 			},
 		},
 
@@ -862,6 +1376,7 @@ return 20;
 			SwitchStatement{&FieldAccess{"age", nil},
 				nil,
 				[]Statement{&JumpStatement{ReturnJump, Num(20)}},
+				false,
 			},
 		},
 
@@ -876,10 +1391,11 @@ break;
 }`,
 			SwitchStatement{&FieldAccess{"age", &MethodCall{"year", []Expression{}, nil}},
 				[]*CaseStatement{
-					{Num(1998), []Statement{&JumpStatement{ReturnJump, Num(20)}}},
-					{Num(20), []Statement{&JumpStatement{ReturnJump, Num(2)}}},
+					{[]Expression{Num(1998)}, []Statement{&JumpStatement{ReturnJump, Num(20)}}},
+					{[]Expression{Num(20)}, []Statement{&JumpStatement{ReturnJump, Num(2)}}},
 				},
 				[]Statement{&JumpStatement{BreakJump, nil}},
+				false,
 			},
 		},
 	}
@@ -903,7 +1419,7 @@ func TestParser_caseStmt(t *testing.T) {
 			`case 1:
 return;
 `,
-			CaseStatement{Num(1), []Statement{
+			CaseStatement{[]Expression{Num(1)}, []Statement{
 				&JumpStatement{ReturnJump, nil},
 			}},
 		},
@@ -912,7 +1428,7 @@ return;
 			`case true:
 		return 8;
 		`,
-			CaseStatement{Boolean(true), []Statement{
+			CaseStatement{[]Expression{Boolean(true)}, []Statement{
 				&JumpStatement{ReturnJump, Num(8)},
 			}},
 		},
@@ -922,7 +1438,7 @@ return;
 return 900;
 }
 		`,
-			CaseStatement{Num(8), []Statement{
+			CaseStatement{[]Expression{Num(8)}, []Statement{
 				StatementList{
 					&JumpStatement{ReturnJump, Num(900)},
 				},
@@ -933,7 +1449,25 @@ return 900;
 			`case 'A':
 		return;
 		`,
-			CaseStatement{Char('A'), []Statement{
+			CaseStatement{[]Expression{Char('A')}, []Statement{
+				&JumpStatement{ReturnJump, nil},
+			}},
+		},
+
+		{
+			`case RED:
+		return;
+		`,
+			CaseStatement{[]Expression{&FieldAccess{"RED", nil}}, []Statement{
+				&JumpStatement{ReturnJump, nil},
+			}},
+		},
+
+		{
+			`case -1:
+		return;
+		`,
+			CaseStatement{[]Expression{Num(-1)}, []Statement{
 				&JumpStatement{ReturnJump, nil},
 			}},
 		},
@@ -941,7 +1475,7 @@ return 900;
 
 	for _, d := range data {
 		withParser(d.str, func(p *Parser) {
-			result := p.caseStmt()
+			result := p.caseStmt(false)
 			if r, expect := result.String(), d.expect.String(); r != expect {
 				t.Errorf("Expecting \n%s but got\n%s", expect, r)
 			}
@@ -949,18 +1483,204 @@ return 900;
 	}
 }
 
-func TestParser_jumpStmt(t *testing.T) {
+func TestParser_switchArrow(t *testing.T) {
+	assign := func(value Expression) *AssignmentStatement {
+		return &AssignmentStatement{fakeToken("=", Assignment), &FieldAccess{"a", nil}, value}
+	}
+
 	data := []struct {
 		str    string
 		expect Statement
 	}{
-		{"return;", &JumpStatement{ReturnJump, nil}},
+		{
+			`switch(a){
+case 1, 2 -> a = 3;
+case 4 -> { a = 5; }
+default -> a = 6;
+}`,
+			&SwitchStatement{&FieldAccess{"a", nil},
+				[]*CaseStatement{
+					{[]Expression{Num(1), Num(2)}, StatementList{assign(Num(3))}},
+					{[]Expression{Num(4)}, StatementList{assign(Num(5))}},
+				},
+				[]Statement{StatementList{assign(Num(6))}},
+				true,
+			},
+		},
+		{
+			`switch(a){
+case RED, GREEN:
+a = 1;
+}`,
+			&SwitchStatement{&FieldAccess{"a", nil},
+				[]*CaseStatement{
+					{[]Expression{&FieldAccess{"RED", nil}, &FieldAccess{"GREEN", nil}}, StatementList{assign(Num(1))}},
+				},
+				nil,
+				false,
+			},
+		},
+	}
+
+	for _, d := range data {
+		withParser(d.str, func(p *Parser) {
+			sw := p.statement()
+			if res, ex := PrettyPrint(sw), PrettyPrint(d.expect); res != ex {
+				t.Errorf("Expecting \n%s but got \n%s", ex, res)
+			}
+		})
+	}
+}
+
+func TestParser_switchExp(t *testing.T) {
+	yield := func(value Expression) *JumpStatement {
+		return &JumpStatement{YieldJump, value}
+	}
+
+	data := []struct {
+		str    string
+		expect Expression
+	}{
+		{
+			`switch(a){
+case 1, 2 -> "low";
+case 3 -> {
+yield "mid";
+}
+default -> "high";
+}`,
+			&SwitchExpression{SwitchStatement{&FieldAccess{"a", nil},
+				[]*CaseStatement{
+					{[]Expression{Num(1), Num(2)}, StatementList{yield(String("low"))}},
+					{[]Expression{Num(3)}, StatementList{yield(String("mid"))}},
+				},
+				[]Statement{StatementList{yield(String("high"))}},
+				true,
+			}, NamedType{}},
+		},
+		{
+			`switch(a){
+case 'a':
+yield 1;
+default:
+yield (2);
+}`,
+			&SwitchExpression{SwitchStatement{&FieldAccess{"a", nil},
+				[]*CaseStatement{
+					{[]Expression{Char('a')}, StatementList{yield(Num(1))}},
+				},
+				[]Statement{yield(Num(2))},
+				false,
+			}, NamedType{}},
+		},
+	}
+
+	for _, d := range data {
+		withParser(d.str, func(p *Parser) {
+			sw := p.expression()
+			if res, ex := PrettyPrint(sw), PrettyPrint(d.expect); res != ex {
+				t.Errorf("Expecting \n%s but got \n%s", ex, res)
+			}
+		})
+	}
+}
+
+func TestParser_yieldAsName(t *testing.T) {
+	data := []struct {
+		str    string
+		expect Statement
+	}{
+		{"yield = 1;", &AssignmentStatement{fakeToken("=", Assignment), &FieldAccess{"yield", nil}, Num(1)}},
+		{"yield.run();", &MethodCallStatement{&FieldAccess{"yield", &MethodCall{"run", []Expression{}, nil}}}},
+		{"yield 1;", &JumpStatement{YieldJump, Num(1)}},
+	}
+
+	for _, d := range data {
+		withParser(d.str, func(p *Parser) {
+			stmt := p.statement()
+			if res, ex := PrettyPrint(stmt), PrettyPrint(d.expect); res != ex {
+				t.Errorf("Expecting \n%s but got \n%s", ex, res)
+			}
+		})
+	}
+}
+
+func TestParser_switch_error(t *testing.T) {
+	data := []string{
+		`switch(a){ case 1 -> a = 1; case 2: a = 2; }`,
+		`switch(a){ case 1: a = 1; default -> a = 2; }`,
+		`switch(a){ case 1 -> a = 1; default: a = 2; }`,
+	}
+
+	for _, str := range data {
+		content := fmt.Sprintf("class A { void run() { %s } }", str)
+		assertReported(t, content, "Different case kinds used in the switch.")
+	}
+}
+
+func TestParser_switch_panic(t *testing.T) {
+	data := []string{
+		`switch(a){ case 1 -> ; }`,
+	}
+
+	for _, str := range data {
+		withParser(str, func(p *Parser) {
+			defer assertPanic(t, fmt.Sprintf("Should panic on %s", str))
+			p.statement()
+		})
+	}
+}
+
+func TestParser_assertStmt(t *testing.T) {
+	data := []struct {
+		str    string
+		expect Statement
+	}{
+		{"assert ok;", &AssertStatement{&FieldAccess{"ok", nil}, nil}},
+		{`assert ok : "not ok";`, &AssertStatement{&FieldAccess{"ok", nil}, String("not ok")}},
+		{"assert this.isOk() : count;", &AssertStatement{
+			&This{&MethodCall{"isOk", []Expression{}, nil}, ""},
+			&FieldAccess{"count", nil},
+		}},
+	}
+
+	for _, d := range data {
+		withParser(d.str, func(p *Parser) {
+			stmt := p.statement()
+			if res, ex := PrettyPrint(stmt), PrettyPrint(d.expect); res != ex {
+				t.Errorf("Expecting \n%s but got \n%s", ex, res)
+			}
+		})
+	}
+}
+
+func TestParser_assertStmt_panic(t *testing.T) {
+	data := []string{
+		"assert;",
+		"assert ok :;",
+		"assert ok",
+	}
+
+	for _, str := range data {
+		withParser(str, func(p *Parser) {
+			defer assertPanic(t, fmt.Sprintf("Should panic on %s", str))
+			p.statement()
+		})
+	}
+}
+
+func TestParser_jumpStmt(t *testing.T) {
+	data := []struct {
+		str    string
+		expect Statement
+	}{
+		{"return;", &JumpStatement{ReturnJump, nil}},
 		{"break;", &JumpStatement{BreakJump, nil}},
 		{"continue;", &JumpStatement{ContinueJump, nil}},
 		{"return 1;", &JumpStatement{ReturnJump, Num(1)}},
 		{"return new Hello();",
 			&JumpStatement{ReturnJump,
-				&ObjectCreation{MethodCall{"Hello", []Expression{}, nil}},
+				&ObjectCreation{MethodCall{"Hello", []Expression{}, nil}, nil, nil},
 			},
 		},
 	}
@@ -1002,7 +1722,7 @@ func TestParser_expression(t *testing.T) {
 		},
 		{
 			"new Foo()",
-			&ObjectCreation{MethodCall{"Foo", []Expression{}, nil}},
+			&ObjectCreation{MethodCall{"Foo", []Expression{}, nil}, nil, nil},
 		},
 		{
 			"new Foo[getNumber()]",
@@ -1112,7 +1832,7 @@ func TestParser_relationalExp(t *testing.T) {
 		{"12", Num(12)},
 		{"12 == 12", &BinOp{fakeToken("==", Equal), Num(12), Num(12)}},
 		{"1 >= 2", &BinOp{fakeToken(">=", GreaterThanEqual), Num(1), Num(2)}},
-		{`true != this.status`, &BinOp{fakeToken("!=", NotEqual), Boolean(true), &This{&FieldAccess{"status", nil}}}},
+		{`true != this.status`, &BinOp{fakeToken("!=", NotEqual), Boolean(true), &This{&FieldAccess{"status", nil}, ""}}},
 	}
 	for _, d := range data {
 		withParser(d.str, func(p *Parser) {
@@ -1126,6 +1846,97 @@ func TestParser_relationalExp(t *testing.T) {
 	}
 }
 
+func TestParser_bitwiseExp(t *testing.T) {
+	data := []struct {
+		str    string
+		expect Expression
+	}{
+		{"a | b", &BinOp{fakeToken("|", BitwiseOr), &FieldAccess{"a", nil}, &FieldAccess{"b", nil}}},
+		{
+			"1 | 2 ^ 3 & 4",
+			&BinOp{fakeToken("|", BitwiseOr),
+				Num(1),
+				&BinOp{fakeToken("^", BitwiseXor),
+					Num(2),
+					&BinOp{fakeToken("&", BitwiseAnd), Num(3), Num(4)},
+				},
+			},
+		},
+		{
+			"a & b == c",
+			&BinOp{fakeToken("&", BitwiseAnd),
+				&FieldAccess{"a", nil},
+				&BinOp{fakeToken("==", Equal), &FieldAccess{"b", nil}, &FieldAccess{"c", nil}},
+			},
+		},
+		{
+			"a && b | c",
+			&BinOp{fakeToken("&&", And),
+				&FieldAccess{"a", nil},
+				&BinOp{fakeToken("|", BitwiseOr), &FieldAccess{"b", nil}, &FieldAccess{"c", nil}},
+			},
+		},
+		// left associative
+		{
+			"1 ^ 2 ^ 3",
+			&BinOp{fakeToken("^", BitwiseXor),
+				&BinOp{fakeToken("^", BitwiseXor), Num(1), Num(2)},
+				Num(3),
+			},
+		},
+	}
+
+	for _, d := range data {
+		withParser(d.str, func(p *Parser) {
+			result := p.conditionalOrExp()
+			resStr, expStr := PrettyPrint(result), PrettyPrint(d.expect)
+			if resStr != expStr {
+				t.Errorf("Expecting \n%s but got\n%s", expStr, resStr)
+			}
+		})
+	}
+}
+
+func TestParser_shiftExp(t *testing.T) {
+	data := []struct {
+		str    string
+		expect Expression
+	}{
+		{"1 << 2", &BinOp{fakeToken("<<", LeftShift), Num(1), Num(2)}},
+		{
+			"1 << 2 + 3",
+			&BinOp{fakeToken("<<", LeftShift),
+				Num(1),
+				&BinOp{fakeToken("+", Addition), Num(2), Num(3)},
+			},
+		},
+		{
+			"a >> 2 >>> 1",
+			&BinOp{fakeToken(">>>", UnsignedRightShift),
+				&BinOp{fakeToken(">>", RightShift), &FieldAccess{"a", nil}, Num(2)},
+				Num(1),
+			},
+		},
+		{
+			"1 << 2 < 3",
+			&BinOp{fakeToken("<", LessThan),
+				&BinOp{fakeToken("<<", LeftShift), Num(1), Num(2)},
+				Num(3),
+			},
+		},
+	}
+
+	for _, d := range data {
+		withParser(d.str, func(p *Parser) {
+			result := p.relationalExp()
+			resStr, expStr := PrettyPrint(result), PrettyPrint(d.expect)
+			if resStr != expStr {
+				t.Errorf("Expecting \n%s but got\n%s", expStr, resStr)
+			}
+		})
+	}
+}
+
 func TestParser_fieldAccess(t *testing.T) {
 	data := []struct {
 		str    string
@@ -1290,7 +2101,7 @@ func TestParser_multiplicativeExp(t *testing.T) {
 
 		{
 			"this.height / width",
-			&BinOp{fakeToken("/", Multiplication), &This{&FieldAccess{"height", nil}}, &FieldAccess{"width", nil}},
+			&BinOp{fakeToken("/", Multiplication), &This{&FieldAccess{"height", nil}, ""}, &FieldAccess{"width", nil}},
 		},
 	}
 
@@ -1311,18 +2122,28 @@ func TestParser_validName(t *testing.T) {
 	}{
 		{
 			"this.person",
-			&This{&FieldAccess{"person", nil}},
+			&This{&FieldAccess{"person", nil}, ""},
 		},
 
 		{
 			"this.person()",
-			&This{&MethodCall{"person", []Expression{}, nil}},
+			&This{&MethodCall{"person", []Expression{}, nil}, ""},
 		},
 
 		{
 			"person",
 			&FieldAccess{"person", nil},
 		},
+
+		{
+			"super.person.name",
+			&Super{&FieldAccess{"person", &FieldAccess{"name", nil}}},
+		},
+
+		{
+			"super.toString()",
+			&Super{&MethodCall{"toString", []Expression{}, nil}},
+		},
 	}
 
 	for _, d := range data {
@@ -1348,8 +2169,41 @@ func TestParser_primaryExp(t *testing.T) {
 		{"'你'", NewChar("你")},
 		{"null", Null{}},
 		{"name", &FieldAccess{"name", nil}},
-		{"this.name", &This{&FieldAccess{"name", nil}}},
-		{"this.name()", &This{&MethodCall{"name", []Expression{}, nil}}},
+		{"this.name", &This{&FieldAccess{"name", nil}, ""}},
+		{"this.name()", &This{&MethodCall{"name", []Expression{}, nil}, ""}},
+		{"123L", Long(123)},
+		{"1.5", Double(1.5)},
+		{".5", Double(0.5)},
+		{"1e3", Double(1000)},
+		{"1.5f", Float(1.5)},
+		{"2F", Float(2)},
+		{"3d", Double(3)},
+		{"0x1.8p1", Double(3)},
+		{"(a)", &FieldAccess{"a", nil}},
+		{"(long) a", &Cast{NamedType{"long", false, nil}, &FieldAccess{"a", nil}}},
+		{"(int) 3.5", &Cast{NamedType{"int", false, nil}, Double(3.5)}},
+		{
+			"(byte) (a + 1)",
+			&Cast{NamedType{"byte", false, nil},
+				&BinOp{fakeToken("+", Addition), &FieldAccess{"a", nil}, Num(1)},
+			},
+		},
+		{"(int[]) o", &Cast{NamedType{"int", true, nil}, &FieldAccess{"o", nil}}},
+		{"(String) o", &Cast{NamedType{"String", false, nil}, &FieldAccess{"o", nil}}},
+		{"(Shape[]) o", &Cast{NamedType{"Shape", true, nil}, &FieldAccess{"o", nil}}},
+		{"(Outer.Inner) null", &Cast{NamedType{"Outer.Inner", false, nil}, Null{}}},
+		{
+			"(Box<String>) this.item",
+			&Cast{NamedType{"Box", false, []NamedType{{"String", false, nil}}},
+				&This{&FieldAccess{"item", nil}, ""},
+			},
+		},
+		{
+			"(Box<Box<T>>) (o)",
+			&Cast{NamedType{"Box", false, []NamedType{{"Box", false, []NamedType{{"T", false, nil}}}}},
+				&FieldAccess{"o", nil},
+			},
+		},
 	}
 
 	for _, d := range data {
@@ -1366,6 +2220,93 @@ func TestParser_primaryExp(t *testing.T) {
 	}
 }
 
+func TestParser_referenceCast_ambiguous(t *testing.T) {
+	// a name in parentheses is only a cast if its operand follow
+	data := []struct {
+		str string
+		exp Expression
+	}{
+		{"(a) + b", &BinOp{fakeToken("+", Addition), &FieldAccess{"a", nil}, &FieldAccess{"b", nil}}},
+		{"(a) - 1", &BinOp{fakeToken("-", Subtraction), &FieldAccess{"a", nil}, Num(1)}},
+		{"(a < b)", &BinOp{fakeToken("<", LessThan), &FieldAccess{"a", nil}, &FieldAccess{"b", nil}}},
+		{"(Shape) s", &Cast{NamedType{"Shape", false, nil}, &FieldAccess{"s", nil}}},
+	}
+
+	for _, d := range data {
+		withParser(d.str, func(p *Parser) {
+			exp := p.expression()
+			result, expstr := PrettyPrint(exp), PrettyPrint(d.exp)
+			if result != expstr {
+				t.Errorf("expression from %s expecting %s but got %s", d.str, expstr, result)
+			}
+		})
+	}
+}
+
+func TestParser_unaryExp(t *testing.T) {
+	data := []struct {
+		str string
+		exp Expression
+	}{
+		{"-1", Num(-1)},
+		{"-2147483648", Num(-2147483648)},
+		{"-9223372036854775808L", Long(-9223372036854775808)},
+		{"-0xFFFFFFFF", Num(1)},
+		{"+1", &UnaryOp{fakeToken("+", Addition), Num(1)}},
+		{"-a", &UnaryOp{fakeToken("-", Subtraction), &FieldAccess{"a", nil}}},
+		{"-1.5", &UnaryOp{fakeToken("-", Subtraction), Double(1.5)}},
+		{
+			"- -2147483648",
+			&UnaryOp{fakeToken("-", Subtraction), Num(-2147483648)},
+		},
+		{
+			"-(1)",
+			&UnaryOp{fakeToken("-", Subtraction), Num(1)},
+		},
+		{
+			"(long) -a",
+			&Cast{NamedType{"long", false, nil},
+				&UnaryOp{fakeToken("-", Subtraction), &FieldAccess{"a", nil}},
+			},
+		},
+	}
+
+	for _, d := range data {
+		withParser(d.str, func(p *Parser) {
+			exp := p.unaryExp()
+			result, expstr := PrettyPrint(exp), PrettyPrint(d.exp)
+			if result != expstr {
+				t.Errorf("unaryExp from %s expecting %s but got %s", d.str, expstr, result)
+			}
+
+			if len(p.Errors()) > 0 {
+				t.Errorf("unaryExp from %s should not have error, but got %s", d.str, p.Errors())
+			}
+		})
+	}
+}
+
+func TestParser_literal_outOfRange(t *testing.T) {
+	data := []string{
+		"2147483648",
+		"-2147483649",
+		"(2147483648)",
+		"-(2147483648)",
+		"0x1_0000_0000",
+		"9223372036854775808L",
+		"1e400",
+	}
+
+	for _, str := range data {
+		withParser(str, func(p *Parser) {
+			p.unaryExp()
+			if len(p.Errors()) != 1 {
+				t.Errorf("Literal %s should be reported as out of range", str)
+			}
+		})
+	}
+}
+
 func TestParser_objectInitialization(t *testing.T) {
 	data := []struct {
 		str string
@@ -1373,12 +2314,38 @@ func TestParser_objectInitialization(t *testing.T) {
 	}{
 		{
 			"new Hello()",
-			&ObjectCreation{MethodCall{"Hello", []Expression{}, nil}},
+			&ObjectCreation{MethodCall{"Hello", []Expression{}, nil}, nil, nil},
 		},
 
 		{
 			"new Nice(1, 2)",
-			&ObjectCreation{MethodCall{"Nice", []Expression{Num(1), Num(2)}, nil}},
+			&ObjectCreation{MethodCall{"Nice", []Expression{Num(1), Num(2)}, nil}, nil, nil},
+		},
+
+		{
+			`new Box<String>("a")`,
+			&ObjectCreation{
+				MethodCall{"Box", []Expression{String("a")}, nil},
+				[]NamedType{{"String", false, nil}},
+				nil,
+			},
+		},
+
+		{
+			"new Pair<String, Box<T>>()",
+			&ObjectCreation{
+				MethodCall{"Pair", []Expression{}, nil},
+				[]NamedType{
+					{"String", false, nil},
+					{"Box", false, []NamedType{{"T", false, nil}}},
+				},
+				nil,
+			},
+		},
+
+		{
+			"new Outer.Inner()",
+			&ObjectCreation{MethodCall{"Outer.Inner", []Expression{}, nil}, nil, nil},
 		},
 
 		{
@@ -1401,3 +2368,326 @@ func TestParser_objectInitialization(t *testing.T) {
 		})
 	}
 }
+
+func TestParser_nestedClass(t *testing.T) {
+	str := `class Outer {
+		private class Inner {}
+		public static class Nested {}
+		public void run() {
+			class Local {}
+			Outer.Nested n = null;
+			Greet g = new Greet() {
+				public void greet() {
+					int x = Outer.this.count;
+				}
+			};
+		}
+	}`
+
+	withParser(str, func(p *Parser) {
+		outer := p.Compile()[0].(*Class)
+		expect := []struct {
+			name   string
+			kind   ClassKind
+			access AccessModifier
+		}{
+			{"Outer$Inner", InnerClass, Private},
+			{"Outer$Nested", NestedClass, Public},
+		}
+
+		if len(outer.Classes) != len(expect) {
+			t.Fatalf("Expecting %d member classes but got %d", len(expect), len(outer.Classes))
+		}
+
+		for i, e := range expect {
+			class := outer.Classes[i]
+			if class.Name != e.name || class.Kind != e.kind || class.Access != e.access {
+				t.Errorf("Expecting class %s of kind %d but got %s of kind %d", e.name, e.kind, class.Name, class.Kind)
+			}
+		}
+
+		body := outer.Methods[0].Body
+		if local, ok := body[0].(*Class); !ok || local.Name != "Outer$1Local" || local.Kind != LocalClass {
+			t.Errorf("Expecting a local class Outer$1Local but got %s", PrettyPrint(body[0]))
+		}
+
+		if decl, ok := body[1].(*VariableDeclaration); !ok || decl.Type.Name != "Outer.Nested" {
+			t.Errorf("Expecting a variable of type Outer.Nested but got %s", PrettyPrint(body[1]))
+		}
+
+		decl := body[2].(*VariableDeclaration)
+		anonymous := decl.Value.(*ObjectCreation).Body
+		if anonymous == nil || anonymous.Name != "Outer$1" || anonymous.Kind != AnonymousClass || anonymous.Extend != "Greet" {
+			t.Fatalf("Expecting an anonymous class Outer$1 of Greet but got %s", PrettyPrint(decl.Value))
+		}
+
+		inner := anonymous.Methods[0].Body[0].(*VariableDeclaration)
+		if this, ok := inner.Value.(*This); !ok || this.Qualifier != "Outer" {
+			t.Errorf("Expecting a this qualified by Outer but got %s", PrettyPrint(inner.Value))
+		}
+	})
+}
+
+// assertReported check that str is parsed with only one error of msg
+func assertReported(t *testing.T, str, msg string) {
+	t.Helper()
+	withParser(str, func(p *Parser) {
+		p.Compile()
+		errors := p.Errors()
+		if len(errors) != 1 || !strings.HasSuffix(errors[0].Error(), msg) {
+			t.Errorf("Expecting %s to report %s but got %v", str, msg, errors)
+		}
+	})
+}
+
+func TestParser_nestedClass_error(t *testing.T) {
+	assertReported(t, `class Outer { public Greet g = new Greet() {}; }`,
+		"Anonymous class is not allowed as a property value.")
+}
+
+func TestParser_nestedClass_panic(t *testing.T) {
+	data := []string{
+		`class Outer { public void run() { Greet g = new Greet() {}.x; } }`,
+	}
+
+	for _, str := range data {
+		withParser(str, func(p *Parser) {
+			defer func() {
+				if r := recover(); r == nil {
+					t.Errorf("Expecting %s to panic", str)
+				}
+			}()
+			p.Compile()
+		})
+	}
+}
+
+func TestParser_record(t *testing.T) {
+	intType := NamedType{"int", false, nil}
+	field := func(name string) *This {
+		return &This{&FieldAccess{name, nil}, ""}
+	}
+
+	assign := func(name string) *AssignmentStatement {
+		return &AssignmentStatement{fakeToken("=", Assignment), field(name), &FieldAccess{name, nil}}
+	}
+
+	accessor := func(name string) *MethodDeclaration {
+		return NewMethodDeclaration(Public, intType, name, nil, StatementList{&JumpStatement{ReturnJump, field(name)}})
+	}
+
+	components := []Parameter{{intType, "x", false, false}, {intType, "y", false, false}}
+	newPoint := func(decls ...Declaration) *Class {
+		class := NewEmptyClass("Point", "", "")
+		class.Access = Final
+		class.Components = components
+		for _, decl := range decls {
+			class.AddDeclaration(decl)
+		}
+		return class
+	}
+
+	clamp := &AssignmentStatement{fakeToken("=", Assignment), &FieldAccess{"x", nil}, Num(0)}
+	data := []struct {
+		str    string
+		expect *Class
+	}{
+		{
+			"record Point(int x, int y) {}",
+			newPoint(
+				&PropertyDeclaration{Private | Final, VariableDeclaration{intType, "x", nil, false}},
+				accessor("x"),
+				&PropertyDeclaration{Private | Final, VariableDeclaration{intType, "y", nil, false}},
+				accessor("y"),
+				NewConstructor(Public, "Point", components, StatementList{assign("x"), assign("y")}),
+			),
+		},
+		{
+			`final record Point(int x, int y) {
+				public Point { x = 0; }
+				public int x() { return 1; }
+			}`,
+			newPoint(
+				NewMethodDeclaration(Public, intType, "x", nil, StatementList{&JumpStatement{ReturnJump, Num(1)}}),
+				&PropertyDeclaration{Private | Final, VariableDeclaration{intType, "x", nil, false}},
+				&PropertyDeclaration{Private | Final, VariableDeclaration{intType, "y", nil, false}},
+				accessor("y"),
+				NewConstructor(Public, "Point", components, StatementList{clamp, assign("x"), assign("y")}),
+			),
+		},
+		{
+			`record Point(int x, int y) {
+				public Point(int x, int y) { this.x = x; this.y = y; }
+				public Point(int v) { this(v, v); }
+			}`,
+			newPoint(
+				NewConstructor(Public, "Point", components, StatementList{assign("x"), assign("y")}),
+				NewConstructor(Public, "Point", []Parameter{{intType, "v", false, false}}, StatementList{
+					&ConstructorCallStatement{[]Expression{&FieldAccess{"v", nil}, &FieldAccess{"v", nil}}},
+				}),
+				&PropertyDeclaration{Private | Final, VariableDeclaration{intType, "x", nil, false}},
+				accessor("x"),
+				&PropertyDeclaration{Private | Final, VariableDeclaration{intType, "y", nil, false}},
+				accessor("y"),
+			),
+		},
+	}
+
+	for _, d := range data {
+		withParser(d.str, func(p *Parser) {
+			class := p.Compile()[0].(*Class)
+			if !class.IsRecord() {
+				t.Errorf("Expecting %s to be a record", class.Name)
+			}
+
+			if res, ex := PrettyPrint(class), PrettyPrint(d.expect); res != ex {
+				t.Errorf("Expecting \n%s but got \n%s", ex, res)
+			}
+		})
+	}
+}
+
+func TestParser_recordMember(t *testing.T) {
+	str := `class Outer {
+		public record Pair(int a, int b) {}
+		private static record Empty() {}
+		public record record;
+	}`
+
+	withParser(str, func(p *Parser) {
+		outer := p.Compile()[0].(*Class)
+		expect := []struct {
+			name       string
+			access     AccessModifier
+			components int
+		}{
+			{"Outer$Pair", Public | Final, 2},
+			{"Outer$Empty", Private | Final, 0},
+		}
+
+		if len(outer.Classes) != len(expect) {
+			t.Fatalf("Expecting %d member classes but got %d", len(expect), len(outer.Classes))
+		}
+
+		for i, e := range expect {
+			class := outer.Classes[i]
+			if class.Name != e.name || class.Kind != NestedClass || class.Access != e.access ||
+				!class.IsRecord() || len(class.Components) != e.components {
+				t.Errorf("Expecting record %s but got %s", e.name, PrettyPrint(class))
+			}
+		}
+
+		if len(outer.Properties) != 1 || outer.Properties[0].Type.Name != "record" {
+			t.Errorf("Expecting a property of type record but got %v", outer.Properties)
+		}
+	})
+}
+
+func TestParser_record_error(t *testing.T) {
+	data := []struct {
+		str    string
+		expect string
+	}{
+		{`record Point(int x) { private int y; }`, "Record cannot declare an instance field."},
+		{`record Point(int x) { { x = 1; } }`, "Record cannot declare an instance initializer."},
+		{`record Point(int x) extends Base {}`, "Record cannot extend a class."},
+		{`record Point(int x) { public Point() {} }`, "Non-canonical record constructor must invoke another constructor first."},
+		{`record Point(int x) { public Point() { int y = 0; } }`, "Non-canonical record constructor must invoke another constructor first."},
+		{`record Point(int x) { public Point {} Point {} }`, "Compact constructor is already declared."},
+		{`record Point(int x) { public Point { this(1); } }`, "Compact constructor cannot invoke another constructor."},
+		{`record Point(int x) { final Point {} }`, "Constructor cannot be final."},
+	}
+
+	for _, d := range data {
+		assertReported(t, d.str, d.expect)
+	}
+}
+
+func TestParser_record_panic(t *testing.T) {
+	data := []string{
+		`record Point(int x) { public Point {} public Point(int x) { this.x = x; } }`,
+	}
+
+	for _, str := range data {
+		withParser(str, func(p *Parser) {
+			defer assertPanic(t, fmt.Sprintf("Should panic on %s", str))
+			p.Compile()
+		})
+	}
+}
+
+func TestParser_lambda(t *testing.T) {
+	str := `class Main {
+		public void run() {
+			Op op = (a, b) -> a + b;
+			Op typed = (int a, int b) -> { return a; };
+			Action act = () -> System.out.println(1);
+			Fn f = x -> y -> x;
+			op = Box::new;
+			this.take(this::stop);
+		}
+	}`
+
+	expect := []string{
+		"(a, b) :body (#binop + :left (#field a) :right (#field b))",
+		"(int a, int b) :body (#stmt-block (#return (#field a)))",
+		"() :body (#field System (#field out (#method-call println :args [(#int 1)])))",
+		"(x) :body (#lambda (y) :body (#field x))",
+	}
+
+	withParser(str, func(p *Parser) {
+		body := p.Compile()[0].(*Class).Methods[0].Body
+		for i, e := range expect {
+			lambda, ok := body[i].(*VariableDeclaration).Value.(*Lambda)
+			if !ok {
+				t.Fatalf("Expecting a lambda but got %s", PrettyPrint(body[i]))
+			}
+
+			if name := fmt.Sprintf("Main$$Lambda$%d", i+1); lambda.Name != name {
+				t.Errorf("Expecting lambda to be named %s but got %s", name, lambda.Name)
+			}
+
+			if _, result := lambda.NodeContent(); result != e {
+				t.Errorf("Expecting %#v but got %#v", e, result)
+			}
+		}
+
+		ref, ok := body[4].(*AssignmentStatement).Right.(*MethodReference)
+		if !ok || ref.Target != "Box" || ref.Method != "new" {
+			t.Errorf("Expecting a method reference Box::new but got %s", PrettyPrint(body[4]))
+		}
+
+		call := body[5].(*MethodCallStatement).Method.GetChild().(*MethodCall)
+		if ref, ok := call.Args[0].(*MethodReference); !ok || ref.Target != "this" || ref.Method != "stop" {
+			t.Errorf("Expecting a method reference this::stop but got %s", PrettyPrint(call))
+		}
+	})
+}
+
+func TestParser_lambda_error(t *testing.T) {
+	assertReported(t, `class Main { public Op op = (a, b) -> a; }`,
+		"Lambda is not allowed as a property value.")
+	assertReported(t, `class Main { Runnable r = () -> {}; }`,
+		"Lambda is not allowed as a property value.")
+	assertReported(t, `class Main { public void run() { Op op = (int a, b) -> a; } }`,
+		"Lambda parameters should be either all typed or all inferred.")
+	assertReported(t, `class Main { public void run() { Op op = (a, int b) -> a; } }`,
+		"Lambda parameters should be either all typed or all inferred.")
+}
+
+func TestParser_lambda_panic(t *testing.T) {
+	data := []string{
+		`class Main { public void run() { Op op = (a, b) ->; } }`,
+	}
+
+	for _, str := range data {
+		withParser(str, func(p *Parser) {
+			defer func() {
+				if r := recover(); r == nil {
+					t.Errorf("Expecting %s to panic", str)
+				}
+			}()
+			p.Compile()
+		})
+	}
+}
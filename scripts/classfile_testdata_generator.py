@@ -0,0 +1,275 @@
+#! /usr/bin/python3
+
+# Assemble the class files in pkg/classfile/testdata without a JDK, the
+# classes only have signatures, most methods have no code since they are
+# never run.
+import os
+import struct
+import zipfile
+
+# access flags of classes, fields and methods
+PUBLIC = 0x0001
+PRIVATE = 0x0002
+PROTECTED = 0x0004
+STATIC = 0x0008
+FINAL = 0x0010
+SUPER = 0x0020
+BRIDGE = 0x0040
+VARARGS = 0x0080
+INTERFACE = 0x0200
+ABSTRACT = 0x0400
+SYNTHETIC = 0x1000
+
+# tags of the constant pool entries
+CONSTANT_UTF8 = 1
+CONSTANT_INTEGER = 3
+CONSTANT_LONG = 5
+CONSTANT_DOUBLE = 6
+CONSTANT_CLASS = 7
+CONSTANT_STRING = 8
+CONSTANT_METHOD_REF = 10
+CONSTANT_NAME_AND_TYPE = 12
+
+
+def modified_utf8(value: str) -> bytes:
+    """Encode value like the JVM does, a null character and every
+    supplementary character are encoded as their UTF-16 code units."""
+    utf16 = value.encode('utf-16-be')
+    code_units = struct.unpack('>%dH' % (len(utf16) // 2), utf16)
+
+    encoded = b''
+    for unit in code_units:
+        if 0 < unit < 0x80:
+            encoded += bytes([unit])
+        elif unit < 0x800:
+            encoded += bytes([0xC0 | unit >> 6, 0x80 | unit & 0x3F])
+        else:
+            encoded += bytes([0xE0 | unit >> 12, 0x80 | (unit >> 6) & 0x3F, 0x80 | unit & 0x3F])
+    return encoded
+
+
+class ConstantPool:
+    """The constant pool of a class file, an entry that is added twice
+    keep its first index."""
+
+    def __init__(self):
+        self.entries = []
+        self.indexes = {}
+        self.next_index = 1
+
+    def add(self, key, data: bytes, is_wide=False) -> int:
+        if key in self.indexes:
+            return self.indexes[key]
+
+        index = self.next_index
+        self.indexes[key] = index
+        self.entries.append(data)
+        # long and double take two entries
+        self.next_index += 2 if is_wide else 1
+        return index
+
+    def utf8(self, value: str) -> int:
+        encoded = modified_utf8(value)
+        data = struct.pack('>BH', CONSTANT_UTF8, len(encoded)) + encoded
+        return self.add(('utf8', value), data)
+
+    def class_ref(self, name: str) -> int:
+        name_index = self.utf8(name)
+        return self.add(('class', name), struct.pack('>BH', CONSTANT_CLASS, name_index))
+
+    def string(self, value: str) -> int:
+        value_index = self.utf8(value)
+        return self.add(('string', value), struct.pack('>BH', CONSTANT_STRING, value_index))
+
+    def integer(self, value: int) -> int:
+        return self.add(('integer', value), struct.pack('>Bi', CONSTANT_INTEGER, value))
+
+    def long(self, value: int) -> int:
+        return self.add(('long', value), struct.pack('>Bq', CONSTANT_LONG, value), True)
+
+    def double(self, value: float) -> int:
+        return self.add(('double', value), struct.pack('>Bd', CONSTANT_DOUBLE, value), True)
+
+    def name_and_type(self, name: str, descriptor: str) -> int:
+        name_index = self.utf8(name)
+        descriptor_index = self.utf8(descriptor)
+        data = struct.pack('>BHH', CONSTANT_NAME_AND_TYPE, name_index, descriptor_index)
+        return self.add(('name_and_type', name, descriptor), data)
+
+    def method_ref(self, owner: str, name: str, descriptor: str) -> int:
+        owner_index = self.class_ref(owner)
+        name_and_type_index = self.name_and_type(name, descriptor)
+        data = struct.pack('>BHH', CONSTANT_METHOD_REF, owner_index, name_and_type_index)
+        return self.add(('method_ref', owner, name, descriptor), data)
+
+    def to_bytes(self) -> bytes:
+        return struct.pack('>H', self.next_index) + b''.join(self.entries)
+
+
+def attribute(pool: ConstantPool, name: str, body: bytes) -> bytes:
+    return struct.pack('>HI', pool.utf8(name), len(body)) + body
+
+
+def members_to_bytes(pool: ConstantPool, members: list) -> bytes:
+    """Encode the fields or the methods of a class, each member is a tuple
+    of its flags, name, descriptor and optionally a function which get
+    its attributes."""
+    encoded = struct.pack('>H', len(members))
+    for member in members:
+        flags, name, descriptor = member[:3]
+        attributes = []
+        if len(member) > 3:
+            attributes = member[3](pool)
+
+        encoded += struct.pack('>HHHH', flags, pool.utf8(name), pool.utf8(descriptor), len(attributes))
+        encoded += b''.join(attributes)
+    return encoded
+
+
+def class_file(name, flags, super_name, interfaces, fields, methods, extra_constants=None) -> bytes:
+    pool = ConstantPool()
+    this_index = pool.class_ref(name)
+    super_index = 0
+    if super_name:
+        super_index = pool.class_ref(super_name)
+
+    interface_indexes = [pool.class_ref(interface) for interface in interfaces]
+    # constants that are not used by any member, to test the constant pool
+    if extra_constants:
+        extra_constants(pool)
+
+    encoded_fields = members_to_bytes(pool, fields)
+    encoded_methods = members_to_bytes(pool, methods)
+    source_name = name.split('/')[-1] + '.java'
+    source_file = attribute(pool, 'SourceFile', struct.pack('>H', pool.utf8(source_name)))
+
+    body = struct.pack('>HHH', flags, this_index, super_index)
+    body += struct.pack('>H', len(interface_indexes))
+    body += b''.join(struct.pack('>H', index) for index in interface_indexes)
+    body += encoded_fields
+    body += encoded_methods
+    body += struct.pack('>H', 1)
+    body += source_file
+
+    header = struct.pack('>IHH', 0xCAFEBABE, 0, 52)
+    return header + pool.to_bytes() + body
+
+
+def object_constructor_code(pool: ConstantPool, max_stack=1, max_locals=1) -> list:
+    """The code of a constructor that only call the constructor of Object"""
+    ALOAD_0, INVOKESPECIAL, RETURN = 0x2a, 0xb7, 0xb1
+    object_init = pool.method_ref('java/lang/Object', '<init>', '()V')
+    instructions = bytes([ALOAD_0, INVOKESPECIAL]) + struct.pack('>H', object_init) + bytes([RETURN])
+
+    # no exception table and no attribute
+    body = struct.pack('>HHI', max_stack, max_locals, len(instructions))
+    body += instructions
+    body += struct.pack('>HH', 0, 0)
+    return [attribute(pool, 'Code', body)]
+
+
+def constant_value(value: int):
+    """Get the attributes of a static final int field initialized to value"""
+    def attributes(pool: ConstantPool) -> list:
+        return [attribute(pool, 'ConstantValue', struct.pack('>H', pool.integer(value)))]
+    return attributes
+
+
+def greeter_constants(pool: ConstantPool):
+    pool.long(1 << 40)
+    pool.double(1.5)
+    pool.string('héllo\u0000 𝄞')
+
+
+greeter = class_file('com/acme/Greeter', PUBLIC | SUPER, 'java/lang/Object', [], [
+    (PUBLIC | STATIC | FINAL, 'VERSION', 'I', constant_value(3)),
+    (PUBLIC, 'name', 'Ljava/lang/String;'),
+    (PRIVATE, 'secret', 'J'),
+    (PUBLIC, 'grid', '[[I'),
+], [
+    (PUBLIC, '<init>', '()V', object_constructor_code),
+    (PUBLIC, '<init>', '(Ljava/lang/String;)V'),
+    (PUBLIC, 'greet', '(Ljava/lang/String;)Ljava/lang/String;'),
+    (PUBLIC | STATIC, 'twice', '(I)I'),
+    (PUBLIC | STATIC | VARARGS, 'sum', '([I)I'),
+    (PUBLIC, 'self', '()Lcom/acme/Greeter;'),
+    (PUBLIC, 'shape', '()Lcom/acme/Shape;'),
+    (PUBLIC, 'builder', '()Ljava/lang/StringBuilder;'),
+    (PUBLIC, 'missing', '(Lcom/missing/Gone;)V'),
+    (STATIC, '<clinit>', '()V'),
+    (STATIC | SYNTHETIC, 'access$000', '(Lcom/acme/Greeter;)J'),
+], extra_constants=greeter_constants)
+
+shape = class_file('com/acme/Shape', PUBLIC | INTERFACE | ABSTRACT, 'java/lang/Object', [], [], [
+    (PUBLIC | ABSTRACT, 'area', '()D'),
+    (PUBLIC | STATIC, 'unit', '()Lcom/acme/Shape;'),
+    (PUBLIC, 'describe', '()Ljava/lang/String;'),
+])
+
+square = class_file('com/acme/shapes/Square', PUBLIC | SUPER | FINAL, 'java/lang/Object', ['com/acme/Shape'], [
+    (PUBLIC | FINAL, 'side', 'D'),
+], [
+    (PUBLIC, '<init>', '(D)V'),
+    (PUBLIC, 'area', '()D'),
+    (PUBLIC | SYNTHETIC | BRIDGE, 'area', '()Ljava/lang/Object;'),
+])
+
+widget = class_file('com/acme/Widget', PUBLIC | SUPER, 'java/lang/Object', [], [
+    (PROTECTED, 'size', 'I'),
+    (0, 'owner', 'Ljava/lang/String;'),
+], [
+    (PROTECTED, '<init>', '()V'),
+    (PUBLIC, '<init>', '(I)V'),
+    (PROTECTED, 'resize', '(I)V'),
+    (PROTECTED | STATIC, 'count', '()I'),
+    (0, 'internal', '()V'),
+])
+
+# a shape of another package, so the methods of tile only differ by the
+# package of their parameter
+flat_shape = class_file('com/acme/shapes/Shape', PUBLIC | INTERFACE | ABSTRACT, 'java/lang/Object', [], [], [
+    (PUBLIC | ABSTRACT, 'corners', '()I'),
+])
+
+tile = class_file('com/acme/Tile', PUBLIC | SUPER, 'java/lang/Object', ['com/acme/Shape', 'com/acme/shapes/Shape'], [], [
+    (PUBLIC, '<init>', '()V', object_constructor_code),
+    (PUBLIC, 'area', '()D'),
+    (PUBLIC, 'corners', '()I'),
+    (PUBLIC, 'fit', '(Lcom/acme/Shape;)Z'),
+    (PUBLIC, 'fit', '(Lcom/acme/shapes/Shape;)Z'),
+])
+
+
+def write_testdata(class_files: dict):
+    """Write every class file into testdata/classes and into helpers.jar,
+    along with a file that is not a class file."""
+    script_dir = os.path.dirname(os.path.abspath(__file__))
+    testdata = os.path.join(script_dir, '..', 'pkg', 'classfile', 'testdata')
+    for name, content in class_files.items():
+        path = os.path.join(testdata, 'classes', name)
+        os.makedirs(os.path.dirname(path), exist_ok=True)
+        with open(path, 'wb') as f:
+            f.write(content)
+
+    # a fixed date so the jar only change with its content
+    date = (2020, 1, 1, 0, 0, 0)
+    with zipfile.ZipFile(os.path.join(testdata, 'helpers.jar'), 'w') as jar:
+        jar.writestr(zipfile.ZipInfo('META-INF/MANIFEST.MF', date), 'Manifest-Version: 1.0\r\n\r\n')
+        for name, content in class_files.items():
+            entry = zipfile.ZipInfo(name, date)
+            entry.compress_type = zipfile.ZIP_DEFLATED
+            jar.writestr(entry, content)
+
+    with open(os.path.join(testdata, 'not_a_class.class'), 'wb') as f:
+        f.write(b'hello world')
+
+
+if __name__ == '__main__':
+    write_testdata({
+        'com/acme/Greeter.class': greeter,
+        'com/acme/Shape.class': shape,
+        'com/acme/shapes/Square.class': square,
+        'com/acme/Widget.class': widget,
+        'com/acme/Tile.class': tile,
+        'com/acme/shapes/Shape.class': flat_shape,
+    })